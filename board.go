@@ -0,0 +1,442 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// BoardId is the sender of the posts made by the bulletin board itself.
+const BoardId int64 = -1
+
+// Board is the bulletin board as seen by its users: an append-only log of posts, grouped by epoch.
+// The nodes post proposal hashes, complaints and shares, and the board logic (the primary, or the
+// contract on a chain) posts the agreed lists and epoch advances.
+type Board interface {
+	// Post appends a post to the board.
+	Post(ctx context.Context, post *services.BoardPost) error
+	// Subscribe returns all posts of the given kinds, from the first one, in board order.
+	// On a chain, posts only show up once they are final.
+	Subscribe(kinds ...services.BoardPost_Kind) <-chan *services.BoardPost
+	// ReadEpoch returns the posts of an epoch, in board order.
+	ReadEpoch(ctx context.Context, epoch Epoch) ([]*services.BoardPost, error)
+}
+
+func newPost(kind services.BoardPost_Kind, epoch Epoch, from int64, msg proto.Message) *services.BoardPost {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return &services.BoardPost{
+		Kind:    kind,
+		Epoch:   int32(epoch),
+		From:    from,
+		Payload: payload,
+	}
+}
+
+// boardLog keeps the posts of a board in order.
+type boardLog struct {
+	lock    *sync.Mutex
+	cond    *sync.Cond
+	posts   []*services.BoardPost
+	byEpoch map[Epoch][]*services.BoardPost
+}
+
+func newBoardLog() *boardLog {
+	lock := &sync.Mutex{}
+
+	return &boardLog{
+		lock:    lock,
+		cond:    sync.NewCond(lock),
+		byEpoch: make(map[Epoch][]*services.BoardPost),
+	}
+}
+
+func (l *boardLog) append(post *services.BoardPost) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.posts = append(l.posts, post)
+	l.byEpoch[Epoch(post.Epoch)] = append(l.byEpoch[Epoch(post.Epoch)], post)
+
+	l.cond.Broadcast()
+}
+
+func (l *boardLog) readEpoch(epoch Epoch) []*services.BoardPost {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return append([]*services.BoardPost{}, l.byEpoch[epoch]...)
+}
+
+// subscribe streams the posts of the given kinds (all if none) until ctx is done.
+func (l *boardLog) subscribe(ctx context.Context, kinds []services.BoardPost_Kind) <-chan *services.BoardPost {
+	out := make(chan *services.BoardPost, 64)
+
+	wanted := make(map[services.BoardPost_Kind]bool)
+	for _, k := range kinds {
+		wanted[k] = true
+	}
+
+	// wake up the reader when ctx is done
+	go func() {
+		<-ctx.Done()
+		l.lock.Lock()
+		l.cond.Broadcast()
+		l.lock.Unlock()
+	}()
+
+	go func() {
+		defer close(out)
+
+		for next := 0; ; next++ {
+			l.lock.Lock()
+			for next >= len(l.posts) && ctx.Err() == nil {
+				l.cond.Wait()
+			}
+			if ctx.Err() != nil {
+				l.lock.Unlock()
+				return
+			}
+			post := l.posts[next]
+			l.lock.Unlock()
+
+			if len(wanted) > 0 && !wanted[post.Kind] {
+				continue
+			}
+
+			select {
+			case out <- post:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// nextPost waits on the clock for the next post of a subscription. It returns nil once the subscription ends.
+func nextPost(clock Clock, posts <-chan *services.BoardPost) *services.BoardPost {
+	_, post, ok := clock.Wait(posts)
+	if !ok {
+		return nil
+	}
+
+	return post.(*services.BoardPost)
+}
+
+// grpcBoard is the board run by the primary, reached over gRPC.
+type grpcBoard struct {
+	client services.BulletinBoardServiceClient
+
+	// logging
+	log *logrus.Entry
+}
+
+// DialBoard connects to the primary through the transport, with the given certificate if not nil, and the given options.
+func DialBoard(network Transport, primaryIP string, creds *Credentials, logger *logrus.Logger, opts ...grpc.DialOption) (Board, error) {
+	conn, err := network.Dial(primaryIP, append([]grpc.DialOption{creds.dialOption()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &grpcBoard{
+		client: services.NewBulletinBoardServiceClient(conn),
+		log: logger.WithFields(
+			logrus.Fields{
+				"board": primaryIP,
+			}),
+	}, nil
+}
+
+func (b *grpcBoard) Post(ctx context.Context, post *services.BoardPost) error {
+	// a board that can't be reached fails the post right away, rather than holding up the phase
+	_, err := b.client.Post(ctx, post)
+
+	return err
+}
+
+func (b *grpcBoard) Subscribe(kinds ...services.BoardPost_Kind) <-chan *services.BoardPost {
+	out := make(chan *services.BoardPost)
+
+	go func() {
+		defer close(out)
+
+		stream, err := b.client.Subscribe(context.Background(), &services.SubscribeRequest{Kinds: kinds}, grpc.WaitForReady(true))
+		if err != nil {
+			b.log.Errorf("can't subscribe to the primary: %s", err.Error())
+			return
+		}
+
+		for {
+			post, err := stream.Recv()
+			if err != nil {
+				b.log.Errorf("subscription to the primary ended: %s", err.Error())
+				return
+			}
+
+			out <- post
+		}
+	}()
+
+	return out
+}
+
+func (b *grpcBoard) ReadEpoch(ctx context.Context, epoch Epoch) ([]*services.BoardPost, error) {
+	list, err := b.client.ReadEpoch(ctx, &services.EpochRequest{Epoch: int32(epoch)}, grpc.WaitForReady(true))
+	if err != nil {
+		return nil, err
+	}
+
+	return list.List, nil
+}
+
+// Post takes a post from a node.
+func (bb *BulletinBoard) Post(ctx context.Context, post *services.BoardPost) (*services.Empty, error) {
+	if err := bb.creds.authorize(ctx, senderIdentity(post.From)); err != nil {
+		return nil, err
+	}
+
+	if err := bb.handlePost(post); err != nil {
+		return nil, err
+	}
+
+	bb.posts.append(post)
+
+	return &services.Empty{}, nil
+}
+
+func (bb *BulletinBoard) Subscribe(req *services.SubscribeRequest, stream services.BulletinBoardService_SubscribeServer) error {
+	for post := range bb.posts.subscribe(stream.Context(), req.Kinds) {
+		if err := stream.Send(post); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (bb *BulletinBoard) ReadEpoch(ctx context.Context, req *services.EpochRequest) (*services.BoardPostList, error) {
+	return &services.BoardPostList{List: bb.posts.readEpoch(Epoch(req.Epoch))}, nil
+}
+
+// handlePost hands a post from a node to the board logic.
+func (bb *BulletinBoard) handlePost(post *services.BoardPost) error {
+	var msg proto.Message
+	switch post.Kind {
+	case services.BoardPost_PROPOSAL_HASH:
+		msg = &services.ProposalHash{}
+	case services.BoardPost_COMPLAINTS:
+		msg = &services.ComplaintList{}
+	case services.BoardPost_SHARE:
+		// in production mode, the board never sees a share
+		if bb.mode == ModeProduction {
+			return fmt.Errorf("the board doesn't take shares in production mode")
+		}
+		msg = &services.Share{}
+	case services.BoardPost_SHARE_CHECK:
+		if bb.mode != ModeProduction {
+			return fmt.Errorf("the board only takes share checks in production mode")
+		}
+		msg = &services.ShareCheck{}
+	case services.BoardPost_SHARE_ERASED:
+		msg = &services.ShareErasure{}
+	case services.BoardPost_DEALING_COMMITMENT:
+		msg = &services.DealingCommitment{}
+	case services.BoardPost_DEALING_COMPLAINTS:
+		msg = &services.DealingComplaints{}
+	case services.BoardPost_DEALING_REVEAL:
+		msg = &services.DealingReveal{}
+	case services.BoardPost_KILL:
+		msg = &services.Goodbye{}
+	default:
+		return fmt.Errorf("nodes can't post %s", post.Kind.String())
+	}
+
+	if err := proto.Unmarshal(post.Payload, msg); err != nil {
+		return err
+	}
+
+	// the sender of the post signed the message
+	signed := msg.(signedMessage)
+	if sender := messageSender(signed); sender != post.From {
+		return fmt.Errorf("a post from %d can't carry a message from %d", post.From, sender)
+	}
+	if err := bb.identityKeys.verify(signed); err != nil {
+		return err
+	}
+
+	switch msg := msg.(type) {
+	case *services.ProposalHash:
+		// a node hashing proposals differently can't agree with the others
+		if err := checkProposalHash(msg); err != nil {
+			return err
+		}
+		bb.submitProposalHash(msg)
+	case *services.ComplaintList:
+		bb.submitComplaints(msg)
+	case *services.Share:
+		bb.assembleShare(msg)
+	case *services.ShareCheck:
+		bb.submitShareCheck(msg)
+	case *services.ShareErasure:
+		bb.submitShareErasure(msg)
+	case *services.DealingCommitment:
+		bb.dealingCommitments.put(Epoch(msg.Epoch), msg.Dealer, msg)
+	case *services.DealingComplaints:
+		bb.dealingComplaints.put(Epoch(msg.Epoch), msg.From, msg)
+	case *services.DealingReveal:
+		bb.dealingReveals.put(Epoch(msg.Epoch), msg.Dealer, msg)
+	case *services.Goodbye:
+		bb.submitGoodbye(msg)
+	}
+
+	return nil
+}
+
+// publish posts a message of the board logic.
+func (bb *BulletinBoard) publish(kind services.BoardPost_Kind, epoch Epoch, msg proto.Message) {
+	post := newPost(kind, epoch, BoardId, msg)
+
+	if bb.board == nil {
+		bb.posts.append(post)
+		return
+	}
+
+	if err := bb.board.Post(context.Background(), post); err != nil {
+		bb.log.Fatalf("can't post %s: %s", kind.String(), err.Error())
+	}
+}
+
+// followBoard runs the board logic on the posts of an external board, such as a chain.
+func (bb *BulletinBoard) followBoard() {
+	posts := bb.board.Subscribe(
+		services.BoardPost_PROPOSAL_HASH,
+		services.BoardPost_COMPLAINTS,
+		services.BoardPost_SHARE,
+		services.BoardPost_SHARE_CHECK,
+		services.BoardPost_SHARE_ERASED,
+		services.BoardPost_DEALING_COMMITMENT,
+		services.BoardPost_DEALING_COMPLAINTS,
+		services.BoardPost_DEALING_REVEAL,
+		services.BoardPost_KILL,
+	)
+
+	for post := nextPost(bb.clock, posts); post != nil; post = nextPost(bb.clock, posts) {
+		if err := bb.handlePost(post); err != nil {
+			bb.log.Warnf("[primary] ignoring a post from %d: %s", post.From, err.Error())
+		}
+	}
+}
+
+// followBoard hands the posts of the board logic to the node.
+func (node *Node) followBoard() {
+	posts := node.board.Subscribe(
+		services.BoardPost_PROPOSAL_HASH_LIST,
+		services.BoardPost_FINAL_LIST,
+		services.BoardPost_DEALING_COMMITMENT_LIST,
+		services.BoardPost_DEALING_ACCUSATIONS,
+		services.BoardPost_QUALIFIED_DEALERS,
+		services.BoardPost_ADVANCE_EPOCH,
+	)
+
+	// tells the old group of each epoch, once the aborted ones are known
+	base := node.baseConfig
+
+	for post := nextPost(node.clock, posts); post != nil; post = nextPost(node.clock, posts) {
+		if post.From != BoardId {
+			node.log.Warnf("ignoring a %s post from %d", post.Kind.String(), post.From)
+			continue
+		}
+
+		epoch := Epoch(post.Epoch)
+
+		// the lists only concern the old group of their epoch
+		isOldMember := base.ForEpoch(epoch).IsOldMember(node.id)
+
+		switch post.Kind {
+		case services.BoardPost_PROPOSAL_HASH_LIST:
+			// the replicas deliver the hash lists, if any
+			if !isOldMember || node.replicas != nil {
+				continue
+			}
+
+			list := &services.ProposalHashList{}
+			if err := proto.Unmarshal(post.Payload, list); err != nil {
+				node.log.Errorf("can't decode the hash list: %s", err.Error())
+				continue
+			}
+			node.onProposalHashList(list)
+
+		case services.BoardPost_FINAL_LIST:
+			if !isOldMember {
+				continue
+			}
+
+			list := &services.ProposalHashList{}
+			if err := proto.Unmarshal(post.Payload, list); err != nil {
+				node.log.Errorf("can't decode the final list: %s", err.Error())
+				continue
+			}
+			node.onFinalList(list)
+
+		case services.BoardPost_DEALING_COMMITMENT_LIST:
+			if !isOldMember {
+				continue
+			}
+
+			list := &services.DealingCommitmentList{}
+			if err := proto.Unmarshal(post.Payload, list); err != nil {
+				node.log.Errorf("can't decode the dealing commitments: %s", err.Error())
+				continue
+			}
+			node.onDealingCommitments(list)
+
+		case services.BoardPost_DEALING_ACCUSATIONS:
+			if !isOldMember {
+				continue
+			}
+
+			list := &services.DealingAccusationList{}
+			if err := proto.Unmarshal(post.Payload, list); err != nil {
+				node.log.Errorf("can't decode the dealing accusations: %s", err.Error())
+				continue
+			}
+			node.onDealingAccusations(list)
+
+		case services.BoardPost_QUALIFIED_DEALERS:
+			if !isOldMember {
+				continue
+			}
+
+			qualified := &services.QualifiedDealers{}
+			if err := proto.Unmarshal(post.Payload, qualified); err != nil {
+				node.log.Errorf("can't decode the qualified dealers: %s", err.Error())
+				continue
+			}
+			node.onQualifiedDealers(qualified)
+
+		case services.BoardPost_ADVANCE_EPOCH:
+			advance := &services.EpochAdvance{}
+			if err := proto.Unmarshal(post.Payload, advance); err != nil {
+				node.log.Errorf("can't decode the epoch advance: %s", err.Error())
+				continue
+			}
+
+			if advance.Aborted {
+				base = base.AfterAbort(epoch)
+			}
+			node.onEpochVerdict(epoch, advance)
+		}
+	}
+
+	node.log.Infof("the board subscription ended")
+}
@@ -0,0 +1,217 @@
+package Schultz
+
+import (
+	"context"
+	crand "crypto/rand"
+	"testing"
+	"time"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+// testChain runs a chain with accounts for the board and parties 1 to 3, and returns their keys.
+func testChain(config ChainConfig) (*SimChain, map[int64]ed25519.PrivateKey) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	keys := make(map[int64]ed25519.PrivateKey)
+	accounts := make(IdentityKeys)
+	for _, id := range []int64{BoardId, 1, 2, 3} {
+		pub, priv, err := ed25519.GenerateKey(crand.Reader)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		keys[id] = priv
+		accounts[id] = pub
+	}
+
+	return BuildSimChain(config, accounts, logger), keys
+}
+
+func TestSimChain_Finality(t *testing.T) {
+	chain, keys := testChain(ChainConfig{BlockTime: 10, Finality: 3})
+	defer chain.Stop()
+
+	posts := chain.Subscribe(services.BoardPost_PROPOSAL_HASH)
+	ctx := context.Background()
+
+	for from := int64(1); from <= 3; from++ {
+		hash := &services.ProposalHash{Epoch: 1, Proposer: from, Hash: []byte{byte(from)}}
+		assert.Nil(t, chain.Account(keys[from]).Post(ctx, newPost(services.BoardPost_PROPOSAL_HASH, 1, from, hash)))
+	}
+	// other kinds are filtered out
+	assert.Nil(t, chain.Account(keys[1]).Post(ctx, newPost(services.BoardPost_SHARE, 1, 1, &services.Share{Epoch: 1, From: 1})))
+
+	for from := int64(1); from <= 3; from++ {
+		select {
+		case post := <-posts:
+			// in the order of posting, once 3 blocks are on top
+			assert.Equal(t, from, post.From)
+			assert.True(t, post.Block > 0)
+			assert.True(t, chain.Height() >= post.Block+3)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("post %d never got final", from)
+		}
+	}
+
+	list, err := chain.ReadEpoch(ctx, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(list))
+
+	list, err = chain.ReadEpoch(ctx, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(list))
+}
+
+func TestSimChain_Gas(t *testing.T) {
+	// all zero bytes except the kind
+	post := &services.BoardPost{Kind: services.BoardPost_SHARE, Payload: make([]byte, 33)}
+	data, err := proto.Marshal(post)
+	assert.Nil(t, err)
+
+	nonZero := 0
+	for _, b := range data {
+		if b != 0 {
+			nonZero += 1
+		}
+	}
+
+	expected := uint64(txGas + nonZeroByteGas*nonZero + zeroByteGas*(len(data)-nonZero) + 2*storageWordGas)
+	assert.Equal(t, expected, postGas(post))
+
+	// too big for any block
+	chain, keys := testChain(ChainConfig{BlockTime: 10, GasLimit: expected - 1})
+	defer chain.Stop()
+
+	assert.NotNil(t, chain.Account(keys[BoardId]).Post(context.Background(), post))
+}
+
+func TestSimChain_Accounts(t *testing.T) {
+	chain, keys := testChain(ChainConfig{BlockTime: 10, Finality: 1})
+	defer chain.Stop()
+
+	ctx := context.Background()
+	advance := func(from int64) *services.BoardPost {
+		return newPost(services.BoardPost_ADVANCE_EPOCH, 1, from, &services.EpochAdvance{Aborted: true})
+	}
+
+	// a party can neither post as the board, nor as another party
+	assert.NotNil(t, chain.Account(keys[1]).Post(ctx, advance(BoardId)))
+	assert.NotNil(t, chain.Account(keys[1]).Post(ctx, advance(2)))
+	// nor without signing, nor from an account the chain doesn't have
+	assert.NotNil(t, chain.Post(ctx, advance(BoardId)))
+	assert.NotNil(t, chain.Account(keys[1]).Post(ctx, advance(4)))
+
+	assert.Nil(t, chain.Account(keys[BoardId]).Post(ctx, advance(BoardId)))
+
+	deadline := time.After(5 * time.Second)
+	for {
+		list, err := chain.ReadEpoch(ctx, 1)
+		assert.Nil(t, err)
+		if len(list) > 0 {
+			assert.Len(t, list, 1)
+			assert.Equal(t, BoardId, list[0].From)
+			break
+		}
+
+		select {
+		case <-deadline:
+			t.Fatalf("the board's post never got final")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestBulletinBoard_AbortsWithoutQuorum(t *testing.T) {
+	// 1 hands off to 5
+	pp := BuildConfig(1, FieldPrime, makeOneToN(4), []int64{2, 3, 4, 5})
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	bb := BuildBulletinBoard(logger, "", nil, pp)
+	bb.SetDeadlines(DeadlineConfig{ProposalHashes: 10})
+
+	// 2t+1 = 3 hashes are needed
+	for _, id := range []int64{1, 2} {
+		bb.submitProposalHash(&services.ProposalHash{Epoch: 1, Proposer: id, Hash: make([]byte, 32), Version: ProposalHashVersion})
+	}
+
+	err := bb.runEpoch(1)
+	assert.NotNil(t, err)
+	assert.Equal(t, []int64{3, 4}, bb.missed)
+
+	bb.abortEpoch(1, err)
+
+	posts := bb.posts.readEpoch(1)
+	assert.Len(t, posts, 1)
+	assert.Equal(t, services.BoardPost_ADVANCE_EPOCH, posts[0].Kind)
+
+	advance := &services.EpochAdvance{}
+	assert.Nil(t, proto.Unmarshal(posts[0].Payload, advance))
+	assert.True(t, advance.Aborted)
+	assert.Equal(t, []int64{3, 4}, advance.Missed)
+
+	// the first handoff is retried at the next epoch
+	assert.False(t, pp.ForEpoch(2).IsOldMember(1))
+	assert.True(t, pp.AfterAbort(1).ForEpoch(2).IsOldMember(1))
+	assert.False(t, pp.AfterAbort(1).ForEpoch(3).IsOldMember(1))
+}
+
+func TestBulletinBoard_Goodbyes(t *testing.T) {
+	pp, keys := withIdentityKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	urls := make(map[NewNodeID]string)
+	for _, id := range pp.Members() {
+		urls[NewNodeID(id)] = ""
+	}
+	bb := BuildBulletinBoard(logger, "", urls, pp)
+
+	ctx := context.Background()
+	goodbye := func(from int64, key ed25519.PrivateKey) *services.BoardPost {
+		msg := &services.Goodbye{Epoch: 1, From: from}
+		if key != nil {
+			signMessage(key, msg)
+		}
+
+		return newPost(services.BoardPost_KILL, 1, from, msg)
+	}
+	killed := func() bool {
+		select {
+		case <-bb.killChan:
+			return true
+		default:
+			return false
+		}
+	}
+
+	// unsigned, forged or from the dealer, a goodbye doesn't count
+	_, err := bb.Post(ctx, goodbye(1, nil))
+	assert.NotNil(t, err)
+	_, err = bb.Post(ctx, goodbye(1, keys[2]))
+	assert.NotNil(t, err)
+	_, err = bb.Post(ctx, goodbye(DealerId, keys[DealerId]))
+	assert.Nil(t, err)
+
+	// and neither does saying it twice
+	for _, from := range []int64{1, 1, 2, 2, 3, 3} {
+		_, err := bb.Post(ctx, goodbye(from, keys[from]))
+		assert.Nil(t, err)
+	}
+	assert.False(t, killed())
+
+	_, err = bb.Post(ctx, goodbye(4, keys[4]))
+	assert.Nil(t, err)
+	assert.True(t, killed())
+
+	// late goodbyes are fine
+	_, err = bb.Post(ctx, goodbye(4, keys[4]))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,133 @@
+package Schultz
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"./services"
+	"github.com/ncw/gmp"
+)
+
+// Proposals are hashed over a canonical encoding: every field element takes the same number of bytes,
+// every other byte string is prefixed with its length, and points come with the id of their node. Two
+// different proposals thus never encode the same way. The encoding starts with a tag naming what is
+// encoded and its version, and binds the proposal to its epoch and to the session.
+
+// ProposalHashVersion is the version of the encoding proposals are hashed over. It goes with every
+// proposal hash, so that nodes hashing differently find out instead of disagreeing on the hashes.
+const ProposalHashVersion = 3
+
+const (
+	proposalHashTag = "mpss/proposal/v3"
+	commitmentsTag  = "mpss/commitments/v3"
+	sessionTag      = "mpss/session/v3"
+)
+
+// fieldBytes is the size of an encoded field element.
+var fieldBytes = (FieldPrime.BitLen() + 7) / 8
+
+// canonical keeps the first error it runs into, so that encoding goes on and the error is checked once,
+// with Err, at the end.
+type canonical struct {
+	buf bytes.Buffer
+	err error
+}
+
+func newCanonical(tag string) *canonical {
+	c := &canonical{}
+	c.bytes([]byte(tag))
+
+	return c
+}
+
+func (c *canonical) uint32(v uint32) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	c.buf.Write(b[:])
+}
+
+func (c *canonical) int64(v int64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(v))
+	c.buf.Write(b[:])
+}
+
+// bytes writes b prefixed with its length.
+func (c *canonical) bytes(b []byte) {
+	c.uint32(uint32(len(b)))
+	c.buf.Write(b)
+}
+
+// element writes x on fieldBytes bytes. Decoding rejects points that don't fit, but x may still come from
+// a peer, so one that doesn't fit is an error rather than a crash.
+func (c *canonical) element(x *gmp.Int) {
+	b, err := elementBytes(x)
+	if err != nil {
+		if c.err == nil {
+			c.err = err
+		}
+		return
+	}
+
+	c.buf.Write(b)
+}
+
+// raw writes b as is, for fixed-size values.
+func (c *canonical) raw(b []byte) {
+	c.buf.Write(b)
+}
+
+func (c *canonical) Bytes() []byte {
+	return c.buf.Bytes()
+}
+
+// Err returns the first value that couldn't be encoded.
+func (c *canonical) Err() error {
+	return c.err
+}
+
+// elementBytes encodes x big-endian on fieldBytes bytes.
+func elementBytes(x *gmp.Int) ([]byte, error) {
+	if x == nil || x.Sign() < 0 || (x.BitLen()+7)/8 > fieldBytes {
+		return nil, fmt.Errorf("%v is not a field element", x)
+	}
+
+	b := make([]byte, fieldBytes)
+	raw := x.Bytes()
+	copy(b[fieldBytes-len(raw):], raw)
+
+	return b, nil
+}
+
+// sessionID identifies the handoff the parameters were built for. It stays the same across the epochs
+// of the handoff, so that proposals can't be replayed in another one.
+func sessionID(oldDegree, newDegree int, prime *gmp.Int, oldGroup, newGroup []int64) [32]byte {
+	c := newCanonical(sessionTag)
+	c.uint32(uint32(oldDegree))
+	c.uint32(uint32(newDegree))
+	c.bytes(prime.Bytes())
+
+	for _, group := range [][]int64{oldGroup, newGroup} {
+		c.uint32(uint32(len(group)))
+		for _, id := range group {
+			c.int64(id)
+		}
+	}
+
+	return sha256.Sum256(c.Bytes())
+}
+
+// checkProposalHash makes sure a proposal hash was computed the way this node computes them.
+func checkProposalHash(ph *services.ProposalHash) error {
+	if ph.Version != ProposalHashVersion {
+		return fmt.Errorf("the proposal hash from %d has version %d, while this node uses version %d", ph.Proposer, ph.Version, ProposalHashVersion)
+	}
+
+	if len(ph.Hash) != sha256.Size {
+		return fmt.Errorf("the proposal hash from %d has %d bytes", ph.Proposer, len(ph.Hash))
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package Schultz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"./services"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointsOnBlindingPoly_Bytes(t *testing.T) {
+	encode := func(pz PointsOnBlindingPoly) []byte {
+		b, err := pz.Bytes()
+		assert.Nil(t, err)
+		return b
+	}
+
+	// the same bytes, with a leading byte moved from one point to its neighbor
+	a := PointsOnBlindingPoly{points: map[NewNodeID]*gmp.Int{1: gmp.NewInt(0x0102), 2: gmp.NewInt(0x03)}}
+	b := PointsOnBlindingPoly{points: map[NewNodeID]*gmp.Int{1: gmp.NewInt(0x01), 2: gmp.NewInt(0x0203)}}
+	assert.False(t, bytes.Equal(encode(a), encode(b)))
+
+	// the same point for another node
+	c := PointsOnBlindingPoly{points: map[NewNodeID]*gmp.Int{1: gmp.NewInt(0x0102), 3: gmp.NewInt(0x03)}}
+	assert.False(t, bytes.Equal(encode(a), encode(c)))
+
+	assert.Len(t, encode(a), 4+2*(4+fieldBytes))
+
+	// what doesn't fit is an error, not a crash
+	big := gmp.NewInt(0).Lsh(gmp.NewInt(1), uint(8*fieldBytes))
+	for _, x := range []*gmp.Int{nil, gmp.NewInt(-1), big} {
+		_, err := PointsOnBlindingPoly{points: map[NewNodeID]*gmp.Int{1: gmp.NewInt(1), 2: x}}.Bytes()
+		assert.NotNil(t, err)
+	}
+}
+
+func TestProposal_HashBindsEpochAndSession(t *testing.T) {
+	pp, _ := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), []int64{2, 3, 4, 5}))
+
+	p := GenerateProposal(pp, 3)
+	assert.Nil(t, p.Verify(pp, 3))
+
+	// the epoch is part of the hash, and checked
+	moved := p
+	moved.epoch = 4
+	assert.NotEqual(t, p.Hash(), moved.Hash())
+	assert.NotNil(t, p.Verify(pp, 4))
+
+	// so is the session
+	other := BuildConfig(1, FieldPrime, makeOneToN(4), []int64{2, 3, 4, 6})
+	assert.NotEqual(t, pp.SessionID(), other.SessionID())
+	assert.NotNil(t, p.Verify(other.WithEncryptionKeys(pp.encryptionKeys), 3))
+
+	// which stays the same across the epochs of the handoff
+	assert.Equal(t, pp.SessionID(), pp.AfterHandoff().SessionID())
+
+	// the slices hash like the proposal
+	slice, err := p.Slice(2)
+	assert.Nil(t, err)
+	hash, err := slice.Hash()
+	assert.Nil(t, err)
+	assert.Equal(t, p.Hash(), hash)
+	assert.Nil(t, slice.Verify(pp, 3))
+	assert.NotNil(t, slice.Verify(pp, 4))
+}
+
+func TestCheckProposalHash(t *testing.T) {
+	ph := &services.ProposalHash{Epoch: 1, Proposer: 1, Hash: make([]byte, 32), Version: ProposalHashVersion}
+	assert.Nil(t, checkProposalHash(ph))
+
+	ph.Hash = make([]byte, 31)
+	assert.NotNil(t, checkProposalHash(ph))
+
+	// a node hashing the old way finds out
+	ph.Hash = make([]byte, 32)
+	ph.Version = 1
+	err := checkProposalHash(ph)
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "version 1"))
+	assert.True(t, strings.Contains(err.Error(), "version 3"))
+}
+
+func TestBulletinBoard_RejectsOtherHashVersions(t *testing.T) {
+	pp, keys := withIdentityKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	bb := BuildBulletinBoard(logger, "", nil, pp)
+
+	hash := &services.ProposalHash{Epoch: 1, Proposer: 1, Hash: make([]byte, 32)}
+	signMessage(keys[1], hash)
+	assert.NotNil(t, bb.handlePost(newPost(services.BoardPost_PROPOSAL_HASH, 1, 1, hash)))
+
+	hash.Version = ProposalHashVersion
+	signMessage(keys[1], hash)
+	assert.Nil(t, bb.handlePost(newPost(services.BoardPost_PROPOSAL_HASH, 1, 1, hash)))
+}
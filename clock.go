@@ -0,0 +1,95 @@
+package Schultz
+
+import (
+	"context"
+	"reflect"
+	"time"
+)
+
+// Clock tells a party the time and runs its deadlines. Outside a simulation it is the wall clock. In a
+// simulation it is the virtual clock of the simulator, which only moves on once no party has anything
+// left to do. The parties tell it what they do: they start their goroutines with Go, wait with Wait,
+// and wake each other up with Send and Close.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel closed after d, or as soon as cancel is closed.
+	After(d time.Duration, cancel <-chan struct{}) <-chan struct{}
+	// WithTimeout returns a context that is done after d, or once cancelled.
+	WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc)
+	// Go runs f on a new goroutine.
+	Go(f func())
+	// Wait receives from the first of the channels that is ready, waiting for one if none is. It
+	// returns its index, the value received, and whether the channel is still open. Nil channels are skipped.
+	Wait(chans ...interface{}) (int, interface{}, bool)
+	// Send sends v on ch if it has room, without blocking, and tells whether it did. Only one
+	// goroutine may wait on a channel sent to.
+	Send(ch interface{}, v interface{}) bool
+	// Close closes ch, which wakes up everyone waiting on it.
+	Close(ch interface{})
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time {
+	return time.Now()
+}
+
+func (wallClock) After(d time.Duration, cancel <-chan struct{}) <-chan struct{} {
+	return timeout(d, cancel)
+}
+
+func (wallClock) WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(parent, d)
+}
+
+func (wallClock) Go(f func()) {
+	go f()
+}
+
+func (wallClock) Wait(chans ...interface{}) (int, interface{}, bool) {
+	if i, v, ok := tryReceive(chans); i >= 0 {
+		return i, v, ok
+	}
+
+	return receive(chans)
+}
+
+func (wallClock) Send(ch interface{}, v interface{}) bool {
+	return reflect.ValueOf(ch).TrySend(reflect.ValueOf(v))
+}
+
+func (wallClock) Close(ch interface{}) {
+	reflect.ValueOf(ch).Close()
+}
+
+// tryReceive receives from the first of the channels that is ready, without blocking. It returns -1 if none is.
+func tryReceive(chans []interface{}) (int, interface{}, bool) {
+	for i, ch := range chans {
+		c := reflect.ValueOf(ch)
+		if !c.IsValid() || c.IsNil() {
+			continue
+		}
+
+		// the zero Value if the receive would block
+		if v, ok := c.TryRecv(); v.IsValid() {
+			return i, v.Interface(), ok
+		}
+	}
+
+	return -1, nil, false
+}
+
+// receive waits for any of the channels to be ready and receives from it.
+func receive(chans []interface{}) (int, interface{}, bool) {
+	cases := make([]reflect.SelectCase, len(chans))
+	for i, ch := range chans {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv}
+		if c := reflect.ValueOf(ch); c.IsValid() && !c.IsNil() {
+			cases[i].Chan = c
+		}
+	}
+
+	i, v, ok := reflect.Select(cases)
+
+	return i, v.Interface(), ok
+}
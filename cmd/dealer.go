@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"../../src/protocols/schultz"
+	"github.com/docopt/docopt-go"
+	"github.com/ncw/gmp"
+)
+
+func main() {
+	usage := `Dealer in MPSS Protocol. Shares an existing secret among the old group.
+
+The secret is read as a big-endian integer from the secret file, or from stdin if none is given.
+The config must use bootstrap = "dealer".
+--genidentity writes a new signing key to the given file and prints the public key for the config.
+
+Usage:
+  dealer --config=<cfg> [--secret=<file>] [options]
+  dealer --genidentity=<file>
+
+Options:
+  -h --help     		Show this screen.
+  --version     		Show version.
+  -c, --config=<cfg>  	Path to the configuration file.
+  --secret=<file>  		Path to the secret [default: -].
+  --genidentity=<file>  	Generate an identity key pair.
+  --logdir=<dir>  		set the log directory [default: .].
+  -v, --verbose  		Verbose output [default: false].
+  --debug  				Super verbose output [default: false].`
+
+	arguments, err := docopt.ParseDoc(usage)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if keyFile, err := arguments.String("--genidentity"); err == nil && keyFile != "" {
+		identityKey, err := schultz.GenerateIdentityKey(keyFile)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("identityKey = \"%s\"\n", identityKey)
+		return
+	}
+
+	var cmdOpt CmdOpt
+	err = arguments.Bind(&cmdOpt)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	logger, pp, systemConfig, nodeIPList, _ := Init("dealer", cmdOpt)
+
+	if systemConfig.GetBootstrap() != schultz.BootstrapDealer {
+		logger.Fatalf("the config uses bootstrap = %q", systemConfig.GetBootstrap())
+	}
+	RequireGrpcBoard(logger, systemConfig)
+	RequireIdentityKeys(logger, pp)
+	RequireTLS(logger, systemConfig)
+
+	identityKey, err := schultz.LoadIdentityKey(systemConfig.Dealer.IdentityKeyFile)
+	if err != nil {
+		logger.Fatalf("can't load the identity key: %s", err.Error())
+	}
+
+	var secretBytes []byte
+	if cmdOpt.Secret == "-" {
+		secretBytes, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		secretBytes, err = ioutil.ReadFile(cmdOpt.Secret)
+	}
+	if err != nil {
+		logger.Fatalf("can't read the secret: %s", err.Error())
+	}
+
+	secret := gmp.NewInt(0).SetBytes(secretBytes)
+
+	// erase the raw copy of the secret
+	for i := range secretBytes {
+		secretBytes[i] = 0
+	}
+
+	creds := Credentials(logger, systemConfig, schultz.DealerIdentity(), systemConfig.Dealer.TlsCert, systemConfig.Dealer.TlsKey)
+
+	board, err := schultz.DialBoard(schultz.GRPCTransport{}, systemConfig.Primary.Url, creds, logger)
+	if err != nil {
+		logger.Fatalf("can't connect to the primary: %s", err.Error())
+	}
+
+	dealer := schultz.BuildDealer(pp, logger, board, nodeIPList)
+	dealer.SetCredentials(creds)
+	dealer.SetIdentityKey(identityKey)
+	dealer.SetDeadlines(systemConfig.Deadlines)
+
+	// erases the secret
+	if err := dealer.Deal(secret); err != nil {
+		logger.Fatalf("dealer failed: %s", err.Error())
+	}
+}
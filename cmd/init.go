@@ -1,15 +1,18 @@
 package cmd
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"math/rand"
+	"os"
 	"path"
 
 	"../../src/protocols/schultz"
-	polycommit "../../src/utils/polycommit/pbc"
 	"../../src/utils/polyring"
 	"github.com/rifflock/lfshook"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
 )
 
 var Log *logrus.Logger
@@ -36,15 +39,235 @@ func NewLogger(nodeName string, logDir string) *logrus.Logger {
 }
 
 type CmdOpt struct {
-	Config  string
-	Verbose bool
-	Debug   bool
-	Round   int32
-	LogDir  string `docopt:"--logdir"`
-	Id      string // ignored by the primary
+	Config      string
+	Verbose     bool
+	Debug       bool
+	Round       int32
+	LogDir      string `docopt:"--logdir"`
+	Id          string // ignored by the primary
+	Secret      string // only used by the dealer
+	GenKey      string `docopt:"--genkey"`      // only used by the node and the replica
+	GenIdentity string `docopt:"--genidentity"` // only used by the node and the dealer
+	GenStoreKey string `docopt:"--genstorekey"` // only used by the node
+	Recover     bool   // only used by the node
+	Memory      bool   // only used by the protocol
 }
 
-func Init(nodeName string, opt CmdOpt) (*logrus.Logger, schultz.PublicParameter, schultz.SystemConfig, []string, polyring.Polynomial) {
+// the passphrase of a share store without a key file
+const shareStorePassphraseEnv = "MPSS_SHARE_STORE_PASSPHRASE"
+
+// ShareStore returns the store the peer keeps its share in, or nil if it has none.
+func ShareStore(logger *logrus.Logger, peerConfig schultz.PeerConfig) schultz.ShareStore {
+	if peerConfig.ShareStore == "" {
+		return nil
+	}
+
+	secret := []byte(os.Getenv(shareStorePassphraseEnv))
+	if peerConfig.ShareStoreKeyFile != "" {
+		var err error
+		secret, err = schultz.LoadShareStoreKey(peerConfig.ShareStoreKeyFile)
+		if err != nil {
+			logger.Fatalf("can't load the key of the share store: %s", err.Error())
+		}
+	}
+
+	store, err := schultz.NewFileShareStore(peerConfig.ShareStore, secret)
+	if err != nil {
+		logger.Fatalf("%s: set shareStoreKeyFile or %s", err.Error(), shareStorePassphraseEnv)
+	}
+
+	return store
+}
+
+// Replicas returns the replicas of the bulletin board, or nil if the primary orders the proposals alone.
+func Replicas(logger *logrus.Logger, systemConfig schultz.SystemConfig) *schultz.ReplicaSet {
+	if len(systemConfig.Replicas) == 0 {
+		return nil
+	}
+
+	replicas, err := schultz.BuildReplicaSet(systemConfig.Replicas)
+	if err != nil {
+		logger.Fatalf("invalid replicas: %s", err.Error())
+	}
+
+	return replicas
+}
+
+// EncryptionKeys parses the encryption keys of the peers. It returns nil if some peer has none.
+func EncryptionKeys(logger *logrus.Logger, systemConfig schultz.SystemConfig) map[int64]schultz.EncryptionPublicKey {
+	keys := make(map[int64]schultz.EncryptionPublicKey)
+	for name, peer := range systemConfig.Peers {
+		if peer.PublicKey == "" {
+			return nil
+		}
+
+		raw, err := hex.DecodeString(peer.PublicKey)
+		if err != nil {
+			logger.Fatalf("invalid public key for peer %s: %s", name, err.Error())
+		}
+
+		key, err := schultz.ParseEncryptionPublicKey(raw)
+		if err != nil {
+			logger.Fatalf("invalid public key for peer %s: %s", name, err.Error())
+		}
+
+		keys[peer.Id] = key
+	}
+
+	return keys
+}
+
+// RequireEncryptionKeys stops the commands running as separate processes if some peer has no encryption key.
+func RequireEncryptionKeys(logger *logrus.Logger, pp schultz.PublicParameter) {
+	if !pp.HasEncryptionKeys() {
+		logger.Fatalf("every peer needs a publicKey. Generate keys with node --genkey=<file>")
+	}
+}
+
+// IdentityKeys parses the identity keys of the peers, and of the dealer with bootstrap = "dealer".
+// It returns nil if one of them has none.
+func IdentityKeys(logger *logrus.Logger, systemConfig schultz.SystemConfig) schultz.IdentityKeys {
+	keys := make(schultz.IdentityKeys)
+	for name, peer := range systemConfig.Peers {
+		if peer.IdentityKey == "" {
+			return nil
+		}
+
+		key, err := schultz.ParseIdentityKey(peer.IdentityKey)
+		if err != nil {
+			logger.Fatalf("invalid identity key for peer %s: %s", name, err.Error())
+		}
+
+		keys[peer.Id] = key
+	}
+
+	if systemConfig.GetBootstrap() == schultz.BootstrapDealer {
+		if systemConfig.Dealer.IdentityKey == "" {
+			return nil
+		}
+
+		key, err := schultz.ParseIdentityKey(systemConfig.Dealer.IdentityKey)
+		if err != nil {
+			logger.Fatalf("invalid identity key for the dealer: %s", err.Error())
+		}
+
+		keys[schultz.DealerId] = key
+	}
+
+	return keys
+}
+
+// RequireIdentityKeys stops the commands running as separate processes if some party has no identity key.
+func RequireIdentityKeys(logger *logrus.Logger, pp schultz.PublicParameter) {
+	if !pp.HasIdentityKeys() {
+		logger.Fatalf("every peer and the dealer need an identityKey. Generate keys with node --genidentity=<file>")
+	}
+}
+
+// Credentials loads the TLS certificate of a party. It returns nil if the config has no [tls] section.
+func Credentials(logger *logrus.Logger, systemConfig schultz.SystemConfig, identity schultz.Identity, certFile, keyFile string) *schultz.Credentials {
+	if systemConfig.TLS.CA == "" {
+		return nil
+	}
+
+	if certFile == "" || keyFile == "" {
+		logger.Fatalf("%s needs tlsCert and tlsKey. Generate them with keygen", identity)
+	}
+
+	creds, err := schultz.LoadCredentials(systemConfig.TLS.CA, certFile, keyFile)
+	if err != nil {
+		logger.Fatalf("can't load the TLS certificate of %s: %s", identity, err.Error())
+	}
+
+	if creds.Identity() != identity {
+		logger.Fatalf("%s is the certificate of %s, not %s", certFile, creds.Identity(), identity)
+	}
+
+	return creds
+}
+
+// RequireTLS stops the commands running as separate processes if the config has no CA,
+// since their connections go over the network.
+func RequireTLS(logger *logrus.Logger, systemConfig schultz.SystemConfig) {
+	if systemConfig.TLS.CA == "" {
+		logger.Fatalf("the config needs a [tls] section. Generate the certificates with keygen")
+	}
+}
+
+// RequireGrpcBoard stops the commands running as separate processes on a simulated chain,
+// which only lives inside the local simulation.
+func RequireGrpcBoard(logger *logrus.Logger, systemConfig schultz.SystemConfig) {
+	if systemConfig.GetBoard() != schultz.BoardGrpc {
+		logger.Fatalf("board = %q only works with the protocol command", systemConfig.GetBoard())
+	}
+}
+
+// LocalKeys loads the private keys of every party running in this process. The keys missing from
+// the config are generated instead, since nobody else needs them.
+func LocalKeys(logger *logrus.Logger, pp schultz.PublicParameter, systemConfig schultz.SystemConfig) (schultz.PublicParameter, map[int64]*schultz.EncryptionKey, map[int64]ed25519.PrivateKey) {
+	// the encryption keys of the nodes
+	encryptionKeys := make(map[int64]*schultz.EncryptionKey)
+	if pp.HasEncryptionKeys() {
+		for _, nodeConfig := range systemConfig.Peers {
+			key, err := schultz.LoadEncryptionKey(nodeConfig.PrivateKeyFile)
+			if err != nil {
+				logger.Fatalf("can't load the key of node %d: %s", nodeConfig.Id, err.Error())
+			}
+			encryptionKeys[nodeConfig.Id] = key
+		}
+	} else {
+		public := make(map[int64]schultz.EncryptionPublicKey)
+		for _, nodeConfig := range systemConfig.Peers {
+			key, err := schultz.GenerateEncryptionKey()
+			if err != nil {
+				logger.Fatalf("can't generate a key: %s", err.Error())
+			}
+			encryptionKeys[nodeConfig.Id] = key
+			public[nodeConfig.Id] = key.Public
+		}
+		pp = pp.WithEncryptionKeys(public)
+	}
+
+	// the same for the identity keys, which the dealer has too
+	identityKeys := make(map[int64]ed25519.PrivateKey)
+	if pp.HasIdentityKeys() {
+		for _, nodeConfig := range systemConfig.Peers {
+			key, err := schultz.LoadIdentityKey(nodeConfig.IdentityKeyFile)
+			if err != nil {
+				logger.Fatalf("can't load the identity key of node %d: %s", nodeConfig.Id, err.Error())
+			}
+			identityKeys[nodeConfig.Id] = key
+		}
+
+		if systemConfig.GetBootstrap() == schultz.BootstrapDealer {
+			key, err := schultz.LoadIdentityKey(systemConfig.Dealer.IdentityKeyFile)
+			if err != nil {
+				logger.Fatalf("can't load the identity key of the dealer: %s", err.Error())
+			}
+			identityKeys[schultz.DealerId] = key
+		}
+	} else {
+		public := make(schultz.IdentityKeys)
+		ids := []int64{schultz.DealerId}
+		for _, nodeConfig := range systemConfig.Peers {
+			ids = append(ids, nodeConfig.Id)
+		}
+
+		for _, id := range ids {
+			pub, key, err := ed25519.GenerateKey(crand.Reader)
+			if err != nil {
+				logger.Fatalf("can't generate a key: %s", err.Error())
+			}
+			identityKeys[id] = key
+			public[id] = pub
+		}
+		pp = pp.WithIdentityKeys(public)
+	}
+
+	return pp, encryptionKeys, identityKeys
+}
+
+func Init(nodeName string, opt CmdOpt) (*logrus.Logger, schultz.PublicParameter, schultz.SystemConfig, map[schultz.NewNodeID]string, polyring.Polynomial) {
 	systemConfig, err := schultz.ParseConfigFile(opt.Config)
 	if err != nil {
 		panic(err.Error())
@@ -66,26 +289,39 @@ func Init(nodeName string, opt CmdOpt) (*logrus.Logger, schultz.PublicParameter,
 		ForceColors:   true,
 	})
 
-	var nodeIdList []int64
+	nodeIPList := make(map[schultz.NewNodeID]string)
 	for _, cf := range systemConfig.Peers {
-		nodeIdList = append(nodeIdList, cf.Id)
+		nodeIPList[schultz.NewNodeID(cf.Id)] = cf.Url
 	}
 
-	var nodeIPList []string
-	for _, cf := range systemConfig.Peers {
-		nodeIPList = append(nodeIPList, cf.Url)
+	oldGroup, newGroup, err := systemConfig.Committees()
+	if err != nil {
+		logger.Fatalf("invalid committees: %s", err.Error())
 	}
 
-	if len(nodeIdList) < 3*systemConfig.Degree+1 {
-		logger.Fatalf("N >= 3t+1 is required. N=%d, t=%d", len(nodeIdList), systemConfig.Degree)
+	newDegree := systemConfig.GetNewDegree()
+	if err := schultz.CheckGroupSizes(len(oldGroup), len(newGroup), systemConfig.Degree, newDegree); err != nil {
+		logger.Fatalf("invalid committees: %s", err.Error())
 	}
 
-	pp := schultz.BuildConfig(
+	pp, err := schultz.BuildHandoffConfig(
 		systemConfig.Degree,
-		polycommit.Curve.Ngmp,
-		nodeIdList,
-		nodeIdList,
+		newDegree,
+		schultz.FieldPrime,
+		oldGroup,
+		newGroup,
 	)
+	if err != nil {
+		logger.Fatalf("invalid degrees: %s", err.Error())
+	}
+
+	if keys := EncryptionKeys(logger, systemConfig); keys != nil {
+		pp = pp.WithEncryptionKeys(keys)
+	}
+
+	if keys := IdentityKeys(logger, systemConfig); keys != nil {
+		pp = pp.WithIdentityKeys(keys)
+	}
 
 	// make sure all nodes start with the same polynomial
 	rng := rand.New(rand.NewSource(0))
@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"../../src/protocols/schultz"
+	"github.com/docopt/docopt-go"
+)
+
+func main() {
+	usage := `TLS certificates for a config of the MPSS Protocol.
+
+Creates a CA in the output directory and signs a certificate for the primary, every peer and replica,
+and the dealer if the config uses bootstrap = "dealer". Prints the lines to add to the config.
+
+Usage:
+  keygen --config=<cfg> [--out=<dir>]
+
+Options:
+  -h --help     		Show this screen.
+  -c, --config=<cfg>  	Path to the configuration file.
+  --out=<dir>  			Where to write the certificates [default: ./certs].`
+
+	arguments, err := docopt.ParseDoc(usage)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	configFile, _ := arguments.String("--config")
+	outDir, _ := arguments.String("--out")
+
+	systemConfig, err := schultz.ParseConfigFile(configFile)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	parties := map[schultz.Identity]string{
+		schultz.PrimaryIdentity(): systemConfig.Primary.Url,
+	}
+
+	peers := make(map[schultz.Identity]string)
+	for name, peer := range systemConfig.Peers {
+		parties[schultz.NodeIdentity(peer.Id)] = peer.Url
+		peers[schultz.NodeIdentity(peer.Id)] = "peers." + name
+	}
+
+	replicas := make(map[schultz.Identity]string)
+	for name, replica := range systemConfig.Replicas {
+		parties[schultz.ReplicaIdentity(replica.Id)] = replica.Url
+		replicas[schultz.ReplicaIdentity(replica.Id)] = "replicas." + name
+	}
+
+	if systemConfig.GetBootstrap() == schultz.BootstrapDealer {
+		parties[schultz.DealerIdentity()] = ""
+	}
+
+	if err := os.MkdirAll(outDir, 0700); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	ca, files, err := schultz.GenerateCertificates(outDir, parties)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	// print the sections in a stable order
+	var identities []schultz.Identity
+	for identity := range files {
+		identities = append(identities, identity)
+	}
+	sort.Slice(identities, func(i, j int) bool {
+		if identities[i].Role != identities[j].Role {
+			return identities[i].Role < identities[j].Role
+		}
+		return identities[i].Id < identities[j].Id
+	})
+
+	fmt.Printf("[tls]\nca = \"%s\"\n", ca)
+
+	for _, identity := range identities {
+		section := identity.Role
+		switch identity.Role {
+		case schultz.RoleNode:
+			section = peers[identity]
+		case schultz.RoleReplica:
+			section = replicas[identity]
+		}
+
+		fmt.Printf("\n[%s]\ntlsCert = \"%s\"\ntlsKey = \"%s\"\n", section, files[identity].Cert, files[identity].Key)
+	}
+}
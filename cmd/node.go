@@ -13,13 +13,25 @@ import (
 func main() {
 	usage := `Main node in MPSS Protocol.
 
+--genkey writes a new private key to the given file and prints the public key for the config.
+--genidentity does the same for the key the node signs its messages with.
+--genstorekey writes a new key for the share store to the given file.
+--recover rebuilds a lost share from the other holders, saves it to the share store and exits.
+
 Usage:
   node --config=<cfg> --id=<id> [options]
+  node --genkey=<file>
+  node --genidentity=<file>
+  node --genstorekey=<file>
 
 Options:
   -h --help     		Show this screen.
   --version     		Show version.
   -c, --config=<cfg>  	Path to the configuration file.
+  --genkey=<file>  		Generate a key pair.
+  --genidentity=<file>  	Generate an identity key pair.
+  --genstorekey=<file>  	Generate a key for the share store.
+  --recover  			Recover a lost share [default: false].
   --round=<round>  		set the maxEpoch [default: 1].
   --logdir=<dir>  		set the maxEpoch [default: ./log-node].
   -v, --verbose  		Verbose output [default: false].
@@ -32,6 +44,38 @@ Options:
 		os.Exit(1)
 	}
 
+	if keyFile, err := arguments.String("--genkey"); err == nil && keyFile != "" {
+		publicKey, err := schultz.GenerateEncryptionKeyFile(keyFile)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("publicKey = \"%s\"\n", publicKey)
+		return
+	}
+
+	if keyFile, err := arguments.String("--genidentity"); err == nil && keyFile != "" {
+		identityKey, err := schultz.GenerateIdentityKey(keyFile)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("identityKey = \"%s\"\n", identityKey)
+		return
+	}
+
+	if keyFile, err := arguments.String("--genstorekey"); err == nil && keyFile != "" {
+		if err := schultz.GenerateShareStoreKey(keyFile); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("shareStoreKeyFile = \"%s\"\n", keyFile)
+		return
+	}
+
 	var cmdOpt CmdOpt
 	err = arguments.Bind(&cmdOpt)
 	if err != nil {
@@ -39,9 +83,23 @@ Options:
 	}
 
 	logger, pp, systemConfig, _, secretSharePoly := Init(cmdOpt.Id, cmdOpt)
+	RequireGrpcBoard(logger, systemConfig)
+	RequireEncryptionKeys(logger, pp)
+	RequireIdentityKeys(logger, pp)
+	RequireTLS(logger, systemConfig)
 
 	myConfig := systemConfig.Peers[cmdOpt.Id]
 
+	key, err := schultz.LoadEncryptionKey(myConfig.PrivateKeyFile)
+	if err != nil {
+		logger.Fatalf("can't load the key: %s", err.Error())
+	}
+
+	identityKey, err := schultz.LoadIdentityKey(myConfig.IdentityKeyFile)
+	if err != nil {
+		logger.Fatalf("can't load the identity key: %s", err.Error())
+	}
+
 	peerIPs := make(map[schultz.NewNodeID]string)
 	for _, otherConfig := range systemConfig.Peers {
 		if otherConfig.Id == myConfig.Id {
@@ -51,11 +109,54 @@ Options:
 		peerIPs[schultz.NewNodeID(otherConfig.Id)] = otherConfig.Url
 	}
 
-	share := gmp.NewInt(0)
-	secretSharePoly.EvalMod(gmp.NewInt(myConfig.Id), pp.GetPrime(), share)
+	// only the old group starts with a share
+	var share *gmp.Int
+	if pp.IsOldMember(myConfig.Id) {
+		share = gmp.NewInt(0)
+		secretSharePoly.EvalMod(gmp.NewInt(myConfig.Id), pp.GetPrime(), share)
+	}
 
 	logger.Infof("starting node %d", myConfig.Id)
 	myNode := schultz.BuildNode(pp, logger, myConfig.Id, systemConfig.Primary.Url, myConfig.Url, peerIPs, share)
+	myNode.SetReplicas(Replicas(logger, systemConfig))
+	myNode.SetModeOption(systemConfig.GetMode())
+	myNode.SetDeadlines(systemConfig.Deadlines)
+	myNode.SetMessageLimits(systemConfig.Messages)
+	myNode.SetEncryptionKey(key)
+	myNode.SetIdentityKey(identityKey)
+	myNode.SetCredentials(Credentials(logger, systemConfig, schultz.NodeIdentity(myConfig.Id), myConfig.TlsCert, myConfig.TlsKey))
+
+	if cmdOpt.Recover {
+		store := ShareStore(logger, myConfig)
+		if store == nil {
+			logger.Fatalf("a recovered share needs a share store")
+		}
+
+		if err := myNode.ConnectPeers(); err != nil {
+			logger.Fatalf("cannot connect to peers: %s", err.Error())
+		}
+
+		state, err := myNode.Recover(systemConfig.Deadlines.GetRecovery())
+		if err != nil {
+			logger.Fatalf("can't recover the share: %s", err.Error())
+		}
+
+		if err := store.Save(state); err != nil {
+			logger.Fatalf("can't save the recovered share: %s", err.Error())
+		}
+
+		logger.Infof("recovered the share of epoch %d", state.Epoch)
+		return
+	}
+
+	// a restarted node picks up where it left off
+	resumed := false
+	if store := ShareStore(logger, myConfig); store != nil {
+		resumed, err = myNode.UseShareStore(store)
+		if err != nil {
+			logger.Fatalf("can't load the share store: %s", err.Error())
+		}
+	}
 
 	go myNode.Serve()
 
@@ -64,7 +165,22 @@ Options:
 	}
 
 	// must use epoch zero to kick off the protocol
-	myNode.SubmitShareToPrimary(0)
+	if pp.IsOldMember(myConfig.Id) && !resumed {
+		switch systemConfig.GetBootstrap() {
+		case schultz.BootstrapDKG:
+			if err := myNode.RunDKG(); err != nil {
+				logger.Fatalf("DKG failed: %s", err.Error())
+			}
+		case schultz.BootstrapDealer:
+			if err := myNode.WaitForDealer(); err != nil {
+				logger.Fatalf("can't get a share from the dealer: %s", err.Error())
+			}
+		}
+
+		if err := myNode.ReportShare(0); err != nil {
+			logger.Errorf("can't report the initial share: %s", err.Error())
+		}
+	}
 
 	// start the main thread
 	var waitGoRoutines sync.WaitGroup
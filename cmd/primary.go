@@ -38,9 +38,19 @@ Options:
 	logger, pp, systemConfig, nodeIPList, _ := Init("primary", cmdOpt)
 
 	logger.Infof("using config file %s", cmdOpt.Config)
+	RequireGrpcBoard(logger, systemConfig)
+	RequireEncryptionKeys(logger, pp)
+	RequireIdentityKeys(logger, pp)
+	RequireTLS(logger, systemConfig)
 
 	// build the primary
 	primary := schultz.BuildBulletinBoard(logger, systemConfig.Primary.Url, nodeIPList, pp)
+	primary.SetBootstrapOption(systemConfig.GetBootstrap())
+	primary.SetModeOption(systemConfig.GetMode())
+	primary.SetDeadlines(systemConfig.Deadlines)
+	primary.SetMessageLimits(systemConfig.Messages)
+	primary.SetReplicas(Replicas(logger, systemConfig))
+	primary.SetCredentials(Credentials(logger, systemConfig, schultz.PrimaryIdentity(), systemConfig.Primary.TlsCert, systemConfig.Primary.TlsKey))
 
 	go primary.StartProtocol()
 
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"crypto/rand"
 	"fmt"
 	"os"
 	"runtime/pprof"
@@ -9,6 +10,7 @@ import (
 	"../../src/protocols/schultz"
 	"github.com/docopt/docopt-go"
 	"github.com/ncw/gmp"
+	"golang.org/x/crypto/ed25519"
 )
 
 func main() {
@@ -23,6 +25,7 @@ Options:
   -c, --config=<cfg>  	Path to the configuration file.
   --round=<round>  		set the maxEpoch [default: 1].
   --logdir=<dir>  		set the maxEpoch [default: .].
+  --memory  			Run over an in-memory network instead of TCP [default: false].
   -v, --verbose  		Verbose output [default: false].
   --debug  				Super verbose output [default: false].
 `
@@ -49,8 +52,61 @@ Options:
 	pprof.StartCPUProfile(f)
 	defer pprof.StopCPUProfile()
 
+	pp, encryptionKeys, identityKeys := LocalKeys(logger, pp, systemConfig)
+
+	// everything runs here, so the parties may as well talk without sockets
+	var network schultz.Transport = schultz.GRPCTransport{}
+	if cmdOpt.Memory {
+		network = schultz.NewMemoryNetwork()
+	}
+
 	// build the primary
 	primary := schultz.BuildBulletinBoard(logger, systemConfig.Primary.Url, nodeIPList, pp)
+	primary.SetBootstrapOption(systemConfig.GetBootstrap())
+	primary.SetModeOption(systemConfig.GetMode())
+	primary.SetDeadlines(systemConfig.Deadlines)
+	primary.SetMessageLimits(systemConfig.Messages)
+	primary.SetCredentials(Credentials(logger, systemConfig, schultz.PrimaryIdentity(), systemConfig.Primary.TlsCert, systemConfig.Primary.TlsKey))
+	primary.SetTransport(network)
+
+	replicaSet := Replicas(logger, systemConfig)
+	primary.SetReplicas(replicaSet)
+
+	// the board is either served by the primary or a simulated chain, where every party has an account
+	var chain *schultz.SimChain
+	if systemConfig.GetBoard() == schultz.BoardChain {
+		accounts := make(schultz.IdentityKeys)
+		for id, key := range identityKeys {
+			accounts[id] = key.Public().(ed25519.PublicKey)
+		}
+
+		boardPublic, boardKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			logger.Fatalf("can't generate the key of the board: %s", err.Error())
+		}
+		accounts[schultz.BoardId] = boardPublic
+
+		chain = schultz.BuildSimChain(systemConfig.Chain, accounts, logger)
+		primary.SetBoard(chain.Account(boardKey))
+	}
+
+	// run the replicas of the bulletin board, if any
+	for _, replicaConfig := range systemConfig.Replicas {
+		key, err := schultz.LoadIdentityKey(replicaConfig.PrivateKeyFile)
+		if err != nil {
+			logger.Fatalf("can't load the key of replica %d: %s", replicaConfig.Id, err.Error())
+		}
+
+		replica := schultz.BuildReplica(pp, logger, replicaConfig.Id, key, replicaSet)
+		replica.SetCredentials(Credentials(logger, systemConfig, schultz.ReplicaIdentity(replicaConfig.Id), replicaConfig.TlsCert, replicaConfig.TlsKey))
+		replica.SetTransport(network)
+		if err := replica.Connect(systemConfig.Primary.Url, nodeIPList); err != nil {
+			logger.Fatalf("replica %d can't connect: %s", replicaConfig.Id, err.Error())
+		}
+
+		go replica.Serve()
+		go replica.Run()
+	}
 
 	// build all the nodes
 	var nodes []schultz.Node
@@ -67,20 +123,38 @@ Options:
 			peerIPs[schultz.NewNodeID(otherConfig.Id)] = otherConfig.Url
 		}
 
-		share := gmp.NewInt(0)
-		secretSharePoly.EvalMod(gmp.NewInt(nodeConfig.Id), pp.GetPrime(), share)
+		// only the old group starts with a share
+		var share *gmp.Int
+		if pp.IsOldMember(nodeConfig.Id) {
+			share = gmp.NewInt(0)
+			secretSharePoly.EvalMod(gmp.NewInt(nodeConfig.Id), pp.GetPrime(), share)
+		}
 
 		nodes = append(nodes, schultz.BuildNode(pp, logger, nodeConfig.Id, systemConfig.Primary.Url, ip, peerIPs, share))
+		nodes[len(nodes)-1].SetCredentials(Credentials(logger, systemConfig, schultz.NodeIdentity(nodeConfig.Id), nodeConfig.TlsCert, nodeConfig.TlsKey))
+	}
+
+	for i := range nodes {
+		nodes[i].SetReplicas(replicaSet)
+		nodes[i].SetTransport(network)
+		nodes[i].SetModeOption(systemConfig.GetMode())
+		nodes[i].SetDeadlines(systemConfig.Deadlines)
+		nodes[i].SetMessageLimits(systemConfig.Messages)
+		nodes[i].SetEncryptionKey(encryptionKeys[nodes[i].GetId()])
+		nodes[i].SetIdentityKey(identityKeys[nodes[i].GetId()])
+		if chain != nil {
+			nodes[i].SetBoard(chain.Account(identityKeys[nodes[i].GetId()]))
+		}
 	}
 
 	for i := range nodes {
 		logger.Infof("starting %d th node", i)
-		go func(node schultz.Node) {
-			node.Serve()
-		}(nodes[i])
+		go nodes[i].Serve()
 	}
 
-	go primary.Serve()
+	if chain == nil {
+		go primary.Serve()
+	}
 	go primary.StartProtocol()
 
 	// prevent the primary from getting killed
@@ -95,7 +169,50 @@ Options:
 
 	// must use epoch zero to kick off the protocol
 	for i := range nodes {
-		go nodes[i].SubmitShareToPrimary(0)
+		if pp.IsOldMember(nodes[i].GetId()) {
+			go func(node *schultz.Node) {
+				switch systemConfig.GetBootstrap() {
+				case schultz.BootstrapDKG:
+					if err := node.RunDKG(); err != nil {
+						logger.Fatalf("DKG failed: %s", err.Error())
+					}
+				case schultz.BootstrapDealer:
+					if err := node.WaitForDealer(); err != nil {
+						logger.Fatalf("can't get a share from the dealer: %s", err.Error())
+					}
+				}
+
+				if err := node.ReportShare(0); err != nil {
+					logger.Errorf("node %d can't report its initial share: %s", node.GetId(), err.Error())
+				}
+			}(&nodes[i])
+		}
+	}
+
+	// deal the benchmark secret
+	if systemConfig.GetBootstrap() == schultz.BootstrapDealer {
+		creds := Credentials(logger, systemConfig, schultz.DealerIdentity(), systemConfig.Dealer.TlsCert, systemConfig.Dealer.TlsKey)
+
+		var dealerBoard schultz.Board
+		if chain != nil {
+			dealerBoard = chain.Account(identityKeys[schultz.DealerId])
+		} else {
+			dealerBoard, err = schultz.DialBoard(network, systemConfig.Primary.Url, creds, logger)
+			if err != nil {
+				logger.Fatalf("the dealer can't connect to the primary: %s", err.Error())
+			}
+		}
+
+		dealer := schultz.BuildDealer(pp, logger, dealerBoard, nodeIPList)
+		dealer.SetCredentials(creds)
+		dealer.SetTransport(network)
+		dealer.SetIdentityKey(identityKeys[schultz.DealerId])
+		dealer.SetDeadlines(systemConfig.Deadlines)
+		go func() {
+			if err := dealer.Deal(gmp.NewInt(0).Set(secretSharePoly.GetPtrToConstant())); err != nil {
+				logger.Fatalf("dealer failed: %s", err.Error())
+			}
+		}()
 	}
 
 	var waitGoRoutines sync.WaitGroup
@@ -107,4 +224,9 @@ Options:
 	}
 
 	waitGoRoutines.Wait()
+
+	if chain != nil {
+		chain.Report()
+		chain.Stop()
+	}
 }
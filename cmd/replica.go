@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"../../src/protocols/schultz"
+	"github.com/docopt/docopt-go"
+)
+
+func main() {
+	usage := `Replica of the bulletin board in MPSS Protocol.
+
+The replicas listed in the config order the proposal hashes among themselves.
+--genkey writes a new private key to the given file and prints the public key for the config.
+
+Usage:
+  replica --config=<cfg> --id=<id> [options]
+  replica --genkey=<file>
+
+Options:
+  -h --help     		Show this screen.
+  --version     		Show version.
+  -c, --config=<cfg>  	Path to the configuration file.
+  --genkey=<file>  		Generate a key pair.
+  --logdir=<dir>  		set the log directory [default: .].
+  -v, --verbose  		Verbose output [default: false].
+  --debug  				Super verbose output [default: false].`
+
+	arguments, err := docopt.ParseDoc(usage)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	if keyFile, err := arguments.String("--genkey"); err == nil && keyFile != "" {
+		publicKey, err := schultz.GenerateIdentityKey(keyFile)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		fmt.Printf("publicKey = \"%s\"\n", publicKey)
+		return
+	}
+
+	var cmdOpt CmdOpt
+	err = arguments.Bind(&cmdOpt)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	logger, pp, systemConfig, nodeIPList, _ := Init("replica-"+cmdOpt.Id, cmdOpt)
+
+	myConfig, ok := systemConfig.Replicas[cmdOpt.Id]
+	if !ok {
+		logger.Fatalf("can't find replica %s in the config", cmdOpt.Id)
+	}
+
+	key, err := schultz.LoadIdentityKey(myConfig.PrivateKeyFile)
+	if err != nil {
+		logger.Fatalf("can't load the key: %s", err.Error())
+	}
+
+	RequireIdentityKeys(logger, pp)
+	RequireTLS(logger, systemConfig)
+
+	replica := schultz.BuildReplica(pp, logger, myConfig.Id, key, Replicas(logger, systemConfig))
+	replica.SetCredentials(Credentials(logger, systemConfig, schultz.ReplicaIdentity(myConfig.Id), myConfig.TlsCert, myConfig.TlsKey))
+	if err := replica.Connect(systemConfig.Primary.Url, nodeIPList); err != nil {
+		logger.Fatalf("can't connect: %s", err.Error())
+	}
+
+	go replica.Run()
+
+	// blocks
+	replica.Serve()
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"../../src/protocols/schultz"
+	"github.com/docopt/docopt-go"
+	"github.com/ncw/gmp"
+)
+
+func main() {
+	usage := `MPSS Protocol over a simulated network (local).
+
+Usage:
+  simulate --config=<cfg> [options]
+
+Options:
+  -h --help     		Show this screen.
+  --version     		Show version.
+  -c, --config=<cfg>  	Path to the configuration file. The network is in its [simulation] section.
+  --round=<round>  		set the maxEpoch [default: 1].
+  --logdir=<dir>  		set the maxEpoch [default: .].
+  -v, --verbose  		Verbose output [default: false].
+  --debug  				Super verbose output [default: false].
+`
+
+	arguments, err := docopt.ParseDoc(usage)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	var cmdOpt CmdOpt
+	err = arguments.Bind(&cmdOpt)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	logger, pp, systemConfig, nodeIPList, secretSharePoly := Init("simulate", cmdOpt)
+
+	// the simulated network only times the handoffs, between parties that all play along
+	if systemConfig.GetBootstrap() != schultz.BootstrapFixed {
+		logger.Fatalf("the simulation needs bootstrap = %q", schultz.BootstrapFixed)
+	}
+	if systemConfig.GetBoard() != schultz.BoardGrpc || len(systemConfig.Replicas) > 0 {
+		logger.Fatalf("the simulation runs the board itself, without a chain or replicas")
+	}
+
+	pp, encryptionKeys, identityKeys := LocalKeys(logger, pp, systemConfig)
+
+	sim := schultz.BuildSimulation(systemConfig.Simulation, logger)
+	defer sim.Stop()
+
+	// build the primary, which only runs the board logic
+	primary := schultz.BuildBulletinBoard(logger, systemConfig.Primary.Url, nodeIPList, pp)
+	primary.SetDeadlines(systemConfig.Deadlines)
+	primary.SetSuicideOption(false)
+	sim.AddBoard(&primary)
+
+	// build all the nodes
+	var nodes []schultz.Node
+
+	for name, nodeConfig := range systemConfig.Peers {
+		peerIPs := make(map[schultz.NewNodeID]string)
+		for otherName, otherConfig := range systemConfig.Peers {
+			if otherName == name {
+				continue
+			}
+
+			peerIPs[schultz.NewNodeID(otherConfig.Id)] = otherConfig.Url
+		}
+
+		// only the old group starts with a share
+		var share *gmp.Int
+		if pp.IsOldMember(nodeConfig.Id) {
+			share = gmp.NewInt(0)
+			secretSharePoly.EvalMod(gmp.NewInt(nodeConfig.Id), pp.GetPrime(), share)
+		}
+
+		nodes = append(nodes, schultz.BuildNode(pp, logger, nodeConfig.Id, systemConfig.Primary.Url, nodeConfig.Url, peerIPs, share))
+	}
+
+	for i := range nodes {
+		nodes[i].SetDeadlines(systemConfig.Deadlines)
+		nodes[i].SetEncryptionKey(encryptionKeys[nodes[i].GetId()])
+		nodes[i].SetIdentityKey(identityKeys[nodes[i].GetId()])
+		sim.AddNode(&nodes[i])
+	}
+
+	for i := range nodes {
+		go nodes[i].Serve()
+	}
+
+	sim.Go(primary.StartProtocol)
+
+	for i := range nodes {
+		if err := nodes[i].ConnectPrimary(); err != nil {
+			logger.Fatalf("cannot connect to the board")
+		}
+	}
+
+	// must use epoch zero to kick off the protocol
+	for i := range nodes {
+		if pp.IsOldMember(nodes[i].GetId()) {
+			node := &nodes[i]
+			sim.Go(func() { node.ReportShare(0) })
+		}
+	}
+
+	var waitGoRoutines sync.WaitGroup
+	waitGoRoutines.Add(len(nodes))
+
+	// every node reports its benchmark, in simulated time
+	for i := range nodes {
+		node := &nodes[i]
+		sim.Go(func() { node.StartProtocol(&waitGoRoutines, schultz.Epoch(cmdOpt.Round)) })
+	}
+	sim.Start()
+
+	waitGoRoutines.Wait()
+}
@@ -0,0 +1,221 @@
+package Schultz
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"../../utils/conv"
+	"../../utils/polyring"
+	"filippo.io/nistec"
+	"github.com/ncw/gmp"
+)
+
+// Polynomials are committed to with Feldman commitments on P-256, the curve the points of a proposal are
+// encrypted on: a_0 + a_1 x + ... + a_t x^t is committed to as a_0 G, a_1 G, ..., a_t G. The secret is
+// thus shared in the field of the exponents, whose prime is the order of the curve. P-256 has prime order,
+// so every point on the curve is in the group generated by G and there is no subgroup to check.
+//
+// The commitments of the pbc package were checked the same way, against the commitments to the
+// coefficients with no witness, so only the group changed: binding still rests on discrete logarithms.
+// It had to change for two reasons. A point encrypted to an old node can only be proven to lie on the
+// committed polynomials if the ciphertext and the commitments are in the same group, of the same order.
+// And the pbc commitments only went over the wire as a gob blob of their internals, with no encoding a
+// decoder could check the points of. A P-256 point has a standard compressed encoding, and being on the
+// curve is all there is to check.
+//
+// The arithmetic is that of nistec, constant time, since the points are multiplied by secrets: the
+// randomness of the ciphertexts and the keys of the nodes. Shares, persisted ones included, are thus
+// below the order of P-256. A store written with the pbc commitments doesn't decode, so a node never
+// resumes with a share of the old field.
+
+// FieldPrime is the prime of the field the secret is shared in: the order of P-256.
+var FieldPrime = gmp.NewInt(0).SetBytes(encryptionCurve.Params().N.Bytes())
+
+// pointBytes is the size of a compressed point. The point at infinity, the commitment to a zero
+// coefficient, takes as many bytes, all zero.
+const pointBytes = 33
+
+// curvePoint is a point of P-256. The operations return new points and leave their operands alone.
+type curvePoint struct {
+	p *nistec.P256Point
+}
+
+func infinity() curvePoint {
+	return curvePoint{nistec.NewP256Point()}
+}
+
+// scalarOf reduces k modulo the order and encodes it on the 32 bytes nistec takes.
+func scalarOf(k *big.Int) []byte {
+	b := make([]byte, 32)
+	new(big.Int).Mod(k, encryptionCurve.Params().N).FillBytes(b)
+
+	return b
+}
+
+// The scalar multiplications below only fail on a scalar that isn't 32 bytes, which scalarOf never returns.
+
+func baseMult(k *big.Int) curvePoint {
+	p, _ := nistec.NewP256Point().ScalarBaseMult(scalarOf(k))
+	return curvePoint{p}
+}
+
+func (p curvePoint) mult(k *big.Int) curvePoint {
+	q, _ := nistec.NewP256Point().ScalarMult(p.p, scalarOf(k))
+	return curvePoint{q}
+}
+
+func (p curvePoint) add(q curvePoint) curvePoint {
+	return curvePoint{nistec.NewP256Point().Add(p.p, q.p)}
+}
+
+func (p curvePoint) isInfinity() bool {
+	// nistec encodes the point at infinity as a single zero
+	return len(p.p.Bytes()) == 1
+}
+
+func (p curvePoint) equal(q curvePoint) bool {
+	return bytes.Equal(p.p.Bytes(), q.p.Bytes())
+}
+
+// Bytes compresses the point on pointBytes bytes.
+func (p curvePoint) Bytes() []byte {
+	if p.isInfinity() {
+		return make([]byte, pointBytes)
+	}
+
+	return p.p.BytesCompressed()
+}
+
+// parsePoint decodes a compressed point, rejecting anything that is not on the curve.
+func parsePoint(b []byte) (curvePoint, error) {
+	if len(b) != pointBytes {
+		return curvePoint{}, fmt.Errorf("a point has %d bytes, not %d", len(b), pointBytes)
+	}
+
+	if bytes.Equal(b, make([]byte, pointBytes)) {
+		return infinity(), nil
+	}
+
+	p, err := nistec.NewP256Point().SetBytes(b)
+	if err != nil {
+		return curvePoint{}, fmt.Errorf("not a point on P-256")
+	}
+
+	return curvePoint{p}, nil
+}
+
+// PolyCommit commits to a polynomial with one point for each of its coefficients.
+type PolyCommit struct {
+	coefficients []curvePoint
+}
+
+func NewPolyCommit(poly polyring.Polynomial) PolyCommit {
+	coefficients := poly.GetAllCoefficients()
+
+	comm := PolyCommit{coefficients: make([]curvePoint, len(coefficients))}
+	for i, a := range coefficients {
+		comm.coefficients[i] = baseMult(conv.GmpInt2BigInt(a))
+	}
+
+	return comm
+}
+
+// AdditiveHomomorphism returns the commitment to the sum of the polynomials committed to by a and b.
+func AdditiveHomomorphism(a, b PolyCommit) PolyCommit {
+	if len(a.coefficients) < len(b.coefficients) {
+		a, b = b, a
+	}
+
+	sum := PolyCommit{coefficients: make([]curvePoint, len(a.coefficients))}
+	for i, c := range a.coefficients {
+		if i < len(b.coefficients) {
+			c = c.add(b.coefficients[i])
+		}
+		sum.coefficients[i] = c
+	}
+
+	return sum
+}
+
+// Degree is the degree of the committed polynomial, as many coefficients as it was committed with.
+func (c PolyCommit) Degree() int {
+	return len(c.coefficients) - 1
+}
+
+// eval returns f(x) G, for the committed f.
+func (c PolyCommit) eval(x *big.Int) curvePoint {
+	acc := infinity()
+	for i := len(c.coefficients) - 1; i >= 0; i-- {
+		acc = acc.mult(x).add(c.coefficients[i])
+	}
+
+	return acc
+}
+
+// VerifyEval tells whether the committed polynomial evaluates to y at x.
+func (c PolyCommit) VerifyEval(x, y *big.Int) bool {
+	return c.eval(x).equal(baseMult(y))
+}
+
+func (c PolyCommit) Equals(other PolyCommit) bool {
+	return bytes.Equal(c.Bytes(), other.Bytes())
+}
+
+// Bytes encodes the compressed points of the coefficients one after the other.
+func (c PolyCommit) Bytes() []byte {
+	b := make([]byte, 0, len(c.coefficients)*pointBytes)
+	for _, p := range c.coefficients {
+		b = append(b, p.Bytes()...)
+	}
+
+	return b
+}
+
+func (c PolyCommit) String() string {
+	s := make([]string, len(c.coefficients))
+	for i, p := range c.coefficients {
+		s[i] = hex.EncodeToString(p.Bytes())
+	}
+
+	return "[" + strings.Join(s, " ") + "]"
+}
+
+func encodeCommitment(comm PolyCommit) []byte {
+	return comm.Bytes()
+}
+
+// decodeCommitment decodes the points of a commitment, each of which has to be on the curve.
+func decodeCommitment(b []byte) (PolyCommit, error) {
+	if len(b)%pointBytes != 0 {
+		return PolyCommit{}, fmt.Errorf("a commitment of %d bytes", len(b))
+	}
+
+	comm := PolyCommit{coefficients: make([]curvePoint, len(b)/pointBytes)}
+	for i := range comm.coefficients {
+		p, err := parsePoint(b[i*pointBytes : (i+1)*pointBytes])
+		if err != nil {
+			return PolyCommit{}, fmt.Errorf("coefficient %d: %s", i, err.Error())
+		}
+		comm.coefficients[i] = p
+	}
+
+	return comm, nil
+}
+
+// decodeCommitmentOfDegree decodes a commitment that has to be to a polynomial of the given degree. A
+// commitment of higher degree would silently raise the threshold of the sharing it is part of.
+func decodeCommitmentOfDegree(b []byte, degree int) (PolyCommit, error) {
+	comm, err := decodeCommitment(b)
+	if err != nil {
+		return PolyCommit{}, err
+	}
+
+	if comm.Degree() != degree {
+		return PolyCommit{}, fmt.Errorf("a commitment of degree %d, not %d", comm.Degree(), degree)
+	}
+
+	return comm, nil
+}
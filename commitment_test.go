@@ -0,0 +1,112 @@
+package Schultz
+
+import (
+	"crypto/elliptic"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"../../utils/conv"
+	"../../utils/polyring"
+	"github.com/ncw/gmp"
+	"github.com/stretchr/testify/assert"
+)
+
+// offCurvePoint returns the compression of an x for which there is no point on the curve.
+func offCurvePoint() []byte {
+	b := make([]byte, pointBytes)
+	b[0] = 2
+	for x := int64(1); ; x++ {
+		big.NewInt(x).FillBytes(b[1:])
+		if px, _ := elliptic.UnmarshalCompressed(encryptionCurve, b); px == nil {
+			return b
+		}
+	}
+}
+
+func TestPolyCommit_VerifyEval(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+
+	poly, err := polyring.NewRand(2, r, FieldPrime)
+	assert.Nil(t, err)
+	comm := NewPolyCommit(poly)
+	assert.Equal(t, 2, comm.Degree())
+
+	y := gmp.NewInt(0)
+	poly.EvalMod(gmp.NewInt(3), FieldPrime, y)
+	assert.True(t, comm.VerifyEval(big.NewInt(3), conv.GmpInt2BigInt(y)))
+	assert.False(t, comm.VerifyEval(big.NewInt(4), conv.GmpInt2BigInt(y)))
+
+	// a zero constant commits to the point at infinity
+	poly.GetPtrToConstant().SetInt64(0)
+	comm = NewPolyCommit(poly)
+	assert.True(t, comm.VerifyEval(big.NewInt(0), big.NewInt(0)))
+
+	// the commitment to a sum is the sum of the commitments
+	other, err := polyring.NewRand(1, r, FieldPrime)
+	assert.Nil(t, err)
+	sum := AdditiveHomomorphism(comm, NewPolyCommit(other))
+	assert.Equal(t, 2, sum.Degree())
+
+	a, b := gmp.NewInt(0), gmp.NewInt(0)
+	poly.EvalMod(gmp.NewInt(5), FieldPrime, a)
+	other.EvalMod(gmp.NewInt(5), FieldPrime, b)
+	a.Add(a, b)
+	a.Mod(a, FieldPrime)
+	assert.True(t, sum.VerifyEval(big.NewInt(5), conv.GmpInt2BigInt(a)))
+}
+
+func TestFieldPrime(t *testing.T) {
+	// the secret is shared modulo the order of the curve, on as many bytes as a coordinate
+	assert.True(t, baseMult(conv.GmpInt2BigInt(FieldPrime)).isInfinity())
+	assert.Equal(t, 32, fieldBytes)
+
+	p := baseMult(big.NewInt(7))
+	assert.True(t, p.mult(big.NewInt(2)).equal(p.add(p)))
+	assert.True(t, p.add(p.neg()).isInfinity())
+	assert.True(t, p.mult(conv.GmpInt2BigInt(FieldPrime)).isInfinity())
+
+	// the encoding round-trips, the point at infinity included
+	for _, q := range []curvePoint{p, infinity()} {
+		decoded, err := parsePoint(q.Bytes())
+		assert.Nil(t, err)
+		assert.True(t, q.equal(decoded))
+	}
+}
+
+func TestDecodeCommitment(t *testing.T) {
+	poly, err := polyring.NewRand(2, rand.New(rand.NewSource(0)), FieldPrime)
+	assert.Nil(t, err)
+	poly.GetPtrToConstant().SetInt64(0)
+	comm := NewPolyCommit(poly)
+
+	encoded := encodeCommitment(comm)
+	assert.Len(t, encoded, 3*pointBytes)
+
+	decoded, err := decodeCommitment(encoded)
+	assert.Nil(t, err)
+	assert.True(t, comm.Equals(decoded))
+
+	// nothing encodes the commitment to no polynomial
+	decoded, err = decodeCommitment(nil)
+	assert.Nil(t, err)
+	assert.True(t, PolyCommit{}.Equals(decoded))
+
+	_, err = decodeCommitment(encoded[1:])
+	assert.NotNil(t, err)
+
+	_, err = decodeCommitment(append(encoded[:2*pointBytes], offCurvePoint()...))
+	assert.NotNil(t, err)
+}
+
+func TestDecodeCommitmentOfDegree(t *testing.T) {
+	poly, err := polyring.NewRand(2, rand.New(rand.NewSource(0)), FieldPrime)
+	assert.Nil(t, err)
+	encoded := encodeCommitment(NewPolyCommit(poly))
+
+	_, err = decodeCommitmentOfDegree(encoded, 2)
+	assert.Nil(t, err)
+
+	_, err = decodeCommitmentOfDegree(encoded, 1)
+	assert.NotNil(t, err)
+}
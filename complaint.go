@@ -0,0 +1,385 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storeWholeProposal keeps a whole proposal we checked, to hand out to the peers pulling it.
+// These are our own, the ones we pulled and the ones the board revealed.
+func (node *Node) storeWholeProposal(epoch Epoch, proposal *services.Proposal) {
+	node.wholeProposalsLock.Lock()
+	defer node.wholeProposalsLock.Unlock()
+
+	// peers still finishing the previous epoch may pull from it, older ones are of no use anymore
+	for e := range node.wholeProposals {
+		if e+1 < epoch {
+			delete(node.wholeProposals, e)
+		}
+	}
+
+	if _, ok := node.wholeProposals[epoch]; !ok {
+		node.wholeProposals[epoch] = make(map[int64]*services.Proposal)
+	}
+	node.wholeProposals[epoch][proposal.From] = proposal
+}
+
+func (node *Node) wholeProposal(epoch Epoch, proposer int64) (*services.Proposal, bool) {
+	node.wholeProposalsLock.Lock()
+	defer node.wholeProposalsLock.Unlock()
+
+	proposal, ok := node.wholeProposals[epoch][proposer]
+
+	return proposal, ok
+}
+
+// GetProposal hands out the proposal this node made in the requested epoch.
+// The primary uses it to settle complaints about proposals that never arrived.
+func (node *Node) GetProposal(ctx context.Context, req *services.ProposalRequest) (*services.Proposal, error) {
+	if err := node.creds.authorize(ctx, PrimaryIdentity()); err != nil {
+		return nil, err
+	}
+
+	if req.Proposer != node.id {
+		return nil, status.Errorf(codes.NotFound, "only proposals by %d are available", node.id)
+	}
+
+	proposal, ok := node.wholeProposal(Epoch(req.Epoch), node.id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no proposal for epoch %d", req.Epoch)
+	}
+
+	return proposal, nil
+}
+
+func (node *Node) onFinalList(hashList *services.ProposalHashList) {
+	node.finalLists.put(Epoch(hashList.Epoch), 0, hashList)
+
+	node.log.Debugf("channel received the final list from the primary")
+}
+
+// checkProposals verifies our slices of the proposals in the agreed list, and returns the valid ones
+// along with a complaint about each of the others.
+func (node *Node) checkProposals(epoch Epoch, agreed map[int64]Hash, received map[int64]*services.Proposal) (map[int64]*ProposalSlice, []*services.Complaint) {
+	myId := OldNodeID(node.id)
+
+	valid := make(map[int64]*ProposalSlice)
+	var complaints []*services.Complaint
+
+	for from, hashRef := range agreed {
+		msg, ok := received[from]
+		if !ok {
+			node.log.Errorf("can't find a proposal from %d, which appears in the primary's list", from)
+			complaints = append(complaints, &services.Complaint{Accused: from})
+			continue
+		}
+
+		slice, err := sliceFromMessage(msg, node.config, myId)
+		if err == nil && slice.GetRecipient() != myId {
+			err = fmt.Errorf("the slice is for %d", slice.GetRecipient())
+		}
+		if err == nil {
+			var hash [32]byte
+			hash, err = slice.Hash()
+			if err == nil && !hashRef.Equal(hash) {
+				err = fmt.Errorf("wrong hash")
+			}
+		}
+		if err != nil {
+			// whatever we got is not our slice of the agreed proposal
+			node.log.Errorf("the proposal from %d doesn't match the primary's list: %s", from, err.Error())
+			complaints = append(complaints, &services.Complaint{Accused: from})
+			continue
+		}
+
+		if err := slice.Verify(node.config, epoch); err != nil {
+			node.log.Errorf("invalid proposal from %d: %s", from, err.Error())
+			complaints = append(complaints, &services.Complaint{Accused: from, Proposal: msg})
+			continue
+		}
+
+		if _, err := slice.PointsFor(node.encryptionKey, node.config); err != nil {
+			node.log.Errorf("invalid proposal from %d: %s", from, err.Error())
+
+			// the proposal passed the checks, so some chunk doesn't decrypt: show it to everyone
+			disclosure, err := slice.Disclose(node.encryptionKey)
+			if err != nil {
+				node.log.Errorf("can't disclose the key of the points from %d: %s", from, err.Error())
+				continue
+			}
+
+			complaints = append(complaints, &services.Complaint{
+				Accused:         from,
+				Proposal:        msg,
+				SharedKey:       disclosure.Shared,
+				DisclosureProof: disclosure.Proof,
+				Point:           int32(disclosure.Point),
+				Chunk:           int32(disclosure.Chunk),
+			})
+			continue
+		}
+
+		valid[from] = &slice
+	}
+
+	return valid, complaints
+}
+
+func (bb *BulletinBoard) submitComplaints(complaints *services.ComplaintList) {
+	bb.complaints.put(Epoch(complaints.Epoch), complaints.From, complaints)
+}
+
+// attachedSlice checks that the slice an old node j attached to a complaint is the slice of the agreed
+// proposal the accused sent it, signed by the accused. A node that pulled the proposal attaches all of
+// it, and its slice is cut out. Anything else proves nothing against the accused.
+func (bb *BulletinBoard) attachedSlice(j int64, accused int64, msg *services.Proposal, hashRef Hash) (ProposalSlice, error) {
+	if msg.From != accused {
+		return ProposalSlice{}, fmt.Errorf("the slice is from %d", msg.From)
+	}
+
+	if err := bb.identityKeys.verify(msg); err != nil {
+		return ProposalSlice{}, err
+	}
+
+	slice, err := sliceFromMessage(msg, bb.config, OldNodeID(j))
+	if err != nil {
+		return ProposalSlice{}, err
+	}
+
+	if slice.GetRecipient() != OldNodeID(j) {
+		return ProposalSlice{}, fmt.Errorf("not the slice for %d", j)
+	}
+
+	hash, err := slice.Hash()
+	if err != nil || !hashRef.Equal(hash) {
+		return ProposalSlice{}, fmt.Errorf("not the agreed proposal")
+	}
+
+	return slice, nil
+}
+
+// checkProposalFor decides whether the agreed proposal is bad from the point of view of old node j,
+// given the slice of it sent to j. Past Verify, the points sent to j are only bad if j disclosed the key
+// of a chunk that doesn't decrypt.
+func (bb *BulletinBoard) checkProposalFor(epoch Epoch, j int64, slice ProposalSlice, disclosure *KeyDisclosure) error {
+	if err := slice.Verify(bb.config, epoch); err != nil {
+		return err
+	}
+
+	if disclosure == nil {
+		return nil
+	}
+
+	badDisclosure, err := slice.VerifyDisclosedPoints(*disclosure, bb.config)
+	if badDisclosure {
+		bb.log.Warnf("[primary] %d disclosed a bad key: %s", j, err.Error())
+		return nil
+	}
+
+	return err
+}
+
+// sliceOfRevealed cuts the slice for old node j out of a proposal revealed by its proposer.
+// The whole proposal must be valid, since it is sent to every old node.
+func (bb *BulletinBoard) sliceOfRevealed(epoch Epoch, j int64, msg *services.Proposal, hashRef Hash) (ProposalSlice, error) {
+	proposal, err := ProposalFromMessage(msg, bb.config)
+	if err != nil {
+		return ProposalSlice{}, err
+	}
+
+	if !hashRef.Equal(proposal.Hash()) {
+		return ProposalSlice{}, fmt.Errorf("not the agreed proposal")
+	}
+
+	if err := proposal.Verify(bb.config, epoch); err != nil {
+		return ProposalSlice{}, err
+	}
+
+	return proposal.Slice(OldNodeID(j))
+}
+
+// fetchProposals asks each proposer for its whole proposal, in parallel. The nodes wait twice the
+// complaint deadline for the final list, so a proposer gets half of it to answer, and fails otherwise.
+func (bb *BulletinBoard) fetchProposals(epoch Epoch, proposers []int64) (map[int64]*services.Proposal, map[int64]error) {
+	ctx, cancel := bb.clock.WithTimeout(context.Background(), bb.deadlines.GetComplaints()/2)
+	defer cancel()
+
+	type fetched struct {
+		proposer int64
+		proposal *services.Proposal
+		err      error
+	}
+	results := make(chan fetched, len(proposers))
+
+	for _, proposer := range proposers {
+		proposer := proposer
+		bb.clock.Go(func() {
+			client, ok := bb.nodes[NewNodeID(proposer)]
+			if !ok {
+				bb.clock.Send(results, fetched{proposer: proposer, err: fmt.Errorf("no client for %d", proposer)})
+				return
+			}
+
+			proposal, err := client.GetProposal(ctx, &services.ProposalRequest{
+				Epoch:    int32(epoch),
+				Proposer: proposer,
+			})
+			if err == nil && proposal.From != proposer {
+				err = fmt.Errorf("got a proposal from %d", proposal.From)
+			}
+			if err == nil {
+				// everyone checks the revealed proposal
+				err = bb.identityKeys.verify(proposal)
+			}
+			bb.clock.Send(results, fetched{proposer: proposer, proposal: proposal, err: err})
+		})
+	}
+
+	revealed := make(map[int64]*services.Proposal)
+	failed := make(map[int64]error)
+	for range proposers {
+		_, received, _ := bb.clock.Wait(results)
+		r := received.(fetched)
+		if r.err != nil {
+			failed[r.proposer] = r.err
+		} else {
+			revealed[r.proposer] = r.proposal
+		}
+	}
+
+	return revealed, failed
+}
+
+// resolveComplaints waits for a complaint report from each old node, up to the deadline, and checks every complaint.
+// A complaint carrying the agreed proposal is checked right away. For a proposal that never arrived,
+// the primary asks the proposer for it and reveals it to everyone. Proposers found cheating are dropped,
+// and the remaining proposals make up the final list, which is sent to the old group.
+func (bb *BulletinBoard) resolveComplaints(epoch Epoch, proposalHash []*services.ProposalHash) error {
+	agreed := make(map[int64]Hash)
+	for _, ph := range proposalHash {
+		var tmp Hash
+		copy(tmp[:], ph.Hash)
+		agreed[ph.Proposer] = tmp
+	}
+
+	dropped := make(map[int64]bool)
+	reported := make(map[int64]bool)
+	var reports []*services.ComplaintList
+
+	// the old nodes complain once their time to get the proposals is up
+	stop := bb.clock.After(bb.deadlines.GetProposals()+bb.deadlines.GetComplaints(), nil)
+
+	for len(reported) < len(bb.config.oldGroup) {
+		msg := bb.complaints.next(epoch, stop)
+		if msg == nil {
+			// whoever didn't report has nothing to complain about
+			bb.recordMissed("complaints", missing(bb.config.oldGroup, func(id int64) bool { return reported[id] }))
+			break
+		}
+		report := msg.(*services.ComplaintList)
+
+		if !bb.config.IsOldMember(report.From) || reported[report.From] {
+			bb.log.Warnf("[primary] ignoring complaints from %d", report.From)
+			continue
+		}
+		reported[report.From] = true
+		reports = append(reports, report)
+	}
+
+	// the proposals some accuser never got are asked from their proposers all at once
+	var unseen []int64
+	for _, report := range reports {
+		for _, c := range report.List {
+			if _, ok := agreed[c.Accused]; ok && c.Proposal == nil && !contains(unseen, c.Accused) {
+				unseen = append(unseen, c.Accused)
+			}
+		}
+	}
+	revealed, unrevealed := bb.fetchProposals(epoch, unseen)
+
+	for _, report := range reports {
+		for _, c := range report.List {
+			hashRef, ok := agreed[c.Accused]
+			if !ok || dropped[c.Accused] {
+				continue
+			}
+
+			logEntry := bb.log.WithFields(logrus.Fields{
+				"accuser": report.From,
+				"accused": c.Accused,
+			})
+
+			// the accuser has the agreed proposal and claims it is invalid
+			if c.Proposal != nil {
+				var disclosure *KeyDisclosure
+				if len(c.SharedKey) > 0 {
+					disclosure = &KeyDisclosure{
+						Point:  NewNodeID(c.Point),
+						Chunk:  int(c.Chunk),
+						Shared: c.SharedKey,
+						Proof:  c.DisclosureProof,
+					}
+				}
+
+				slice, err := bb.attachedSlice(report.From, c.Accused, c.Proposal, hashRef)
+				if err != nil {
+					// an honest node only attaches the agreed slice it got, signed by its proposer
+					logEntry.Warnf("[primary] false complaint: the attached slice doesn't hold: %s", err.Error())
+					continue
+				}
+
+				if err := bb.checkProposalFor(epoch, report.From, slice, disclosure); err != nil {
+					logEntry.Warnf("[primary] complaint upheld: %s", err.Error())
+					dropped[c.Accused] = true
+				} else {
+					logEntry.Warnf("[primary] false complaint")
+				}
+				continue
+			}
+
+			// the accuser never got the agreed proposal, and the proposer had to reveal it
+			if err, ok := unrevealed[c.Accused]; ok {
+				logEntry.Warnf("[primary] complaint upheld: can't get the proposal: %s", err.Error())
+				dropped[c.Accused] = true
+				continue
+			}
+
+			slice, err := bb.sliceOfRevealed(epoch, report.From, revealed[c.Accused], hashRef)
+			if err == nil {
+				err = bb.checkProposalFor(epoch, report.From, slice, nil)
+			}
+			if err != nil {
+				logEntry.Warnf("[primary] complaint upheld: %s", err.Error())
+				dropped[c.Accused] = true
+			}
+		}
+	}
+
+	final := services.ProposalHashList{Epoch: int32(epoch)}
+	for _, ph := range proposalHash {
+		if dropped[ph.Proposer] {
+			continue
+		}
+
+		final.List = append(final.List, ph)
+		if p, ok := revealed[ph.Proposer]; ok {
+			final.Revealed = append(final.Revealed, p)
+		}
+	}
+
+	if len(final.List) < bb.config.degree+1 {
+		return fmt.Errorf("only %d proposals left", len(final.List))
+	}
+
+	bb.log.WithField("size", proto.Size(&final)).Infof("[primary] %d proposals dropped", len(dropped))
+
+	bb.publish(services.BoardPost_FINAL_LIST, epoch, &final)
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package Schultz
+
+import (
+	"testing"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveComplaints(t *testing.T) {
+	const epoch = Epoch(1)
+
+	pp, encryptionKeys := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	pp, identityKeys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	// 2 and 4 are honest, and 3 encrypts a chunk that doesn't decrypt to node 1
+	proposals := map[int64]Proposal{
+		2: GenerateProposal(pp, epoch),
+		3: GenerateProposal(pp, epoch),
+		4: GenerateProposal(pp, epoch),
+	}
+
+	cheat := proposals[3]
+	var err error
+	cheat.pointToPeers[OldNodeID(1)], err = undecryptablePoints(pp, cheat, OldNodeID(1), encryptionKeys[1])
+	assert.Nil(t, err)
+
+	agreed := make(map[int64]Hash)
+	var hashes []*services.ProposalHash
+	for from, p := range proposals {
+		hash := p.Hash()
+		agreed[from] = hash
+
+		ph := &services.ProposalHash{Epoch: int32(epoch), Proposer: from, Hash: hash[:], Version: ProposalHashVersion}
+		signMessage(identityKeys[from], ph)
+		hashes = append(hashes, ph)
+	}
+
+	// the slices each node got
+	received := func(j int64) map[int64]*services.Proposal {
+		slices := make(map[int64]*services.Proposal)
+		for from, p := range proposals {
+			slice, err := p.Slice(OldNodeID(j))
+			assert.Nil(t, err)
+
+			slices[from] = slice.Message(from)
+			signMessage(identityKeys[from], slices[from])
+		}
+
+		return slices
+	}
+
+	complaintsOf := func(j int64) []*services.Complaint {
+		node := BuildNode(pp, logger, j, "", "", nil, nil)
+		node.SetEncryptionKey(encryptionKeys[j])
+
+		_, complaints := node.checkProposals(epoch, agreed, received(j))
+		return complaints
+	}
+
+	bb := BuildBulletinBoard(logger, "", nil, pp)
+
+	// node 1 complains about 3, with the key that shows the chunk is out of range
+	complaints := complaintsOf(1)
+	assert.Len(t, complaints, 1)
+	assert.Equal(t, int64(3), complaints[0].Accused)
+	assert.NotEmpty(t, complaints[0].SharedKey)
+
+	report := &services.ComplaintList{Epoch: int32(epoch), From: 1, List: complaints}
+	signMessage(identityKeys[1], report)
+	bb.submitComplaints(report)
+
+	// node 4 got valid slices, but claims a chunk from 2 doesn't decrypt
+	assert.Empty(t, complaintsOf(4))
+
+	slice, err := proposals[2].Slice(OldNodeID(4))
+	assert.Nil(t, err)
+	disclosure, err := slice.points.discloseChunk(encryptionKeys[4], NewNodeID(1), 0)
+	assert.Nil(t, err)
+
+	report = &services.ComplaintList{Epoch: int32(epoch), From: 4, List: []*services.Complaint{{
+		Accused:         2,
+		Proposal:        received(4)[2],
+		SharedKey:       disclosure.Shared,
+		DisclosureProof: disclosure.Proof,
+		Point:           int32(disclosure.Point),
+		Chunk:           int32(disclosure.Chunk),
+	}}}
+	signMessage(identityKeys[4], report)
+	bb.submitComplaints(report)
+
+	// node 2 attaches a slice from 4 it tampered with, and node 3 one that nobody signed
+	tampered := received(2)[4]
+	tampered.Points[0].Proof[0] ^= 1
+
+	unsigned := received(3)[4]
+	unsigned.Session[0] ^= 1
+	unsigned.Signature = nil
+
+	for j, attached := range map[int64]*services.Proposal{2: tampered, 3: unsigned} {
+		report := &services.ComplaintList{Epoch: int32(epoch), From: j, List: []*services.Complaint{{
+			Accused:  4,
+			Proposal: attached,
+		}}}
+		signMessage(identityKeys[j], report)
+		bb.submitComplaints(report)
+	}
+
+	assert.Nil(t, bb.resolveComplaints(epoch, hashes))
+
+	// the cheater is dropped, and the falsely accused proposers stay
+	var final *services.ProposalHashList
+	for _, post := range bb.posts.readEpoch(epoch) {
+		if post.Kind == services.BoardPost_FINAL_LIST {
+			final = &services.ProposalHashList{}
+			assert.Nil(t, proto.Unmarshal(post.Payload, final))
+		}
+	}
+	assert.NotNil(t, final)
+
+	var kept []int64
+	for _, ph := range final.List {
+		kept = append(kept, ph.Proposer)
+	}
+	assert.ElementsMatch(t, []int64{2, 4}, kept)
+}
+
+func TestNode_HandoffWithoutPeer(t *testing.T) {
+	pp, _ := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	pp, keys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	// no client for any peer: the node gives up the epoch, and doesn't exit
+	node := BuildNode(pp, logger, 1, "", "", nil, nil)
+	node.SetIdentityKey(keys[1])
+	node.SetDeadlines(DeadlineConfig{ProposalHashes: 50, Proposals: 50})
+
+	assert.NotNil(t, node.handoff(1, &BenchmarkEntry{}))
+}
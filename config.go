@@ -1,23 +1,394 @@
 package Schultz
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/BurntSushi/toml"
 	log "github.com/sirupsen/logrus"
 )
 
 type PrimaryConfig struct {
 	Url string
+	// PEM files of the TLS certificate and its key, signed by the CA in [tls]
+	TlsCert string
+	TlsKey  string
+}
+
+// DealerConfig holds the keys of the dealer, which never serves.
+type DealerConfig struct {
+	TlsCert string
+	TlsKey  string
+	// hex-encoded ed25519 public key the dealer signs its messages with
+	IdentityKey string
+	// file holding the hex-encoded ed25519 seed. Only read by the dealer itself.
+	IdentityKeyFile string
+}
+
+// TLSConfig holds the CA every party trusts. Without it, connections are plain,
+// which only the protocol command allows.
+type TLSConfig struct {
+	// PEM file of the CA certificate
+	CA string
 }
 
 type PeerConfig struct {
 	Id  int64
 	Url string
+	// hex-encoded P-256 public key the points of the proposals are encrypted to
+	PublicKey string
+	// file holding the hex-encoded private key. Only read by the node itself.
+	PrivateKeyFile string
+	// hex-encoded ed25519 public key the node signs its messages with
+	IdentityKey string
+	// file holding the hex-encoded ed25519 seed. Only read by the node itself.
+	IdentityKeyFile string
+	// PEM files of the TLS certificate and its key
+	TlsCert string
+	TlsKey  string
+	// file the node saves its share to after every epoch, to resume from after a restart. Optional.
+	ShareStore string
+	// file holding the key the share store is encrypted under. Without it, the key is derived from a passphrase.
+	ShareStoreKeyFile string
+}
+
+// ReplicaConfig describes a replica of the bulletin board.
+type ReplicaConfig struct {
+	Id  int64
+	Url string
+	// hex-encoded ed25519 public key
+	PublicKey string
+	// file holding the hex-encoded ed25519 seed. Only read by the replica itself.
+	PrivateKeyFile string
+	// PEM files of the TLS certificate and its key
+	TlsCert string
+	TlsKey  string
+}
+
+// ChainConfig describes the simulated chain backing the bulletin board.
+type ChainConfig struct {
+	// time between two blocks, in milliseconds. Defaults to 100.
+	BlockTime int
+	// gas limit of a block. Defaults to 30000000.
+	GasLimit uint64
+	// number of blocks on top of a block before its posts are final. Defaults to 2.
+	Finality int
+}
+
+func (c ChainConfig) GetBlockTime() int {
+	if c.BlockTime == 0 {
+		return 100
+	}
+
+	return c.BlockTime
+}
+
+func (c ChainConfig) GetGasLimit() uint64 {
+	if c.GasLimit == 0 {
+		return 30000000
+	}
+
+	return c.GasLimit
+}
+
+func (c ChainConfig) GetFinality() int {
+	if c.Finality == 0 {
+		return 2
+	}
+
+	return c.Finality
+}
+
+// DeadlineConfig bounds how long each phase of an epoch waits for the other parties, in milliseconds.
+// Each deadline counts from the start of its phase. A phase that runs out of time goes on with the
+// quorum it has, and the board aborts the epoch if there is none.
+type DeadlineConfig struct {
+	// for 2t+1 proposal hashes to be agreed on. Defaults to 10000.
+	ProposalHashes int
+	// for the proposals of the old group, once the hashes are agreed on. Defaults to 10000.
+	Proposals int
+	// for the complaints of the old group, once the proposals are in. Defaults to 10000.
+	Complaints int
+	// for the blinded shares, from the start of the epoch. Defaults to 60000.
+	BlindedShares int
+	// for the new shares, or share checks, of the holders, once the blinded shares are in. Defaults to 30000.
+	Shares int
+	// for the helpers of a node recovering a lost share, for both rounds. Defaults to 10000.
+	Recovery int
+	// for the dealings of the initial sharing, and for each round of complaints about them. Defaults to 10000.
+	Dealings int
+}
+
+func millisOr(ms, def int) time.Duration {
+	if ms == 0 {
+		ms = def
+	}
+
+	return time.Duration(ms) * time.Millisecond
+}
+
+func (c DeadlineConfig) GetProposalHashes() time.Duration {
+	return millisOr(c.ProposalHashes, 10000)
+}
+
+func (c DeadlineConfig) GetProposals() time.Duration {
+	return millisOr(c.Proposals, 10000)
+}
+
+func (c DeadlineConfig) GetComplaints() time.Duration {
+	return millisOr(c.Complaints, 10000)
+}
+
+func (c DeadlineConfig) GetBlindedShares() time.Duration {
+	return millisOr(c.BlindedShares, 60000)
+}
+
+func (c DeadlineConfig) GetShares() time.Duration {
+	return millisOr(c.Shares, 30000)
+}
+
+func (c DeadlineConfig) GetRecovery() time.Duration {
+	return millisOr(c.Recovery, 10000)
+}
+
+func (c DeadlineConfig) GetDealings() time.Duration {
+	return millisOr(c.Dealings, 10000)
+}
+
+// MessageConfig bounds the size of the messages, in bytes. A proposal grows with the product of the
+// degree and the size of the groups, so large groups need more than the 4 MB gRPC takes by default.
+type MessageConfig struct {
+	// largest message a party sends or takes. Defaults to 4194304.
+	MaxSize int
+	// proposals larger than this are streamed in chunks of this size. Defaults to 1048576.
+	ChunkSize int
+	// largest proposal a node takes in chunks. Defaults to 268435456.
+	MaxProposalSize int
+}
+
+func (c MessageConfig) GetMaxSize() int {
+	if c.MaxSize == 0 {
+		return 4 << 20
+	}
+
+	return c.MaxSize
+}
+
+func (c MessageConfig) GetChunkSize() int {
+	if c.ChunkSize == 0 {
+		return 1 << 20
+	}
+
+	return c.ChunkSize
+}
+
+func (c MessageConfig) GetMaxProposalSize() int {
+	if c.MaxProposalSize == 0 {
+		return 256 << 20
+	}
+
+	return c.MaxProposalSize
+}
+
+// how the latency of a simulated link spreads around its mean
+const (
+	// always the mean
+	LatencyConstant = "constant"
+	// anywhere within the jitter of the mean
+	LatencyUniform = "uniform"
+	// normally, with the jitter as standard deviation
+	LatencyNormal = "normal"
+)
+
+// LinkConfig models a link of the simulated network, one way.
+type LinkConfig struct {
+	// mean latency, in milliseconds
+	Latency int
+	// how far the latency strays from its mean, in milliseconds
+	Jitter int
+	// one of the Latency* constants. Defaults to LatencyUniform.
+	Distribution string
+	// in Mbit/s. Zero means unlimited.
+	Bandwidth int
+	// probability that a packet is lost, below 1
+	Loss float64
+	// how long a lost packet takes to be sent again, in milliseconds. Defaults to 200.
+	Rto int
 }
 
+func (c LinkConfig) GetDistribution() string {
+	if c.Distribution == "" {
+		return LatencyUniform
+	}
+
+	return c.Distribution
+}
+
+func (c LinkConfig) GetRto() time.Duration {
+	return millisOr(c.Rto, 200)
+}
+
+// LinkOverride models the link from one party to another. The board is party -1.
+type LinkOverride struct {
+	From int64
+	To   int64
+	LinkConfig
+}
+
+// SimulationConfig describes the network of the simulate command.
+type SimulationConfig struct {
+	// seeds the randomness of the links
+	Seed int64
+	// the model of every link without an override
+	Link  LinkConfig
+	Links []LinkOverride
+}
+
+// linkFor returns the model of the link from one party to another.
+func (c SimulationConfig) linkFor(from, to int64) LinkConfig {
+	for _, link := range c.Links {
+		if link.From == from && link.To == to {
+			return link.LinkConfig
+		}
+	}
+
+	return c.Link
+}
+
+// what the board learns at the end of an epoch
+const (
+	// the holders send their shares and the board recovers the secret. For benchmarks only, with BootstrapFixed.
+	ModeBenchmark = "benchmark"
+	// the holders only tell whether their shares lie on the committed polynomial
+	ModeProduction = "production"
+)
+
+// where the bulletin board lives
+const (
+	// the primary keeps the posts and serves them over gRPC
+	BoardGrpc = "grpc"
+	// a simulated chain, with blocks, gas and finality. Only in the local simulation.
+	BoardChain = "chain"
+)
+
+// how the initial sharing is created
+const (
+	// every node evaluates the same hardcoded polynomial. For benchmarks only.
+	BootstrapFixed = "fixed"
+	// the old group runs a distributed key generation
+	BootstrapDKG = "dkg"
+	// a dealer shares a user-supplied secret
+	BootstrapDealer = "dealer"
+)
+
 type SystemConfig struct {
-	Degree  int
-	Primary PrimaryConfig
-	Peers   map[string]PeerConfig
+	// one of the Bootstrap* constants. Defaults to BootstrapFixed.
+	Bootstrap string
+	// one of the Mode* constants. Defaults to ModeBenchmark.
+	Mode string
+
+	Degree int
+	// degree of the sharing after the handoff. Defaults to Degree.
+	NewDegree int
+	Primary   PrimaryConfig
+	Peers     map[string]PeerConfig
+
+	// replicas ordering the proposal hashes. If empty, the primary orders them alone.
+	Replicas map[string]ReplicaConfig
+
+	// one of the Board* constants. Defaults to BoardGrpc.
+	Board string
+	// only used with BoardChain
+	Chain ChainConfig
+
+	TLS    TLSConfig
+	Dealer DealerConfig
+
+	Deadlines DeadlineConfig
+	Messages  MessageConfig
+
+	// only used by the simulate command
+	Simulation SimulationConfig
+
+	// ids of the nodes handing off the shares. Default to all peers.
+	OldGroup []int64
+	// ids of the nodes receiving the shares. Default to all peers.
+	NewGroup []int64
+}
+
+// Committees returns the old and the new group of the handoff.
+// An empty group in the config means every peer.
+func (c SystemConfig) Committees() ([]int64, []int64, error) {
+	var allIds []int64
+	known := make(map[int64]bool)
+	for _, peer := range c.Peers {
+		allIds = append(allIds, peer.Id)
+		known[peer.Id] = true
+	}
+
+	sort.Slice(allIds, func(i, j int) bool { return allIds[i] < allIds[j] })
+
+	oldGroup := c.OldGroup
+	if len(oldGroup) == 0 {
+		oldGroup = allIds
+	}
+
+	newGroup := c.NewGroup
+	if len(newGroup) == 0 {
+		newGroup = allIds
+	}
+
+	for _, group := range [][]int64{oldGroup, newGroup} {
+		seen := make(map[int64]bool)
+		for _, id := range group {
+			if id == DealerId {
+				return nil, nil, fmt.Errorf("node id %d is reserved", id)
+			}
+			if !known[id] {
+				return nil, nil, fmt.Errorf("node %d is not in the peer list", id)
+			}
+			if seen[id] {
+				return nil, nil, fmt.Errorf("node %d appears twice in a group", id)
+			}
+			seen[id] = true
+		}
+	}
+
+	return oldGroup, newGroup, nil
+}
+
+// GetNewDegree returns the degree of the sharing held by the new group.
+func (c SystemConfig) GetNewDegree() int {
+	if c.NewDegree == 0 {
+		return c.Degree
+	}
+
+	return c.NewDegree
+}
+
+func (c SystemConfig) GetBootstrap() string {
+	if c.Bootstrap == "" {
+		return BootstrapFixed
+	}
+
+	return c.Bootstrap
+}
+
+func (c SystemConfig) GetMode() string {
+	if c.Mode == "" {
+		return ModeBenchmark
+	}
+
+	return c.Mode
+}
+
+func (c SystemConfig) GetBoard() string {
+	if c.Board == "" {
+		return BoardGrpc
+	}
+
+	return c.Board
 }
 
 func ParseConfigFile(tomlPath string) (SystemConfig, error) {
@@ -32,5 +403,60 @@ func ParseConfigFile(tomlPath string) (SystemConfig, error) {
 		log.Fatal("configuration file not fully parsed")
 	}
 
+	switch config.GetBootstrap() {
+	case BootstrapFixed, BootstrapDKG, BootstrapDealer:
+	default:
+		log.Fatalf("unknown bootstrap method %s", config.Bootstrap)
+	}
+
+	switch config.GetMode() {
+	case ModeBenchmark:
+		// the board learns the secret, which only the hardcoded sharing can afford
+		if config.GetBootstrap() != BootstrapFixed {
+			log.Fatalf("mode = %q needs bootstrap = %q, use mode = %q with %q", ModeBenchmark, BootstrapFixed, ModeProduction, config.GetBootstrap())
+		}
+	case ModeProduction:
+		// the fixed sharing comes without a commitment to check the shares against
+		if config.GetBootstrap() == BootstrapFixed {
+			log.Fatalf("mode = %q needs bootstrap = %q or %q", ModeProduction, BootstrapDKG, BootstrapDealer)
+		}
+	default:
+		log.Fatalf("unknown mode %s", config.Mode)
+	}
+
+	switch config.GetBoard() {
+	case BoardGrpc:
+	case BoardChain:
+		if len(config.Replicas) > 0 {
+			log.Fatal("the chain orders the proposal hashes itself, so it can't have replicas")
+		}
+	default:
+		log.Fatalf("unknown board %s", config.Board)
+	}
+
+	links := []LinkConfig{config.Simulation.Link}
+	for _, link := range config.Simulation.Links {
+		links = append(links, link.LinkConfig)
+	}
+	for _, link := range links {
+		switch link.GetDistribution() {
+		case LatencyConstant, LatencyUniform, LatencyNormal:
+		default:
+			log.Fatalf("unknown latency distribution %s", link.Distribution)
+		}
+
+		if link.Latency < 0 || link.Jitter < 0 || link.Bandwidth < 0 || link.Rto < 0 {
+			log.Fatal("the latency, jitter, bandwidth and rto of a link can't be negative")
+		}
+		if link.Loss < 0 || link.Loss >= 1 {
+			log.Fatalf("a link loses packets with a probability of %v, not in [0, 1)", link.Loss)
+		}
+	}
+
+	// a chunk goes in one message, along with its header
+	if config.Messages.GetChunkSize()+chunkOverhead > config.Messages.GetMaxSize() {
+		log.Fatalf("chunks of %d bytes don't fit in messages of %d bytes", config.Messages.GetChunkSize(), config.Messages.GetMaxSize())
+	}
+
 	return config, nil
 }
@@ -0,0 +1,215 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+
+	"../../utils/polyring"
+	"./services"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
+	"google.golang.org/grpc"
+)
+
+// DealerId is the id used by the dealer in its messages.
+// No node can have it, since the secret lives at x=0.
+const DealerId int64 = 0
+
+// Dealer shares a user-supplied secret among the old group (epoch 0).
+type Dealer struct {
+	config PublicParameter
+
+	board      Board
+	peerIPList map[NewNodeID]string
+	// TLS certificate of the dealer. If nil, connections are plain.
+	creds *Credentials
+	// how the dealer reaches the nodes
+	network Transport
+	// signs the commitment and the shares
+	identityKey ed25519.PrivateKey
+	// how long the nodes wait for their shares
+	deadlines DeadlineConfig
+
+	// logging
+	log *logrus.Entry
+}
+
+// eraseInt overwrites the limbs of x with zeros before setting it to zero.
+func eraseInt(x *gmp.Int) {
+	if x == nil {
+		return
+	}
+
+	x.SetBytes(make([]byte, (x.BitLen()+7)/8))
+	x.SetInt64(0)
+}
+
+// erasePolynomial overwrites the coefficients of p in place.
+func erasePolynomial(p polyring.Polynomial) {
+	for _, c := range p.GetAllCoefficients() {
+		eraseInt(c)
+	}
+}
+
+// Deal samples a random polynomial with the secret as its constant term and sends each member of the old group
+// its share. The commitment to the polynomial goes on the board, which forwards it to the nodes so they can
+// check their shares. The secret, the polynomial and the shares are erased before returning.
+func (d *Dealer) Deal(secret *gmp.Int) error {
+	defer eraseInt(secret)
+
+	if secret.Cmp(d.config.prime) >= 0 {
+		return fmt.Errorf("the secret has to be smaller than the prime")
+	}
+
+	poly, err := polyring.NewRand(d.config.degree, NewCryptoRand(), d.config.prime)
+	if err != nil {
+		return err
+	}
+
+	poly.GetPtrToConstant().Set(secret)
+
+	return d.deal(poly)
+}
+
+// deal commits to the polynomial and sends the shares on it. It erases the polynomial and the shares.
+// The polynomial has to be of the degree of the sharing, or the threshold would not be the one expected.
+func (d *Dealer) deal(poly polyring.Polynomial) error {
+	defer erasePolynomial(poly)
+
+	ctx := context.Background()
+
+	comm := NewPolyCommit(poly)
+	if comm.Degree() != d.config.degree {
+		return fmt.Errorf("a polynomial of degree %d, not %d", comm.Degree(), d.config.degree)
+	}
+
+	d.log.Debugf("posting the commitment to the board")
+	commitment := &services.DealingCommitment{
+		Epoch:      0,
+		Dealer:     DealerId,
+		Commitment: encodeCommitment(comm),
+	}
+	signMessage(d.identityKey, commitment)
+
+	err := d.board.Post(ctx, newPost(services.BoardPost_DEALING_COMMITMENT, 0, DealerId, commitment))
+	if err != nil {
+		return err
+	}
+
+	// every node gets its share within the time it waits for it, however long the others take
+	sendCtx, cancel := context.WithTimeout(ctx, d.deadlines.GetDealings())
+	defer cancel()
+
+	errs := make(chan error, len(d.config.oldGroup))
+	for _, j := range d.config.oldGroup {
+		peerIP, ok := d.peerIPList[NewNodeID(j)]
+		if !ok {
+			return fmt.Errorf("can't find the address of %d", j)
+		}
+
+		share := gmp.NewInt(0)
+		poly.EvalMod(gmp.NewInt(j), d.config.prime, share)
+
+		d.log.Debugf("sending a share to %d", j)
+		dealing := &services.Dealing{
+			Epoch: 0,
+			From:  DealerId,
+			Share: share.Bytes(),
+			To:    j,
+		}
+		eraseInt(share)
+		signMessage(d.identityKey, dealing)
+
+		go func(j int64, peerIP string, dealing *services.Dealing) {
+			errs <- d.sendDealing(sendCtx, j, peerIP, dealing)
+		}(j, peerIP, dealing)
+	}
+
+	var failed error
+	for range d.config.oldGroup {
+		if err := <-errs; err != nil && failed == nil {
+			failed = err
+		}
+	}
+	if failed != nil {
+		return failed
+	}
+
+	d.log.Infof("shares sent to %d nodes", len(d.config.oldGroup))
+
+	return nil
+}
+
+// sendDealing sends node j its share, and erases the share once it is sent or fails.
+func (d *Dealer) sendDealing(ctx context.Context, j int64, peerIP string, dealing *services.Dealing) error {
+	defer zeroBytes(dealing.Share)
+
+	conn, err := d.network.Dial(peerIP, d.creds.dialOption())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// nodes may not be serving yet
+	_, err = services.NewNodeClient(conn).SubmitDealing(ctx, dealing, grpc.WaitForReady(true))
+	if err != nil {
+		return fmt.Errorf("can't send the share of %d: %s", j, err.Error())
+	}
+
+	return nil
+}
+
+// WaitForDealer receives the initial share from the dealer (epoch 0)
+// and checks it against the commitment published on the board.
+func (node *Node) WaitForDealer() error {
+	if !node.config.IsOldMember(node.id) {
+		return fmt.Errorf("node %d is not in the old group", node.id)
+	}
+
+	_, received, _ := node.clock.Wait(node.startDealingCollector(Epoch(0), false))
+	result := received.(dkgResult)
+	if result.err != nil {
+		return result.err
+	}
+
+	node.share = result.share
+	node.secretCommitment = result.commitment
+
+	node.log.Infof("got the initial share from the dealer")
+
+	return nil
+}
+
+func BuildDealer(pp PublicParameter, logger *logrus.Logger, board Board, peerIPs map[NewNodeID]string) Dealer {
+	return Dealer{
+		config:     pp,
+		board:      board,
+		peerIPList: peerIPs,
+		network:    GRPCTransport{},
+		log: logger.WithFields(
+			logrus.Fields{
+				"name": "dealer",
+			}),
+	}
+}
+
+// SetCredentials makes the dealer use mutual TLS to send the shares.
+func (d *Dealer) SetCredentials(creds *Credentials) {
+	d.creds = creds
+}
+
+// SetTransport makes the dealer reach the nodes through the given transport instead of TCP.
+func (d *Dealer) SetTransport(network Transport) {
+	d.network = network
+}
+
+// SetDeadlines sets how long the dealer tries to reach each node. It should match the nodes' own.
+func (d *Dealer) SetDeadlines(deadlines DeadlineConfig) {
+	d.deadlines = deadlines
+}
+
+// SetIdentityKey sets the key the dealer signs its messages with.
+func (d *Dealer) SetIdentityKey(key ed25519.PrivateKey) {
+	d.identityKey = key
+}
@@ -0,0 +1,143 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"../../utils/conv"
+	"../../utils/polyring"
+	"./services"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// dealingRecorder keeps the dealings sent over the network, as the sender holds them.
+type dealingRecorder struct {
+	*MemoryNetwork
+
+	dealings []*services.Dealing
+	lock     sync.Mutex
+}
+
+func (r *dealingRecorder) Dial(addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	record := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if dealing, ok := req.(*services.Dealing); ok {
+			r.lock.Lock()
+			r.dealings = append(r.dealings, dealing)
+			r.lock.Unlock()
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	return r.MemoryNetwork.Dial(addr, append(opts, grpc.WithUnaryInterceptor(record))...)
+}
+
+func TestDealer(t *testing.T) {
+	const n, degree = 4, 1
+
+	pp, identityKeys := withIdentityKeys(BuildConfig(degree, FieldPrime, makeOneToN(n), makeOneToN(n)))
+	prime := pp.GetPrime()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	deadlines := DeadlineConfig{Dealings: 1000}
+
+	network := &dealingRecorder{MemoryNetwork: NewMemoryNetwork()}
+	primaryUrl := "127.0.0.1:8000"
+	urls := make(map[NewNodeID]string)
+	for _, id := range pp.Members() {
+		urls[NewNodeID(id)] = fmt.Sprintf("127.0.0.1:%d", 8000+id)
+	}
+
+	primary := BuildBulletinBoard(logger, primaryUrl, urls, pp)
+	primary.SetSuicideOption(false)
+	primary.SetBootstrapOption(BootstrapDealer)
+	primary.SetModeOption(ModeProduction)
+	primary.SetDeadlines(deadlines)
+	primary.SetTransport(network.MemoryNetwork)
+	go primary.Serve()
+	go primary.StartProtocol()
+
+	nodes := make([]Node, n)
+	errs := make(chan error, n)
+	for i, id := range pp.Members() {
+		nodes[i] = BuildNode(pp, logger, id, primaryUrl, urls[NewNodeID(id)], nil, nil)
+		nodes[i].SetTransport(network.MemoryNetwork)
+		nodes[i].SetDeadlines(deadlines)
+		nodes[i].SetIdentityKey(identityKeys[id])
+		go nodes[i].Serve()
+		assert.Nil(t, nodes[i].ConnectPrimary())
+
+		go func(node *Node) {
+			errs <- node.WaitForDealer()
+		}(&nodes[i])
+	}
+
+	board, err := DialBoard(network, primaryUrl, nil, logger)
+	assert.Nil(t, err)
+	dealer := BuildDealer(pp, logger, board, urls)
+	dealer.SetTransport(network)
+	dealer.SetIdentityKey(identityKeys[DealerId])
+	dealer.SetDeadlines(deadlines)
+
+	// a secret out of range is erased all the same
+	tooLarge := gmp.NewInt(0).Set(prime)
+	assert.NotNil(t, dealer.Deal(tooLarge))
+	assert.Equal(t, 0, tooLarge.Sign())
+
+	// so is a polynomial of the wrong degree, which the dealer doesn't share
+	overDegree, err := polyring.NewRand(degree+1, NewCryptoRand(), prime)
+	assert.Nil(t, err)
+	assert.NotNil(t, dealer.deal(overDegree))
+	for _, c := range overDegree.GetAllCoefficients() {
+		assert.Equal(t, 0, c.Sign())
+	}
+
+	poly, err := polyring.NewRand(degree, NewCryptoRand(), prime)
+	assert.Nil(t, err)
+	secret := gmp.NewInt(0).Set(poly.GetPtrToConstant())
+	coefficients := poly.GetAllCoefficients()
+
+	assert.Nil(t, dealer.deal(poly))
+
+	for range nodes {
+		select {
+		case err := <-errs:
+			assert.Nil(t, err)
+		case <-time.After(30 * time.Second):
+			t.Fatalf("the nodes didn't get their shares")
+		}
+	}
+
+	// every node checked its share against the commitment, and the shares are on the secret
+	var Xs, Ys []*gmp.Int
+	for i := range nodes {
+		assert.True(t, nodes[i].secretCommitment.VerifyEval(big.NewInt(nodes[i].id), conv.GmpInt2BigInt(nodes[i].share)))
+
+		Xs = append(Xs, gmp.NewInt(nodes[i].id))
+		Ys = append(Ys, nodes[i].share)
+	}
+
+	shared, wrong, err := DecodeReedSolomon(degree, Xs, Ys, prime)
+	assert.Nil(t, err)
+	assert.Empty(t, wrong)
+	assert.Equal(t, 0, secret.Cmp(shared.GetPtrToConstant()))
+
+	// the dealer kept neither the polynomial nor the shares it sent
+	for _, c := range coefficients {
+		assert.Equal(t, 0, c.Sign())
+	}
+
+	assert.Len(t, network.dealings, n)
+	for _, dealing := range network.dealings {
+		assert.Equal(t, make([]byte, len(dealing.Share)), dealing.Share)
+	}
+}
@@ -0,0 +1,615 @@
+package Schultz
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"sort"
+
+	"../../utils/conv"
+	"../../utils/polyring"
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/ncw/gmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// cryptoSource is a math/rand source backed by crypto/rand, used to sample secrets.
+type cryptoSource struct{}
+
+func (s cryptoSource) Uint64() uint64 {
+	var buf [8]byte
+	if _, err := crand.Read(buf[:]); err != nil {
+		panic(err.Error())
+	}
+
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s cryptoSource) Seed(int64) {}
+
+// NewCryptoRand returns a *rand.Rand that draws from crypto/rand.
+func NewCryptoRand() *rand.Rand {
+	return rand.New(cryptoSource{})
+}
+
+func (node *Node) SubmitDealing(ctx context.Context, dealing *services.Dealing) (*services.Empty, error) {
+	if err := node.creds.authorize(ctx, senderIdentity(dealing.From)); err != nil {
+		return nil, err
+	}
+	if err := node.checkSignature(dealing); err != nil {
+		return nil, err
+	}
+
+	// one dealing from each dealer fits
+	if !node.clock.Send(node.dealingChan, dealing) {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many dealings")
+	}
+
+	return &services.Empty{}, nil
+}
+
+// The lists of the board wait in an inbox, so that following the board never blocks on a DKG the
+// node doesn't run, such as after resuming from its share store.
+
+func (node *Node) onDealingCommitments(list *services.DealingCommitmentList) {
+	node.dealingLists.put(Epoch(list.Epoch), 0, list)
+
+	node.log.Debugf("received dealing commitments from the primary")
+}
+
+func (node *Node) onDealingAccusations(list *services.DealingAccusationList) {
+	if !node.clock.Send(node.dealingAccusationChan, list) {
+		node.log.Warnf("dropping the dealing accusations for epoch %d, nobody took the last ones", list.Epoch)
+	}
+}
+
+func (node *Node) onQualifiedDealers(qualified *services.QualifiedDealers) {
+	node.qualifiedDealers.put(Epoch(qualified.Epoch), 0, qualified)
+}
+
+type dkgResult struct {
+	share      *gmp.Int
+	commitment PolyCommit
+	err        error
+}
+
+// startDealingCollector waits for the dealers fixed by the primary and sums up their dealings.
+// With complain, the node complains about the dealers it got no valid dealing from by the deadline,
+// and sums up the dealings of the dealers that qualified, some of them revealed on the board.
+// Otherwise, every dealer has to deal it a valid share. The node gives up on the DKG if the board
+// doesn't post the dealers, or the ones that qualified, in time.
+func (node *Node) startDealingCollector(epoch Epoch, complain bool) <-chan dkgResult {
+	out := make(chan dkgResult, 1)
+
+	node.clock.Go(func() {
+		// the board waits a round for the commitments
+		msg := node.dealingLists.next(epoch, node.clock.After(2*node.deadlines.GetDealings(), nil))
+		if msg == nil {
+			node.clock.Send(out, dkgResult{err: fmt.Errorf("the board posted no dealers")})
+			return
+		}
+		list := msg.(*services.DealingCommitmentList)
+
+		dealers := make(map[int64]PolyCommit)
+		for _, dc := range list.List {
+			if err := node.baseConfig.identityKeys.verify(dc); err != nil {
+				node.clock.Send(out, dkgResult{err: fmt.Errorf("the board's list of dealers is forged: %s", err.Error())})
+				return
+			}
+
+			comm, err := decodeCommitmentOfDegree(dc.Commitment, node.config.degree)
+			if err != nil {
+				node.clock.Send(out, dkgResult{err: fmt.Errorf("bad commitment from dealer %d: %s", dc.Dealer, err.Error())})
+				return
+			}
+			dealers[dc.Dealer] = comm
+		}
+
+		// the valid dealings, by dealer
+		valid := make(map[int64]*gmp.Int)
+		defer func() {
+			for _, share := range valid {
+				eraseInt(share)
+			}
+		}()
+
+		stop := node.clock.After(node.deadlines.GetDealings(), nil)
+	collect:
+		for len(valid) < len(dealers) {
+			i, received, _ := node.clock.Wait(node.dealingChan, stop)
+			if i == 1 {
+				break collect
+			}
+
+			dealing := received.(*services.Dealing)
+			if Epoch(dealing.Epoch) != epoch {
+				node.log.Infof("ignoring dealing for epoch %d (at epoch %d)", dealing.Epoch, epoch)
+				continue
+			}
+
+			if err := node.checkDealing(dealing, dealers); err != nil {
+				node.log.Warnf("ignoring the dealing from %d: %s", dealing.From, err.Error())
+				continue
+			}
+			valid[dealing.From] = gmp.NewInt(0).SetBytes(dealing.Share)
+		}
+
+		var complaints []int64
+		for dealer := range dealers {
+			if _, ok := valid[dealer]; !ok {
+				complaints = append(complaints, dealer)
+			}
+		}
+		sort.Slice(complaints, func(i, j int) bool { return complaints[i] < complaints[j] })
+
+		qualified := make([]int64, 0, len(dealers))
+		revealed := make(map[int64]*services.Dealing)
+
+		if !complain {
+			if len(complaints) > 0 {
+				node.clock.Send(out, dkgResult{err: fmt.Errorf("no valid dealing from %v", complaints)})
+				return
+			}
+			for dealer := range dealers {
+				qualified = append(qualified, dealer)
+			}
+		} else {
+			if len(complaints) > 0 {
+				node.log.Warnf("complaining about the dealings of %v", complaints)
+			}
+
+			msg := &services.DealingComplaints{
+				Epoch:   int32(epoch),
+				From:    node.id,
+				Dealers: complaints,
+			}
+			node.sign(msg)
+
+			if err := node.board.Post(context.Background(), newPost(services.BoardPost_DEALING_COMPLAINTS, epoch, node.id, msg)); err != nil {
+				node.clock.Send(out, dkgResult{err: fmt.Errorf("can't post the dealing complaints: %s", err.Error())})
+				return
+			}
+
+			// the board takes up to three rounds to settle the complaints, and one more for the posts to get through
+			posted := node.qualifiedDealers.next(epoch, node.clock.After(4*node.deadlines.GetDealings(), nil))
+			if posted == nil {
+				node.clock.Send(out, dkgResult{err: fmt.Errorf("the board posted no qualified dealers")})
+				return
+			}
+			q := posted.(*services.QualifiedDealers)
+
+			qualified = q.Dealers
+			for _, dealing := range q.Revealed {
+				if dealing.To == node.id {
+					revealed[dealing.From] = dealing
+				}
+			}
+		}
+
+		share := gmp.NewInt(0)
+		var commitment PolyCommit
+		for i, dealer := range qualified {
+			comm, ok := dealers[dealer]
+			if !ok {
+				node.clock.Send(out, dkgResult{err: fmt.Errorf("dealer %d qualified without a commitment", dealer)})
+				return
+			}
+
+			dealt, ok := valid[dealer]
+			if !ok {
+				// the dealer revealed the dealing the node complained about
+				dealing, ok := revealed[dealer]
+				if !ok {
+					node.clock.Send(out, dkgResult{err: fmt.Errorf("dealer %d qualified without revealing the dealing of %d", dealer, node.id)})
+					return
+				}
+				if err := node.checkDealing(dealing, dealers); err != nil {
+					node.clock.Send(out, dkgResult{err: fmt.Errorf("the revealed dealing of %d: %s", dealer, err.Error())})
+					return
+				}
+				dealt = gmp.NewInt(0).SetBytes(dealing.Share)
+				valid[dealer] = dealt
+			}
+
+			share.Add(share, dealt)
+			share.Mod(share, node.config.prime)
+
+			if i == 0 {
+				commitment = comm
+			} else {
+				commitment = AdditiveHomomorphism(commitment, comm)
+			}
+		}
+
+		node.clock.Send(out, dkgResult{share: share, commitment: commitment})
+	})
+
+	return out
+}
+
+// checkDealing checks that a dealing of one of the dealers is signed, meant for the node and on the dealer's commitment.
+func (node *Node) checkDealing(dealing *services.Dealing, dealers map[int64]PolyCommit) error {
+	comm, ok := dealers[dealing.From]
+	if !ok {
+		return fmt.Errorf("%d is not a dealer", dealing.From)
+	}
+	if dealing.To != node.id {
+		return fmt.Errorf("the dealing is for %d", dealing.To)
+	}
+	if err := node.baseConfig.identityKeys.verify(dealing); err != nil {
+		return err
+	}
+
+	dealt := gmp.NewInt(0).SetBytes(dealing.Share)
+	defer eraseInt(dealt)
+	if !comm.VerifyEval(big.NewInt(node.id), conv.GmpInt2BigInt(dealt)) {
+		return fmt.Errorf("not on the committed polynomial")
+	}
+
+	return nil
+}
+
+// RunDKG generates the initial sharing jointly with the rest of the old group (epoch 0).
+// Every member deals a random polynomial and posts a commitment to it on the bulletin board, then answers
+// the complaints about its dealings. The share of each node is the sum of the dealings of the dealers that
+// qualified, at least t+1 of them, so no single party learns the secret. The polynomial is erased before
+// returning.
+func (node *Node) RunDKG() error {
+	epoch := Epoch(0)
+
+	if !node.config.IsOldMember(node.id) {
+		return fmt.Errorf("node %d is not in the old group", node.id)
+	}
+
+	if err := node.ConnectPeers(); err != nil {
+		return err
+	}
+
+	resultChan := node.startDealingCollector(epoch, true)
+
+	poly, err := polyring.NewRand(node.config.degree, NewCryptoRand(), node.config.prime)
+	if err != nil {
+		return err
+	}
+	defer erasePolynomial(poly)
+
+	node.log.Debugf("posting the dealing commitment to the board")
+	commitment := &services.DealingCommitment{
+		Epoch:      int32(epoch),
+		Dealer:     node.id,
+		Commitment: encodeCommitment(NewPolyCommit(poly)),
+	}
+	node.sign(commitment)
+
+	err = node.board.Post(context.Background(), newPost(services.BoardPost_DEALING_COMMITMENT, epoch, node.id, commitment))
+	if err != nil {
+		return err
+	}
+
+	// kept to answer complaints
+	dealings := make(map[int64]*services.Dealing)
+	defer func() {
+		for _, dealing := range dealings {
+			zeroBytes(dealing.Share)
+		}
+	}()
+
+	share := gmp.NewInt(0)
+	defer eraseInt(share)
+
+	for _, j := range node.config.oldGroup {
+		poly.EvalMod(gmp.NewInt(j), node.config.prime, share)
+
+		dealing := &services.Dealing{
+			Epoch: int32(epoch),
+			From:  node.id,
+			Share: share.Bytes(),
+			To:    j,
+		}
+		node.sign(dealing)
+		dealings[j] = dealing
+
+		if j == node.id {
+			node.clock.Send(node.dealingChan, dealing)
+			continue
+		}
+
+		nodeClient, ok := node.nodes[NewNodeID(j)]
+		if !ok {
+			return fmt.Errorf("can't find the node client for %d", j)
+		}
+
+		// a peer that doesn't get its dealing complains, and gets it on the board
+		j, dealing := j, dealing
+		node.clock.Go(func() {
+			ctx, cancel := node.clock.WithTimeout(context.Background(), node.deadlines.GetDealings())
+			defer cancel()
+
+			// peers may not be serving yet
+			if _, err := nodeClient.SubmitDealing(ctx, proto.Clone(dealing).(*services.Dealing), grpc.WaitForReady(true)); err != nil {
+				node.log.Warnf("can't send the dealing of %d: %s", j, status.Convert(err).Message())
+			}
+		})
+	}
+
+	for {
+		i, received, _ := node.clock.Wait(node.dealingAccusationChan, resultChan)
+		if i == 0 {
+			list := received.(*services.DealingAccusationList)
+			if Epoch(list.Epoch) != epoch {
+				continue
+			}
+			if err := node.answerDealingComplaints(epoch, list, dealings); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result := received.(dkgResult)
+		if result.err != nil {
+			return result.err
+		}
+
+		node.share = result.share
+		node.secretCommitment = result.commitment
+
+		node.log.Infof("DKG finished")
+
+		return nil
+	}
+}
+
+// answerDealingComplaints reveals the dealings of the nodes complaining about this one.
+func (node *Node) answerDealingComplaints(epoch Epoch, list *services.DealingAccusationList, dealings map[int64]*services.Dealing) error {
+	reveal := &services.DealingReveal{
+		Epoch:  int32(epoch),
+		Dealer: node.id,
+	}
+
+	for _, complaints := range list.List {
+		if !contains(complaints.Dealers, node.id) {
+			continue
+		}
+		if err := node.baseConfig.identityKeys.verify(complaints); err != nil {
+			node.log.Warnf("ignoring a forged complaint: %s", err.Error())
+			continue
+		}
+
+		dealing, ok := dealings[complaints.From]
+		if !ok {
+			continue
+		}
+		reveal.Dealings = append(reveal.Dealings, dealing)
+	}
+
+	if len(reveal.Dealings) == 0 {
+		return nil
+	}
+
+	node.log.Warnf("revealing the dealings of %d nodes that complained", len(reveal.Dealings))
+	node.sign(reveal)
+
+	return node.board.Post(context.Background(), newPost(services.BoardPost_DEALING_REVEAL, epoch, node.id, reveal))
+}
+
+// consensusOnDealings fixes the dealers of the initial sharing and publishes their commitments.
+// For the DKG, that is every member of the old group whose commitment came in by the deadline, at least
+// 2t+1 of them. The nodes then complain about the dealers they got no valid dealing from, and a dealer
+// has to answer by revealing the dealings of the complainers. Those that don't are disqualified, and the
+// rest make up the initial sharing. When importing a secret, the dealer is the only dealer.
+func (bb *BulletinBoard) consensusOnDealings(epoch Epoch) error {
+	wanted, minDealers, minQualified := len(bb.config.oldGroup), 2*bb.config.degree+1, bb.config.degree+1
+	isDealer := bb.config.IsOldMember
+	if bb.bootstrap == BootstrapDealer {
+		wanted, minDealers, minQualified = 1, 1, 1
+		isDealer = func(id int64) bool { return id == DealerId }
+	}
+
+	var candidates []*services.DealingCommitment
+	commitments := make(map[int64]PolyCommit)
+	stop := bb.clock.After(bb.deadlines.GetDealings(), nil)
+
+	for len(candidates) < wanted {
+		msg := bb.dealingCommitments.next(epoch, stop)
+		if msg == nil {
+			break
+		}
+		dc := msg.(*services.DealingCommitment)
+
+		if !isDealer(dc.Dealer) {
+			bb.log.Warnf("[primary] ignoring dealing commitment from %d", dc.Dealer)
+			continue
+		}
+
+		comm, err := decodeCommitmentOfDegree(dc.Commitment, bb.config.degree)
+		if err != nil {
+			bb.log.Warnf("[primary] ignoring dealing commitment from %d: %s", dc.Dealer, err.Error())
+			continue
+		}
+
+		commitments[dc.Dealer] = comm
+		candidates = append(candidates, dc)
+	}
+
+	if len(candidates) < minDealers {
+		return fmt.Errorf("only %d of %d dealing commitments", len(candidates), minDealers)
+	}
+
+	bb.publish(services.BoardPost_DEALING_COMMITMENT_LIST, epoch, &services.DealingCommitmentList{
+		Epoch: int32(epoch),
+		List:  candidates,
+	})
+
+	qualified := make(map[int64]bool)
+	for dealer := range commitments {
+		qualified[dealer] = true
+	}
+
+	// the dealer has no one to answer to: a node without a valid share gives up
+	var revealed []*services.Dealing
+	if bb.bootstrap != BootstrapDealer {
+		revealed = bb.settleDealingComplaints(epoch, commitments, qualified)
+	}
+
+	var dealers []int64
+	first := true
+	for _, dc := range candidates {
+		if !qualified[dc.Dealer] {
+			continue
+		}
+		dealers = append(dealers, dc.Dealer)
+
+		if first {
+			bb.secretCommitment = commitments[dc.Dealer]
+			first = false
+		} else {
+			bb.secretCommitment = AdditiveHomomorphism(bb.secretCommitment, commitments[dc.Dealer])
+		}
+	}
+
+	// with t+1 of them, one is honest and keeps the secret unknown
+	if len(dealers) < minQualified {
+		return fmt.Errorf("only %d dealers qualified", len(dealers))
+	}
+
+	bb.log.Infof("[primary] dealers fixed: %v. commitment to the secret: %s", dealers, bb.secretCommitment.String())
+
+	bb.publish(services.BoardPost_QUALIFIED_DEALERS, epoch, &services.QualifiedDealers{
+		Epoch:    int32(epoch),
+		Dealers:  dealers,
+		Revealed: revealed,
+	})
+
+	return nil
+}
+
+// settleDealingComplaints collects the complaints of the old group, and the answers of the accused dealers.
+// It disqualifies the dealers that don't answer every complaint with a dealing on their commitment, and
+// returns the dealings revealed by the others.
+func (bb *BulletinBoard) settleDealingComplaints(epoch Epoch, commitments map[int64]PolyCommit, qualified map[int64]bool) []*services.Dealing {
+	// who complained about each dealer
+	accusers := make(map[int64][]int64)
+	var accusations []*services.DealingComplaints
+	received := make(map[int64]bool)
+	// the nodes complain once they are done collecting the dealings, a deadline after the list
+	stop := bb.clock.After(2*bb.deadlines.GetDealings(), nil)
+
+	for len(received) < len(bb.config.oldGroup) {
+		msg := bb.dealingComplaints.next(epoch, stop)
+		if msg == nil {
+			bb.log.Warnf("[primary] no dealing complaints from %v", missing(bb.config.oldGroup, func(id int64) bool { return received[id] }))
+			break
+		}
+		dc := msg.(*services.DealingComplaints)
+
+		if !bb.config.IsOldMember(dc.From) {
+			bb.log.Warnf("[primary] ignoring dealing complaints from %d, which is not in the old group", dc.From)
+			continue
+		}
+		received[dc.From] = true
+
+		accused := false
+		for _, dealer := range dc.Dealers {
+			if _, ok := commitments[dealer]; ok && !contains(accusers[dealer], dc.From) {
+				accusers[dealer] = append(accusers[dealer], dc.From)
+				accused = true
+			}
+		}
+		if accused {
+			accusations = append(accusations, dc)
+		}
+	}
+
+	if len(accusers) == 0 {
+		return nil
+	}
+
+	bb.publish(services.BoardPost_DEALING_ACCUSATIONS, epoch, &services.DealingAccusationList{
+		Epoch: int32(epoch),
+		List:  accusations,
+	})
+
+	// a dealer answers every complaint, or is out
+	var revealed []*services.Dealing
+	answered := make(map[int64]bool)
+	stop = bb.clock.After(bb.deadlines.GetDealings(), nil)
+
+	for len(answered) < len(accusers) {
+		msg := bb.dealingReveals.next(epoch, stop)
+		if msg == nil {
+			break
+		}
+		reveal := msg.(*services.DealingReveal)
+
+		complainers, ok := accusers[reveal.Dealer]
+		if !ok {
+			continue
+		}
+		answered[reveal.Dealer] = true
+
+		dealings, err := bb.checkReveal(reveal, commitments[reveal.Dealer], complainers)
+		if err != nil {
+			bb.log.Warnf("[primary] disqualifying dealer %d: %s", reveal.Dealer, err.Error())
+			qualified[reveal.Dealer] = false
+			continue
+		}
+		revealed = append(revealed, dealings...)
+	}
+
+	for dealer := range accusers {
+		if !answered[dealer] {
+			bb.log.Warnf("[primary] disqualifying dealer %d, which didn't answer the complaints", dealer)
+			qualified[dealer] = false
+		}
+	}
+
+	// the dealings of a disqualified dealer don't count
+	var kept []*services.Dealing
+	for _, dealing := range revealed {
+		if qualified[dealing.From] {
+			kept = append(kept, dealing)
+		}
+	}
+
+	return kept
+}
+
+// checkReveal checks that a dealer revealed a dealing on its commitment for each of the complainers.
+func (bb *BulletinBoard) checkReveal(reveal *services.DealingReveal, comm PolyCommit, complainers []int64) ([]*services.Dealing, error) {
+	byNode := make(map[int64]*services.Dealing)
+	for _, dealing := range reveal.Dealings {
+		if dealing.From != reveal.Dealer || Epoch(dealing.Epoch) != Epoch(reveal.Epoch) {
+			return nil, fmt.Errorf("revealed a dealing of another dealer or epoch")
+		}
+		if err := bb.identityKeys.verify(dealing); err != nil {
+			return nil, err
+		}
+		byNode[dealing.To] = dealing
+	}
+
+	var dealings []*services.Dealing
+	for _, j := range complainers {
+		dealing, ok := byNode[j]
+		if !ok {
+			return nil, fmt.Errorf("didn't reveal the dealing of %d", j)
+		}
+
+		share := gmp.NewInt(0).SetBytes(dealing.Share)
+		if !comm.VerifyEval(big.NewInt(j), conv.GmpInt2BigInt(share)) {
+			return nil, fmt.Errorf("the dealing of %d is not on the committed polynomial", j)
+		}
+		dealings = append(dealings, dealing)
+	}
+
+	return dealings, nil
+}
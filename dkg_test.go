@@ -0,0 +1,270 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"../../utils/conv"
+	"../../utils/polyring"
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// buildDKG starts a primary in DKG mode and builds the nodes of an in-memory network, without starting them.
+func buildDKG(t *testing.T, n, degree int) (*BulletinBoard, []Node) {
+	pp, _ := withEncryptionKeys(BuildConfig(degree, FieldPrime, makeOneToN(n), makeOneToN(n)))
+	pp, identityKeys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	deadlines := DeadlineConfig{Dealings: 1000}
+
+	network := NewMemoryNetwork()
+	primaryUrl := "127.0.0.1:8000"
+	urls := make(map[NewNodeID]string)
+	for _, id := range pp.Members() {
+		urls[NewNodeID(id)] = fmt.Sprintf("127.0.0.1:%d", 8000+id)
+	}
+
+	primary := BuildBulletinBoard(logger, primaryUrl, urls, pp)
+	primary.SetSuicideOption(false)
+	primary.SetBootstrapOption(BootstrapDKG)
+	primary.SetModeOption(ModeProduction)
+	primary.SetDeadlines(deadlines)
+	primary.SetTransport(network)
+	go primary.Serve()
+	go primary.StartProtocol()
+
+	nodes := make([]Node, n)
+	for i, id := range pp.Members() {
+		peers := make(map[NewNodeID]string)
+		for other, url := range urls {
+			if int64(other) != id {
+				peers[other] = url
+			}
+		}
+
+		nodes[i] = BuildNode(pp, logger, id, primaryUrl, urls[NewNodeID(id)], peers, nil)
+		nodes[i].SetTransport(network)
+		nodes[i].SetDeadlines(deadlines)
+		nodes[i].SetIdentityKey(identityKeys[id])
+		go nodes[i].Serve()
+		assert.Nil(t, nodes[i].ConnectPrimary())
+	}
+
+	return &primary, nodes
+}
+
+// runDKG runs the DKG on the nodes and waits for them all.
+func runDKG(t *testing.T, nodes []*Node) {
+	errs := make(chan error, len(nodes))
+	for _, node := range nodes {
+		go func(node *Node) {
+			errs <- node.RunDKG()
+		}(node)
+	}
+
+	for range nodes {
+		select {
+		case err := <-errs:
+			assert.Nil(t, err)
+		case <-time.After(30 * time.Second):
+			t.Fatalf("the DKG didn't finish")
+		}
+	}
+}
+
+// qualifiedDealers reads the dealers that qualified off the board.
+func qualifiedDealers(t *testing.T, primary *BulletinBoard) []int64 {
+	for _, post := range primary.posts.readEpoch(0) {
+		if post.Kind == services.BoardPost_QUALIFIED_DEALERS {
+			qualified := &services.QualifiedDealers{}
+			assert.Nil(t, proto.Unmarshal(post.Payload, qualified))
+
+			return qualified.Dealers
+		}
+	}
+
+	t.Fatalf("no qualified dealers on the board")
+	return nil
+}
+
+// checkDKGShares checks that the shares are on a polynomial of the degree, and on the commitment of each node.
+func checkDKGShares(t *testing.T, nodes []*Node, degree int) {
+	var Xs, Ys []*gmp.Int
+	for _, node := range nodes {
+		assert.NotNil(t, node.share)
+		assert.True(t, node.secretCommitment.VerifyEval(big.NewInt(node.id), conv.GmpInt2BigInt(node.share)))
+
+		Xs = append(Xs, gmp.NewInt(node.id))
+		Ys = append(Ys, node.share)
+	}
+
+	_, wrong, err := DecodeReedSolomon(degree, Xs, Ys, nodes[0].config.prime)
+	assert.Nil(t, err)
+	assert.Empty(t, wrong)
+}
+
+func TestDKG(t *testing.T) {
+	const n, degree = 4, 1
+
+	primary, nodes := buildDKG(t, n, degree)
+
+	var honest []*Node
+	for i := range nodes {
+		honest = append(honest, &nodes[i])
+	}
+	runDKG(t, honest)
+
+	checkDKGShares(t, honest, degree)
+	assert.ElementsMatch(t, makeOneToN(n), qualifiedDealers(t, primary))
+}
+
+func TestDKG_Resume(t *testing.T) {
+	const n, degree = 4, 1
+
+	primary, nodes := buildDKG(t, n, degree)
+
+	var all []*Node
+	for i := range nodes {
+		all = append(all, &nodes[i])
+	}
+	runDKG(t, all)
+
+	// the first node restarts after the DKG, while the board still holds its posts
+	before := &nodes[0]
+	store := NewMemoryShareStore()
+	assert.Nil(t, store.Save(&ShareState{Epoch: 0, Share: before.share, Commitment: before.secretCommitment}))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	node := BuildNode(before.baseConfig, logger, before.id, before.primaryIP, "", nil, nil)
+	node.SetTransport(before.network)
+	node.SetIdentityKey(before.identityKey)
+
+	resumed, err := node.UseShareStore(store)
+	assert.Nil(t, err)
+	assert.True(t, resumed)
+	assert.Nil(t, node.ConnectPrimary())
+
+	// the DKG posts replayed to the node don't keep it from following the board
+	primary.publish(services.BoardPost_ADVANCE_EPOCH, 1, &services.EpochAdvance{})
+
+	select {
+	case <-node.decidedChan(1):
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the verdict never reached the restarted node")
+	}
+}
+
+func TestDKG_NoDealers(t *testing.T) {
+	pp := BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	// the board never posts the dealers
+	node := BuildNode(pp, logger, 1, "", "", nil, nil)
+	node.SetDeadlines(DeadlineConfig{Dealings: 50})
+
+	select {
+	case result := <-node.startDealingCollector(0, true):
+		assert.NotNil(t, result.err)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("the node never gave up on the DKG")
+	}
+}
+
+func TestDKG_BadDealer(t *testing.T) {
+	const n, degree = 5, 1
+
+	primary, nodes := buildDKG(t, n, degree)
+
+	// the last node commits to one polynomial, deals shares off it and never answers the complaints
+	bad := &nodes[n-1]
+	assert.Nil(t, bad.ConnectPeers())
+
+	poly, err := polyring.NewRand(degree, NewCryptoRand(), bad.config.prime)
+	assert.Nil(t, err)
+
+	commitment := &services.DealingCommitment{
+		Epoch:      0,
+		Dealer:     bad.id,
+		Commitment: encodeCommitment(NewPolyCommit(poly)),
+	}
+	bad.sign(commitment)
+	assert.Nil(t, bad.board.Post(context.Background(), newPost(services.BoardPost_DEALING_COMMITMENT, 0, bad.id, commitment)))
+
+	var honest []*Node
+	for i := range nodes[:n-1] {
+		honest = append(honest, &nodes[i])
+	}
+
+	go func() {
+		for _, node := range honest {
+			share := gmp.NewInt(0)
+			poly.EvalMod(gmp.NewInt(node.id), bad.config.prime, share)
+			share.Add(share, gmp.NewInt(1))
+
+			dealing := &services.Dealing{From: bad.id, To: node.id, Share: share.Bytes()}
+			bad.sign(dealing)
+			bad.nodes[NewNodeID(node.id)].SubmitDealing(context.Background(), dealing)
+		}
+	}()
+
+	runDKG(t, honest)
+
+	checkDKGShares(t, honest, degree)
+	assert.ElementsMatch(t, makeOneToN(n-1), qualifiedDealers(t, primary))
+}
+
+func TestDKG_OverDegreeDealer(t *testing.T) {
+	const n, degree = 5, 1
+
+	primary, nodes := buildDKG(t, n, degree)
+
+	// the last node commits to a polynomial of a higher degree and deals valid shares off it,
+	// which would raise the threshold of the sum
+	bad := &nodes[n-1]
+	assert.Nil(t, bad.ConnectPeers())
+
+	poly, err := polyring.NewRand(degree+1, NewCryptoRand(), bad.config.prime)
+	assert.Nil(t, err)
+
+	commitment := &services.DealingCommitment{
+		Epoch:      0,
+		Dealer:     bad.id,
+		Commitment: encodeCommitment(NewPolyCommit(poly)),
+	}
+	bad.sign(commitment)
+	assert.Nil(t, bad.board.Post(context.Background(), newPost(services.BoardPost_DEALING_COMMITMENT, 0, bad.id, commitment)))
+
+	var honest []*Node
+	for i := range nodes[:n-1] {
+		honest = append(honest, &nodes[i])
+	}
+
+	go func() {
+		for _, node := range honest {
+			share := gmp.NewInt(0)
+			poly.EvalMod(gmp.NewInt(node.id), bad.config.prime, share)
+
+			dealing := &services.Dealing{From: bad.id, To: node.id, Share: share.Bytes()}
+			bad.sign(dealing)
+			bad.nodes[NewNodeID(node.id)].SubmitDealing(context.Background(), dealing)
+		}
+	}()
+
+	runDKG(t, honest)
+
+	checkDKGShares(t, honest, degree)
+	assert.ElementsMatch(t, makeOneToN(n-1), qualifiedDealers(t, primary))
+}
@@ -0,0 +1,349 @@
+package Schultz
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ncw/gmp"
+)
+
+// Each old node has a P-256 key, which what is sent to it is encrypted to. The masks of a recovery are
+// encrypted with hashed ElGamal: the sender picks r, publishes R = rG, and pads each point with a hash of
+// the shared key S = r PK. A Schnorr proof of knowledge of r binds the ciphertexts to the recovery, so that
+// nobody can pass off the ciphertexts of another one as their own. Only the recipient can tell what they
+// hold, which is all a recovery needs. The points of a proposal have to be checked by everyone: they are
+// encrypted as VerifiablePoints.
+
+var encryptionCurve = elliptic.P256()
+
+// EncryptionKey is the key an old node decrypts the points sent to it with.
+type EncryptionKey struct {
+	d      *big.Int
+	Public EncryptionPublicKey
+}
+
+type EncryptionPublicKey struct {
+	x, y *big.Int
+}
+
+func GenerateEncryptionKey() (*EncryptionKey, error) {
+	d, x, y, err := elliptic.GenerateKey(encryptionCurve, crand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncryptionKey{
+		d:      new(big.Int).SetBytes(d),
+		Public: EncryptionPublicKey{x, y},
+	}, nil
+}
+
+func encryptionKeyFromScalar(d *big.Int) (*EncryptionKey, error) {
+	if d.Sign() <= 0 || d.Cmp(encryptionCurve.Params().N) >= 0 {
+		return nil, fmt.Errorf("the scalar is out of range")
+	}
+
+	x, y := encryptionCurve.ScalarBaseMult(d.Bytes())
+
+	return &EncryptionKey{
+		d:      d,
+		Public: EncryptionPublicKey{x, y},
+	}, nil
+}
+
+func ParseEncryptionPublicKey(b []byte) (EncryptionPublicKey, error) {
+	x, y := elliptic.Unmarshal(encryptionCurve, b)
+	if x == nil {
+		return EncryptionPublicKey{}, fmt.Errorf("not a point on P-256")
+	}
+
+	return EncryptionPublicKey{x, y}, nil
+}
+
+func (pk EncryptionPublicKey) Bytes() []byte {
+	return elliptic.Marshal(encryptionCurve, pk.x, pk.y)
+}
+
+// GenerateEncryptionKeyFile writes a fresh private key to keyFile and returns the public key in hex.
+func GenerateEncryptionKeyFile(keyFile string) (string, error) {
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		return "", err
+	}
+
+	scalar := hex.EncodeToString(key.d.Bytes())
+	if err := ioutil.WriteFile(keyFile, []byte(scalar+"\n"), 0600); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key.Public.Bytes()), nil
+}
+
+func LoadEncryptionKey(keyFile string) (*EncryptionKey, error) {
+	content, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	scalar, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%s doesn't hold an encryption key", keyFile)
+	}
+
+	key, err := encryptionKeyFromScalar(new(big.Int).SetBytes(scalar))
+	if err != nil {
+		return nil, fmt.Errorf("%s doesn't hold an encryption key: %s", keyFile, err.Error())
+	}
+
+	return key, nil
+}
+
+// challenge hashes the transcript of a proof into a scalar.
+func challenge(domain string, parts ...[]byte) *big.Int {
+	hash := sha256.New()
+	hash.Write([]byte(domain))
+	for _, part := range parts {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(part)))
+		hash.Write(length[:])
+		hash.Write(part)
+	}
+
+	c := new(big.Int).SetBytes(hash.Sum(nil))
+
+	return c.Mod(c, encryptionCurve.Params().N)
+}
+
+func randomScalar() (*big.Int, error) {
+	n := encryptionCurve.Params().N
+	for {
+		k, err := crand.Int(crand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if k.Sign() > 0 {
+			return k, nil
+		}
+	}
+}
+
+// checks s P == A + c Q
+func checkResponse(s *big.Int, px, py *big.Int, a []byte, c *big.Int, qx, qy *big.Int) bool {
+	ax, ay := elliptic.Unmarshal(encryptionCurve, a)
+	if ax == nil {
+		return false
+	}
+
+	lx, ly := encryptionCurve.ScalarMult(px, py, s.Bytes())
+	cx, cy := encryptionCurve.ScalarMult(qx, qy, c.Bytes())
+	rx, ry := encryptionCurve.Add(ax, ay, cx, cy)
+
+	return lx.Cmp(rx) == 0 && ly.Cmp(ry) == 0
+}
+
+// pad is the one-time pad of the point for new node k sent to old node j.
+func pad(shared, ephemeral []byte, j OldNodeID, k NewNodeID, prime *gmp.Int) *gmp.Int {
+	var ids [8]byte
+	binary.BigEndian.PutUint32(ids[:4], uint32(j))
+	binary.BigEndian.PutUint32(ids[4:], uint32(k))
+
+	hash := sha256.New()
+	hash.Write([]byte("mpss-pad"))
+	hash.Write(shared)
+	hash.Write(ephemeral)
+	hash.Write(ids[:])
+
+	p := gmp.NewInt(0).SetBytes(hash.Sum(nil))
+
+	return p.Mod(p, prime)
+}
+
+// EncryptedPoints are points for one old node, encrypted to its key.
+type EncryptedPoints struct {
+	// R = rG
+	ephemeral []byte
+	// point + pad, for each new node
+	cipher map[NewNodeID]*gmp.Int
+	// Schnorr proof of knowledge of r
+	proofCommitment []byte
+	proofResponse   *big.Int
+}
+
+func (e EncryptedPoints) sortedIds() []NewNodeID {
+	var keys []NewNodeID
+	for k := range e.cipher {
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
+
+// cipherBytes encodes the ciphertexts canonically, each with the id of its new node.
+func (e EncryptedPoints) cipherBytes() ([]byte, error) {
+	keys := e.sortedIds()
+
+	c := &canonical{}
+	c.uint32(uint32(len(keys)))
+	for _, k := range keys {
+		c.uint32(uint32(k))
+		c.element(e.cipher[k])
+	}
+
+	return c.Bytes(), c.Err()
+}
+
+// proofContext binds the Schnorr proof to the ciphertexts, their old node and what they were sent with.
+func (e EncryptedPoints) proofContext(j OldNodeID, bound []byte) ([][]byte, error) {
+	var id [4]byte
+	binary.BigEndian.PutUint32(id[:], uint32(j))
+
+	cipher, err := e.cipherBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	return [][]byte{e.ephemeral, id[:], cipher, bound}, nil
+}
+
+func (e EncryptedPoints) Bytes() ([]byte, error) {
+	cipher, err := e.cipherBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &canonical{}
+	c.bytes(e.ephemeral)
+	c.bytes(cipher)
+	c.bytes(e.proofCommitment)
+	if e.proofResponse != nil {
+		c.bytes(e.proofResponse.Bytes())
+	} else {
+		c.bytes(nil)
+	}
+
+	return c.Bytes(), nil
+}
+
+func (e EncryptedPoints) Equal(other EncryptedPoints) bool {
+	a, err := e.Bytes()
+	if err != nil {
+		return false
+	}
+	b, err := other.Bytes()
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(a, b) && len(e.cipher) == len(other.cipher)
+}
+
+// encryptPoints encrypts the points for old node j to its key pk. bound is what the ciphertexts are bound to.
+func encryptPoints(pk EncryptionPublicKey, j OldNodeID, points PointsOnBlindingPoly, prime *gmp.Int, bound []byte) (EncryptedPoints, error) {
+	r, err := randomScalar()
+	if err != nil {
+		return EncryptedPoints{}, err
+	}
+
+	ex, ey := encryptionCurve.ScalarBaseMult(r.Bytes())
+	sx, sy := encryptionCurve.ScalarMult(pk.x, pk.y, r.Bytes())
+
+	e := EncryptedPoints{
+		ephemeral: elliptic.Marshal(encryptionCurve, ex, ey),
+		cipher:    make(map[NewNodeID]*gmp.Int, len(points.points)),
+	}
+	shared := elliptic.Marshal(encryptionCurve, sx, sy)
+
+	for k, point := range points.points {
+		c := gmp.NewInt(0).Add(point, pad(shared, e.ephemeral, j, k, prime))
+		e.cipher[k] = c.Mod(c, prime)
+	}
+
+	// prove knowledge of r
+	w, err := randomScalar()
+	if err != nil {
+		return EncryptedPoints{}, err
+	}
+
+	tx, ty := encryptionCurve.ScalarBaseMult(w.Bytes())
+	e.proofCommitment = elliptic.Marshal(encryptionCurve, tx, ty)
+
+	context, err := e.proofContext(j, bound)
+	if err != nil {
+		return EncryptedPoints{}, err
+	}
+	c := challenge("mpss-schnorr", append([][]byte{e.proofCommitment}, context...)...)
+
+	s := new(big.Int).Mul(c, r)
+	s.Add(s, w)
+	e.proofResponse = s.Mod(s, encryptionCurve.Params().N)
+
+	return e, nil
+}
+
+// verify checks the proof that the sender knows the randomness of the ciphertexts.
+func (e EncryptedPoints) verify(j OldNodeID, bound []byte) error {
+	ex, ey := elliptic.Unmarshal(encryptionCurve, e.ephemeral)
+	if ex == nil {
+		return fmt.Errorf("the ephemeral key for %d is not on the curve", j)
+	}
+
+	if e.proofResponse == nil {
+		return fmt.Errorf("no proof for the points of %d", j)
+	}
+
+	context, err := e.proofContext(j, bound)
+	if err != nil {
+		return fmt.Errorf("the points of %d: %s", j, err.Error())
+	}
+	c := challenge("mpss-schnorr", append([][]byte{e.proofCommitment}, context...)...)
+
+	params := encryptionCurve.Params()
+	if !checkResponse(e.proofResponse, params.Gx, params.Gy, e.proofCommitment, c, ex, ey) {
+		return fmt.Errorf("bad proof for the points of %d", j)
+	}
+
+	return nil
+}
+
+func (e EncryptedPoints) decryptWith(shared []byte, j OldNodeID, prime *gmp.Int) PointsOnBlindingPoly {
+	points := PointsOnBlindingPoly{points: make(map[NewNodeID]*gmp.Int, len(e.cipher))}
+
+	for k, c := range e.cipher {
+		m := gmp.NewInt(0).Sub(c, pad(shared, e.ephemeral, j, k, prime))
+		points.points[k] = m.Mod(m, prime)
+	}
+
+	return points
+}
+
+func (e EncryptedPoints) sharedKey(key *EncryptionKey) ([]byte, error) {
+	ex, ey := elliptic.Unmarshal(encryptionCurve, e.ephemeral)
+	if ex == nil {
+		return nil, fmt.Errorf("the ephemeral key is not on the curve")
+	}
+
+	sx, sy := encryptionCurve.ScalarMult(ex, ey, key.d.Bytes())
+
+	return elliptic.Marshal(encryptionCurve, sx, sy), nil
+}
+
+// decrypt recovers the points for old node j, which holds key.
+func (e EncryptedPoints) decrypt(key *EncryptionKey, j OldNodeID, prime *gmp.Int) (PointsOnBlindingPoly, error) {
+	shared, err := e.sharedKey(key)
+	if err != nil {
+		return PointsOnBlindingPoly{}, err
+	}
+
+	return e.decryptWith(shared, j, prime), nil
+}
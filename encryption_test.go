@@ -0,0 +1,67 @@
+package Schultz
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ncw/gmp"
+	"github.com/stretchr/testify/assert"
+)
+
+// withEncryptionKeys gives every node of pp a fresh encryption key.
+func withEncryptionKeys(pp PublicParameter) (PublicParameter, map[int64]*EncryptionKey) {
+	keys := make(map[int64]*EncryptionKey)
+	public := make(map[int64]EncryptionPublicKey)
+
+	for _, id := range pp.Members() {
+		key, err := GenerateEncryptionKey()
+		if err != nil {
+			panic(err.Error())
+		}
+
+		keys[id] = key
+		public[id] = key.Public
+	}
+
+	return pp.WithEncryptionKeys(public), keys
+}
+
+func TestEncryptionKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mpss")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := path.Join(dir, "node.key")
+
+	publicHex, err := GenerateEncryptionKeyFile(keyFile)
+	assert.Nil(t, err)
+
+	key, err := LoadEncryptionKey(keyFile)
+	assert.Nil(t, err)
+
+	public, err := ParseEncryptionPublicKey(key.Public.Bytes())
+	assert.Nil(t, err)
+	assert.Equal(t, key.Public.Bytes(), public.Bytes())
+	assert.Equal(t, publicHex, hex.EncodeToString(public.Bytes()))
+}
+
+func TestEncryptedPoints_NotFieldElement(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	assert.Nil(t, err)
+
+	points := PointsOnBlindingPoly{points: map[NewNodeID]*gmp.Int{2: gmp.NewInt(5)}}
+	e, err := encryptPoints(key.Public, 1, points, FieldPrime, []byte("bound"))
+	assert.Nil(t, err)
+	assert.Nil(t, e.verify(1, []byte("bound")))
+
+	// a ciphertext too long to be a field element fails the check, and doesn't crash the node
+	e.cipher[2] = gmp.NewInt(0).Lsh(gmp.NewInt(1), uint(8*fieldBytes))
+	assert.NotNil(t, e.verify(1, []byte("bound")))
+	assert.False(t, e.Equal(e))
+
+	_, err = e.toMessage(1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,48 @@
+package Schultz
+
+import (
+	"context"
+
+	"./services"
+	"github.com/ncw/gmp"
+)
+
+// Proactive security only holds if a share is gone once the new group took over. As soon as the board
+// confirms an epoch, a node erases the share it held before it, and records the erasure on the board
+// so that anyone can check which nodes claim to have done so.
+
+// zeroBytes overwrites b, which held a share or something encoding one.
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// eraseShare destroys the share held before the epoch. The state without it replaces the one in the
+// store, and the erasure is posted on the board.
+func (node *Node) eraseShare(epoch Epoch, old *gmp.Int) {
+	node.saveState(epoch, nil)
+
+	if old == nil {
+		return
+	}
+
+	eraseInt(old)
+
+	msg := services.ShareErasure{
+		Epoch: int32(epoch),
+		From:  node.id,
+	}
+	node.sign(&msg)
+
+	if err := node.board.Post(context.Background(), newPost(services.BoardPost_SHARE_ERASED, epoch, node.id, &msg)); err != nil {
+		node.log.Errorf("can't record the erasure of epoch %d: %s", epoch, err.Error())
+		return
+	}
+
+	node.log.Infof("erased epoch %d", epoch)
+}
+
+func (bb *BulletinBoard) submitShareErasure(erasure *services.ShareErasure) {
+	bb.log.Infof("[primary] %d erased its share before epoch %d", erasure.From, erasure.Epoch)
+}
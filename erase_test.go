@@ -0,0 +1,144 @@
+package Schultz
+
+import (
+	"context"
+	crand "crypto/rand"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingBoard keeps what a node posts.
+type recordingBoard struct {
+	posts []*services.BoardPost
+}
+
+func (b *recordingBoard) Post(ctx context.Context, post *services.BoardPost) error {
+	b.posts = append(b.posts, post)
+	return nil
+}
+
+func (b *recordingBoard) Subscribe(kinds ...services.BoardPost_Kind) <-chan *services.BoardPost {
+	return nil
+}
+
+func (b *recordingBoard) ReadEpoch(ctx context.Context, epoch Epoch) ([]*services.BoardPost, error) {
+	return nil, nil
+}
+
+func randomShare(pp PublicParameter) *gmp.Int {
+	b := make([]byte, 32)
+	if _, err := crand.Read(b); err != nil {
+		panic(err.Error())
+	}
+
+	x := gmp.NewInt(0).SetBytes(b)
+	return x.Mod(x, pp.GetPrime())
+}
+
+// reachableInts calls f on every integer reachable from v.
+func reachableInts(v reflect.Value, seen map[uintptr]bool, f func(*gmp.Int)) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || seen[v.Pointer()] {
+			return
+		}
+		seen[v.Pointer()] = true
+
+		if v.Type() == reflect.TypeOf(&gmp.Int{}) {
+			f((*gmp.Int)(unsafe.Pointer(v.Pointer())))
+			return
+		}
+		reachableInts(v.Elem(), seen, f)
+	case reflect.Interface:
+		reachableInts(v.Elem(), seen, f)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			reachableInts(v.Field(i), seen, f)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			reachableInts(v.Index(i), seen, f)
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			reachableInts(key, seen, f)
+			reachableInts(v.MapIndex(key), seen, f)
+		}
+	}
+}
+
+func TestEraseInt(t *testing.T) {
+	x := randomShare(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	alias := x
+
+	eraseInt(x)
+	assert.Equal(t, 0, alias.Sign())
+
+	// nothing to erase
+	eraseInt(nil)
+}
+
+func TestNode_EraseShare(t *testing.T) {
+	pp, keys := withIdentityKeys(BuildConfig(1, FieldPrime, makeOneToN(4), []int64{2, 3, 4, 5}))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	oldShare := randomShare(pp)
+	newShare := randomShare(pp)
+
+	store := NewMemoryShareStore()
+	assert.Nil(t, store.Save(&ShareState{
+		Epoch:   3,
+		Share:   oldShare,
+		Pending: &PendingShare{Epoch: 4, Share: newShare},
+	}))
+
+	board := &recordingBoard{}
+	node := BuildNode(pp, logger, 2, "", "", nil, nil)
+	node.board = board
+	node.SetIdentityKey(keys[2])
+
+	resumed, err := node.UseShareStore(store)
+	assert.Nil(t, err)
+	assert.True(t, resumed)
+	held := node.share
+
+	// the board confirms the new share
+	node.onEpochVerdict(4, &services.EpochAdvance{})
+	assert.Equal(t, Epoch(4), node.settlePending())
+	assert.Equal(t, 0, node.share.Cmp(newShare))
+
+	// the old share is zeroed, and no integer the node can reach holds it
+	assert.Equal(t, 0, held.Sign())
+	reachedNew := false
+	reachableInts(reflect.ValueOf(node), make(map[uintptr]bool), func(x *gmp.Int) {
+		assert.False(t, x == held)
+		assert.NotEqual(t, 0, x.Cmp(oldShare))
+		reachedNew = reachedNew || x == node.share
+	})
+	assert.True(t, reachedNew)
+
+	// the store holds only the new share
+	state, err := store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, Epoch(4), state.Epoch)
+	assert.Equal(t, 0, state.Share.Cmp(newShare))
+	assert.Nil(t, state.Pending)
+
+	// and the board has a signed record of the erasure
+	assert.Len(t, board.posts, 1)
+	assert.Equal(t, services.BoardPost_SHARE_ERASED, board.posts[0].Kind)
+
+	erasure := &services.ShareErasure{}
+	assert.Nil(t, proto.Unmarshal(board.posts[0].Payload, erasure))
+	assert.Equal(t, int32(4), erasure.Epoch)
+	assert.Nil(t, pp.identityKeys.verify(erasure))
+}
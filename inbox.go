@@ -0,0 +1,165 @@
+package Schultz
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+)
+
+// how many epochs ahead of a collector a message may be and still be kept for later
+const maxEpochsAhead = 2
+
+// inbox hands the messages of one kind to the collector of the current epoch.
+//
+// A fast peer may start epoch e+1 before we are done with epoch e. Its messages are kept until we get
+// there instead of being lost: at most one per sender and epoch, at most capacity senders per epoch,
+// and nothing more than maxEpochsAhead epochs ahead. Anything past those bounds is dropped, so a
+// faulty peer can't make us buffer without limit. Messages from the board, such as hash lists, are
+// filed under sender 0.
+//
+// Delivering never blocks, so a collector that gave up on its epoch never holds up the senders.
+type inbox struct {
+	name     string
+	capacity int
+
+	// the epoch of the collector. Older messages are dropped.
+	current Epoch
+	held    map[Epoch]*heldMessages
+	lock    *sync.Mutex
+	// signals the collector that a message arrived
+	arrived chan struct{}
+	// the collector waits on the clock of its party
+	clock Clock
+
+	log *logrus.Entry
+}
+
+// heldMessages are the messages of an epoch, in the order they arrived.
+type heldMessages struct {
+	from  map[int64]bool
+	queue []proto.Message
+}
+
+func newInbox(name string, capacity int, log *logrus.Entry) *inbox {
+	return &inbox{
+		name:     name,
+		capacity: capacity,
+		held:     make(map[Epoch]*heldMessages),
+		lock:     &sync.Mutex{},
+		arrived:  make(chan struct{}, 1),
+		clock:    wallClock{},
+		log:      log,
+	}
+}
+
+// put delivers a message, within the bounds of the inbox.
+func (ib *inbox) put(epoch Epoch, from int64, msg proto.Message) {
+	ib.lock.Lock()
+	defer ib.lock.Unlock()
+
+	if epoch < ib.current {
+		ib.log.Infof("ignoring %s from a previous epoch: %d (at epoch %d)", ib.name, epoch, ib.current)
+		return
+	}
+
+	if epoch > ib.current+maxEpochsAhead {
+		ib.log.Warnf("dropping %s from %d for epoch %d, too far ahead of epoch %d", ib.name, from, epoch, ib.current)
+		return
+	}
+
+	held, ok := ib.held[epoch]
+	if !ok {
+		held = &heldMessages{from: make(map[int64]bool)}
+		ib.held[epoch] = held
+	}
+
+	if held.from[from] {
+		ib.log.Warnf("dropping a second %s from %d for epoch %d", ib.name, from, epoch)
+		return
+	}
+
+	if len(held.from) >= ib.capacity {
+		ib.log.Warnf("dropping %s from %d for epoch %d, too many senders already", ib.name, from, epoch)
+		return
+	}
+
+	held.from[from] = true
+	held.queue = append(held.queue, msg)
+
+	// a signal still pending is enough
+	ib.clock.Send(ib.arrived, struct{}{})
+}
+
+// next returns the next message for epoch e, or nil once stop is closed. A nil stop never closes.
+// Messages for earlier epochs are dropped from then on.
+func (ib *inbox) next(e Epoch, stop <-chan struct{}) proto.Message {
+	for {
+		if msg := ib.take(e); msg != nil {
+			return msg
+		}
+
+		if i, _, _ := ib.clock.Wait(ib.arrived, stop); i == 1 {
+			return nil
+		}
+	}
+}
+
+// advance moves the inbox on to epoch e, even if the collector never got to the epochs in between.
+func (ib *inbox) advance(e Epoch) {
+	ib.lock.Lock()
+	defer ib.lock.Unlock()
+
+	ib.advanceLocked(e)
+}
+
+func (ib *inbox) advanceLocked(e Epoch) {
+	if e <= ib.current {
+		return
+	}
+
+	ib.current = e
+
+	for epoch := range ib.held {
+		if epoch < e {
+			delete(ib.held, epoch)
+		}
+	}
+}
+
+func (ib *inbox) take(e Epoch) proto.Message {
+	ib.lock.Lock()
+	defer ib.lock.Unlock()
+
+	ib.advanceLocked(e)
+
+	held, ok := ib.held[e]
+	if !ok || len(held.queue) == 0 {
+		return nil
+	}
+
+	msg := held.queue[0]
+	held.queue = held.queue[1:]
+
+	return msg
+}
+
+// timeout returns a channel closed after d, or as soon as cancel is closed.
+func timeout(d time.Duration, cancel <-chan struct{}) <-chan struct{} {
+	out := make(chan struct{})
+
+	go func() {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-cancel:
+		}
+
+		close(out)
+	}()
+
+	return out
+}
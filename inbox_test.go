@@ -0,0 +1,72 @@
+package Schultz
+
+import (
+	"testing"
+	"time"
+
+	"./services"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInbox_FutureEpochs(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	ib := newInbox("share", 2, logger.WithField("name", "test"))
+
+	share := func(epoch Epoch, from int64) *services.Share {
+		return &services.Share{Epoch: int32(epoch), From: from}
+	}
+
+	// the collector is at epoch 1
+	assert.Nil(t, ib.take(1))
+
+	// from a previous epoch, dropped
+	ib.put(0, 1, share(0, 1))
+	ib.put(1, 4, share(1, 4))
+	// kept for later
+	ib.put(2, 2, share(2, 2))
+	ib.put(2, 1, share(2, 1))
+	// a second one from the same sender, and a third sender, dropped
+	ib.put(2, 1, share(2, 9))
+	ib.put(2, 3, share(2, 3))
+	// too far ahead, dropped
+	ib.put(1+maxEpochsAhead+1, 1, share(1+maxEpochsAhead+1, 1))
+	// kept for the epoch after
+	ib.put(3, 1, share(3, 1))
+	ib.put(3, 4, share(3, 4))
+
+	assert.Equal(t, share(1, 4), ib.next(1, nil))
+
+	// the kept messages come in the order they arrived
+	assert.Equal(t, share(2, 2), ib.next(2, nil))
+	assert.Equal(t, share(2, 1), ib.next(2, nil))
+	assert.Nil(t, ib.take(2))
+
+	assert.Equal(t, share(3, 1), ib.next(3, nil))
+	assert.Equal(t, share(3, 4), ib.next(3, nil))
+
+	// nothing is left behind
+	assert.Nil(t, ib.take(1+maxEpochsAhead+1))
+	assert.Empty(t, ib.held)
+}
+
+func TestInbox_Stop(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	ib := newInbox("proposal hash", 1, logger.WithField("name", "test"))
+
+	// a collector past its deadline gets nothing
+	assert.Nil(t, ib.next(1, timeout(10*time.Millisecond, nil)))
+
+	// and doesn't hold up the senders
+	ib.put(1, 1, &services.ProposalHash{Epoch: 1, Proposer: 1})
+	ib.put(1, 2, &services.ProposalHash{Epoch: 1, Proposer: 2})
+
+	stop := make(chan struct{})
+	close(stop)
+	assert.Equal(t, &services.ProposalHash{Epoch: 1, Proposer: 1}, ib.next(1, stop))
+	assert.Nil(t, ib.next(1, stop))
+}
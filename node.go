@@ -1,27 +1,22 @@
 package Schultz
 
 import (
-	"bytes"
 	"context"
-	"crypto/sha256"
-	"encoding/gob"
 	"fmt"
 	"google.golang.org/grpc/status"
-	"math/big"
-	"net"
-	"strconv"
+	"math/rand"
 	"sync"
 	"time"
 
-	"../../utils/conv"
-	"../../utils/interpolation"
-	polycommit "../../utils/polycommit/pbc"
+	"../../utils/polyring"
 	"./services"
 	"github.com/golang/protobuf/proto"
 	"github.com/montanaflynn/stats"
 	"github.com/ncw/gmp"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
 )
 
@@ -46,18 +41,84 @@ type Node struct {
 	config PublicParameter
 	share  *gmp.Int
 
+	// parameters of the first handoff, to tell the old group of any epoch
+	baseConfig PublicParameter
+
+	// commitment to the secret, if the sharing was created by the DKG
+	secretCommitment PolyCommit
+	// one of the Mode* constants. In production mode, the share never leaves the node.
+	mode string
+	// decrypts the points of the proposals sent to this node
+	encryptionKey *EncryptionKey
+	// TLS certificate of the node. If nil, connections are plain.
+	creds *Credentials
+	// tells the time of the benchmark and runs the deadlines
+	clock Clock
+	// draws the polynomials of the proposal of an epoch. If nil, they come from crypto/rand.
+	proposalRand func(epoch Epoch) *rand.Rand
+	// of the epochs run so far
+	benchmark Benchmark
+	// how the node reaches the other parties
+	network Transport
+	// signs every message the node sends
+	identityKey ed25519.PrivateKey
+
 	myIP       string
 	peerIPList map[NewNodeID]string
 	primaryIP  string
 
-	nodes       map[NewNodeID]services.NodeClient
-	primaryNode services.BulletinBoardServiceClient
-
-	blindedShareChan chan *services.BlindedShare
-	proposalListChan chan *services.ProposalHashList
-	proposalChan     chan *services.Proposal
-
-	waitAdvancedEpoch *sync.WaitGroup
+	nodes map[NewNodeID]services.NodeClient
+	board Board
+
+	// replicas of the bulletin board, if it is replicated
+	replicas      *ReplicaSet
+	replicaNodes  map[int64]services.ReplicaServiceClient
+	certified     map[Epoch]bool
+	certifiedLock *sync.Mutex
+
+	// messages of a later epoch wait in the inbox until the node gets there
+	blindedShares *inbox
+	proposalLists *inbox
+	proposals     *inbox
+	finalLists    *inbox
+
+	dealingChan  chan *services.Dealing
+	dealingLists *inbox
+	// the complaints about the dealers of the DKG, and the dealers that qualified
+	dealingAccusationChan chan *services.DealingAccusationList
+	qualifiedDealers      *inbox
+
+	// the board's verdict on each epoch. The phases of an epoch stop waiting once it is decided.
+	verdicts    *inbox
+	decided     map[Epoch]chan struct{}
+	decidedLock *sync.Mutex
+	// how long each phase waits for the other parties
+	deadlines DeadlineConfig
+	// how large the messages get
+	limits MessageConfig
+	// proposals being streamed to the node, by sender
+	transfers     map[int64]*proposalTransfer
+	transfersLock *sync.Mutex
+
+	// where the share is saved after every epoch, if anywhere
+	store ShareStore
+	// whether the node was restarted, the last epoch it finished before, and the new share then
+	// waiting for its verdict
+	resumed     bool
+	resumeEpoch Epoch
+	pending     *PendingShare
+	// whether the new group took over
+	handedOff bool
+	// what the node held after the last epoch it finished, for the peers that lose their shares
+	held     *ShareState
+	heldLock *sync.Mutex
+	// the recoveries the node helps with, by recovering node
+	recoveries     map[int64]*recoverySession
+	recoveriesLock *sync.Mutex
+
+	// whole proposals: our own, to be revealed on request, and those pulled from peers
+	wholeProposals     map[Epoch]map[int64]*services.Proposal
+	wholeProposalsLock *sync.Mutex
 
 	// logging
 	log *logrus.Entry
@@ -103,63 +164,212 @@ func (node *Node) Report(b *Benchmark) {
 	}).Warn("benchmark.")
 }
 
-func (node *Node) AdvanceEpoch(ctx context.Context, hashList *services.Empty) (*services.Empty, error) {
-	node.log.Debugf("starting the protocol, as instructed by the primary")
+func (node *Node) GetId() int64 {
+	return node.id
+}
 
-	node.waitAdvancedEpoch.Done()
+func (node *Node) onEpochVerdict(epoch Epoch, verdict *services.EpochAdvance) {
+	node.log.Debugf("the board decided epoch %d (aborted: %t)", epoch, verdict.Aborted)
 
-	return &services.Empty{}, nil
+	node.decidedLock.Lock()
+	decided := node.decidedChanLocked(epoch)
+	select {
+	case <-decided:
+	default:
+		node.clock.Close(decided)
+	}
+	node.decidedLock.Unlock()
+
+	node.verdicts.put(epoch, 0, verdict)
+}
+
+// decidedChan returns a channel closed once the board has decided the epoch.
+func (node *Node) decidedChan(epoch Epoch) <-chan struct{} {
+	node.decidedLock.Lock()
+	defer node.decidedLock.Unlock()
+
+	return node.decidedChanLocked(epoch)
+}
+
+func (node *Node) decidedChanLocked(epoch Epoch) chan struct{} {
+	decided, ok := node.decided[epoch]
+	if !ok {
+		for e := range node.decided {
+			if e < epoch-maxEpochsAhead {
+				delete(node.decided, e)
+			}
+		}
+
+		decided = make(chan struct{})
+		node.decided[epoch] = decided
+	}
+
+	return decided
+}
+
+// deadline returns a channel closed after d, or as soon as the board has decided the epoch.
+func (node *Node) deadline(epoch Epoch, d time.Duration) <-chan struct{} {
+	return node.clock.After(d, node.decidedChan(epoch))
 }
 
+// waitForVerdict waits for the board to confirm or abort the epoch.
+func (node *Node) waitForVerdict(epoch Epoch) *services.EpochAdvance {
+	var verdict *services.EpochAdvance
+	if msg := node.verdicts.next(epoch, node.decidedChan(epoch)); msg != nil {
+		verdict = msg.(*services.EpochAdvance)
+	} else {
+		// the verdict may have come too far ahead of us to be kept, as when catching up after a restart
+		var err error
+		verdict, err = node.readVerdict(epoch)
+		if err != nil {
+			// without a verdict the node keeps its share, as if the epoch was aborted
+			node.log.Errorf("can't read the verdict on epoch %d: %s", epoch, err.Error())
+			verdict = &services.EpochAdvance{Aborted: true}
+		}
+	}
+
+	if len(verdict.Missed) > 0 {
+		node.log.Infof("nodes %v missed a deadline of epoch %d", verdict.Missed, epoch)
+	}
+
+	return verdict
+}
+
+// readVerdict looks up the verdict on a decided epoch on the board.
+func (node *Node) readVerdict(epoch Epoch) (*services.EpochAdvance, error) {
+	posts, err := node.board.ReadEpoch(context.Background(), epoch)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, post := range posts {
+		if post.Kind != services.BoardPost_ADVANCE_EPOCH || post.From != BoardId {
+			continue
+		}
+
+		verdict := &services.EpochAdvance{}
+		if err := proto.Unmarshal(post.Payload, verdict); err != nil {
+			return nil, err
+		}
+
+		return verdict, nil
+	}
+
+	return nil, fmt.Errorf("no verdict on the board")
+}
+
+// epochStarted tells whether the board has seen anything of the epoch yet. If it can't tell, it assumes so.
+func (node *Node) epochStarted(epoch Epoch) bool {
+	posts, err := node.board.ReadEpoch(context.Background(), epoch)
+	return err != nil || len(posts) > 0
+}
+
+// StartCheckingProposals takes a hash list certified by the replicas.
 func (node *Node) StartCheckingProposals(ctx context.Context, hashList *services.ProposalHashList) (*services.Empty, error) {
-	node.proposalListChan <- hashList
+	// only the replicas send hash lists directly
+	if err := node.creds.authorizeRole(ctx, RoleReplica); err != nil {
+		return nil, err
+	}
 
-	node.log.Debugf("channel received hashes from the primary")
+	node.onProposalHashList(hashList)
 
 	return &services.Empty{}, nil
 }
 
-func (node *Node) startProposalHashCollector(e Epoch, b *BenchmarkEntry) chan map[int64]Hash {
-	out := make(chan map[int64]Hash)
+func (node *Node) onProposalHashList(hashList *services.ProposalHashList) {
+	// with a replicated bulletin board, take the first list certified by a quorum of replicas
+	if node.replicas != nil {
+		if err := node.replicas.VerifyCertificate(hashList); err != nil {
+			node.log.Warnf("ignoring a hash list for epoch %d: %s", hashList.Epoch, err.Error())
+			return
+		}
 
-	go func() {
-		proposalList := make(map[int64]Hash)
+		if !node.firstCertified(Epoch(hashList.Epoch)) {
+			return
+		}
+	}
 
-		for {
-			list := <-node.proposalListChan
+	node.proposalLists.put(Epoch(hashList.Epoch), 0, hashList)
 
-			if Epoch(list.Epoch) < e {
-				node.log.Infof("ignoring message from a previous epoch: %d (at epoch %d)", list.Epoch, e)
-				continue
-			} else if Epoch(list.Epoch) > e {
-				panic("this can not happen in out setting.")
-			}
+	node.log.Debugf("channel received hashes from the board")
+}
 
-			for i := range list.List {
-				pp := list.List[i]
+// firstCertified tells whether this is the first certified list of the epoch.
+// Each replica delivers the list on its own.
+func (node *Node) firstCertified(epoch Epoch) bool {
+	node.certifiedLock.Lock()
+	defer node.certifiedLock.Unlock()
 
-				// benchmark
-				b.bytesOnChain += proto.Size(pp)
+	if node.certified[epoch] {
+		return false
+	}
 
-				if len(pp.Hash) != sha256.Size {
-					panic("wrong size")
-				}
+	for e := range node.certified {
+		if e < epoch {
+			delete(node.certified, e)
+		}
+	}
+	node.certified[epoch] = true
 
-				var tmp Hash
-				copy(tmp[:], pp.Hash)
+	return true
+}
 
-				proposalList[pp.Proposer] = tmp
-			}
+// waitForHashList waits for a list of proposal hashes from the board in lists.
+// The board has the deadline of the phase to decide, and the node waits twice as long.
+func (node *Node) waitForHashList(e Epoch, b *BenchmarkEntry, lists *inbox, deadline time.Duration) (*services.ProposalHashList, error) {
+	msg := lists.next(e, node.deadline(e, 2*deadline))
+	if msg == nil {
+		return nil, fmt.Errorf("no %s from the board", lists.name)
+	}
+	list := msg.(*services.ProposalHashList)
 
-			out <- proposalList
-			break
+	// benchmark
+	b.bytesOnChain += proto.Size(list)
+
+	for i := range list.List {
+		if err := checkProposalHash(list.List[i]); err != nil {
+			return nil, fmt.Errorf("can't use the board's %s: %s", lists.name, err.Error())
 		}
-	}()
 
-	return out
+		// the board can't make up hashes
+		if err := node.baseConfig.identityKeys.verify(list.List[i]); err != nil {
+			return nil, fmt.Errorf("the board's %s is forged: %s", lists.name, err.Error())
+		}
+	}
+
+	return list, nil
+}
+
+func hashListToMap(list *services.ProposalHashList) map[int64]Hash {
+	proposalList := make(map[int64]Hash)
+
+	for _, pp := range list.List {
+		var tmp Hash
+		copy(tmp[:], pp.Hash)
+
+		proposalList[pp.Proposer] = tmp
+	}
+
+	return proposalList
 }
 
 func (node *Node) SubmitProposal(ctx context.Context, proposal *services.Proposal) (*services.Empty, error) {
+	if err := node.creds.authorize(ctx, NodeIdentity(proposal.From)); err != nil {
+		return nil, err
+	}
+	if err := node.receiveProposal(ctx, proposal); err != nil {
+		return nil, err
+	}
+
+	return &services.Empty{}, nil
+}
+
+// receiveProposal takes a proposal from its sender, whole or streamed.
+func (node *Node) receiveProposal(ctx context.Context, proposal *services.Proposal) error {
+	if err := node.checkSignature(proposal); err != nil {
+		return err
+	}
+
 	sender, ok := peer.FromContext(ctx)
 	if !ok {
 		node.log.Error("can't get peer info")
@@ -167,31 +377,63 @@ func (node *Node) SubmitProposal(ctx context.Context, proposal *services.Proposa
 		node.log.Debugf("receiving a proposal from %s", sender.Addr)
 	}
 	// this should not block for too long
-	node.proposalChan <- proposal
+	node.proposals.put(Epoch(proposal.Epoch), proposal.From, proposal)
 
-	return &services.Empty{}, nil
+	return nil
 }
 
-func (node *Node) startProposalCollector(e Epoch, b *BenchmarkEntry) chan map[NewNodeID]*gmp.Int {
-	hashListChan := node.startProposalHashCollector(e, b)
+// combinedProposals is what an old node sends to the new group.
+type combinedProposals struct {
+	shares map[NewNodeID]*gmp.Int
+	// encoded commitment to the new sharing, in production mode
+	commitment []byte
+	err        error
+}
 
-	out := make(chan map[NewNodeID]*gmp.Int)
+func (node *Node) startProposalCollector(e Epoch, b *BenchmarkEntry) chan combinedProposals {
+	out := make(chan combinedProposals, 1)
 
-	allProposals := make(chan map[int64]*Proposal)
+	node.clock.Go(func() {
+		hashList, err := node.waitForHashList(e, b, node.proposalLists, node.deadlines.GetProposalHashes())
+		if err != nil {
+			node.clock.Send(out, combinedProposals{err: err})
+			return
+		}
+		proposalListFromPrimary := hashListToMap(hashList)
 
-	// start receiving all proposals
-	go func() {
-		proposalReceived := make(map[int64]*Proposal)
+		var listed []int64
+		for _, ph := range hashList.List {
+			listed = append(listed, ph.Proposer)
+		}
 
-		for {
-			proposal := <-node.proposalChan
+		// only the listed proposals are needed. The proposers push them, and halfway to the deadline
+		// we pull those still missing. The ones we miss by the deadline are complained about, and the
+		// board reveals them.
+		proposalReceived := make(map[int64]*services.Proposal)
+		isReceived := func(id int64) bool {
+			_, ok := proposalReceived[id]
+			return ok
+		}
+		stop := node.deadline(e, node.deadlines.GetProposals())
+		pull := node.deadline(e, node.deadlines.GetProposals()/2)
+
+		wait := pull
+		for len(proposalReceived) < len(listed) {
+			msg := node.proposals.next(e, wait)
+			if msg == nil && wait == pull {
+				node.pullProposals(e, proposalListFromPrimary, missing(listed, isReceived), node.deadlines.GetProposals()/2)
+				wait = stop
+				continue
+			}
+			if msg == nil {
+				node.log.Warnf("nodes %v missed the deadline for their proposals", missing(listed, isReceived))
+				break
+			}
+			proposal := msg.(*services.Proposal)
 
-			// ignore proposals for a previous epoch
-			if Epoch(proposal.Epoch) < e {
-				node.log.Infof("ignoring proposal from a previous epoch: %d (at epoch %d)", proposal.Epoch, e)
+			if _, ok := proposalListFromPrimary[proposal.From]; !ok {
+				node.log.Debugf("ignoring the proposal from %d, which is not in the list", proposal.From)
 				continue
-			} else if Epoch(proposal.Epoch) > e {
-				panic("this can not happen in out setting.")
 			}
 
 			// benchmark
@@ -199,199 +441,292 @@ func (node *Node) startProposalCollector(e Epoch, b *BenchmarkEntry) chan map[Ne
 
 			node.log.Debugf("proposal from=%d of size=%d", proposal.From, proto.Size(proposal))
 
-			// reassemble protocol
-			from := proposal.From
-
-			buf := bytes.NewBuffer(proposal.Gob)
-			dec := gob.NewDecoder(buf)
+			proposalReceived[proposal.From] = proposal
 
-			p := Proposal{}
-			dec.Decode(&p)
+			node.log.Debugf("received a proposal from %d (%d / %d received)", proposal.From, len(proposalReceived), len(listed))
+		}
 
-			proposalReceived[from] = &p
+		node.log.Debugf("#proposals %d", len(proposalReceived))
 
-			node.log.Debugf("received a proposal from %d (%d / %d received)", from, len(proposalReceived), len(node.config.oldGroup))
+		valid, complaints := node.checkProposals(e, proposalListFromPrimary, proposalReceived)
 
-			// break if one proposal from each node has been received
-			if len(proposalReceived) >= len(node.config.oldGroup) {
-				break
-			}
+		complaintMsg := services.ComplaintList{
+			Epoch: int32(e),
+			From:  node.id,
+			List:  complaints,
 		}
+		node.sign(&complaintMsg)
 
-		node.log.Debugf("#proposals %d", len(proposalReceived))
+		// benchmark
+		b.bytesOnChain += proto.Size(&complaintMsg)
 
-		allProposals <- proposalReceived
-	}()
+		node.log.Debugf("submitting %d complaints to the primary", len(complaints))
 
-	go func() {
-		// block until receive all of the hashes from primary
-		proposalListFromPrimary := <-hashListChan
-		proposalReceived := <-allProposals
+		err = node.board.Post(context.Background(), newPost(services.BoardPost_COMPLAINTS, e, node.id, &complaintMsg))
+		if err != nil {
+			node.clock.Send(out, combinedProposals{err: fmt.Errorf("can't submit complaints: %s", status.Convert(err).Message())})
+			return
+		}
 
-		var proposalVerified []int64
+		// the primary drops the proposers found cheating and reveals the proposals we missed
+		finalList, err := node.waitForHashList(e, b, node.finalLists, node.deadlines.GetComplaints())
+		if err != nil {
+			node.clock.Send(out, combinedProposals{err: err})
+			return
+		}
 
-		for from, hashRef := range proposalListFromPrimary {
-			proposal, ok := proposalReceived[from]
-			if !ok {
-				node.log.Errorf("can't find a proposal from %d, which appears in the primary's list", from)
+		// revealed proposals are whole, we only need our slice
+		if err := node.takeRevealed(finalList, valid); err != nil {
+			node.clock.Send(out, combinedProposals{err: err})
+			return
+		}
 
-				fmt.Printf("proposal received: ")
-				for k := range proposalReceived {
-					fmt.Printf("%d, ", k)
-				}
-				fmt.Printf("\n")
-				panic("die")
-			}
+		var proposalVerified []int64
+		var proposalsToUse []*ProposalSlice
+		var pointsToUse []PointsOnBlindingPoly
 
-			if hashRef.Equal(proposal.Hash()) {
-				proposalVerified = append(proposalVerified, from)
-			} else {
-				// should not happen
-				panic("hash mismatch")
+		for from, hashRef := range hashListToMap(finalList) {
+			proposal, points, err := node.checkFinal(e, from, hashRef, proposalListFromPrimary, valid)
+			if err != nil {
+				// the board or a proposer cheated, and the handoff can't go on
+				node.clock.Send(out, combinedProposals{err: err})
+				return
 			}
+
+			proposalVerified = append(proposalVerified, from)
+			proposalsToUse = append(proposalsToUse, proposal)
+			pointsToUse = append(pointsToUse, points)
 		}
 
 		node.log.Infof("Hash matched. proposal to use: %v", proposalVerified)
 
-		myId := OldNodeID(node.id)
-		myIdBig := big.NewInt(node.id)
+		combined := combinedProposals{shares: CombineProposals(node.config, node.share, pointsToUse)}
+		if node.mode == ModeProduction {
+			combined.commitment = encodeCommitment(nextCommitment(node.secretCommitment, proposalsToUse))
+		}
 
-		for _, pi := range proposalVerified {
-			commQ := proposalReceived[pi].commQ
-			commRs := proposalReceived[pi].commRs
+		node.clock.Send(out, combined)
+	})
 
-			for newNodeK, pointOnQPlusRk := range proposalReceived[pi].pointToPeers[myId].points {
-				Rk := commRs[newNodeK]
-				comm := polycommit.AdditiveHomomorphism(commQ, Rk)
-				if !comm.VerifyEval(myIdBig, conv.GmpInt2BigInt(pointOnQPlusRk)) {
-					node.log.Errorf("points not on Q+Rk for k=%d", newNodeK)
-				}
-			}
+	return out
+}
+
+// takeRevealed adds our slices of the proposals the board revealed to the valid ones.
+func (node *Node) takeRevealed(finalList *services.ProposalHashList, valid map[int64]*ProposalSlice) error {
+	final := hashListToMap(finalList)
+
+	for _, msg := range finalList.Revealed {
+		if _, ok := valid[msg.From]; ok {
+			continue
 		}
 
-		// start to combine the proposal into one share for each new group node
-		combinedNewShare := make(map[NewNodeID]*gmp.Int)
-		for _, newNodeId := range node.config.newGroup {
-			combinedNewShare[NewNodeID(newNodeId)] = gmp.NewInt(0)
-			// set it to my share
-			combinedNewShare[NewNodeID(newNodeId)].Set(node.share)
+		if err := node.baseConfig.identityKeys.verify(msg); err != nil {
+			return fmt.Errorf("the proposal from %d revealed by the board is forged: %s", msg.From, err.Error())
 		}
 
-		for _, pi := range proposalVerified {
-			for newNodeK, pointOnQPlusRk := range proposalReceived[pi].pointToPeers[myId].points {
-				p, ok := combinedNewShare[newNodeK]
-				if !ok {
-					fmt.Printf("%v\n", combinedNewShare)
-					println(newNodeK)
-					panic("can't happen")
-				}
+		proposal, err := ProposalFromMessage(msg, node.config)
+		if err != nil {
+			return fmt.Errorf("can't decode the proposal from %d revealed by the board: %s", msg.From, err.Error())
+		}
 
-				p.Add(p, pointOnQPlusRk)
-			}
+		slice, err := proposal.Slice(OldNodeID(node.id))
+		if err != nil {
+			return fmt.Errorf("the proposal from %d revealed by the board has nothing for me: %s", msg.From, err.Error())
+		}
+
+		// peers that missed it may pull it from us. A proposal off the list is rejected by checkFinal.
+		if hashRef, ok := final[msg.From]; ok && hashRef.Equal(proposal.Hash()) && Epoch(msg.Epoch) == Epoch(finalList.Epoch) {
+			node.storeWholeProposal(Epoch(finalList.Epoch), msg)
 		}
 
-		out <- combinedNewShare
-	}()
+		valid[msg.From] = &slice
+	}
 
-	return out
+	return nil
+}
+
+// checkFinal checks a proposal of the board's final list, and decrypts our points.
+func (node *Node) checkFinal(e Epoch, from int64, hashRef Hash, agreed map[int64]Hash, valid map[int64]*ProposalSlice) (*ProposalSlice, PointsOnBlindingPoly, error) {
+	// the board may only drop proposals from the agreed list
+	if agreedRef, ok := agreed[from]; !ok || !agreedRef.Equal(hashRef) {
+		return nil, PointsOnBlindingPoly{}, fmt.Errorf("the final list has a proposal from %d that was never agreed on", from)
+	}
+
+	proposal, ok := valid[from]
+	if !ok {
+		return nil, PointsOnBlindingPoly{}, fmt.Errorf("no valid proposal from %d, which appears in the board's final list", from)
+	}
+	if hash, err := proposal.Hash(); err != nil || !hashRef.Equal(hash) {
+		return nil, PointsOnBlindingPoly{}, fmt.Errorf("no valid proposal from %d, which appears in the board's final list", from)
+	}
+
+	// revealed proposals haven't been checked yet
+	if err := proposal.Verify(node.config, e); err != nil {
+		return nil, PointsOnBlindingPoly{}, fmt.Errorf("the final list contains an invalid proposal from %d: %s", from, err.Error())
+	}
+	points, err := proposal.PointsFor(node.encryptionKey, node.config)
+	if err != nil {
+		return nil, PointsOnBlindingPoly{}, fmt.Errorf("the final list contains an invalid proposal from %d: %s", from, err.Error())
+	}
+
+	return proposal, points, nil
 }
 
 func (node *Node) SubmitBlindedShare(ctx context.Context, in *services.BlindedShare) (*services.Empty, error) {
-	node.blindedShareChan <- in
+	if err := node.creds.authorize(ctx, NodeIdentity(in.From)); err != nil {
+		return nil, err
+	}
+	if err := node.checkSignature(in); err != nil {
+		return nil, err
+	}
+
+	node.blindedShares.put(Epoch(in.Epoch), in.From, in)
 
 	return &services.Empty{}, nil
 }
 
-func (node *Node) startShareReconstructor(epoch Epoch, b *BenchmarkEntry) <-chan *gmp.Int {
-	out := make(chan *gmp.Int)
+// reconstructedShare is the share of a new node and the commitment to the new sharing, in production mode.
+type reconstructedShare struct {
+	share      *gmp.Int
+	commitment PolyCommit
+	err        error
+}
 
-	go func() {
-		sharesReceived := make(map[int64]*gmp.Int)
-		i := 0
-		for {
-			share := <-node.blindedShareChan
+func (node *Node) startShareReconstructor(epoch Epoch, b *BenchmarkEntry) <-chan reconstructedShare {
+	out := make(chan reconstructedShare, 1)
 
-			// ignore proposals for a previous epoch
-			if Epoch(share.Epoch) < epoch {
-				node.log.Warnf("ignoring proposal from a previous epoch: %d (at epoch %d)", share.Epoch, epoch)
-				continue
-			} else if Epoch(share.Epoch) > epoch {
-				panic("this can not happen in out setting.")
+	node.clock.Go(func() {
+		commitmentsReceived := make(map[int64][]byte)
+		stop := node.deadline(epoch, node.deadlines.GetBlindedShares())
+
+		// Online error correction: the blinded shares are decoded as they come in, and the polynomial is
+		// taken once it agrees with t'+1+t of them. Up to t old nodes may be faulty, so t'+1 of those are
+		// honest and fix the polynomial. An old node that sends nothing is an erasure, not an error: it
+		// only delays the decoding, and the node keeps waiting for more shares until the deadline.
+		degree := node.config.reconstructionDegree()
+		agreeing := degree + 1 + node.config.degree
+
+		var Xs []*gmp.Int
+		var Ys []*gmp.Int
+		var poly polyring.Polynomial
+		var wrong []int
+		decoded := false
+
+		for !decoded && len(commitmentsReceived) < len(node.config.oldGroup) {
+			msg := node.blindedShares.next(epoch, stop)
+			if msg == nil {
+				node.log.Warnf("nodes %v missed the deadline for their blinded shares", missing(node.config.oldGroup, func(id int64) bool {
+					_, ok := commitmentsReceived[id]
+					return ok
+				}))
+				break
 			}
+			share := msg.(*services.BlindedShare)
 
 			node.log.Debugf("received a share from %d", share.From)
 
 			// benchmark
 			b.bytesOffChain += proto.Size(share)
 
-			sharesReceived[share.From] = gmp.NewInt(0)
-			sharesReceived[share.From].SetBytes(share.Share)
+			if !node.config.IsOldMember(share.From) {
+				node.log.Warnf("ignoring a blinded share from %d, which is not in the old group", share.From)
+				continue
+			}
+			if _, ok := commitmentsReceived[share.From]; ok {
+				continue
+			}
 
-			i += 1
-			if i >= len(node.config.oldGroup) {
-				break
+			Xs = append(Xs, gmp.NewInt(share.From))
+			Ys = append(Ys, gmp.NewInt(0).SetBytes(share.Share))
+			commitmentsReceived[share.From] = share.Commitment
+
+			if len(Xs) < agreeing {
+				continue
 			}
-		}
 
-		node.log.Debugf("got enough to reconstruct new shares")
+			// too many errors among the shares so far is no reason to give up: more shares correct more
+			candidate, bad, err := DecodeReedSolomon(degree, Xs, Ys, node.config.prime)
+			if err == nil && len(Xs)-len(bad) >= agreeing {
+				poly, wrong, decoded = candidate, bad, true
+			}
+		}
 
-		var Xs []*gmp.Int
-		var Ys []*gmp.Int
+		node.log.Debugf("got %d blinded shares", len(Xs))
 
-		for x, y := range sharesReceived {
-			Xs = append(Xs, gmp.NewInt(int64(x)))
-			Ys = append(Ys, y)
+		if !decoded {
+			node.clock.Send(out, reconstructedShare{err: fmt.Errorf("can't reconstruct the new share: no polynomial agrees with %d of the %d blinded shares", agreeing, len(Xs))})
+			return
 		}
 
-		// reconstruct the share
-		poly, err := interpolation.LagrangeInterpolate(node.config.degree, Xs, Ys, node.config.prime)
-		if err != nil {
-			panic("can't recover the secret")
+		for _, i := range wrong {
+			node.log.Warnf("wrong blinded share from %s", Xs[i].String())
 		}
 
 		newShare := gmp.NewInt(0)
 		poly.EvalMod(gmp.NewInt(int64(node.id)), node.config.prime, newShare)
 
-		out <- newShare
-		close(out)
-	}()
+		result := reconstructedShare{share: newShare}
+		if node.mode == ModeProduction {
+			// an honest old node is enough to vouch for the commitment
+			commitment, err := majorityCommitment(commitmentsReceived, node.config.degree+1, degree)
+			if err != nil {
+				result.err = fmt.Errorf("no agreed commitment to the new sharing: %s", err.Error())
+			}
+			result.commitment = commitment
+		}
+
+		node.clock.Send(out, result)
+	})
 
 	return out
 }
 
-func (node *Node) SubmitShareToPrimary(epoch Epoch) {
+func (node *Node) submitShare(epoch Epoch, share *gmp.Int) error {
 	ctx := context.Background()
 	msg := services.Share{
 		Epoch: int32(epoch),
 		From:  node.id,
-		Share: node.share.Bytes(),
-	}
-	_, err := node.primaryNode.AssembleShare(ctx, &msg)
-	if err != nil {
-		panic(err.Error())
+		Share: share.Bytes(),
 	}
+	node.sign(&msg)
+
+	return node.board.Post(ctx, newPost(services.BoardPost_SHARE, epoch, node.id, &msg))
 }
 
 func (node *Node) StartProtocol(wsFinish *sync.WaitGroup, maxEpoch Epoch) {
-	epoch := Epoch(0)
+	epoch := node.resumeEpoch
 
 	b := make(Benchmark)
+	node.benchmark = b
 
-	for {
-		// wait for instructions from the primary and advance the epoch
-		// epoch is only advanced here
-		node.waitAdvancedEpoch.Wait()
+	// wait for the board to confirm the initial sharing, or the last epoch finished before a restart
+	node.waitForVerdict(epoch)
 
+	if node.pending != nil {
+		epoch = node.settlePending()
+	}
+	node.saveState(epoch, nil)
+
+	// a restarted node may have proposed in the epoch it crashed in. Proposing again would contradict
+	// the hash it sent, so it sits that epoch out and gets a new share in the one after.
+	catchingUp := node.resumed
+
+	for {
 		// enter the next epoch
+		// epoch is only advanced here
 		epoch += 1
-		node.waitAdvancedEpoch.Add(1)
 		// only run up to maxEpoch
 		if epoch > maxEpoch {
 			break
 		}
 
-		// connect to peers at the first epoch
-		if epoch == 1 {
+		// the collectors of aborted epochs may have given up early
+		for _, ib := range []*inbox{node.blindedShares, node.proposals, node.proposalLists, node.finalLists} {
+			ib.advance(epoch)
+		}
+
+		// connect to peers at the first epoch, unless the DKG did already
+		if len(node.nodes) == 0 {
 			if err := node.ConnectPeers(); err != nil {
 				node.log.Fatalf("cannot connect to peers")
 			}
@@ -400,150 +735,345 @@ func (node *Node) StartProtocol(wsFinish *sync.WaitGroup, maxEpoch Epoch) {
 		// prepare for the benchmark
 		benchmarkEntry := BenchmarkEntry{}
 
-		node.log.Infof("entering epoch %d", epoch)
+		isOldMember := node.config.IsOldMember(node.id)
+		isNewMember := node.config.IsNewMember(node.id)
+
+		sitOut := catchingUp && node.epochStarted(epoch)
+		catchingUp = false
 
-		// start the pipeline workers
-		combinedProposalChan := node.startProposalCollector(epoch, &benchmarkEntry)
+		if sitOut {
+			node.log.Infof("sitting out epoch %d, which started before the restart", epoch)
+		} else {
+			node.log.Infof("entering epoch %d (old member: %t, new member: %t)", epoch, isOldMember, isNewMember)
+		}
 
 		// construct a new notification channel
-		newShareChan := node.startShareReconstructor(epoch, &benchmarkEntry)
+		var newShareChan <-chan reconstructedShare
+		if isNewMember && !sitOut {
+			newShareChan = node.startShareReconstructor(epoch, &benchmarkEntry)
+		}
 
 		// start the benchmark timer
-		startTime := time.Now()
+		startTime := node.clock.Now()
+
+		// only the old group proposes and hands off the shares
+		if isOldMember && !sitOut {
+			if node.share == nil {
+				node.log.Warnf("no share to hand off in epoch %d", epoch)
+			} else if err := node.handoff(epoch, &benchmarkEntry); err != nil {
+				node.log.Warnf("giving up the handoff of epoch %d: %s", epoch, err.Error())
+			}
+		}
 
-		p := GenerateProposal(node.config)
+		// the new share replaces the old one once the board confirms the epoch
+		newShare := reconstructedShare{err: fmt.Errorf("sat the epoch out")}
+		if newShareChan != nil {
+			_, received, _ := node.clock.Wait(newShareChan)
+			newShare = received.(reconstructedShare)
+			if newShare.err == nil {
+				node.log.Debugf("got a new share in epoch %d", epoch)
+
+				// a share the board never heard of can't be confirmed
+				if err := node.reportShare(epoch, newShare.share, newShare.commitment); err != nil {
+					newShare.err = fmt.Errorf("can't report it: %s", err.Error())
+				}
+			}
 
-		// populate the message with a hash
-		hash := p.Hash()
-		proposalMsg := services.ProposalHash{
-			Epoch:    int32(epoch),
-			Proposer: node.id,
-			Hash:     hash[:],
+			if newShare.err != nil {
+				node.log.Warnf("no new share in epoch %d: %s", epoch, newShare.err.Error())
+			} else {
+				// keep the old share until the board confirms the new one
+				node.saveState(epoch-1, &PendingShare{
+					Epoch:      epoch,
+					Share:      newShare.share,
+					Commitment: newShare.commitment,
+				})
+			}
 		}
 
-		node.log.Debug("submitting hash to the primary")
+		// benchmark
+		endTime := node.clock.Now()
+		benchmarkEntry.latency = endTime.Sub(startTime)
 
-		ctx := context.Background()
-		_, err := node.primaryNode.SubmitProposalHash(ctx, &proposalMsg)
-		if err != nil {
-			st, ok := status.FromError(err)
-			if !ok {
-				node.log.Errorf("can't get status")
-			}
-			node.log.Errorf("%s", st.Message())
+		// store the benchmark results
+		b[epoch] = benchmarkEntry
+
+		// an aborted epoch changes nothing, and the handoff is retried at the next one
+		if verdict := node.waitForVerdict(epoch); verdict.Aborted {
+			node.log.Warnf("epoch %d was aborted", epoch)
+			node.saveState(epoch, nil)
+			continue
 		}
 
-		// send proposal messages to peers
-		for i := range node.nodes {
-			go func(dst NewNodeID) {
-				pMsg := services.Proposal{
-					Epoch: int32(epoch),
-					From:  node.id,
-					Gob:   p.ToBytes(),
-				}
+		// the share now lives with the new group
+		old := node.share
+		node.share = nil
+		if isNewMember && newShare.err == nil {
+			node.share = newShare.share
+			node.secretCommitment = newShare.commitment
+		}
 
-				node.log.Debugf("sending proposal to %d", dst)
-				_, err := node.nodes[dst].SubmitProposal(ctx, &pMsg)
-				if err != nil {
-					st, ok := status.FromError(err)
-					if !ok {
-						node.log.Fatalf("can't get status")
-					}
-					node.log.Fatalf("error while sending proposal: %s", st.Message())
-				}
-			}(i)
+		// the new group holds the shares from now on
+		node.config = node.config.AfterHandoff()
+		node.handedOff = true
+
+		node.eraseShare(epoch, old)
+
+		if !isNewMember {
+			node.log.Infof("leaving the committee after epoch %d", epoch)
+			break
 		}
+	}
 
-		// send a proposal to myself
-		node.log.Debugf("sending myself a proposal")
+	node.Report(&b)
 
-		node.proposalChan <- &services.Proposal{
-			Epoch: int32(epoch),
-			From:  node.id,
-			Gob:   p.ToBytes()}
+	goodbye := &services.Goodbye{
+		Epoch: int32(epoch),
+		From:  node.id,
+	}
+	node.sign(goodbye)
+
+	ctx := context.Background()
+	if err := node.board.Post(ctx, newPost(services.BoardPost_KILL, epoch, node.id, goodbye)); err != nil {
+		node.log.Errorf("can't say goodbye to the board: %s", err.Error())
+	}
 
-		node.log.Debugf("done sending myself a proposal")
+	wsFinish.Done()
+	node.log.Infof("done")
+}
+
+// settlePending takes the new share reported before a restart, if the board confirmed its epoch.
+// It returns the epoch of the share.
+func (node *Node) settlePending() Epoch {
+	pending := node.pending
+	node.pending = nil
+
+	if verdict := node.waitForVerdict(pending.Epoch); verdict.Aborted {
+		node.log.Warnf("epoch %d was aborted", pending.Epoch)
+	} else {
+		old := node.share
+		node.share = pending.Share
+		node.secretCommitment = pending.Commitment
+		node.config = node.baseConfig.AfterHandoff()
+		node.handedOff = true
+
+		node.eraseShare(pending.Epoch, old)
+	}
+
+	return pending.Epoch
+}
+
+// saveState saves the share held after the epoch, along with a new share waiting for its verdict.
+func (node *Node) saveState(epoch Epoch, pending *PendingShare) {
+	node.heldLock.Lock()
+	node.held = &ShareState{
+		Epoch:      epoch,
+		Share:      node.share,
+		Commitment: node.secretCommitment,
+		HandedOff:  node.handedOff,
+	}
+	node.heldLock.Unlock()
 
-		// collect the combined proposal to be sent to new members
-		combinedProposal := <-combinedProposalChan
+	if node.store == nil {
+		return
+	}
 
-		node.log.Infof("Proposal verified and new shares generated.")
+	err := node.store.Save(&ShareState{
+		Epoch:      epoch,
+		Share:      node.share,
+		Commitment: node.secretCommitment,
+		HandedOff:  node.handedOff,
+		Pending:    pending,
+	})
+	if err != nil {
+		node.log.Errorf("can't save the share after epoch %d: %s", epoch, err.Error())
+	}
+}
 
-		// handle the share to myself separately
-		myReShare, ok := combinedProposal[NewNodeID(node.id)]
-		if ok {
-			node.log.Debugf("got a share for myself")
+// handoff runs the old-group half of an epoch: propose, agree on the proposals and
+// send a blinded share to every member of the new group.
+func (node *Node) handoff(epoch Epoch, benchmarkEntry *BenchmarkEntry) error {
+	// the new group stops waiting for blinded shares this long after the epoch started
+	blindedDue := node.clock.Now().Add(node.deadlines.GetBlindedShares())
 
-			node.blindedShareChan <- &services.BlindedShare{
-				Epoch: int32(epoch),
-				From:  node.id,
-				Share: myReShare.Bytes()}
+	// start the pipeline workers
+	combinedProposalChan := node.startProposalCollector(epoch, benchmarkEntry)
 
-			// delete the share since we now have it
-			delete(combinedProposal, NewNodeID(node.id))
+	var p Proposal
+	if node.proposalRand != nil {
+		p = generateProposal(node.config, epoch, node.proposalRand(epoch))
+	} else {
+		p = GenerateProposal(node.config, epoch)
+	}
+
+	// signed, so that the primary can reveal it to everyone
+	ownProposal := p.Message(node.id)
+	node.sign(ownProposal)
+	node.storeWholeProposal(epoch, ownProposal)
+
+	// each member of the old group only gets its slice of the proposal. Nothing goes out unless
+	// every slice can be cut and sent.
+	slices := make(map[int64]*services.Proposal, len(node.config.oldGroup))
+	for _, oldNodeId := range node.config.oldGroup {
+		if _, ok := node.nodes[NewNodeID(oldNodeId)]; !ok && oldNodeId != node.id {
+			return fmt.Errorf("can't find the node client for %d", oldNodeId)
 		}
 
-		for newNodeId, reShare := range combinedProposal {
-			nodeClient, ok := node.nodes[NewNodeID(newNodeId)]
-			if !ok {
-				node.log.Fatalf("can't find the node client for %d", newNodeId)
-			}
+		slice, err := p.Slice(OldNodeID(oldNodeId))
+		if err != nil {
+			return fmt.Errorf("can't cut the proposal for %d: %s", oldNodeId, err.Error())
+		}
+
+		msg := slice.Message(node.id)
+		node.sign(msg)
+		slices[oldNodeId] = msg
+	}
 
-			node.log.Debugf("submitting a blinded share to %d", newNodeId)
+	// populate the message with a hash
+	hash := p.Hash()
+	proposalMsg := services.ProposalHash{
+		Epoch:    int32(epoch),
+		Proposer: node.id,
+		Hash:     hash[:],
+		Version:  ProposalHashVersion,
+	}
+	node.sign(&proposalMsg)
+
+	ctx := context.Background()
 
-			_, err := nodeClient.SubmitBlindedShare(ctx, &services.BlindedShare{
-				Epoch: int32(epoch),
-				From:  node.id,
-				Share: reShare.Bytes(),
+	if node.replicas != nil {
+		node.log.Debug("submitting hash to the replicas")
+
+		// a quorum of replicas is enough, and none of them holds up the others
+		hashCtx, cancel := node.clock.WithTimeout(ctx, node.deadlines.GetProposalHashes())
+		defer cancel()
+
+		for id, replica := range node.replicaNodes {
+			id, replica := id, replica
+			node.clock.Go(func() {
+				if _, err := replica.SubmitProposalHash(hashCtx, &proposalMsg); err != nil {
+					node.log.Warnf("can't submit the hash to replica %d: %s", id, err.Error())
+				}
 			})
+		}
+	} else {
+		node.log.Debug("submitting hash to the primary")
 
-			node.log.Debugf("a blinded share submitted to %d", newNodeId)
+		err := node.board.Post(ctx, newPost(services.BoardPost_PROPOSAL_HASH, epoch, node.id, &proposalMsg))
+		if err != nil {
+			st, ok := status.FromError(err)
+			if !ok {
+				node.log.Errorf("can't get status")
+			}
+			node.log.Errorf("%s", st.Message())
+		}
+	}
 
+	// send proposal messages to the other members of the old group
+	for _, oldNodeId := range node.config.oldGroup {
+		if oldNodeId == node.id {
+			continue
+		}
+
+		dst := NewNodeID(oldNodeId)
+		pMsg := slices[oldNodeId]
+		nodeClient := node.nodes[dst]
+		node.clock.Go(func() {
+			// a broken stream is resumed until the proposals are due
+			ctx, cancel := node.clock.WithTimeout(ctx, node.deadlines.GetProposalHashes()+node.deadlines.GetProposals())
+			defer cancel()
+
+			node.log.Debugf("sending proposal to %d", dst)
+			err := node.sendProposal(ctx, nodeClient, pMsg)
 			if err != nil {
-				panic(err.Error())
+				// the board reveals the proposal if dst complains
+				node.log.Warnf("can't send the proposal to %d: %s", dst, status.Convert(err).Message())
 			}
-		}
+		})
+	}
 
-		newShare := <-newShareChan
-		node.log.Infof("new share is %s", newShare.String())
+	// send a proposal to myself
+	node.log.Debugf("sending myself a proposal")
 
-		// benchmark
-		endTime := time.Now()
-		benchmarkEntry.latency = endTime.Sub(startTime)
+	node.proposals.put(epoch, node.id, slices[node.id])
 
-		// store the benchmark results
-		b[epoch] = benchmarkEntry
+	node.log.Debugf("done sending myself a proposal")
 
-		// sending stuff to the primary
-		node.log.Debugf("new share sending to the primary")
-		_, err = node.primaryNode.AssembleShare(ctx, &services.Share{
-			Epoch: int32(epoch),
-			From:  node.id,
-			Share: newShare.Bytes(),
-		})
+	// collect the combined proposal to be sent to new members
+	_, received, _ := node.clock.Wait(combinedProposalChan)
+	combined := received.(combinedProposals)
+	if combined.err != nil {
+		return combined.err
+	}
+	combinedProposal := combined.shares
 
-		node.log.Debugf("new share sent to the primary")
+	node.log.Infof("Proposal verified and new shares generated.")
 
-		if err != nil {
-			panic("can't send stuff to the primary")
+	// handle the share to myself separately
+	myReShare, ok := combinedProposal[NewNodeID(node.id)]
+	if ok {
+		node.log.Debugf("got a share for myself")
+
+		myMsg := &services.BlindedShare{
+			Epoch:      int32(epoch),
+			From:       node.id,
+			Share:      myReShare.Bytes(),
+			Commitment: combined.commitment}
+		node.sign(myMsg)
+
+		node.blindedShares.put(epoch, node.id, myMsg)
+
+		// delete the share since we now have it
+		delete(combinedProposal, NewNodeID(node.id))
+	}
+
+	// the shares go out in parallel, so that a peer that doesn't answer only misses its own
+	// what is left of the time is measured on the node's clock, which may be a simulator's
+	blindedCtx, cancel := node.clock.WithTimeout(context.Background(), blindedDue.Sub(node.clock.Now()))
+	defer cancel()
+
+	for newNodeId := range combinedProposal {
+		if _, ok := node.nodes[newNodeId]; !ok {
+			return fmt.Errorf("can't find the node client for %d", newNodeId)
 		}
 	}
 
-	node.Report(&b)
+	sent := make(chan struct{}, len(combinedProposal))
+	for newNodeId, reShare := range combinedProposal {
+		nodeClient := node.nodes[newNodeId]
 
-	ctx := context.Background()
-	_, err := node.primaryNode.Kill(ctx, &services.Empty{})
-	if err != nil {
-		panic(err.Error())
+		node.log.Debugf("submitting a blinded share to %d", newNodeId)
+
+		msg := &services.BlindedShare{
+			Epoch:      int32(epoch),
+			From:       node.id,
+			Share:      reShare.Bytes(),
+			Commitment: combined.commitment,
+		}
+		node.sign(msg)
+
+		newNodeId := newNodeId
+		node.clock.Go(func() {
+			defer node.clock.Send(sent, struct{}{})
+
+			if _, err := nodeClient.SubmitBlindedShare(blindedCtx, msg); err != nil {
+				node.log.Warnf("can't send the blinded share to %d: %s", newNodeId, status.Convert(err).Message())
+				return
+			}
+
+			node.log.Debugf("a blinded share submitted to %d", newNodeId)
+		})
+	}
+	for range combinedProposal {
+		node.clock.Wait(sent)
 	}
 
-	wsFinish.Done()
-	node.log.Infof("done")
+	return nil
 }
 
 func (node *Node) ConnectPeers() error {
 	for nodeId, peerIP := range node.peerIPList {
-		conn, err := grpc.Dial(peerIP, grpc.WithInsecure())
+		conn, err := node.network.Dial(peerIP, node.creds.dialOption(), node.limits.dialOption())
 		if err != nil {
 			return err
 		}
@@ -554,49 +1084,154 @@ func (node *Node) ConnectPeers() error {
 	return nil
 }
 
+// ConnectPrimary connects to the board, i.e., the primary unless SetBoard was called, and starts following it.
 func (node *Node) ConnectPrimary() error {
-	node.log.Debugf("dialing the primary at %s", node.primaryIP)
-	conn, err := grpc.Dial(node.primaryIP, grpc.WithInsecure())
-	if err != nil {
-		return err
+	if node.board == nil {
+		node.log.Debugf("dialing the primary at %s", node.primaryIP)
+		board, err := DialBoard(node.network, node.primaryIP, node.creds, node.log.Logger, node.limits.dialOption())
+		if err != nil {
+			return err
+		}
+
+		node.board = board
+		node.log.Debugf("connected to the primary at %s", node.primaryIP)
 	}
 
-	node.primaryNode = services.NewBulletinBoardServiceClient(conn)
-	node.log.Debugf("connected to the primary at %s", node.primaryIP)
+	// the replicas take over ordering the proposal hashes
+	if node.replicas != nil {
+		for id, url := range node.replicas.urls {
+			conn, err := node.network.Dial(url, node.creds.dialOption())
+			if err != nil {
+				return err
+			}
+
+			node.replicaNodes[id] = services.NewReplicaServiceClient(conn)
+			node.log.Debugf("connected to replica %d at %s", id, url)
+		}
+	}
+
+	node.clock.Go(node.followBoard)
 
 	return nil
 }
 
-func (node *Node) Serve() {
-	_, port, err := net.SplitHostPort(node.myIP)
-	if err != nil {
-		node.log.Fatalf("can't serve: %s", err.Error())
+// UseShareStore makes the node save its share after every epoch. If the store holds the state of an
+// earlier run, the node resumes from the last epoch it finished, and UseShareStore returns true.
+// It has to be called before ConnectPrimary.
+func (node *Node) UseShareStore(store ShareStore) (bool, error) {
+	node.store = store
+
+	state, err := store.Load()
+	if err != nil || state == nil {
+		return false, err
 	}
-	portUint, err := strconv.ParseUint(port, 10, 16)
-	if err != nil {
-		panic(err.Error())
+
+	node.share = state.Share
+	node.secretCommitment = state.Commitment
+	if state.HandedOff {
+		node.config = node.baseConfig.AfterHandoff()
+		node.handedOff = true
+	}
+	node.resumed = true
+	node.resumeEpoch = state.Epoch
+	node.pending = state.Pending
+
+	// the board replays its verdicts from the start, and the DKG of epoch 0, which the node doesn't run again
+	for _, ib := range []*inbox{node.verdicts, node.dealingLists, node.qualifiedDealers} {
+		ib.advance(state.Epoch)
+	}
+
+	node.log.Infof("resuming after epoch %d", state.Epoch)
+
+	return true, nil
+}
+
+func (node *Node) SetModeOption(opt string) {
+	node.mode = opt
+}
+
+// useClock makes the node tell the time, run its deadlines and wait on the given clock.
+func (node *Node) useClock(clock Clock) {
+	node.clock = clock
+	for _, ib := range []*inbox{node.verdicts, node.blindedShares, node.proposals, node.proposalLists, node.finalLists, node.dealingLists, node.qualifiedDealers} {
+		ib.clock = clock
+	}
+}
+
+// SetDeadlines sets how long each phase of an epoch waits for the other parties.
+func (node *Node) SetDeadlines(deadlines DeadlineConfig) {
+	node.deadlines = deadlines
+}
+
+// SetMessageLimits sets how large the messages the node sends and takes get.
+func (node *Node) SetMessageLimits(limits MessageConfig) {
+	node.limits = limits
+}
+
+// SetEncryptionKey sets the key the points of the proposals sent to this node are encrypted to.
+func (node *Node) SetEncryptionKey(key *EncryptionKey) {
+	node.encryptionKey = key
+}
+
+// SetIdentityKey sets the key the node signs its messages with.
+func (node *Node) SetIdentityKey(key ed25519.PrivateKey) {
+	node.identityKey = key
+}
+
+func (node *Node) sign(msg signedMessage) {
+	signMessage(node.identityKey, msg)
+}
+
+// checkSignature rejects a message not signed by the sender it names.
+func (node *Node) checkSignature(msg signedMessage) error {
+	if err := node.baseConfig.identityKeys.verify(msg); err != nil {
+		return status.Errorf(codes.Unauthenticated, "%s", err.Error())
 	}
 
-	hostPort := fmt.Sprintf("0.0.0.0:%d", portUint)
+	return nil
+}
+
+// SetCredentials makes the node use mutual TLS on all its connections, and check who sends each message.
+// It has to be called before Serve, ConnectPeers and ConnectPrimary.
+func (node *Node) SetCredentials(creds *Credentials) {
+	node.creds = creds
+}
+
+// SetTransport makes the node reach the other parties through the given transport instead of TCP.
+// It has to be called before Serve, ConnectPeers and ConnectPrimary.
+func (node *Node) SetTransport(network Transport) {
+	node.network = network
+}
 
-	lis, err := net.Listen("tcp4", hostPort)
+// SetBoard makes the node use the given board instead of the primary.
+// It has to be called before ConnectPrimary.
+func (node *Node) SetBoard(board Board) {
+	node.board = board
+}
+
+// SetReplicas makes the node accept only hash lists certified by the replicas.
+// It has to be called before ConnectPrimary.
+func (node *Node) SetReplicas(replicas *ReplicaSet) {
+	node.replicas = replicas
+}
+
+func (node *Node) Serve() {
+	lis, err := node.network.Listen(node.myIP)
 	if err != nil {
-		node.log.Fatalf("cannot listen to %s, %v", hostPort, err)
+		node.log.Fatalf("cannot listen to %s, %v", node.myIP, err)
 	}
 
-	s := grpc.NewServer()
+	s := grpc.NewServer(append(node.creds.serverOptions(), node.limits.serverOptions()...)...)
 	services.RegisterNodeServer(s, node)
 
-	node.log.Infof("serving on %s", hostPort)
+	node.log.Infof("serving on %s", lis.Addr())
 	if err := s.Serve(lis); err != nil {
 		node.log.Fatalf("can't serve")
 	}
 }
 
 func BuildNode(pp PublicParameter, logger *logrus.Logger, id int64, primaryIP, myIP string, peerIPs map[NewNodeID]string, initShare *gmp.Int) Node {
-	var wgStart sync.WaitGroup
 	var wgStop sync.WaitGroup
-	wgStart.Add(1)
 	wgStop.Add(1)
 
 	nodeLogger := logger.WithFields(
@@ -605,17 +1240,40 @@ func BuildNode(pp PublicParameter, logger *logrus.Logger, id int64, primaryIP, m
 		})
 
 	return Node{
-		id:                id,
-		primaryIP:         primaryIP,
-		myIP:              myIP,
-		peerIPList:        peerIPs,
-		config:            pp,
-		waitAdvancedEpoch: &wgStart,
-		share:             initShare,
-		nodes:             make(map[NewNodeID]services.NodeClient),
-		blindedShareChan:  make(chan *services.BlindedShare),
-		proposalChan:      make(chan *services.Proposal),
-		proposalListChan:  make(chan *services.ProposalHashList),
+		id:                 id,
+		primaryIP:          primaryIP,
+		myIP:               myIP,
+		peerIPList:         peerIPs,
+		config:             pp,
+		baseConfig:         pp,
+		mode:               ModeBenchmark,
+		verdicts:           newInbox("verdict", 1, nodeLogger),
+		decided:            make(map[Epoch]chan struct{}),
+		decidedLock:        &sync.Mutex{},
+		share:              initShare,
+		nodes:              make(map[NewNodeID]services.NodeClient),
+		blindedShares:      newInbox("blinded share", len(pp.Members()), nodeLogger),
+		proposals:          newInbox("proposal", len(pp.Members()), nodeLogger),
+		proposalLists:      newInbox("hash list", 1, nodeLogger),
+		finalLists:         newInbox("final list", 1, nodeLogger),
+		wholeProposals:     make(map[Epoch]map[int64]*services.Proposal),
+		wholeProposalsLock: &sync.Mutex{},
+		heldLock:           &sync.Mutex{},
+		recoveries:         make(map[int64]*recoverySession),
+		recoveriesLock:     &sync.Mutex{},
+		network:            GRPCTransport{},
+		clock:              wallClock{},
+		transfers:          make(map[int64]*proposalTransfer),
+		transfersLock:      &sync.Mutex{},
+		replicaNodes:       make(map[int64]services.ReplicaServiceClient),
+		certified:          make(map[Epoch]bool),
+		certifiedLock:      &sync.Mutex{},
+		// one dealing from each dealer, so late dealers never block
+		dealingChan:  make(chan *services.Dealing, len(pp.oldGroup)),
+		dealingLists: newInbox("dealing commitments", 1, nodeLogger),
+
+		dealingAccusationChan: make(chan *services.DealingAccusationList, 1),
+		qualifiedDealers:      newInbox("qualified dealers", 1, nodeLogger),
 
 		log: nodeLogger,
 	}
@@ -2,13 +2,11 @@ package Schultz
 
 import (
 	"context"
-	"crypto/sha256"
 	"fmt"
-	"net"
 	"os"
-	"strconv"
+	"sync"
+	"time"
 
-	"../../utils/interpolation"
 	"github.com/ncw/gmp"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -18,14 +16,58 @@ import "./services"
 type BulletinBoard struct {
 	config PublicParameter
 
-	proposalHashChan chan *services.ProposalHash
-	proposalHashFull chan struct{}
-	shareChan        chan *services.Share
-	killChan         chan struct{}
+	// messages of a later epoch wait in the inbox until the primary gets there
+	proposalHashes *inbox
+	complaints     *inbox
+	shares         *inbox
+	// the nodes that said goodbye. killChan is closed once they all did.
+	goodbyes    map[int64]bool
+	goodbyeLock *sync.Mutex
+	killChan    chan struct{}
+
+	// how long each phase waits for the nodes
+	deadlines DeadlineConfig
+	// how large the messages get
+	limits MessageConfig
+	// nodes that missed a deadline of the current epoch
+	missed []int64
+	// when the current epoch started
+	epochStart time.Time
+	// tells the time and runs the deadlines
+	clock Clock
+
+	// the initial sharing: the commitments of the dealers, the complaints about them and their answers
+	dealingCommitments *inbox
+	dealingComplaints  *inbox
+	dealingReveals     *inbox
+
+	// how the initial sharing is created. One of the Bootstrap* constants.
+	bootstrap string
+	// commitment to the secret, unless the sharing is fixed
+	secretCommitment PolyCommit
+	// one of the Mode* constants. In production mode, the shares never reach the board.
+	mode        string
+	shareChecks *inbox
+
+	// where the posts go. If nil, the primary keeps them itself and serves them over gRPC.
+	board Board
+	posts *boardLog
+
+	// replicas ordering the proposal hashes, if any
+	replicas       *ReplicaSet
+	certifiedLists *inbox
+	certified      map[Epoch]bool
+	certifiedLock  *sync.Mutex
 
 	myIP       string
-	peerIPList []string
-	nodes      []services.NodeClient
+	peerIPList map[NewNodeID]string
+	nodes      map[NewNodeID]services.NodeClient
+	// TLS certificate of the primary. If nil, connections are plain.
+	creds *Credentials
+	// how the primary reaches the nodes
+	network Transport
+	// the keys the posts are signed with
+	identityKeys IdentityKeys
 
 	// logging
 	log *logrus.Entry
@@ -34,185 +76,295 @@ type BulletinBoard struct {
 	allowSuicide bool
 }
 
-func (bb *BulletinBoard) SubmitProposalHash(ctx context.Context, hash *services.ProposalHash) (*services.Empty, error) {
-	select {
-	case bb.proposalHashChan <- hash:
-	case <-bb.proposalHashFull:
+func (bb *BulletinBoard) submitProposalHash(hash *services.ProposalHash) {
+	bb.proposalHashes.put(Epoch(hash.Epoch), hash.Proposer, hash)
+}
+
+// SubmitProposalHashList takes a hash list certified by the replicas.
+func (bb *BulletinBoard) SubmitProposalHashList(ctx context.Context, list *services.ProposalHashList) (*services.Empty, error) {
+	if err := bb.creds.authorizeRole(ctx, RoleReplica); err != nil {
+		return nil, err
+	}
+
+	if bb.replicas == nil {
+		bb.log.Warnf("[primary] ignoring a hash list, since there are no replicas")
+		return &services.Empty{}, nil
+	}
+
+	if err := bb.replicas.VerifyCertificate(list); err != nil {
+		bb.log.Warnf("[primary] ignoring a hash list for epoch %d: %s", list.Epoch, err.Error())
+		return &services.Empty{}, nil
+	}
+
+	// every replica delivers the list
+	bb.certifiedLock.Lock()
+	first := !bb.certified[Epoch(list.Epoch)]
+	bb.certified[Epoch(list.Epoch)] = true
+	bb.certifiedLock.Unlock()
+
+	if first {
+		bb.certifiedLists.put(Epoch(list.Epoch), 0, list)
 	}
+
 	return &services.Empty{}, nil
 }
 
-func (bb *BulletinBoard) consensusOnProposalHash(epoch Epoch) {
-	// just need 2t+1 proposals
-	proposalHash := make([]*services.ProposalHash, 2*bb.config.degree+1)
+// waitForCertifiedList waits for the hash list the replicas agreed on.
+// The replicas send it to the nodes themselves.
+func (bb *BulletinBoard) waitForCertifiedList(epoch Epoch) ([]*services.ProposalHash, error) {
+	// the replicas wait for the hashes themselves, give them as long again to agree
+	msg := bb.certifiedLists.next(epoch, bb.clock.After(2*bb.deadlines.GetProposalHashes(), nil))
+	if msg == nil {
+		return nil, fmt.Errorf("the replicas agreed on no hash list")
+	}
+	list := msg.(*services.ProposalHashList)
 
-	i := 0
-	for {
-		hashMsg := <-bb.proposalHashChan
+	bb.log.Info("[primary] certified hash list received")
+
+	return list.List, nil
+}
 
-		if Epoch(hashMsg.Epoch) < epoch {
-			bb.log.Infof("[primary] ignoring hashMsg from a previous epoch: %d (at epoch %d)", hashMsg.Epoch, epoch)
+// consensusOnProposalHash picks the first 2t+1 proposal hashes and publishes them to the old group.
+// It fails if fewer arrive by the deadline.
+func (bb *BulletinBoard) consensusOnProposalHash(epoch Epoch) ([]*services.ProposalHash, error) {
+	if bb.replicas != nil {
+		return bb.waitForCertifiedList(epoch)
+	}
+
+	// just need 2t+1 proposals
+	proposalHash := make([]*services.ProposalHash, 0, 2*bb.config.degree+1)
+	received := make(map[int64]bool)
+	stop := bb.clock.After(bb.deadlines.GetProposalHashes(), nil)
+
+	for len(proposalHash) < cap(proposalHash) {
+		msg := bb.proposalHashes.next(epoch, stop)
+		if msg == nil {
+			bb.recordMissed("proposal hashes", missing(bb.config.oldGroup, func(id int64) bool { return received[id] }))
+			return nil, fmt.Errorf("only %d of %d proposal hashes", len(proposalHash), cap(proposalHash))
+		}
+		hashMsg := msg.(*services.ProposalHash)
+
+		if err := checkProposalHash(hashMsg); err != nil {
+			bb.log.Warnf("[primary] ignoring a proposal hash: %s", err.Error())
 			continue
-		} else if Epoch(hashMsg.Epoch) > epoch {
-			panic("this can not happen in out setting.")
 		}
 
-		if len(hashMsg.Hash) != sha256.Size {
-			panic(fmt.Sprintf("wrong size: Wanted %d. Got %d", sha256.Size, len(hashMsg.Hash)))
+		if !bb.config.IsOldMember(hashMsg.Proposer) {
+			bb.log.Warnf("[primary] ignoring a proposal hash from %d, which is not in the old group", hashMsg.Proposer)
+			continue
 		}
 
 		bb.log.Debugf("[primary] receiving hash from %d", hashMsg.Proposer)
-		proposalHash[i] = hashMsg
+		proposalHash = append(proposalHash, hashMsg)
+		received[hashMsg.Proposer] = true
 
-		i += 1
-		bb.log.Debugf("[primary] %d hash received", i)
-		if i >= len(proposalHash) {
-			break
-		}
+		bb.log.Debugf("[primary] %d hash received", len(proposalHash))
 	}
 
-	// close to notify the upstream
-	close(bb.proposalHashFull)
-
 	bb.log.Info("primary enough hashes received")
 
-	// send out the list to all nodes
-	ctx := context.Background()
-	for _, node := range bb.nodes {
-		msg := services.ProposalHashList{
-			Epoch: int32(epoch),
-			List:  proposalHash,
-		}
-		_, err := node.StartCheckingProposals(ctx, &msg)
-		if err != nil {
-			bb.log.Fatalf(err.Error())
+	// publish the list to the old group
+	bb.publish(services.BoardPost_PROPOSAL_HASH_LIST, epoch, &services.ProposalHashList{
+		Epoch: int32(epoch),
+		List:  proposalHash,
+	})
+
+	return proposalHash, nil
+}
+
+// recordMissed notes the nodes that missed the deadline of a phase. They are listed in the verdict on the epoch.
+func (bb *BulletinBoard) recordMissed(phase string, ids []int64) {
+	if len(ids) == 0 {
+		return
+	}
+
+	bb.log.Warnf("[primary] nodes %v missed the deadline for their %s", ids, phase)
+
+	for _, id := range ids {
+		if !contains(bb.missed, id) {
+			bb.missed = append(bb.missed, id)
 		}
 	}
 }
 
-func (bb *BulletinBoard) AssembleShare(ctx context.Context, in *services.Share) (*services.Empty, error) {
-	tmp := gmp.NewInt(0)
-	tmp.SetBytes(in.Share)
-	bb.log.Debugf("from=%d, share=%s", in.From, tmp.String())
-
-	bb.shareChan <- in
+func (bb *BulletinBoard) assembleShare(in *services.Share) {
+	bb.log.Debugf("from=%d", in.From)
 
-	return &services.Empty{}, nil
+	bb.shares.put(Epoch(in.Epoch), in.From, in)
 }
 
-func (bb *BulletinBoard) assembleSecret(epoch Epoch) {
-	degree := bb.config.degree
-	prime := bb.config.prime
+// holders returns the degree of the sharing at the end of an epoch and the nodes holding it.
+func (bb *BulletinBoard) holders(epoch Epoch) (int, []int64) {
+	// the initial shares are held by the old group, later ones by the new group
+	if epoch == 0 {
+		return bb.config.degree, bb.config.oldGroup
+	}
 
-	Xs := make([]*gmp.Int, len(bb.config.newGroup))
-	Ys := make([]*gmp.Int, len(bb.config.newGroup))
+	return bb.config.newDegree, bb.config.newGroup
+}
 
-	for i := range Xs {
-		Xs[i] = gmp.NewInt(0)
-		Ys[i] = gmp.NewInt(0)
+// sharesDeadline closes when the holders have had their time to send their shares, after their time
+// to get the blinded shares. The initial sharing waits for every node to come up instead.
+func (bb *BulletinBoard) sharesDeadline(epoch Epoch) <-chan struct{} {
+	if epoch == 0 {
+		return nil
 	}
 
-	i := 0
-	for {
-		share := <-bb.shareChan
+	blinded := bb.epochStart.Add(bb.deadlines.GetBlindedShares()).Sub(bb.clock.Now())
+	if blinded < 0 {
+		blinded = 0
+	}
 
-		if Epoch(share.Epoch) < epoch {
-			bb.log.Infof("[primary] ignoring share from a previous epoch: %d (at epoch %d)", share.Epoch, epoch)
-			continue
-		} else if Epoch(share.Epoch) > epoch {
-			panic("this can not happen in out setting.")
-		}
+	return bb.clock.After(blinded+bb.deadlines.GetShares(), nil)
+}
 
-		bb.log.Debugf("worker gets a share")
-		Xs[i].SetInt64(int64(share.From))
-		Ys[i].SetBytes(share.Share)
+// assembleSecret recovers the secret from the shares of the holders, or from those that arrived by the deadline,
+// and reports the wrong shares. The secret isn't kept: the shares and the polynomial are erased.
+func (bb *BulletinBoard) assembleSecret(epoch Epoch) error {
+	prime := bb.config.prime
 
-		i += 1
-		if i >= len(Xs) {
+	degree, holders := bb.holders(epoch)
+
+	var Xs, Ys []*gmp.Int
+	defer func() {
+		for _, y := range Ys {
+			eraseInt(y)
+		}
+	}()
+	received := make(map[int64]bool)
+	stop := bb.sharesDeadline(epoch)
+
+	for len(received) < len(holders) {
+		msg := bb.shares.next(epoch, stop)
+		if msg == nil {
+			bb.recordMissed("shares", missing(holders, func(id int64) bool { return received[id] }))
 			break
 		}
+		share := msg.(*services.Share)
+
+		if !contains(holders, share.From) {
+			bb.log.Warnf("[primary] ignoring a share from %d, which holds no share", share.From)
+			continue
+		}
+
+		bb.log.Debugf("worker gets a share")
+		Xs = append(Xs, gmp.NewInt(share.From))
+		Ys = append(Ys, gmp.NewInt(0).SetBytes(share.Share))
+		received[share.From] = true
 	}
 
-	poly, err := interpolation.LagrangeInterpolate(degree, Xs, Ys, prime)
+	poly, wrong, err := DecodeReedSolomon(degree, Xs, Ys, prime)
 	if err != nil {
-		panic("can't recover the secret")
+		return fmt.Errorf("can't recover the secret: %s", err.Error())
 	}
 
-	secret := gmp.NewInt(0)
-	poly.EvalMod(gmp.NewInt(0), prime, secret)
+	for _, i := range wrong {
+		bb.log.Warnf("[primary] wrong share from %s", Xs[i].String())
+	}
 
-	bb.log.WithField("secret", secret.String()).Warnf("finishing epoch %d", epoch)
+	erasePolynomial(poly)
+
+	bb.log.Warnf("finishing epoch %d", epoch)
+
+	return nil
+}
+
+// finishEpoch waits for the holders of the new sharing and advances the epoch.
+func (bb *BulletinBoard) finishEpoch(epoch Epoch) error {
+	var err error
+	if bb.mode == ModeProduction {
+		err = bb.confirmHandoff(epoch)
+	} else {
+		err = bb.assembleSecret(epoch)
+	}
+	if err != nil {
+		return err
+	}
 
 	// connect to all nodes if firstRun is true
-	if epoch == 0 {
+	if epoch == 0 && len(bb.nodes) == 0 {
 		bb.log.Debugf("connect to all peers")
 		bb.ConnectToPeers()
 	}
 
-	// notify nodes to advance the epoch
-	ctx := context.Background()
-	for i := range bb.nodes {
-		go func(dst int) {
-			_, err := bb.nodes[dst].AdvanceEpoch(ctx, &services.Empty{})
-			if err != nil {
-				bb.log.Fatalf(err.Error())
-			}
-		}(i)
+	// after a handoff the new group refreshes the shares among itself
+	if epoch > 0 {
+		bb.config = bb.config.AfterHandoff()
 	}
+
+	// notify nodes taking part in the next epoch to advance the epoch
+	bb.publish(services.BoardPost_ADVANCE_EPOCH, epoch, &services.EpochAdvance{
+		Members: bb.config.Members(),
+		Missed:  bb.missed,
+	})
+
+	return nil
 }
 
-func (bb *BulletinBoard) Kill(ctx context.Context, empty *services.Empty) (*services.Empty, error) {
-	bb.killChan <- struct{}{}
+// abortEpoch gives up on an epoch without a quorum. The old group keeps its shares
+// and retries the same handoff at the next epoch.
+func (bb *BulletinBoard) abortEpoch(epoch Epoch, err error) {
+	bb.log.Warnf("[primary] aborting epoch %d: %s", epoch, err.Error())
 
-	return &services.Empty{}, nil
+	bb.publish(services.BoardPost_ADVANCE_EPOCH, epoch, &services.EpochAdvance{
+		Members: bb.config.Members(),
+		Aborted: true,
+		Missed:  bb.missed,
+	})
+}
+
+// submitGoodbye counts the nodes that are done. Each node counts once, however often it says goodbye.
+func (bb *BulletinBoard) submitGoodbye(msg *services.Goodbye) {
+	if _, ok := bb.peerIPList[NewNodeID(msg.From)]; !ok {
+		bb.log.Warnf("[primary] ignoring a goodbye from %d, which is not a node", msg.From)
+		return
+	}
+
+	bb.goodbyeLock.Lock()
+	defer bb.goodbyeLock.Unlock()
+
+	if bb.goodbyes[msg.From] {
+		return
+	}
+	bb.goodbyes[msg.From] = true
+
+	// every node, including those that left the committee, says goodbye
+	if len(bb.goodbyes) == len(bb.peerIPList) {
+		close(bb.killChan)
+	}
 }
 
 func (bb *BulletinBoard) suicide() {
-	i := 0
-	for {
-		<-bb.killChan
-		i += 1
+	<-bb.killChan
 
-		if bb.allowSuicide && i >= len(bb.config.oldGroup) {
-			bb.log.Infof("killing myself...")
-			os.Exit(0)
-		}
+	if bb.allowSuicide {
+		bb.log.Infof("killing myself...")
+		os.Exit(0)
 	}
 }
 
 func (bb *BulletinBoard) ConnectToPeers() {
-	for _, peer := range bb.peerIPList {
-		conn, err := grpc.Dial(peer, grpc.WithInsecure())
+	for id, peer := range bb.peerIPList {
+		conn, err := bb.network.Dial(peer, bb.creds.dialOption(), bb.limits.dialOption())
 		if err != nil {
 			bb.log.Fatalf("cannot connect to: %v", err)
 		}
 
 		bb.log.Debugf("primary connect to %s", peer)
-		bb.nodes = append(bb.nodes, services.NewNodeClient(conn))
+		bb.nodes[id] = services.NewNodeClient(conn)
 	}
 }
 
 func (bb *BulletinBoard) Serve() {
-	_, port, err := net.SplitHostPort(bb.myIP)
-	if err != nil {
-		bb.log.Fatalf("can't serve: %s", err.Error())
-	}
-	portUint, err := strconv.ParseUint(port, 10, 16)
+	lis, err := bb.network.Listen(bb.myIP)
 	if err != nil {
-		panic(err.Error())
+		bb.log.Fatalf("cannot listen to %s, %v", bb.myIP, err)
 	}
 
-	hostPort := fmt.Sprintf("0.0.0.0:%d", portUint)
-
-	lis, err := net.Listen("tcp4", hostPort)
-	if err != nil {
-		bb.log.Fatalf("cannot listen to %s, %v", hostPort, err)
-	}
-
-	s := grpc.NewServer()
+	s := grpc.NewServer(append(bb.creds.serverOptions(), bb.limits.serverOptions()...)...)
 	services.RegisterBulletinBoardServiceServer(s, bb)
 
-	bb.log.Infof("primary serving on %s", hostPort)
+	bb.log.Infof("primary serving on %s", lis.Addr())
 	if err := s.Serve(lis); err != nil {
 		bb.log.Fatalf("can't serve")
 	}
@@ -222,29 +374,110 @@ func (bb *BulletinBoard) StartProtocol() {
 	// prepare to suicide
 	go bb.suicide()
 
+	if bb.board != nil {
+		bb.clock.Go(bb.followBoard)
+	}
+
 	// always running
 	epoch := Epoch(0)
+
+	if bb.bootstrap != BootstrapFixed {
+		bb.ConnectToPeers()
+		if err := bb.consensusOnDealings(epoch); err != nil {
+			bb.log.Fatalf("[primary] no initial sharing: %s", err.Error())
+		}
+	}
+
 	// HACK: wait to receive initial shares from everyone and start the protocol.
-	bb.assembleSecret(epoch)
+	// There is nothing to fall back on if the initial sharing fails.
+	if err := bb.finishEpoch(epoch); err != nil {
+		bb.log.Fatalf("[primary] no initial sharing: %s", err.Error())
+	}
 
 	for {
 		epoch += 1
+		bb.missed = nil
+		bb.epochStart = bb.clock.Now()
+		// the collectors of aborted epochs may have given up early
+		for _, ib := range []*inbox{bb.proposalHashes, bb.complaints, bb.shares, bb.shareChecks, bb.certifiedLists} {
+			ib.advance(epoch)
+		}
 		bb.log.Warnf("primary entering epoch %d", epoch)
 
-		// blocks
-		bb.consensusOnProposalHash(epoch)
-		bb.assembleSecret(epoch)
+		if err := bb.runEpoch(epoch); err != nil {
+			bb.abortEpoch(epoch, err)
+		}
+	}
+}
+
+// runEpoch runs the board's side of a handoff. It fails if a phase gets no quorum by its deadline.
+func (bb *BulletinBoard) runEpoch(epoch Epoch) error {
+	proposalHash, err := bb.consensusOnProposalHash(epoch)
+	if err != nil {
+		return err
+	}
 
-		// restore the notificator
-		bb.proposalHashFull = make(chan struct{})
+	if err := bb.resolveComplaints(epoch, proposalHash); err != nil {
+		return err
 	}
+
+	return bb.finishEpoch(epoch)
 }
 
 func (bb *BulletinBoard) SetSuicideOption(opt bool) {
 	bb.allowSuicide = opt
 }
 
-func BuildBulletinBoard(logger *logrus.Logger, myIP string, nodesIPList []string, cryptoConfig PublicParameter) BulletinBoard {
+func (bb *BulletinBoard) SetBootstrapOption(opt string) {
+	bb.bootstrap = opt
+}
+
+func (bb *BulletinBoard) SetModeOption(opt string) {
+	bb.mode = opt
+}
+
+// SetCredentials makes the primary use mutual TLS on all its connections, and check who posts.
+// It has to be called before Serve and ConnectToPeers.
+func (bb *BulletinBoard) SetCredentials(creds *Credentials) {
+	bb.creds = creds
+}
+
+// SetTransport makes the primary serve and reach the nodes through the given transport instead of TCP.
+// It has to be called before Serve and ConnectToPeers.
+func (bb *BulletinBoard) SetTransport(network Transport) {
+	bb.network = network
+}
+
+// useClock makes the primary tell the time, run its deadlines and wait on the given clock.
+func (bb *BulletinBoard) useClock(clock Clock) {
+	bb.clock = clock
+	for _, ib := range []*inbox{bb.proposalHashes, bb.complaints, bb.shares, bb.shareChecks, bb.certifiedLists, bb.dealingCommitments, bb.dealingComplaints, bb.dealingReveals} {
+		ib.clock = clock
+	}
+}
+
+// SetDeadlines sets how long each phase of an epoch waits for the nodes.
+func (bb *BulletinBoard) SetDeadlines(deadlines DeadlineConfig) {
+	bb.deadlines = deadlines
+}
+
+// SetMessageLimits sets how large the messages the primary sends and takes get.
+// It has to be called before Serve and ConnectToPeers.
+func (bb *BulletinBoard) SetMessageLimits(limits MessageConfig) {
+	bb.limits = limits
+}
+
+// SetBoard makes the primary run on top of an external board, such as a chain, instead of serving the posts itself.
+func (bb *BulletinBoard) SetBoard(board Board) {
+	bb.board = board
+}
+
+// SetReplicas leaves ordering the proposal hashes to the replicas.
+func (bb *BulletinBoard) SetReplicas(replicas *ReplicaSet) {
+	bb.replicas = replicas
+}
+
+func BuildBulletinBoard(logger *logrus.Logger, myIP string, nodesIPList map[NewNodeID]string, cryptoConfig PublicParameter) BulletinBoard {
 	logEntry := logger.WithFields(
 		logrus.Fields{
 			"name": "primary",
@@ -254,14 +487,37 @@ func BuildBulletinBoard(logger *logrus.Logger, myIP string, nodesIPList []string
 		config:     cryptoConfig,
 		myIP:       myIP,
 		peerIPList: nodesIPList,
+		nodes:      make(map[NewNodeID]services.NodeClient),
+
+		identityKeys: cryptoConfig.identityKeys,
+		network:      GRPCTransport{},
+		clock:        wallClock{},
+
+		shares: newInbox("share", len(cryptoConfig.Members()), logEntry),
+
+		goodbyes:    make(map[int64]bool),
+		goodbyeLock: &sync.Mutex{},
+		killChan:    make(chan struct{}),
+
+		mode:        ModeBenchmark,
+		shareChecks: newInbox("share check", len(cryptoConfig.Members()), logEntry),
+
+		proposalHashes: newInbox("proposal hash", len(cryptoConfig.Members()), logEntry),
+		complaints:     newInbox("complaints", len(cryptoConfig.Members()), logEntry),
+
+		// the dealer posts under DealerId
+		dealingCommitments: newInbox("dealing commitment", len(cryptoConfig.Members())+1, logEntry),
+		dealingComplaints:  newInbox("dealing complaints", len(cryptoConfig.Members()), logEntry),
+		dealingReveals:     newInbox("dealing reveal", len(cryptoConfig.Members()), logEntry),
 
-		shareChan: make(chan *services.Share),
-		killChan:  make(chan struct{}),
+		certifiedLists: newInbox("certified hash list", 1, logEntry),
+		certified:      make(map[Epoch]bool),
+		certifiedLock:  &sync.Mutex{},
 
-		proposalHashChan: make(chan *services.ProposalHash),
-		proposalHashFull: make(chan struct{}),
+		posts: newBoardLog(),
 
 		log:          logEntry,
 		allowSuicide: true,
+		bootstrap:    BootstrapFixed,
 	}
 }
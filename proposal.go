@@ -1,17 +1,14 @@
 package Schultz
 
 import (
-	"bytes"
 	"crypto/sha256"
-	"encoding/gob"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"sort"
 	"strings"
-	"time"
 
 	"../../utils/conv"
-	polycommit "../../utils/polycommit/pbc"
 	"../../utils/polyring"
 	"../../utils/vector"
 	"github.com/ncw/gmp"
@@ -43,31 +40,8 @@ func (pz PointsOnBlindingPoly) Equal(other PointsOnBlindingPoly) bool {
 	return true
 }
 
-func (pz PointsOnBlindingPoly) GobEncode() ([]byte, error) {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-
-	if err := enc.Encode(pz.points); err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
-}
-
-func (pz *PointsOnBlindingPoly) GobDecode(buf []byte) error {
-	r := bytes.NewBuffer(buf)
-	dec := gob.NewDecoder(r)
-
-	if err := dec.Decode(&pz.points); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (pz PointsOnBlindingPoly) Bytes() []byte {
-	var buf bytes.Buffer
-
+// Bytes encodes the points canonically, each with the id of its new node.
+func (pz PointsOnBlindingPoly) Bytes() ([]byte, error) {
 	// To store the keys in slice in sorted order
 	var keys []NewNodeID
 	for k := range pz.points {
@@ -76,11 +50,14 @@ func (pz PointsOnBlindingPoly) Bytes() []byte {
 
 	sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
 
+	c := &canonical{}
+	c.uint32(uint32(len(keys)))
 	for _, k := range keys {
-		buf.Write(pz.points[k].Bytes())
+		c.uint32(uint32(k))
+		c.element(pz.points[k])
 	}
 
-	return buf.Bytes()
+	return c.Bytes(), c.Err()
 }
 
 func (pz PointsOnBlindingPoly) String() string {
@@ -93,48 +70,22 @@ func (pz PointsOnBlindingPoly) String() string {
 }
 
 type Proposal struct {
-	commQ polycommit.PolyCommit
-	// one for each new node
-	commRs map[NewNodeID]polycommit.PolyCommit
-	// one for each old node
-	pointToPeers map[OldNodeID]PointsOnBlindingPoly
-}
-
-func (p Proposal) GobEncode() ([]byte, error) {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
+	// the epoch and the session the proposal was made for
+	epoch   Epoch
+	session [32]byte
 
-	if err := enc.Encode(p.commQ); err != nil {
-		return nil, err
-	}
-
-	if err := enc.Encode(p.commRs); err != nil {
-		return nil, err
-	}
-
-	if err := enc.Encode(p.pointToPeers); err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
+	commQ PolyCommit
+	// one for each new node
+	commRs map[NewNodeID]PolyCommit
+	// one for each old node, encrypted to it
+	pointToPeers map[OldNodeID]VerifiablePoints
 }
 
-func (p *Proposal) GobDecode(buf []byte) error {
-	r := bytes.NewBuffer(buf)
-	dec := gob.NewDecoder(r)
-
-	if err := dec.Decode(&p.commQ); err != nil {
-		return err
-	}
-
-	if err := dec.Decode(&p.commRs); err != nil {
-		return err
+func (p Proposal) Equal(other Proposal) bool {
+	if p.epoch != other.epoch || p.session != other.session {
+		return false
 	}
 
-	return dec.Decode(&p.pointToPeers)
-}
-
-func (p Proposal) Equal(other Proposal) bool {
 	if !p.commQ.Equals(other.commQ) {
 		return false
 	}
@@ -166,53 +117,190 @@ func (p Proposal) Equal(other Proposal) bool {
 	return true
 }
 
+// commitmentBytes serializes the commitments along with the epoch and the session. The encrypted
+// points are bound to them.
+func (p Proposal) commitmentBytes() []byte {
+	return commitmentBytes(p.epoch, p.session, p.commQ, p.commRs)
+}
+
+func commitmentBytes(epoch Epoch, session [32]byte, commQ PolyCommit, commRs map[NewNodeID]PolyCommit) []byte {
+	c := newCanonical(commitmentsTag)
+	c.uint32(uint32(epoch))
+	c.raw(session[:])
+	c.bytes(commQ.Bytes())
+
+	// To store the keys in slice in sorted order
+	var keys []NewNodeID
+	for k := range commRs {
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	c.uint32(uint32(len(keys)))
+	for _, k := range keys {
+		c.uint32(uint32(k))
+		c.bytes(commRs[k].Bytes())
+	}
+
+	return c.Bytes()
+}
+
+// recipients returns the old nodes in the order of the leaves of the Merkle tree.
+func (p Proposal) recipients() []OldNodeID {
+	var keys []OldNodeID
+	for k := range p.pointToPeers {
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
+
+func (p Proposal) leaves() [][32]byte {
+	var leaves [][32]byte
+	for _, j := range p.recipients() {
+		leaves = append(leaves, sliceLeaf(j, p.pointToPeers[j]))
+	}
+
+	return leaves
+}
+
+// Hash commits to the commitments and to a Merkle tree over the points for each old node,
+// so that an old node can check its slice of the proposal against the agreed hash.
 func (p Proposal) Hash() [32]byte {
-	hash := sha256.New()
+	return proposalHash(p.commitmentBytes(), merkleRoot(p.leaves()))
+}
 
-	hash.Write(p.commQ.Bytes())
+func proposalHash(commitments []byte, root [32]byte) [32]byte {
+	c := newCanonical(proposalHashTag)
+	c.bytes(commitments)
+	c.raw(root[:])
 
-	{
-		// To store the keys in slice in sorted order
-		var keys []NewNodeID
-		for k := range p.commRs {
-			keys = append(keys, k)
-		}
+	return sha256.Sum256(c.Bytes())
+}
 
-		sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+// Verify checks that Q vanishes at 0 and Rk vanishes at k for every new node k, so that the proposal
+// neither shifts the secret nor leaks it, and that they are of degree t', so that the new shares are a
+// degree t' sharing. The commitments are to the coefficients, so they double as the
+// evaluation witnesses. It also checks that the proposal was made for the epoch and the session, that it
+// covers exactly the old and the new group, and the proofs that the encrypted points lie on Q+Rk. Anyone
+// can run it: only a chunk that doesn't decrypt gets past it, which its old node can show to everyone.
+func (p Proposal) Verify(pp PublicParameter, epoch Epoch) error {
+	if err := verifyBinding(p.epoch, p.session, pp, epoch); err != nil {
+		return err
+	}
+
+	if err := verifyCommitments(p.commQ, p.commRs, pp); err != nil {
+		return err
+	}
+
+	if len(p.pointToPeers) != len(pp.oldGroup) {
+		return fmt.Errorf("wrong number of peers: wanted %d, got %d", len(pp.oldGroup), len(p.pointToPeers))
+	}
+
+	commitments := p.commitmentBytes()
+	for _, j := range pp.oldGroup {
+		points, ok := p.pointToPeers[OldNodeID(j)]
+		if !ok {
+			return fmt.Errorf("no points for %d", j)
+		}
 
-		for _, k := range keys {
-			hash.Write(p.commRs[k].Bytes())
+		if err := verifyEncryptedPoints(OldNodeID(j), points, p.commQ, p.commRs, commitments, pp); err != nil {
+			return err
 		}
 	}
 
-	{
-		// To store the keys in slice in sorted order
-		var keys []OldNodeID
-		for k := range p.pointToPeers {
-			keys = append(keys, k)
+	return nil
+}
+
+func verifyBinding(proposalEpoch Epoch, session [32]byte, pp PublicParameter, epoch Epoch) error {
+	if proposalEpoch != epoch {
+		return fmt.Errorf("made for epoch %d, not %d", proposalEpoch, epoch)
+	}
+
+	if session != pp.SessionID() {
+		return fmt.Errorf("made for another session")
+	}
+
+	return nil
+}
+
+func verifyCommitments(commQ PolyCommit, commRs map[NewNodeID]PolyCommit, pp PublicParameter) error {
+	zero := big.NewInt(0)
+
+	if commQ.Degree() != pp.newDegree {
+		return fmt.Errorf("Q of degree %d, not %d", commQ.Degree(), pp.newDegree)
+	}
+
+	if !commQ.VerifyEval(zero, zero) {
+		return fmt.Errorf("Q(0) != 0")
+	}
+
+	if len(commRs) != len(pp.newGroup) {
+		return fmt.Errorf("wrong number of blinding polynomials: wanted %d, got %d", len(pp.newGroup), len(commRs))
+	}
+
+	for _, k := range pp.newGroup {
+		commRk, ok := commRs[NewNodeID(k)]
+		if !ok {
+			return fmt.Errorf("no blinding polynomial for %d", k)
+		}
+
+		if commRk.Degree() != pp.newDegree {
+			return fmt.Errorf("R%d of degree %d, not %d", k, commRk.Degree(), pp.newDegree)
 		}
 
-		sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+		if !commRk.VerifyEval(big.NewInt(k), zero) {
+			return fmt.Errorf("R%d(%d) != 0", k, k)
+		}
+	}
+
+	return nil
+}
+
+func verifyEncryptedPoints(j OldNodeID, points VerifiablePoints, commQ PolyCommit, commRs map[NewNodeID]PolyCommit, commitments []byte, pp PublicParameter) error {
+	if len(points.cipher) != len(pp.newGroup) {
+		return fmt.Errorf("wrong number of points for %d", j)
+	}
 
-		for _, k := range keys {
-			hash.Write(p.pointToPeers[k].Bytes())
+	for _, k := range pp.newGroup {
+		if _, ok := points.cipher[NewNodeID(k)]; !ok {
+			return fmt.Errorf("no point on Q+R%d for %d", k, j)
 		}
 	}
 
-	var result [32]byte
-	copy(result[:], hash.Sum(nil))
+	pk, ok := pp.encryptionKeys[int64(j)]
+	if !ok {
+		return fmt.Errorf("no encryption key for %d", j)
+	}
 
-	return result
+	return points.verify(j, pk, commQ, commRs, commitments)
 }
 
-func (p Proposal) ToBytes() []byte {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	if err := enc.Encode(p); err != nil {
-		panic(err.Error())
+// verifyPoints checks that the points sent to old node j lie on Q+Rk.
+func verifyPoints(commQ PolyCommit, commRs map[NewNodeID]PolyCommit, j OldNodeID, points PointsOnBlindingPoly) error {
+	jBig := big.NewInt(int64(j))
+
+	// one point for each blinding polynomial
+	if len(points.points) != len(commRs) {
+		return fmt.Errorf("wrong number of points: wanted %d, got %d", len(commRs), len(points.points))
+	}
+
+	for newNodeK, pointOnQPlusRk := range points.points {
+		commRk, ok := commRs[newNodeK]
+		if !ok {
+			return fmt.Errorf("no blinding polynomial for %d", newNodeK)
+		}
+
+		comm := AdditiveHomomorphism(commQ, commRk)
+		if !comm.VerifyEval(jBig, conv.GmpInt2BigInt(pointOnQPlusRk)) {
+			return fmt.Errorf("point for %d not on Q+R%d", j, newNodeK)
+		}
 	}
 
-	return buf.Bytes()
+	return nil
 }
 
 func (p Proposal) String() string {
@@ -221,7 +309,7 @@ func (p Proposal) String() string {
 		s += fmt.Sprintf("-- Comm(Rs): %s\n", comm.String())
 	}
 	for i, peer := range p.pointToPeers {
-		s += fmt.Sprintf("points to peer %d: %s\n", i, peer.String())
+		s += fmt.Sprintf("points to peer %d: %d encrypted\n", i, len(peer.cipher))
 	}
 
 	return strings.TrimSuffix(s, "\n")
@@ -231,10 +319,16 @@ func (p Proposal) Print() {
 	fmt.Println(p.String())
 }
 
-func GenerateProposal(pp PublicParameter) Proposal {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+// GenerateProposal draws the polynomials of the proposal from crypto/rand: whoever knows them can
+// strip the blinding off the blinded shares.
+func GenerateProposal(pp PublicParameter, epoch Epoch) Proposal {
+	return generateProposal(pp, epoch, NewCryptoRand())
+}
 
-	Q, err := polyring.NewRand(pp.degree, r, pp.prime)
+// generateProposal draws the polynomials of the proposal from r.
+func generateProposal(pp PublicParameter, epoch Epoch, r *rand.Rand) Proposal {
+	// Q and Rk are sampled at the new degree so that the new group ends up with a degree t' sharing
+	Q, err := polyring.NewRand(pp.newDegree, r, pp.prime)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -245,14 +339,14 @@ func GenerateProposal(pp PublicParameter) Proposal {
 	// make it zero know
 	Q.GetPtrToConstant().SetUint64(0)
 	// commit to it!
-	commQ := polycommit.NewPolyCommit(Q)
+	commQ := NewPolyCommit(Q)
 
 	// blinding polynomials
 	blindingPolys := make(map[NewNodeID]polyring.Polynomial, len(pp.newGroup))
-	commBlindingPolyList := make(map[NewNodeID]polycommit.PolyCommit, len(pp.newGroup))
+	commBlindingPolyList := make(map[NewNodeID]PolyCommit, len(pp.newGroup))
 
 	for _, newNodeId := range pp.newGroup {
-		blindingPolyForI, err := polyring.NewRand(pp.degree-1, r, pp.prime)
+		blindingPolyForI, err := polyring.NewRand(pp.newDegree-1, r, pp.prime)
 		if err != nil {
 			panic(err.Error())
 		}
@@ -265,7 +359,7 @@ func GenerateProposal(pp PublicParameter) Proposal {
 		blindingPolys[NewNodeID(newNodeId)] = blindingPolyForI
 
 		// commitment to the blinding polynomials
-		commBlindingPolyList[NewNodeID(newNodeId)] = polycommit.NewPolyCommit(blindingPolyForI)
+		commBlindingPolyList[NewNodeID(newNodeId)] = NewPolyCommit(blindingPolyForI)
 	}
 
 	// for each old group member, evaluate points on blinding polynomials
@@ -273,10 +367,13 @@ func GenerateProposal(pp PublicParameter) Proposal {
 	Q.EvalModArray(oldGroupIndices, pp.prime, pointsOnQ.GetPtr())
 
 	proposal := Proposal{
-		commQ,
-		commBlindingPolyList,
-		make(map[OldNodeID]PointsOnBlindingPoly, len(pp.oldGroup)),
+		epoch:        epoch,
+		session:      pp.SessionID(),
+		commQ:        commQ,
+		commRs:       commBlindingPolyList,
+		pointToPeers: make(map[OldNodeID]VerifiablePoints, len(pp.oldGroup)),
 	}
+	commitments := proposal.commitmentBytes()
 
 	// j is the id for an old group member
 	for _, j := range pp.oldGroup {
@@ -296,12 +393,49 @@ func GenerateProposal(pp PublicParameter) Proposal {
 
 			BlidingPointsForJ[NewNodeID(nodeK)] = gmp.NewInt(0)
 			BlidingPointsForJ[NewNodeID(nodeK)].Add(Qj, Rkj)
+			BlidingPointsForJ[NewNodeID(nodeK)].Mod(BlidingPointsForJ[NewNodeID(nodeK)], pp.prime)
 		}
 
-		proposal.pointToPeers[OldNodeID(j)] = PointsOnBlindingPoly{
-			points: BlidingPointsForJ,
+		pk, ok := pp.encryptionKeys[j]
+		if !ok {
+			log.Fatalf("no encryption key for %d", j)
 		}
+
+		encrypted, err := encryptVerifiablePoints(pk, OldNodeID(j), PointsOnBlindingPoly{points: BlidingPointsForJ}, commitments)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		proposal.pointToPeers[OldNodeID(j)] = encrypted
 	}
 
 	return proposal
 }
+
+// CombineProposals adds the points an old node decrypted from each proposal to its share,
+// giving one blinded share for each new group node.
+func CombineProposals(pp PublicParameter, share *gmp.Int, points []PointsOnBlindingPoly) map[NewNodeID]*gmp.Int {
+	combinedNewShare := make(map[NewNodeID]*gmp.Int)
+	for _, newNodeId := range pp.newGroup {
+		combinedNewShare[NewNodeID(newNodeId)] = gmp.NewInt(0)
+		// set it to my share
+		combinedNewShare[NewNodeID(newNodeId)].Set(share)
+	}
+
+	for _, pointsFromProposal := range points {
+		for newNodeK, pointOnQPlusRk := range pointsFromProposal.points {
+			p, ok := combinedNewShare[newNodeK]
+			if !ok {
+				log.Fatalf("node %d is not in the new group", newNodeK)
+			}
+
+			p.Add(p, pointOnQPlusRk)
+		}
+	}
+
+	for _, p := range combinedNewShare {
+		p.Mod(p, pp.prime)
+	}
+
+	return combinedNewShare
+}
@@ -1,12 +1,17 @@
 package Schultz
 
 import (
-	polycommit "../../utils/polycommit/pbc"
+	"../../utils/interpolation"
+	"../../utils/polyring"
+	"./services"
 	"bytes"
 	"encoding/gob"
 	"encoding/hex"
+	"github.com/golang/protobuf/proto"
 	"github.com/ncw/gmp"
 	"github.com/stretchr/testify/assert"
+	"math/big"
+	"math/rand"
 	"testing"
 )
 
@@ -31,29 +36,24 @@ func TestGobGmpInt(t *testing.T) {
 	assert.Nil(t, err)
 }
 
-func TestProposal_GobEncode(t *testing.T) {
+func TestProposal_Message(t *testing.T) {
 	pp := BuildConfig(
 		1,
-		polycommit.Curve.Ngmp,
+		FieldPrime,
 		[]int64{1, 2, 3, 4},
 		[]int64{1, 2, 3, 4},
 	)
+	pp, _ = withEncryptionKeys(pp)
 
-	p := GenerateProposal(pp)
+	p := GenerateProposal(pp, 1)
 
-	buf := bytes.Buffer{}
-	enc := gob.NewEncoder(&buf)
-
-	err := enc.Encode(p)
-	if err != nil {
-		assert.Fail(t, err.Error())
-	}
-
-	dec := gob.NewDecoder(&buf)
+	data, err := proto.Marshal(p.Message(1))
+	assert.Nil(t, err)
 
-	pNew := Proposal{}
+	msg := &services.Proposal{}
+	assert.Nil(t, proto.Unmarshal(data, msg))
 
-	err = dec.Decode(&pNew)
+	pNew, err := ProposalFromMessage(msg, pp)
 	assert.Nil(t, err)
 
 	assert.True(t, p.Equal(pNew))
@@ -69,22 +69,15 @@ func TestProposal_GobEncode(t *testing.T) {
 func genProposalWithDegree(degree int) int {
 	pp := BuildConfig(
 		degree,
-		polycommit.Curve.Ngmp,
+		FieldPrime,
 		makeOneToN(3*degree+1),
 		makeOneToN(3*degree+1),
 	)
+	pp, _ = withEncryptionKeys(pp)
 
-	p := GenerateProposal(pp)
+	p := GenerateProposal(pp, 1)
 
-	buf := bytes.Buffer{}
-	enc := gob.NewEncoder(&buf)
-
-	err := enc.Encode(p)
-	if err != nil {
-		panic(err.Error())
-	}
-
-	return buf.Len()
+	return proto.Size(p.Message(1))
 }
 
 func makeOneToN(n int) []int64 {
@@ -95,6 +88,295 @@ func makeOneToN(n int) []int64 {
 	return a
 }
 
+func mustBuildHandoffConfig(t *testing.T, oldDegree, newDegree int, oldGroup, newGroup []int64) PublicParameter {
+	pp, err := BuildHandoffConfig(oldDegree, newDegree, FieldPrime, oldGroup, newGroup)
+	assert.Nil(t, err)
+
+	return pp
+}
+
+func TestBuildHandoffConfig(t *testing.T) {
+	// the threshold can't be lowered
+	_, err := BuildHandoffConfig(2, 1, FieldPrime, makeOneToN(7), makeOneToN(4))
+	assert.NotNil(t, err)
+
+	pp := mustBuildHandoffConfig(t, 1, 2, makeOneToN(4), []int64{3, 4, 5, 6, 7, 8, 9})
+	assert.Equal(t, []int64{1, 2, 3, 4, 5, 6, 7, 8, 9}, pp.Members())
+
+	// after the handoff, the new group refreshes the shares among itself
+	after := pp.AfterHandoff()
+	assert.Equal(t, 2, after.GetDegree())
+	assert.Equal(t, pp.GetNewGroup(), after.GetOldGroup())
+	assert.Equal(t, pp.GetNewGroup(), after.GetNewGroup())
+	assert.False(t, after.IsOldMember(1))
+	assert.True(t, after.IsOldMember(9))
+}
+
+func TestCheckGroupSizes(t *testing.T) {
+	// N >= 3t+1 as long as the threshold stays
+	assert.Nil(t, CheckGroupSizes(4, 4, 1, 1))
+	assert.NotNil(t, CheckGroupSizes(3, 4, 1, 1))
+	assert.NotNil(t, CheckGroupSizes(4, 3, 1, 1))
+
+	// raising it to t' takes N >= t'+2t+1 and N' >= 3t'+1
+	assert.Nil(t, CheckGroupSizes(5, 7, 1, 2))
+	assert.NotNil(t, CheckGroupSizes(4, 7, 1, 2))
+	assert.NotNil(t, CheckGroupSizes(5, 6, 1, 2))
+	assert.Nil(t, CheckGroupSizes(7, 13, 1, 4))
+	assert.NotNil(t, CheckGroupSizes(6, 13, 1, 4))
+
+	assert.NotNil(t, CheckGroupSizes(7, 7, 2, 1))
+}
+
+func TestDecodeBlindedSharesAtTheBoundary(t *testing.T) {
+	// the blinded shares lie on a degree t' polynomial, and t of them are wrong
+	const oldDegree, newDegree = 1, 2
+	prime := FieldPrime
+
+	poly, err := polyring.NewRand(newDegree, rand.New(rand.NewSource(1)), prime)
+	assert.Nil(t, err)
+
+	blindedShares := func(n int) ([]*gmp.Int, []*gmp.Int) {
+		var Xs, Ys []*gmp.Int
+		for _, j := range makeOneToN(n) {
+			y := gmp.NewInt(0)
+			poly.EvalMod(gmp.NewInt(j), prime, y)
+			Xs = append(Xs, gmp.NewInt(j))
+			Ys = append(Ys, y)
+		}
+		Ys[0].Add(Ys[0], gmp.NewInt(1))
+
+		return Xs, Ys
+	}
+
+	// N = 3t+1 isn't enough once the threshold grows
+	assert.NotNil(t, CheckGroupSizes(4, 7, oldDegree, newDegree))
+	Xs, Ys := blindedShares(4)
+	decoded, _, err := DecodeReedSolomon(newDegree, Xs, Ys, prime)
+	assert.True(t, err != nil || decoded.GetPtrToConstant().Cmp(poly.GetPtrToConstant()) != 0)
+
+	// N = t'+2t+1 is
+	assert.Nil(t, CheckGroupSizes(5, 7, oldDegree, newDegree))
+	Xs, Ys = blindedShares(5)
+	decoded, wrong, err := DecodeReedSolomon(newDegree, Xs, Ys, prime)
+	assert.Nil(t, err)
+	assert.Len(t, wrong, oldDegree)
+	assert.Equal(t, 0, decoded.GetPtrToConstant().Cmp(poly.GetPtrToConstant()))
+}
+
+func TestProposal_Verify(t *testing.T) {
+	pp := BuildConfig(
+		1,
+		FieldPrime,
+		[]int64{1, 2, 3, 4},
+		[]int64{3, 4, 5, 6},
+	)
+	pp, keys := withEncryptionKeys(pp)
+
+	r := rand.New(rand.NewSource(0))
+
+	p := GenerateProposal(pp, 1)
+	assert.Nil(t, p.Verify(pp, 1))
+
+	// a Q with a nonzero constant shifts the secret
+	Q, err := polyring.NewRand(pp.GetDegree(), r, pp.GetPrime())
+	assert.Nil(t, err)
+	Q.GetPtrToConstant().SetInt64(1)
+
+	shifted := GenerateProposal(pp, 1)
+	shifted.commQ = NewPolyCommit(Q)
+	assert.NotNil(t, shifted.Verify(pp, 1))
+
+	// an Rk that doesn't vanish at k
+	Rk, err := polyring.NewRand(pp.GetDegree(), r, pp.GetPrime())
+	assert.Nil(t, err)
+	Rk.GetPtrToConstant().SetInt64(1)
+
+	unblinded := GenerateProposal(pp, 1)
+	unblinded.commRs[NewNodeID(5)] = NewPolyCommit(Rk)
+	assert.NotNil(t, unblinded.Verify(pp, 1))
+
+	// a Q or an Rk of a higher degree passes the checks at 0 and k, but the new shares wouldn't be a
+	// degree t' sharing
+	Q, err = polyring.NewRand(pp.GetDegree()+1, r, pp.GetPrime())
+	assert.Nil(t, err)
+	Q.GetPtrToConstant().SetInt64(0)
+
+	higherQ := GenerateProposal(pp, 1)
+	higherQ.commQ = NewPolyCommit(Q)
+	assert.True(t, higherQ.commQ.VerifyEval(big.NewInt(0), big.NewInt(0)))
+	assert.NotNil(t, higherQ.Verify(pp, 1))
+
+	Rk, err = polyring.NewRand(pp.GetDegree(), r, pp.GetPrime())
+	assert.Nil(t, err)
+	Rk.MulSelf(polyring.FromVec(-5, 1))
+	Rk.Mod(pp.GetPrime())
+
+	higherRk := GenerateProposal(pp, 1)
+	higherRk.commRs[NewNodeID(5)] = NewPolyCommit(Rk)
+	assert.True(t, higherRk.commRs[NewNodeID(5)].VerifyEval(big.NewInt(5), big.NewInt(0)))
+	assert.NotNil(t, higherRk.Verify(pp, 1))
+
+	// a missing new node
+	missing := GenerateProposal(pp, 1)
+	delete(missing.commRs, NewNodeID(6))
+	assert.NotNil(t, missing.Verify(pp, 1))
+
+	// every old node decrypts points on Q+Rk
+	for _, j := range pp.GetOldGroup() {
+		slice, err := p.Slice(OldNodeID(j))
+		assert.Nil(t, err)
+
+		_, err = slice.PointsFor(keys[j], pp)
+		assert.Nil(t, err)
+	}
+
+	// but not with the key of another node
+	slice, err := p.Slice(OldNodeID(1))
+	assert.Nil(t, err)
+
+	_, err = slice.PointsFor(keys[2], pp)
+	assert.NotNil(t, err)
+
+	// ciphertexts copied from another proposal lose their proof
+	copied := GenerateProposal(pp, 1)
+	copied.pointToPeers[OldNodeID(1)] = p.pointToPeers[OldNodeID(1)]
+	assert.NotNil(t, copied.Verify(pp, 1))
+}
+
+// runs one handoff without the network and returns the shares of the new group
+func handoffWithoutNetwork(t *testing.T, pp PublicParameter, keys map[int64]*EncryptionKey, oldShares map[int64]*gmp.Int) map[int64]*gmp.Int {
+	var proposals []*Proposal
+	for i := 0; i < 2*pp.GetDegree()+1; i++ {
+		p := GenerateProposal(pp, 1)
+		proposals = append(proposals, &p)
+	}
+
+	return handoffWithProposals(t, pp, keys, oldShares, proposals)
+}
+
+// runs one handoff with the given proposals and returns the shares of the new group
+func handoffWithProposals(t *testing.T, pp PublicParameter, keys map[int64]*EncryptionKey, oldShares map[int64]*gmp.Int, proposals []*Proposal) map[int64]*gmp.Int {
+	// blinded shares received by each new node
+	var Xs []*gmp.Int
+	Ys := make(map[NewNodeID][]*gmp.Int)
+	for _, j := range pp.GetOldGroup() {
+		var points []PointsOnBlindingPoly
+		for _, p := range proposals {
+			slice, err := p.Slice(OldNodeID(j))
+			assert.Nil(t, err)
+
+			pointsFromP, err := slice.PointsFor(keys[j], pp)
+			assert.Nil(t, err)
+			points = append(points, pointsFromP)
+		}
+
+		combined := CombineProposals(pp, oldShares[j], points)
+
+		Xs = append(Xs, gmp.NewInt(j))
+		for k, blindedShare := range combined {
+			Ys[k] = append(Ys[k], blindedShare)
+		}
+	}
+
+	newShares := make(map[int64]*gmp.Int)
+	for _, k := range pp.GetNewGroup() {
+		poly, err := interpolation.LagrangeInterpolate(pp.reconstructionDegree(), Xs, Ys[NewNodeID(k)], pp.GetPrime())
+		assert.Nil(t, err)
+
+		newShares[k] = gmp.NewInt(0)
+		poly.EvalMod(gmp.NewInt(k), pp.GetPrime(), newShares[k])
+	}
+
+	return newShares
+}
+
+func TestHandoffWithThresholdChange(t *testing.T) {
+	oldGroup := makeOneToN(4)
+	newGroup := []int64{3, 4, 5, 6, 7, 8, 9}
+	pp, keys := withEncryptionKeys(mustBuildHandoffConfig(t, 1, 2, oldGroup, newGroup))
+
+	secret := gmp.NewInt(6666)
+	secretPoly, err := polyring.NewRand(pp.GetDegree(), rand.New(rand.NewSource(0)), pp.GetPrime())
+	assert.Nil(t, err)
+	secretPoly.GetPtrToConstant().Set(secret)
+
+	oldShares := make(map[int64]*gmp.Int)
+	for _, j := range oldGroup {
+		oldShares[j] = gmp.NewInt(0)
+		secretPoly.EvalMod(gmp.NewInt(j), pp.GetPrime(), oldShares[j])
+	}
+
+	newShares := handoffWithoutNetwork(t, pp, keys, oldShares)
+
+	// any t'+1 new shares recover the secret
+	var Xs, Ys []*gmp.Int
+	for _, k := range newGroup[len(newGroup)-3:] {
+		Xs = append(Xs, gmp.NewInt(k))
+		Ys = append(Ys, newShares[k])
+	}
+
+	poly, err := interpolation.LagrangeInterpolate(2, Xs, Ys, pp.GetPrime())
+	assert.Nil(t, err)
+
+	recovered := gmp.NewInt(0)
+	poly.EvalMod(gmp.NewInt(0), pp.GetPrime(), recovered)
+	assert.Equal(t, secret.String(), recovered.String())
+
+	// but t+1 new shares no longer do
+	poly, err = interpolation.LagrangeInterpolate(1, Xs[:2], Ys[:2], pp.GetPrime())
+	assert.Nil(t, err)
+
+	poly.EvalMod(gmp.NewInt(0), pp.GetPrime(), recovered)
+	assert.NotEqual(t, secret.String(), recovered.String())
+}
+
+func TestHandoffBetweenDistinctGroups(t *testing.T) {
+	for _, groups := range [][2][]int64{
+		// every member leaves, and as many join
+		{{1, 2, 3, 4}, {5, 6, 7, 8}},
+		// one member leaves and two join
+		{{1, 2, 3, 4}, {2, 3, 4, 5, 6}},
+	} {
+		oldGroup, newGroup := groups[0], groups[1]
+		pp, keys := withEncryptionKeys(BuildConfig(1, FieldPrime, oldGroup, newGroup))
+
+		secret := gmp.NewInt(4242)
+		secretPoly, err := polyring.NewRand(pp.GetDegree(), rand.New(rand.NewSource(0)), pp.GetPrime())
+		assert.Nil(t, err)
+		secretPoly.GetPtrToConstant().Set(secret)
+
+		oldShares := make(map[int64]*gmp.Int)
+		for _, j := range oldGroup {
+			oldShares[j] = gmp.NewInt(0)
+			secretPoly.EvalMod(gmp.NewInt(j), pp.GetPrime(), oldShares[j])
+		}
+
+		newShares := handoffWithoutNetwork(t, pp, keys, oldShares)
+		assert.Len(t, newShares, len(newGroup))
+
+		// the members who left hold nothing, and any t+1 of the new group recover the secret
+		for _, j := range oldGroup {
+			if !pp.IsNewMember(j) {
+				assert.NotContains(t, newShares, j)
+			}
+		}
+
+		var Xs, Ys []*gmp.Int
+		for _, k := range newGroup[len(newGroup)-2:] {
+			Xs = append(Xs, gmp.NewInt(k))
+			Ys = append(Ys, newShares[k])
+		}
+
+		poly, err := interpolation.LagrangeInterpolate(1, Xs, Ys, pp.GetPrime())
+		assert.Nil(t, err)
+
+		recovered := gmp.NewInt(0)
+		poly.EvalMod(gmp.NewInt(0), pp.GetPrime(), recovered)
+		assert.Equal(t, secret.String(), recovered.String())
+	}
+}
+
 func TestGenerateProposal(t *testing.T) {
 	//for _, d := range []int{2, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55, 60, 65, 70, 75, 80, 85, 90, 95, 100} {
 	//	size := genProposalWithDegree(d)
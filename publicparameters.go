@@ -1,16 +1,34 @@
 package Schultz
 
 import (
+	"fmt"
+
 	"github.com/ncw/gmp"
 )
 
 type PublicParameter struct {
+	// degree of the sharing held by the old group
 	degree int
+	// degree of the sharing held by the new group
+	newDegree int
 	// prime for Fp
 	prime *gmp.Int
 
+	// members holding shares at the beginning of the epoch
 	oldGroup []int64
+	// members holding shares at the end of the epoch
 	newGroup []int64
+
+	// keys the points of the proposals are encrypted to, for every node
+	encryptionKeys map[int64]EncryptionPublicKey
+	// keys the messages are signed with, for every node and the dealer
+	identityKeys IdentityKeys
+
+	// number of times the first handoff was aborted, which delays it by as many epochs
+	aborted Epoch
+
+	// identifies the handoff, and stays the same after it
+	session [32]byte
 }
 
 func (c PublicParameter) GetThreshold() int {
@@ -21,15 +39,191 @@ func (c PublicParameter) GetDegree() int {
 	return c.degree
 }
 
+func (c PublicParameter) GetNewDegree() int {
+	return c.newDegree
+}
+
+// reconstructionDegree is the degree of the polynomials the blinded shares lie on.
+// Proposals are sampled at the new degree, so raising the threshold gives a degree t' sharing.
+func (c PublicParameter) reconstructionDegree() int {
+	if c.newDegree > c.degree {
+		return c.newDegree
+	}
+
+	return c.degree
+}
+
 func (c PublicParameter) GetPrime() *gmp.Int {
 	return c.prime
 }
 
+// SessionID identifies the handoff, so that proposals are bound to it.
+func (c PublicParameter) SessionID() [32]byte {
+	return c.session
+}
+
+func (c PublicParameter) GetOldGroup() []int64 {
+	return c.oldGroup
+}
+
+func (c PublicParameter) GetNewGroup() []int64 {
+	return c.newGroup
+}
+
+func (c PublicParameter) IsOldMember(id int64) bool {
+	return contains(c.oldGroup, id)
+}
+
+func (c PublicParameter) IsNewMember(id int64) bool {
+	return contains(c.newGroup, id)
+}
+
+// Members returns every node taking part in the handoff, i.e., the union of the old and the new group.
+func (c PublicParameter) Members() []int64 {
+	members := append([]int64{}, c.oldGroup...)
+	for _, id := range c.newGroup {
+		if !contains(members, id) {
+			members = append(members, id)
+		}
+	}
+
+	return members
+}
+
+// AfterHandoff returns the parameters for the epoch following a handoff,
+// in which the new group refreshes the shares among itself.
+func (c PublicParameter) AfterHandoff() PublicParameter {
+	return PublicParameter{
+		degree:         c.newDegree,
+		newDegree:      c.newDegree,
+		prime:          c.prime,
+		oldGroup:       c.newGroup,
+		newGroup:       c.newGroup,
+		encryptionKeys: c.encryptionKeys,
+		identityKeys:   c.identityKeys,
+		session:        c.session,
+	}
+}
+
+// WithEncryptionKeys returns the parameters with the public encryption keys of the nodes.
+func (c PublicParameter) WithEncryptionKeys(keys map[int64]EncryptionPublicKey) PublicParameter {
+	c.encryptionKeys = keys
+
+	return c
+}
+
+// HasEncryptionKeys tells whether every node has an encryption key.
+func (c PublicParameter) HasEncryptionKeys() bool {
+	for _, id := range c.Members() {
+		if _, ok := c.encryptionKeys[id]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithIdentityKeys returns the parameters with the public identity keys of the nodes and the dealer.
+func (c PublicParameter) WithIdentityKeys(keys IdentityKeys) PublicParameter {
+	c.identityKeys = keys
+
+	return c
+}
+
+// HasIdentityKeys tells whether every node has an identity key.
+func (c PublicParameter) HasIdentityKeys() bool {
+	for _, id := range c.Members() {
+		if _, ok := c.identityKeys[id]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ForEpoch returns the parameters in use at the given epoch, if c are those of the first handoff
+// (epoch 1, unless it was aborted).
+func (c PublicParameter) ForEpoch(epoch Epoch) PublicParameter {
+	if epoch <= 1+c.aborted {
+		return c
+	}
+
+	return c.AfterHandoff()
+}
+
+// AfterAbort returns the parameters of the first handoff once the given epoch was aborted.
+// Nothing changes in an aborted epoch, so an aborted first handoff is retried at the next one.
+func (c PublicParameter) AfterAbort(epoch Epoch) PublicParameter {
+	if epoch == 1+c.aborted {
+		c.aborted += 1
+	}
+
+	return c
+}
+
+func contains(group []int64, id int64) bool {
+	for _, member := range group {
+		if member == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// missing returns the members of group that haven't been received.
+func missing(group []int64, received func(id int64) bool) []int64 {
+	var out []int64
+	for _, id := range group {
+		if !received(id) {
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
+
+// CheckGroupSizes tells whether a handoff from a degree t sharing among N nodes to a degree t' sharing
+// among N' nodes tolerates t faulty old nodes and t' faulty new ones. The new nodes decode the blinded
+// shares at degree t' once t'+t+1 of them agree, which the N-t honest old nodes have to make up on their
+// own, so N >= t'+2t+1: 3t+1 as long as the threshold doesn't grow.
+func CheckGroupSizes(oldSize, newSize, oldDegree, newDegree int) error {
+	if newDegree < oldDegree {
+		return fmt.Errorf("the degree can't be lowered. t=%d, t'=%d", oldDegree, newDegree)
+	}
+
+	if oldSize < newDegree+2*oldDegree+1 {
+		return fmt.Errorf("N >= t'+2t+1 is required for the old group. N=%d, t=%d, t'=%d", oldSize, oldDegree, newDegree)
+	}
+
+	if newSize < 3*newDegree+1 {
+		return fmt.Errorf("N' >= 3t'+1 is required for the new group. N'=%d, t'=%d", newSize, newDegree)
+	}
+
+	return nil
+}
+
 func BuildConfig(polydegree int, prime *gmp.Int, oldGroup, newGroup []int64) PublicParameter {
+	return buildHandoffConfig(polydegree, polydegree, prime, oldGroup, newGroup)
+}
+
+// BuildHandoffConfig builds the parameters of a handoff from a degree oldDegree sharing held by oldGroup
+// to a degree newDegree sharing held by newGroup. The threshold can only grow, i.e., newDegree >= oldDegree.
+func BuildHandoffConfig(oldDegree, newDegree int, prime *gmp.Int, oldGroup, newGroup []int64) (PublicParameter, error) {
+	if newDegree < oldDegree {
+		return PublicParameter{}, fmt.Errorf("can't lower the degree from %d to %d", oldDegree, newDegree)
+	}
+
+	return buildHandoffConfig(oldDegree, newDegree, prime, oldGroup, newGroup), nil
+}
+
+func buildHandoffConfig(oldDegree, newDegree int, prime *gmp.Int, oldGroup, newGroup []int64) PublicParameter {
 	return PublicParameter{
-		degree:   polydegree,
-		prime:    prime,
-		oldGroup: oldGroup,
-		newGroup: newGroup,
+		degree:    oldDegree,
+		newDegree: newDegree,
+		prime:     prime,
+		oldGroup:  oldGroup,
+		newGroup:  newGroup,
+		session:   sessionID(oldDegree, newDegree, prime, oldGroup, newGroup),
 	}
 }
@@ -0,0 +1,119 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"./services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PullProposal hands out a whole proposal to an old node that missed its slice. Only the proposer
+// holds it at first, but every node that pulls it, or gets it revealed by the board, keeps it and can
+// pass it on. The slices pushed to a node are of no use to the others, and aren't handed out.
+func (node *Node) PullProposal(ctx context.Context, req *services.ProposalRequest) (*services.Proposal, error) {
+	if err := node.creds.authorizeRole(ctx, RoleNode); err != nil {
+		return nil, err
+	}
+
+	proposal, ok := node.wholeProposal(Epoch(req.Epoch), req.Proposer)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no proposal from %d for epoch %d", req.Proposer, req.Epoch)
+	}
+
+	return proposal, nil
+}
+
+// pullProposal fetches the listed proposal of a proposer, first from the proposer itself and then
+// from the other members of the old group. It returns the whole proposal as the proposer signed it, so
+// that a complaint about our slice of it carries the proposer's signature.
+func (node *Node) pullProposal(ctx context.Context, epoch Epoch, proposer int64, hashRef Hash) (*services.Proposal, error) {
+	peers := []int64{proposer}
+	for _, id := range node.config.oldGroup {
+		if id != proposer && id != node.id {
+			peers = append(peers, id)
+		}
+	}
+
+	req := &services.ProposalRequest{Epoch: int32(epoch), Proposer: proposer}
+
+	for _, id := range peers {
+		client, ok := node.nodes[NewNodeID(id)]
+		if !ok {
+			continue
+		}
+
+		msg, err := client.PullProposal(ctx, req)
+		if err != nil {
+			node.log.Debugf("can't pull the proposal from %d off %d: %s", proposer, id, status.Convert(err).Message())
+			continue
+		}
+
+		proposal, err := node.checkPulledProposal(epoch, proposer, hashRef, msg)
+		if err != nil {
+			node.log.Warnf("%d handed out a bad proposal from %d: %s", id, proposer, err.Error())
+			continue
+		}
+
+		// the listed proposal is the same from every peer. An invalid one is only evidence against
+		// its proposer, and isn't passed on.
+		if err := proposal.Verify(node.config, epoch); err != nil {
+			node.log.Warnf("the listed proposal from %d is invalid: %s", proposer, err.Error())
+			return msg, nil
+		}
+
+		// pass it on to the peers that missed it too
+		node.storeWholeProposal(epoch, msg)
+
+		return msg, nil
+	}
+
+	return nil, fmt.Errorf("no peer has the proposal from %d", proposer)
+}
+
+// checkPulledProposal makes sure a pulled proposal is the listed one, signed by its proposer. It doesn't
+// tell whether the proposal is valid.
+func (node *Node) checkPulledProposal(epoch Epoch, proposer int64, hashRef Hash, msg *services.Proposal) (Proposal, error) {
+	if Epoch(msg.Epoch) != epoch || msg.From != proposer {
+		return Proposal{}, fmt.Errorf("got the proposal from %d for epoch %d", msg.From, msg.Epoch)
+	}
+
+	if err := node.checkSignature(msg); err != nil {
+		return Proposal{}, err
+	}
+
+	proposal, err := ProposalFromMessage(msg, node.config)
+	if err != nil {
+		return Proposal{}, err
+	}
+
+	if !hashRef.Equal(proposal.Hash()) {
+		return Proposal{}, fmt.Errorf("not the listed proposal")
+	}
+
+	return proposal, nil
+}
+
+// pullProposals pulls the given listed proposals in the background. They are delivered whole, along
+// with the pushed slices, and checked like them.
+func (node *Node) pullProposals(epoch Epoch, listed map[int64]Hash, proposers []int64, limit time.Duration) {
+	for _, proposer := range proposers {
+		node.log.Infof("pulling the proposal from %d", proposer)
+
+		proposer := proposer
+		node.clock.Go(func() {
+			ctx, cancel := node.clock.WithTimeout(context.Background(), limit)
+			defer cancel()
+
+			proposal, err := node.pullProposal(ctx, epoch, proposer, listed[proposer])
+			if err != nil {
+				node.log.Warnf("can't pull a proposal: %s", err.Error())
+				return
+			}
+
+			node.proposals.put(epoch, proposer, proposal)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package Schultz
+
+import (
+	"context"
+	"testing"
+
+	"./services"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNode_PullProposal(t *testing.T) {
+	pp, _ := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	pp, keys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := BuildNode(pp, logger, 2, "", "", nil, nil)
+
+	p := GenerateProposal(pp, 1)
+	msg := p.Message(1)
+	signMessage(keys[1], msg)
+
+	// a peer only hands out what it has
+	req := &services.ProposalRequest{Epoch: 1, Proposer: 1}
+	_, err := node.PullProposal(context.Background(), req)
+	assert.NotNil(t, err)
+
+	node.storeWholeProposal(1, msg)
+	pulled, err := node.PullProposal(context.Background(), req)
+	assert.Nil(t, err)
+	assert.Equal(t, msg, pulled)
+
+	// the puller checks it is the listed proposal, and cuts its own slice out of it
+	_, err = node.checkPulledProposal(1, 1, p.Hash(), pulled)
+	assert.Nil(t, err)
+
+	decoded, err := sliceFromMessage(pulled, pp, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, OldNodeID(2), decoded.GetRecipient())
+
+	// but nothing else
+	_, err = node.checkPulledProposal(1, 1, GenerateProposal(pp, 1).Hash(), pulled)
+	assert.NotNil(t, err)
+
+	_, err = node.checkPulledProposal(1, 3, p.Hash(), pulled)
+	assert.NotNil(t, err)
+
+	forged := p.Message(1)
+	signMessage(keys[3], forged)
+	_, err = node.checkPulledProposal(1, 1, p.Hash(), forged)
+	assert.NotNil(t, err)
+}
+
+func TestNode_PullRevealedProposal(t *testing.T) {
+	pp, _ := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	pp, keys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := BuildNode(pp, logger, 2, "", "", nil, nil)
+
+	listed := GenerateProposal(pp, 1)
+	msg := listed.Message(1)
+	signMessage(keys[1], msg)
+
+	offList := GenerateProposal(pp, 1)
+	other := offList.Message(3)
+	signMessage(keys[3], other)
+
+	hash := listed.Hash()
+	finalList := &services.ProposalHashList{
+		Epoch: 1,
+		List: []*services.ProposalHash{
+			{Epoch: 1, Proposer: 1, Hash: hash[:]},
+			{Epoch: 1, Proposer: 3, Hash: hash[:]},
+		},
+		Revealed: []*services.Proposal{msg, other},
+	}
+
+	valid := make(map[int64]*ProposalSlice)
+	assert.Nil(t, node.takeRevealed(finalList, valid))
+
+	// a proposal the board revealed is handed out to the peers that missed it
+	pulled, err := node.PullProposal(context.Background(), &services.ProposalRequest{Epoch: 1, Proposer: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, msg, pulled)
+
+	// but not one that isn't the listed proposal
+	_, err = node.PullProposal(context.Background(), &services.ProposalRequest{Epoch: 1, Proposer: 3})
+	assert.NotNil(t, err)
+
+	// peers still finishing an epoch can pull from it once we moved on
+	next := GenerateProposal(pp, 2).Message(2)
+	signMessage(keys[2], next)
+	node.storeWholeProposal(2, next)
+
+	_, err = node.PullProposal(context.Background(), &services.ProposalRequest{Epoch: 1, Proposer: 1})
+	assert.Nil(t, err)
+
+	node.storeWholeProposal(3, next)
+	_, err = node.PullProposal(context.Background(), &services.ProposalRequest{Epoch: 1, Proposer: 1})
+	assert.NotNil(t, err)
+}
+
+func TestNode_PullInvalidProposal(t *testing.T) {
+	const epoch = Epoch(1)
+
+	pp, encryptionKeys := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	pp, keys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+	node := BuildNode(pp, logger, 2, "", "", nil, nil)
+	node.SetEncryptionKey(encryptionKeys[2])
+
+	// 1 lists and signs a well-formed proposal whose points for 2 fail their proof
+	msg := GenerateProposal(pp, epoch).Message(1)
+	for _, points := range msg.Points {
+		if points.Recipient == 2 {
+			points.Proof[len(points.Proof)-1] ^= 1
+		}
+	}
+	signMessage(keys[1], msg)
+
+	listed, err := ProposalFromMessage(msg, pp)
+	assert.Nil(t, err)
+	hash := listed.Hash()
+
+	// it is the listed proposal, but not a valid one
+	pulled, err := node.checkPulledProposal(epoch, 1, hash, msg)
+	assert.Nil(t, err)
+	assert.NotNil(t, pulled.Verify(pp, epoch))
+
+	// the complaint about it carries the proposal as 1 signed it
+	_, complaints := node.checkProposals(epoch, map[int64]Hash{1: hash}, map[int64]*services.Proposal{1: msg})
+	assert.Len(t, complaints, 1)
+	assert.Equal(t, msg, complaints[0].Proposal)
+
+	// which the board takes as evidence against 1
+	bb := BuildBulletinBoard(logger, "", nil, pp)
+	slice, err := bb.attachedSlice(2, 1, complaints[0].Proposal, hash)
+	assert.Nil(t, err)
+	assert.NotNil(t, bb.checkProposalFor(epoch, 2, slice, nil))
+}
@@ -0,0 +1,505 @@
+package Schultz
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"../../utils/conv"
+	"../../utils/polyring"
+	"./services"
+	"github.com/ncw/gmp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A holder that lost its share gets it back from the other holders, and nobody learns more than before.
+// Each helper i picks a mask polynomial Ri of the degree of the sharing with Ri(r) = 0, like the Rk of a
+// proposal, and hands Ri(j) to every other helper j, encrypted to j and relayed by the recovering node r.
+// Each helper then sends r its share plus the masks it got. These points lie on the sharing plus the sum
+// of the masks, which vanishes at r and hides the shares of the helpers everywhere else.
+
+// recoverySession is what a helper keeps between the two rounds of a recovery.
+type recoverySession struct {
+	session   []byte
+	epoch     Epoch
+	handedOff bool
+	// the mask of the helper at its own id
+	mask *gmp.Int
+}
+
+// recoveryContext is what the masks of a recovery are bound to.
+func recoveryContext(session []byte, recovering int64, epoch Epoch) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("mpss-recovery")
+	binary.Write(&buf, binary.BigEndian, recovering)
+	binary.Write(&buf, binary.BigEndian, int32(epoch))
+	buf.Write(session)
+
+	return buf.Bytes()
+}
+
+// heldConfig returns the parameters of the sharing the holders hold, before or after the first handoff.
+func (node *Node) heldConfig(handedOff bool) PublicParameter {
+	if handedOff {
+		return node.baseConfig.AfterHandoff()
+	}
+
+	return node.baseConfig
+}
+
+// heldState returns what the node held after the last epoch it finished, or nil before the first one.
+func (node *Node) heldState() *ShareState {
+	node.heldLock.Lock()
+	defer node.heldLock.Unlock()
+
+	return node.held
+}
+
+// RecoveryMasks is the first round of a recovery: the helper picks its mask polynomial and hands out
+// its points for the other helpers.
+func (node *Node) RecoveryMasks(ctx context.Context, req *services.RecoveryRequest) (*services.RecoveryMaskList, error) {
+	if err := node.creds.authorize(ctx, senderIdentity(req.From)); err != nil {
+		return nil, err
+	}
+
+	held := node.heldState()
+	if held == nil || held.Share == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "no share to help with")
+	}
+
+	config := node.heldConfig(held.HandedOff)
+	if !config.IsOldMember(req.From) || req.From == node.id {
+		return nil, status.Errorf(codes.PermissionDenied, "%d holds no share after epoch %d", req.From, held.Epoch)
+	}
+	if len(req.Session) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no session")
+	}
+
+	// Ri = g (x - r), of the degree of the sharing
+	mask, err := polyring.NewRand(config.degree-1, NewCryptoRand(), config.prime)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%s", err.Error())
+	}
+	mask.MulSelf(polyring.FromVec(-req.From, 1))
+	mask.Mod(config.prime)
+	defer erasePolynomial(mask)
+
+	own := gmp.NewInt(0)
+	mask.EvalMod(gmp.NewInt(node.id), config.prime, own)
+
+	list := &services.RecoveryMaskList{
+		Epoch:     int32(held.Epoch),
+		From:      node.id,
+		HandedOff: held.HandedOff,
+	}
+	bound := recoveryContext(req.Session, req.From, held.Epoch)
+
+	for _, j := range req.Helpers {
+		if j == node.id || j == req.From || !config.IsOldMember(j) || containsMask(list.Masks, j) {
+			continue
+		}
+
+		pk, ok := config.encryptionKeys[j]
+		if !ok {
+			return nil, status.Errorf(codes.FailedPrecondition, "no encryption key for %d", j)
+		}
+
+		point := gmp.NewInt(0)
+		mask.EvalMod(gmp.NewInt(j), config.prime, point)
+
+		encrypted, err := encryptPoints(pk, OldNodeID(j), PointsOnBlindingPoly{points: map[NewNodeID]*gmp.Int{NewNodeID(req.From): point}}, config.prime, bound)
+		eraseInt(point)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "%s", err.Error())
+		}
+		encoded, err := encrypted.toMessage(OldNodeID(j))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "%s", err.Error())
+		}
+
+		msg := &services.RecoveryMask{
+			Epoch:   int32(held.Epoch),
+			From:    node.id,
+			To:      j,
+			Session: req.Session,
+			Mask:    encoded,
+		}
+		node.sign(msg)
+
+		list.Masks = append(list.Masks, msg)
+	}
+
+	// a new recovery of the same node replaces the old one
+	node.recoveriesLock.Lock()
+	if old, ok := node.recoveries[req.From]; ok {
+		eraseInt(old.mask)
+	}
+	node.recoveries[req.From] = &recoverySession{
+		session:   req.Session,
+		epoch:     held.Epoch,
+		handedOff: held.HandedOff,
+		mask:      own,
+	}
+	node.recoveriesLock.Unlock()
+
+	return list, nil
+}
+
+func containsMask(masks []*services.RecoveryMask, to int64) bool {
+	for _, msg := range masks {
+		if msg.To == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// takeRecoverySession returns the first round of the recovery of a node, once.
+func (node *Node) takeRecoverySession(recovering int64, session []byte) *recoverySession {
+	node.recoveriesLock.Lock()
+	defer node.recoveriesLock.Unlock()
+
+	s, ok := node.recoveries[recovering]
+	if !ok || !bytes.Equal(s.session, session) {
+		return nil
+	}
+	delete(node.recoveries, recovering)
+
+	return s
+}
+
+// openRecoveryMask checks a mask of another helper and decrypts it.
+func (node *Node) openRecoveryMask(config PublicParameter, recovering int64, s *recoverySession, msg *services.RecoveryMask) (*gmp.Int, error) {
+	if msg.To != node.id || Epoch(msg.Epoch) != s.epoch || !bytes.Equal(msg.Session, s.session) {
+		return nil, fmt.Errorf("the mask from %d is not for this recovery", msg.From)
+	}
+	if msg.From == node.id || !config.IsOldMember(msg.From) {
+		return nil, fmt.Errorf("%d holds no share", msg.From)
+	}
+	if err := node.checkSignature(msg); err != nil {
+		return nil, err
+	}
+	if node.encryptionKey == nil {
+		return nil, fmt.Errorf("no key to decrypt the masks with")
+	}
+
+	to, encrypted, err := encryptedPointsFromMessage(msg.Mask, []int64{recovering}, config.prime)
+	if err != nil {
+		return nil, err
+	}
+	if int64(to) != node.id {
+		return nil, fmt.Errorf("the mask from %d is encrypted to %d", msg.From, to)
+	}
+	if err := encrypted.verify(OldNodeID(node.id), recoveryContext(s.session, recovering, s.epoch)); err != nil {
+		return nil, err
+	}
+
+	points, err := encrypted.decrypt(node.encryptionKey, OldNodeID(node.id), config.prime)
+	if err != nil {
+		return nil, err
+	}
+
+	mask, ok := points.points[NewNodeID(recovering)]
+	if !ok || len(points.points) != 1 {
+		return nil, fmt.Errorf("the mask from %d is not for %d", msg.From, recovering)
+	}
+
+	return mask, nil
+}
+
+// RecoverShare is the second round of a recovery: the helper adds the masks of the others to its own and
+// to its share. The result is worth nothing without the points of enough other helpers.
+func (node *Node) RecoverShare(ctx context.Context, req *services.RecoverShareRequest) (*services.RecoveredPoint, error) {
+	if err := node.creds.authorize(ctx, senderIdentity(req.From)); err != nil {
+		return nil, err
+	}
+
+	s := node.takeRecoverySession(req.From, req.Session)
+	if s == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "no recovery of %d under way", req.From)
+	}
+	defer eraseInt(s.mask)
+
+	config := node.heldConfig(s.handedOff)
+
+	total := gmp.NewInt(0).Set(s.mask)
+	defer eraseInt(total)
+
+	helpers := []int64{node.id}
+	for _, msg := range req.Masks {
+		if contains(helpers, msg.From) {
+			return nil, status.Errorf(codes.InvalidArgument, "two masks from %d", msg.From)
+		}
+
+		mask, err := node.openRecoveryMask(config, req.From, s, msg)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err.Error())
+		}
+
+		total.Add(total, mask)
+		eraseInt(mask)
+		helpers = append(helpers, msg.From)
+	}
+
+	// too few masks would leave the share of the helper open to the recovering node
+	if len(helpers) < config.degree+1 {
+		return nil, status.Errorf(codes.FailedPrecondition, "masks from %d helpers, need %d", len(helpers), config.degree+1)
+	}
+	sort.Slice(helpers, func(i, j int) bool { return helpers[i] < helpers[j] })
+
+	node.heldLock.Lock()
+	held := node.held
+	if held == nil || held.Share == nil || held.Epoch != s.epoch {
+		node.heldLock.Unlock()
+		return nil, status.Errorf(codes.FailedPrecondition, "the sharing moved on since epoch %d", s.epoch)
+	}
+	value := gmp.NewInt(0).Add(held.Share, total)
+	commitment := held.Commitment
+	node.heldLock.Unlock()
+
+	value.Mod(value, config.prime)
+	defer eraseInt(value)
+
+	point := &services.RecoveredPoint{
+		Epoch:      int32(s.epoch),
+		From:       node.id,
+		Value:      value.Bytes(),
+		Commitment: encodeCommitment(commitment),
+		HandedOff:  s.handedOff,
+		Helpers:    helpers,
+	}
+	node.sign(point)
+
+	node.log.Infof("helped %d recover its share of epoch %d", req.From, s.epoch)
+
+	return point, nil
+}
+
+// pickHelpers returns the largest group of holders that answered for the same epoch.
+func pickHelpers(lists map[int64]*services.RecoveryMaskList) ([]int64, Epoch, bool) {
+	type sharing struct {
+		epoch     Epoch
+		handedOff bool
+	}
+
+	groups := make(map[sharing][]int64)
+	for id, list := range lists {
+		key := sharing{Epoch(list.Epoch), list.HandedOff}
+		groups[key] = append(groups[key], id)
+	}
+
+	var best sharing
+	for key, ids := range groups {
+		if len(ids) > len(groups[best]) || (len(ids) == len(groups[best]) && key.epoch > best.epoch) {
+			best = key
+		}
+	}
+
+	helpers := groups[best]
+	sort.Slice(helpers, func(i, j int) bool { return helpers[i] < helpers[j] })
+
+	return helpers, best.epoch, best.handedOff
+}
+
+// Recover gets the share of the node back from the other holders, for a node that lost it. It returns the
+// state after the last epoch the helpers finished. The peers have to be connected.
+func (node *Node) Recover(limit time.Duration) (*ShareState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), limit)
+	defer cancel()
+
+	session := make([]byte, 16)
+	if _, err := crand.Read(session); err != nil {
+		return nil, err
+	}
+
+	var peers []int64
+	for _, id := range node.baseConfig.Members() {
+		if _, ok := node.nodes[NewNodeID(id)]; ok && id != node.id {
+			peers = append(peers, id)
+		}
+	}
+
+	// first round: masks from every holder that answers
+	lists := make(map[int64]*services.RecoveryMaskList)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+
+	req := &services.RecoveryRequest{From: node.id, Session: session, Helpers: peers}
+	for _, id := range peers {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+
+			list, err := node.nodes[NewNodeID(id)].RecoveryMasks(ctx, req)
+			if err != nil {
+				node.log.Warnf("%d can't help: %s", id, status.Convert(err).Message())
+				return
+			}
+			if list.From != id {
+				node.log.Warnf("%d answered for %d", id, list.From)
+				return
+			}
+
+			lock.Lock()
+			lists[id] = list
+			lock.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	helpers, epoch, handedOff := pickHelpers(lists)
+	config := node.heldConfig(handedOff)
+	if !config.IsOldMember(node.id) {
+		return nil, fmt.Errorf("the node holds no share after epoch %d", epoch)
+	}
+	if need := node.pointsToRebuild(config); len(helpers) < need {
+		return nil, fmt.Errorf("only %d holders can help, need %d", len(helpers), need)
+	}
+
+	node.log.Infof("recovering the share of epoch %d from %v", epoch, helpers)
+
+	// second round: every helper gets the masks of the others
+	points := make(map[int64]*services.RecoveredPoint)
+	errs := make(chan error, len(helpers))
+
+	for _, j := range helpers {
+		shareReq := &services.RecoverShareRequest{From: node.id, Session: session}
+		for _, k := range helpers {
+			if k == j {
+				continue
+			}
+
+			for _, msg := range lists[k].Masks {
+				if msg.To == j {
+					shareReq.Masks = append(shareReq.Masks, msg)
+				}
+			}
+		}
+
+		wg.Add(1)
+		go func(j int64, shareReq *services.RecoverShareRequest) {
+			defer wg.Done()
+
+			point, err := node.nodes[NewNodeID(j)].RecoverShare(ctx, shareReq)
+			if err == nil {
+				err = node.checkRecoveredPoint(point, j, epoch, helpers)
+			}
+			if err != nil {
+				errs <- fmt.Errorf("%d: %s", j, status.Convert(err).Message())
+				return
+			}
+
+			lock.Lock()
+			points[j] = point
+			lock.Unlock()
+		}(j, shareReq)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		node.log.Warnf("a helper failed: %s", err.Error())
+	}
+
+	return node.rebuildShare(config, epoch, handedOff, points)
+}
+
+// checkRecoveredPoint makes sure a point comes from the helper and includes the masks of all the helpers.
+func (node *Node) checkRecoveredPoint(point *services.RecoveredPoint, from int64, epoch Epoch, helpers []int64) error {
+	if point.From != from || Epoch(point.Epoch) != epoch {
+		return fmt.Errorf("got the point of %d for epoch %d", point.From, point.Epoch)
+	}
+
+	if err := node.checkSignature(point); err != nil {
+		return err
+	}
+
+	if len(point.Helpers) != len(helpers) {
+		return fmt.Errorf("masks from %v instead of %v", point.Helpers, helpers)
+	}
+	for i := range helpers {
+		if point.Helpers[i] != helpers[i] {
+			return fmt.Errorf("masks from %v instead of %v", point.Helpers, helpers)
+		}
+	}
+
+	return nil
+}
+
+// pointsToRebuild is how many points of the helpers rebuilding a share takes. In production mode, the
+// share is checked against the commitment, which catches any wrong point, so t+1 are enough. Otherwise,
+// up to t wrong points have to be corrected, and Berlekamp-Welch only corrects e of them out of t+1+2e.
+func (node *Node) pointsToRebuild(config PublicParameter) int {
+	if node.mode == ModeProduction {
+		return config.degree + 1
+	}
+
+	return 3*config.degree + 1
+}
+
+// rebuildShare interpolates the points of the helpers at the id of the node, where the masks vanish.
+func (node *Node) rebuildShare(config PublicParameter, epoch Epoch, handedOff bool, points map[int64]*services.RecoveredPoint) (*ShareState, error) {
+	var Xs, Ys []*gmp.Int
+	commitments := make(map[int64][]byte)
+
+	for id, point := range points {
+		Xs = append(Xs, gmp.NewInt(id))
+		Ys = append(Ys, gmp.NewInt(0).SetBytes(point.Value))
+		commitments[id] = point.Commitment
+	}
+	defer func() {
+		for _, y := range Ys {
+			eraseInt(y)
+		}
+	}()
+
+	if need := node.pointsToRebuild(config); len(Xs) < need {
+		return nil, fmt.Errorf("can't rebuild the share: only %d points, need %d", len(Xs), need)
+	}
+
+	// out of n points, up to (n-t-1)/2 wrong ones are corrected
+	poly, wrong, err := DecodeReedSolomon(config.degree, Xs, Ys, config.prime)
+	if err == nil && len(Xs)-len(wrong) < config.degree+1 {
+		err = fmt.Errorf("only %d valid points", len(Xs)-len(wrong))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't rebuild the share: %s", err.Error())
+	}
+	defer erasePolynomial(poly)
+
+	for _, i := range wrong {
+		node.log.Warnf("wrong point from %s", Xs[i].String())
+	}
+
+	share := gmp.NewInt(0)
+	poly.EvalMod(gmp.NewInt(node.id), config.prime, share)
+
+	state := &ShareState{
+		Epoch:     epoch,
+		Share:     share,
+		HandedOff: handedOff,
+	}
+
+	if node.mode == ModeProduction {
+		// an honest helper is enough to vouch for the commitment
+		commitment, err := majorityCommitment(commitments, config.degree+1, config.degree)
+		if err != nil {
+			return nil, fmt.Errorf("no agreed commitment to the sharing: %s", err.Error())
+		}
+		if !commitment.VerifyEval(big.NewInt(node.id), conv.GmpInt2BigInt(share)) {
+			return nil, fmt.Errorf("the rebuilt share is not on the committed polynomial")
+		}
+
+		state.Commitment = commitment
+	}
+
+	return state, nil
+}
@@ -0,0 +1,146 @@
+package Schultz
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"../../utils/polyring"
+	"./services"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// localPeer runs the recovery rounds of a node in process.
+type localPeer struct {
+	services.NodeClient
+	node *Node
+}
+
+func (p localPeer) RecoveryMasks(ctx context.Context, in *services.RecoveryRequest, opts ...grpc.CallOption) (*services.RecoveryMaskList, error) {
+	return p.node.RecoveryMasks(ctx, in)
+}
+
+func (p localPeer) RecoverShare(ctx context.Context, in *services.RecoverShareRequest, opts ...grpc.CallOption) (*services.RecoveredPoint, error) {
+	return p.node.RecoverShare(ctx, in)
+}
+
+func TestNode_Recover(t *testing.T) {
+	pp, encryptionKeys := withEncryptionKeys(BuildConfig(2, FieldPrime, makeOneToN(7), makeOneToN(7)))
+	pp, identityKeys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	secretPoly, err := polyring.NewRand(pp.GetDegree(), rand.New(rand.NewSource(0)), pp.GetPrime())
+	assert.Nil(t, err)
+	commitment := NewPolyCommit(secretPoly)
+
+	nodes := make(map[int64]*Node)
+	for _, id := range pp.Members() {
+		share := gmp.NewInt(0)
+		secretPoly.EvalMod(gmp.NewInt(id), pp.GetPrime(), share)
+
+		node := BuildNode(pp, logger, id, "", "", nil, share)
+		node.SetModeOption(ModeProduction)
+		node.SetEncryptionKey(encryptionKeys[id])
+		node.SetIdentityKey(identityKeys[id])
+		node.secretCommitment = commitment
+		node.saveState(3, nil)
+
+		nodes[id] = &node
+	}
+
+	// node 7 lost its share
+	lost := nodes[7]
+	lost.share = nil
+	for id, node := range nodes {
+		if id != 7 {
+			lost.nodes[NewNodeID(id)] = localPeer{node: node}
+		}
+	}
+
+	// a wrong share among six helpers is corrected
+	nodes[1].held.Share = gmp.NewInt(42)
+
+	state, err := lost.Recover(time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, Epoch(3), state.Epoch)
+	assert.False(t, state.HandedOff)
+
+	want := gmp.NewInt(0)
+	secretPoly.EvalMod(gmp.NewInt(7), pp.GetPrime(), want)
+	assert.Equal(t, 0, state.Share.Cmp(want))
+	assert.Equal(t, encodeCommitment(commitment), encodeCommitment(state.Commitment))
+
+	// the helpers forget a recovery once it is done
+	_, err = nodes[2].RecoverShare(context.Background(), &services.RecoverShareRequest{From: 7})
+	assert.NotNil(t, err)
+}
+
+func TestNode_RecoveryNeedsEnoughMasks(t *testing.T) {
+	pp, encryptionKeys := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), []int64{3, 4, 5, 6}))
+	pp, identityKeys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	helper := BuildNode(pp, logger, 1, "", "", nil, gmp.NewInt(5))
+	helper.SetEncryptionKey(encryptionKeys[1])
+	helper.SetIdentityKey(identityKeys[1])
+	helper.saveState(0, nil)
+
+	// only holders can recover
+	_, err := helper.RecoveryMasks(context.Background(), &services.RecoveryRequest{From: 5, Session: []byte{1}, Helpers: []int64{1, 2}})
+	assert.NotNil(t, err)
+
+	list, err := helper.RecoveryMasks(context.Background(), &services.RecoveryRequest{From: 4, Session: []byte{1}, Helpers: []int64{1, 2, 3}})
+	assert.Nil(t, err)
+	assert.Len(t, list.Masks, 2)
+
+	// its own mask alone doesn't hide the share of the helper
+	_, err = helper.RecoverShare(context.Background(), &services.RecoverShareRequest{From: 4, Session: []byte{1}})
+	assert.NotNil(t, err)
+}
+
+func TestNode_RebuildNeedsEnoughPoints(t *testing.T) {
+	pp := BuildConfig(1, FieldPrime, makeOneToN(5), makeOneToN(5))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	secretPoly, err := polyring.NewRand(pp.GetDegree(), rand.New(rand.NewSource(0)), pp.GetPrime())
+	assert.Nil(t, err)
+
+	pointsOf := func(helpers ...int64) map[int64]*services.RecoveredPoint {
+		points := make(map[int64]*services.RecoveredPoint)
+		for _, id := range helpers {
+			value := gmp.NewInt(0)
+			secretPoly.EvalMod(gmp.NewInt(id), pp.GetPrime(), value)
+			points[id] = &services.RecoveredPoint{From: id, Value: value.Bytes()}
+		}
+
+		// the first helper lies
+		points[helpers[0]].Value = gmp.NewInt(42).Bytes()
+
+		return points
+	}
+
+	// without a commitment to check the share against, a wrong point out of t+1 would go unnoticed
+	node := BuildNode(pp, logger, 5, "", "", nil, nil)
+	_, err = node.rebuildShare(pp, 3, false, pointsOf(1, 2))
+	assert.NotNil(t, err)
+	_, err = node.rebuildShare(pp, 3, false, pointsOf(1, 2, 3))
+	assert.NotNil(t, err)
+
+	// t+1+2t points correct t wrong ones
+	state, err := node.rebuildShare(pp, 3, false, pointsOf(1, 2, 3, 4))
+	assert.Nil(t, err)
+
+	want := gmp.NewInt(0)
+	secretPoly.EvalMod(gmp.NewInt(5), pp.GetPrime(), want)
+	assert.Equal(t, 0, state.Share.Cmp(want))
+}
@@ -0,0 +1,935 @@
+package Schultz
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// how long the leader of a view has to get a list committed before the replicas move on to the next view.
+// Doubles with every view change.
+const defaultViewTimeout = 2 * time.Second
+
+// ReplicaSet is the public information about the replicas of the bulletin board: their addresses and keys.
+// n >= 3f+1 replicas tolerate f faulty ones, and n-f signatures make a quorum: any two quorums share
+// at least n-2f >= f+1 replicas, so an honest one, and the honest replicas alone make a quorum.
+type ReplicaSet struct {
+	ids  []int64
+	urls map[int64]string
+	keys map[int64]ed25519.PublicKey
+}
+
+func BuildReplicaSet(configs map[string]ReplicaConfig) (*ReplicaSet, error) {
+	rs := &ReplicaSet{
+		urls: make(map[int64]string),
+		keys: make(map[int64]ed25519.PublicKey),
+	}
+
+	for name, cf := range configs {
+		if _, ok := rs.keys[cf.Id]; ok {
+			return nil, fmt.Errorf("replica id %d appears twice", cf.Id)
+		}
+
+		key, err := ParseIdentityKey(cf.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("bad public key for replica %s", name)
+		}
+
+		rs.ids = append(rs.ids, cf.Id)
+		rs.urls[cf.Id] = cf.Url
+		rs.keys[cf.Id] = key
+	}
+
+	if len(rs.ids) == 0 {
+		return nil, fmt.Errorf("no replicas")
+	}
+
+	sort.Slice(rs.ids, func(i, j int) bool { return rs.ids[i] < rs.ids[j] })
+
+	return rs, nil
+}
+
+func (rs *ReplicaSet) faulty() int {
+	return (len(rs.ids) - 1) / 3
+}
+
+// Quorum is the number of replica signatures a hash list needs.
+func (rs *ReplicaSet) Quorum() int {
+	return len(rs.ids) - rs.faulty()
+}
+
+func (rs *ReplicaSet) leader(epoch Epoch, view int32) int64 {
+	return rs.ids[(int(epoch)+int(view))%len(rs.ids)]
+}
+
+func (rs *ReplicaSet) verify(replica int64, msg, sig []byte) bool {
+	key, ok := rs.keys[replica]
+	return ok && ed25519.Verify(key, msg, sig)
+}
+
+// countSignatures returns the number of distinct replicas with a valid signature over msg made in the given view.
+func (rs *ReplicaSet) countSignatures(sigs []*services.ReplicaSignature, view int32, msg []byte) int {
+	signers := make(map[int64]bool)
+	for _, s := range sigs {
+		if s.View != view || signers[s.Replica] {
+			continue
+		}
+
+		if rs.verify(s.Replica, msg, s.Signature) {
+			signers[s.Replica] = true
+		}
+	}
+
+	return len(signers)
+}
+
+// VerifyCertificate checks that a quorum of replicas committed to the hash list.
+func (rs *ReplicaSet) VerifyCertificate(list *services.ProposalHashList) error {
+	if len(list.Certificate) == 0 {
+		return fmt.Errorf("no certificate")
+	}
+
+	view := list.Certificate[0].View
+	digest := digestHashList(list)
+	msg := signingBytes(services.ConsensusMessage_COMMIT, Epoch(list.Epoch), view, 0, digest[:])
+
+	if n := rs.countSignatures(list.Certificate, view, msg); n < rs.Quorum() {
+		return fmt.Errorf("only %d valid signatures, %d needed", n, rs.Quorum())
+	}
+
+	return nil
+}
+
+// digestHashList hashes the epoch and the ordered proposal hashes of a list.
+// Revealed proposals and the certificate are left out.
+func digestHashList(list *services.ProposalHashList) Hash {
+	h := sha256.New()
+
+	var buf [8]byte
+	binary.BigEndian.PutUint32(buf[:4], uint32(list.Epoch))
+	h.Write(buf[:4])
+
+	for _, ph := range list.List {
+		binary.BigEndian.PutUint64(buf[:], uint64(ph.Proposer))
+		h.Write(buf[:])
+		binary.BigEndian.PutUint32(buf[:4], uint32(len(ph.Hash)))
+		h.Write(buf[:4])
+		h.Write(ph.Hash)
+	}
+
+	var digest Hash
+	copy(digest[:], h.Sum(nil))
+
+	return digest
+}
+
+// signingBytes is what a replica signs for a consensus message.
+func signingBytes(t services.ConsensusMessage_Type, epoch Epoch, view, preparedView int32, digest []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("mpss-bulletin-board")
+
+	var tmp [4]byte
+	for _, v := range []uint32{uint32(t), uint32(epoch), uint32(view), uint32(preparedView)} {
+		binary.BigEndian.PutUint32(tmp[:], v)
+		buf.Write(tmp[:])
+	}
+	buf.Write(digest)
+
+	return buf.Bytes()
+}
+
+func consensusSigningBytes(msg *services.ConsensusMessage) []byte {
+	return signingBytes(msg.Type, Epoch(msg.Epoch), msg.View, msg.PreparedView, msg.Digest)
+}
+
+// replicaTransport carries the messages of a replica to the other replicas,
+// and the certified hash lists to the learners (the primary and the old group).
+type replicaTransport interface {
+	sendToReplica(id int64, msg *services.ConsensusMessage)
+	deliver(config PublicParameter, list *services.ProposalHashList)
+}
+
+type grpcReplicaTransport struct {
+	replicas map[int64]services.ReplicaServiceClient
+	nodes    map[NewNodeID]services.NodeClient
+	primary  services.BulletinBoardServiceClient
+
+	log *logrus.Entry
+}
+
+func (t *grpcReplicaTransport) sendToReplica(id int64, msg *services.ConsensusMessage) {
+	client, ok := t.replicas[id]
+	if !ok {
+		t.log.Errorf("can't find the replica client for %d", id)
+		return
+	}
+
+	// faulty replicas are tolerated, so just log the error
+	go func() {
+		_, err := client.Consensus(context.Background(), msg, grpc.WaitForReady(true))
+		if err != nil {
+			t.log.Warnf("can't reach replica %d: %s", id, err.Error())
+		}
+	}()
+}
+
+func (t *grpcReplicaTransport) deliver(config PublicParameter, list *services.ProposalHashList) {
+	ctx := context.Background()
+
+	go func() {
+		_, err := t.primary.SubmitProposalHashList(ctx, list, grpc.WaitForReady(true))
+		if err != nil {
+			t.log.Warnf("can't deliver the hash list to the primary: %s", err.Error())
+		}
+	}()
+
+	for _, id := range config.oldGroup {
+		node, ok := t.nodes[NewNodeID(id)]
+		if !ok {
+			t.log.Errorf("can't find the node client for %d", id)
+			continue
+		}
+
+		go func(id int64) {
+			_, err := node.StartCheckingProposals(ctx, list, grpc.WaitForReady(true))
+			if err != nil {
+				t.log.Warnf("can't deliver the hash list to %d: %s", id, err.Error())
+			}
+		}(id)
+	}
+}
+
+// Replica is one replica of the bulletin board. The replicas order the proposal hashes of each epoch
+// with PBFT: the leader of a view picks 2t+1 hashes and the replicas prepare and commit the list.
+// The commit signatures of a quorum certify the list, and nodes accept only certified lists.
+// If the leader fails to get a list committed in time, the replicas move on to the next view,
+// carrying over any list that may have been committed.
+type Replica struct {
+	id       int64
+	key      ed25519.PrivateKey
+	replicas *ReplicaSet
+	config   PublicParameter
+
+	transport   replicaTransport
+	viewTimeout time.Duration
+	// TLS certificate of the replica. If nil, connections are plain.
+	creds *Credentials
+	// how the replica reaches the other parties
+	network Transport
+
+	// all input is handled by Run, one event at a time
+	events chan interface{}
+
+	slots  map[Epoch]*slot
+	oldest Epoch
+
+	// logging
+	log *logrus.Entry
+}
+
+type viewTimer struct {
+	epoch Epoch
+	view  int32
+}
+
+// epochAborted tells the replica the board aborted an epoch.
+type epochAborted Epoch
+
+type voteKey struct {
+	view   int32
+	digest Hash
+}
+
+// slot is the state of the ordering of one epoch.
+type slot struct {
+	epoch Epoch
+	view  int32
+
+	// proposal hashes in the order they arrived
+	hashes    []*services.ProposalHash
+	proposers map[int64]bool
+
+	// the list accepted in the current view
+	list   *services.ProposalHashList
+	digest Hash
+
+	// every valid list seen, by digest
+	lists map[Hash]*services.ProposalHashList
+
+	prepares map[voteKey]map[int64][]byte
+	commits  map[voteKey]map[int64][]byte
+
+	proposed       map[int32]bool
+	sentCommit     map[int32]bool
+	sentViewChange map[int32]bool
+	viewChanges    map[int32]map[int64]*services.ConsensusMessage
+
+	// the last list prepared by this replica, with the prepares proving it
+	preparedView int32
+	preparedList *services.ProposalHashList
+	preparedSigs []*services.ReplicaSignature
+
+	decided bool
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *Replica) SubmitProposalHash(ctx context.Context, hash *services.ProposalHash) (*services.Empty, error) {
+	if err := r.creds.authorize(ctx, NodeIdentity(hash.Proposer)); err != nil {
+		return nil, err
+	}
+	if err := r.config.identityKeys.verify(hash); err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%s", err.Error())
+	}
+
+	r.events <- hash
+
+	return &services.Empty{}, nil
+}
+
+func (r *Replica) Consensus(ctx context.Context, msg *services.ConsensusMessage) (*services.Empty, error) {
+	if err := r.creds.authorize(ctx, ReplicaIdentity(msg.Replica)); err != nil {
+		return nil, err
+	}
+
+	r.events <- msg
+
+	return &services.Empty{}, nil
+}
+
+// Run handles the incoming messages. It never returns.
+func (r *Replica) Run() {
+	for ev := range r.events {
+		switch ev := ev.(type) {
+		case *services.ProposalHash:
+			r.onProposalHash(ev)
+		case *services.ConsensusMessage:
+			r.onConsensusMessage(ev)
+		case viewTimer:
+			r.onViewTimer(ev)
+		case epochAborted:
+			// an aborted first handoff is retried at the next epoch
+			r.config = r.config.AfterAbort(Epoch(ev))
+		}
+	}
+}
+
+// listSize is the number of proposal hashes in the list of the given epoch.
+func (r *Replica) listSize(epoch Epoch) int {
+	return 2*r.config.ForEpoch(epoch).degree + 1
+}
+
+// slot returns the state of the given epoch, or nil if it has been dropped already.
+func (r *Replica) slot(epoch Epoch) *slot {
+	if epoch < r.oldest {
+		return nil
+	}
+
+	s, ok := r.slots[epoch]
+	if ok {
+		return s
+	}
+
+	// keep the previous epoch around for late messages
+	if epoch-1 > r.oldest {
+		r.oldest = epoch - 1
+		for e := range r.slots {
+			if e < r.oldest {
+				delete(r.slots, e)
+			}
+		}
+	}
+
+	s = &slot{
+		epoch:          epoch,
+		proposers:      make(map[int64]bool),
+		lists:          make(map[Hash]*services.ProposalHashList),
+		prepares:       make(map[voteKey]map[int64][]byte),
+		commits:        make(map[voteKey]map[int64][]byte),
+		proposed:       make(map[int32]bool),
+		sentCommit:     make(map[int32]bool),
+		sentViewChange: make(map[int32]bool),
+		viewChanges:    make(map[int32]map[int64]*services.ConsensusMessage),
+		preparedView:   -1,
+		timeout:        r.viewTimeout,
+	}
+	r.slots[epoch] = s
+
+	return s
+}
+
+func (r *Replica) onProposalHash(ph *services.ProposalHash) {
+	epoch := Epoch(ph.Epoch)
+
+	if !r.config.ForEpoch(epoch).IsOldMember(ph.Proposer) {
+		r.log.Warnf("ignoring a proposal hash from %d, which is not in the old group", ph.Proposer)
+		return
+	}
+	if err := checkProposalHash(ph); err != nil {
+		r.log.Warnf("ignoring a proposal hash: %s", err.Error())
+		return
+	}
+
+	s := r.slot(epoch)
+	if s == nil || s.decided || s.proposers[ph.Proposer] {
+		return
+	}
+
+	s.proposers[ph.Proposer] = true
+	s.hashes = append(s.hashes, ph)
+
+	r.log.Debugf("received a proposal hash from %d (%d received)", ph.Proposer, len(s.hashes))
+
+	if len(s.hashes) == r.listSize(epoch) {
+		// the epoch is under way. The leader has to get a list committed in time.
+		if s.view == 0 {
+			r.startTimer(s)
+		}
+		r.maybePropose(s)
+	}
+}
+
+func (r *Replica) startTimer(s *slot) {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	t := viewTimer{epoch: s.epoch, view: s.view}
+	s.timer = time.AfterFunc(s.timeout, func() { r.events <- t })
+}
+
+func (r *Replica) onViewTimer(t viewTimer) {
+	s, ok := r.slots[t.epoch]
+	if !ok || s.decided || s.view != t.view {
+		return
+	}
+
+	r.log.Warnf("view %d of epoch %d timed out", t.view, t.epoch)
+	r.startViewChange(s, t.view+1)
+}
+
+// broadcast signs msg and sends it to every replica, including this one.
+func (r *Replica) broadcast(msg *services.ConsensusMessage) {
+	msg.Replica = r.id
+	msg.Signature = ed25519.Sign(r.key, consensusSigningBytes(msg))
+
+	for _, id := range r.replicas.ids {
+		if id != r.id {
+			r.transport.sendToReplica(id, msg)
+		}
+	}
+
+	r.onConsensusMessage(msg)
+}
+
+// checkList checks that list is a well-formed list for the epoch with the given digest.
+func (r *Replica) checkList(epoch Epoch, list *services.ProposalHashList, digest []byte) error {
+	if list == nil {
+		return fmt.Errorf("no list")
+	}
+
+	if Epoch(list.Epoch) != epoch {
+		return fmt.Errorf("list for epoch %d", list.Epoch)
+	}
+
+	if len(list.List) != r.listSize(epoch) {
+		return fmt.Errorf("%d hashes in the list", len(list.List))
+	}
+
+	if len(list.Revealed) > 0 || len(list.Certificate) > 0 {
+		return fmt.Errorf("unexpected fields in the list")
+	}
+
+	config := r.config.ForEpoch(epoch)
+	seen := make(map[int64]bool)
+	for _, ph := range list.List {
+		if Epoch(ph.Epoch) != epoch || !config.IsOldMember(ph.Proposer) || seen[ph.Proposer] {
+			return fmt.Errorf("bad hash from %d", ph.Proposer)
+		}
+		if err := checkProposalHash(ph); err != nil {
+			return err
+		}
+		if err := config.identityKeys.verify(ph); err != nil {
+			return err
+		}
+		seen[ph.Proposer] = true
+	}
+
+	d := digestHashList(list)
+	if !bytes.Equal(d[:], digest) {
+		return fmt.Errorf("wrong digest")
+	}
+
+	return nil
+}
+
+// checkViewChange checks the prepared certificate carried by a view-change, if any.
+func (r *Replica) checkViewChange(epoch Epoch, msg *services.ConsensusMessage) error {
+	if msg.View <= 0 {
+		return fmt.Errorf("view-change to view %d", msg.View)
+	}
+
+	if msg.List == nil {
+		return nil
+	}
+
+	if msg.PreparedView < 0 || msg.PreparedView >= msg.View {
+		return fmt.Errorf("prepared in view %d", msg.PreparedView)
+	}
+
+	if err := r.checkList(epoch, msg.List, msg.Digest); err != nil {
+		return err
+	}
+
+	prepareMsg := signingBytes(services.ConsensusMessage_PREPARE, epoch, msg.PreparedView, 0, msg.Digest)
+	if r.replicas.countSignatures(msg.Prepares, msg.PreparedView, prepareMsg) < r.replicas.Quorum() {
+		return fmt.Errorf("not enough prepares")
+	}
+
+	return nil
+}
+
+func (r *Replica) onConsensusMessage(msg *services.ConsensusMessage) {
+	if !r.replicas.verify(msg.Replica, consensusSigningBytes(msg), msg.Signature) {
+		r.log.Warnf("ignoring a consensus message with a bad signature from %d", msg.Replica)
+		return
+	}
+
+	s := r.slot(Epoch(msg.Epoch))
+	if s == nil || s.decided {
+		return
+	}
+
+	switch msg.Type {
+	case services.ConsensusMessage_PRE_PREPARE:
+		r.onPrePrepare(s, msg)
+	case services.ConsensusMessage_PREPARE:
+		r.onPrepare(s, msg)
+	case services.ConsensusMessage_COMMIT:
+		r.onCommit(s, msg)
+	case services.ConsensusMessage_VIEW_CHANGE:
+		r.onViewChange(s, msg)
+	case services.ConsensusMessage_NEW_VIEW:
+		r.onNewView(s, msg)
+	}
+}
+
+// maybePropose makes this replica propose a list if it leads the current view.
+// In view 0 the leader proposes the first 2t+1 hashes it got. In later views it needs the view-changes
+// of a quorum, and has to re-propose the list prepared in the highest view among them, if any.
+func (r *Replica) maybePropose(s *slot) {
+	if r.replicas.leader(s.epoch, s.view) != r.id || s.proposed[s.view] {
+		return
+	}
+
+	size := r.listSize(s.epoch)
+
+	if s.view == 0 {
+		if len(s.hashes) < size {
+			return
+		}
+
+		s.proposed[0] = true
+
+		list := &services.ProposalHashList{Epoch: int32(s.epoch), List: s.hashes[:size]}
+		digest := digestHashList(list)
+
+		r.log.Infof("proposing a list for epoch %d", s.epoch)
+		r.broadcast(&services.ConsensusMessage{
+			Type:   services.ConsensusMessage_PRE_PREPARE,
+			Epoch:  int32(s.epoch),
+			View:   0,
+			Digest: digest[:],
+			List:   list,
+		})
+		return
+	}
+
+	if len(s.viewChanges[s.view]) < r.replicas.Quorum() {
+		return
+	}
+
+	var justification []*services.ConsensusMessage
+	for _, vc := range s.viewChanges[s.view] {
+		justification = append(justification, vc)
+	}
+	sort.Slice(justification, func(i, j int) bool { return justification[i].Replica < justification[j].Replica })
+
+	list := highestPrepared(justification)
+	if list == nil {
+		if len(s.hashes) < size {
+			return
+		}
+		list = &services.ProposalHashList{Epoch: int32(s.epoch), List: s.hashes[:size]}
+	}
+
+	s.proposed[s.view] = true
+
+	digest := digestHashList(list)
+
+	r.log.Infof("proposing a list for epoch %d in view %d", s.epoch, s.view)
+	r.broadcast(&services.ConsensusMessage{
+		Type:        services.ConsensusMessage_NEW_VIEW,
+		Epoch:       int32(s.epoch),
+		View:        s.view,
+		Digest:      digest[:],
+		List:        list,
+		ViewChanges: justification,
+	})
+}
+
+// highestPrepared returns the list prepared in the highest view among the (checked) view-changes, if any.
+func highestPrepared(viewChanges []*services.ConsensusMessage) *services.ProposalHashList {
+	var list *services.ProposalHashList
+	view := int32(-1)
+
+	for _, vc := range viewChanges {
+		if vc.List != nil && vc.PreparedView > view {
+			list = vc.List
+			view = vc.PreparedView
+		}
+	}
+
+	return list
+}
+
+func (r *Replica) onPrePrepare(s *slot, msg *services.ConsensusMessage) {
+	if msg.Replica != r.replicas.leader(s.epoch, msg.View) || msg.View != s.view || s.list != nil {
+		return
+	}
+
+	if err := r.checkList(s.epoch, msg.List, msg.Digest); err != nil {
+		r.log.Warnf("ignoring a bad pre-prepare from %d: %s", msg.Replica, err.Error())
+		return
+	}
+
+	r.accept(s, msg.List)
+}
+
+// accept makes list the list of the current view and prepares it.
+func (r *Replica) accept(s *slot, list *services.ProposalHashList) {
+	s.list = list
+	s.digest = digestHashList(list)
+	s.lists[s.digest] = list
+
+	r.broadcast(&services.ConsensusMessage{
+		Type:   services.ConsensusMessage_PREPARE,
+		Epoch:  int32(s.epoch),
+		View:   s.view,
+		Digest: s.digest[:],
+	})
+}
+
+func toVoteKey(msg *services.ConsensusMessage) (voteKey, bool) {
+	var key voteKey
+	if len(msg.Digest) != len(key.digest) {
+		return key, false
+	}
+
+	key.view = msg.View
+	copy(key.digest[:], msg.Digest)
+
+	return key, true
+}
+
+func addVote(votes map[voteKey]map[int64][]byte, key voteKey, msg *services.ConsensusMessage) {
+	if votes[key] == nil {
+		votes[key] = make(map[int64][]byte)
+	}
+	votes[key][msg.Replica] = msg.Signature
+}
+
+func votesToSignatures(votes map[int64][]byte, view int32) []*services.ReplicaSignature {
+	var sigs []*services.ReplicaSignature
+	for replica, sig := range votes {
+		sigs = append(sigs, &services.ReplicaSignature{Replica: replica, View: view, Signature: sig})
+	}
+	sort.Slice(sigs, func(i, j int) bool { return sigs[i].Replica < sigs[j].Replica })
+
+	return sigs
+}
+
+func (r *Replica) onPrepare(s *slot, msg *services.ConsensusMessage) {
+	key, ok := toVoteKey(msg)
+	if !ok {
+		return
+	}
+
+	addVote(s.prepares, key, msg)
+
+	// the list of the current view is prepared once a quorum prepared it
+	if s.list == nil || s.sentCommit[s.view] {
+		return
+	}
+
+	current := voteKey{view: s.view, digest: s.digest}
+	if len(s.prepares[current]) < r.replicas.Quorum() {
+		return
+	}
+
+	s.sentCommit[s.view] = true
+	s.preparedView = s.view
+	s.preparedList = s.list
+	s.preparedSigs = votesToSignatures(s.prepares[current], s.view)
+
+	r.broadcast(&services.ConsensusMessage{
+		Type:   services.ConsensusMessage_COMMIT,
+		Epoch:  int32(s.epoch),
+		View:   s.view,
+		Digest: s.digest[:],
+		List:   s.list,
+	})
+}
+
+func (r *Replica) onCommit(s *slot, msg *services.ConsensusMessage) {
+	key, ok := toVoteKey(msg)
+	if !ok {
+		return
+	}
+
+	// commits carry the list, so replicas that missed the proposal can still learn it
+	if _, ok := s.lists[key.digest]; !ok && r.checkList(s.epoch, msg.List, msg.Digest) == nil {
+		s.lists[key.digest] = msg.List
+	}
+
+	addVote(s.commits, key, msg)
+
+	list, ok := s.lists[key.digest]
+	if !ok || len(s.commits[key]) < r.replicas.Quorum() {
+		return
+	}
+
+	s.decided = true
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+
+	certified := &services.ProposalHashList{
+		Epoch:       int32(s.epoch),
+		List:        list.List,
+		Certificate: votesToSignatures(s.commits[key], key.view),
+	}
+
+	r.log.Infof("list of epoch %d committed in view %d", s.epoch, key.view)
+	r.transport.deliver(r.config.ForEpoch(s.epoch), certified)
+}
+
+func (r *Replica) startViewChange(s *slot, view int32) {
+	if view < s.view || s.sentViewChange[view] {
+		return
+	}
+
+	s.sentViewChange[view] = true
+	s.view = view
+	s.list = nil
+	s.timeout *= 2
+	r.startTimer(s)
+
+	msg := &services.ConsensusMessage{
+		Type:  services.ConsensusMessage_VIEW_CHANGE,
+		Epoch: int32(s.epoch),
+		View:  view,
+	}
+
+	if s.preparedList != nil {
+		digest := digestHashList(s.preparedList)
+		msg.Digest = digest[:]
+		msg.List = s.preparedList
+		msg.PreparedView = s.preparedView
+		msg.Prepares = s.preparedSigs
+	}
+
+	r.broadcast(msg)
+}
+
+func (r *Replica) onViewChange(s *slot, msg *services.ConsensusMessage) {
+	if err := r.checkViewChange(s.epoch, msg); err != nil {
+		r.log.Warnf("ignoring a bad view-change from %d: %s", msg.Replica, err.Error())
+		return
+	}
+
+	if s.viewChanges[msg.View] == nil {
+		s.viewChanges[msg.View] = make(map[int64]*services.ConsensusMessage)
+	}
+	s.viewChanges[msg.View][msg.Replica] = msg
+
+	// f+1 view-changes include an honest one, so join them
+	if msg.View > s.view && len(s.viewChanges[msg.View]) > r.replicas.faulty() {
+		r.startViewChange(s, msg.View)
+	}
+
+	r.maybePropose(s)
+}
+
+func (r *Replica) onNewView(s *slot, msg *services.ConsensusMessage) {
+	if msg.Replica != r.replicas.leader(s.epoch, msg.View) || msg.View < s.view || msg.View == 0 {
+		return
+	}
+
+	if msg.View == s.view && s.list != nil {
+		return
+	}
+
+	// the new leader has to justify its list with the view-changes of a quorum
+	var justification []*services.ConsensusMessage
+	signers := make(map[int64]bool)
+	for _, vc := range msg.ViewChanges {
+		if vc.Type != services.ConsensusMessage_VIEW_CHANGE || Epoch(vc.Epoch) != s.epoch || vc.View != msg.View || signers[vc.Replica] {
+			continue
+		}
+
+		if !r.replicas.verify(vc.Replica, consensusSigningBytes(vc), vc.Signature) || r.checkViewChange(s.epoch, vc) != nil {
+			continue
+		}
+
+		signers[vc.Replica] = true
+		justification = append(justification, vc)
+	}
+
+	if len(justification) < r.replicas.Quorum() {
+		r.log.Warnf("ignoring a new-view from %d with %d valid view-changes", msg.Replica, len(justification))
+		return
+	}
+
+	if prepared := highestPrepared(justification); prepared != nil {
+		digest := digestHashList(prepared)
+		if !bytes.Equal(digest[:], msg.Digest) {
+			r.log.Warnf("ignoring a new-view from %d that drops a prepared list", msg.Replica)
+			return
+		}
+	}
+
+	if err := r.checkList(s.epoch, msg.List, msg.Digest); err != nil {
+		r.log.Warnf("ignoring a bad new-view from %d: %s", msg.Replica, err.Error())
+		return
+	}
+
+	if msg.View > s.view {
+		s.sentViewChange[msg.View] = true
+		s.view = msg.View
+	}
+	r.startTimer(s)
+
+	r.accept(s, msg.List)
+}
+
+// Connect dials the other replicas, the nodes and the primary.
+func (r *Replica) Connect(primaryIP string, peerIPs map[NewNodeID]string) error {
+	t := &grpcReplicaTransport{
+		replicas: make(map[int64]services.ReplicaServiceClient),
+		nodes:    make(map[NewNodeID]services.NodeClient),
+		log:      r.log,
+	}
+
+	for id, url := range r.replicas.urls {
+		if id == r.id {
+			continue
+		}
+
+		conn, err := r.network.Dial(url, r.creds.dialOption())
+		if err != nil {
+			return err
+		}
+		t.replicas[id] = services.NewReplicaServiceClient(conn)
+	}
+
+	for id, url := range peerIPs {
+		conn, err := r.network.Dial(url, r.creds.dialOption())
+		if err != nil {
+			return err
+		}
+		t.nodes[id] = services.NewNodeClient(conn)
+	}
+
+	conn, err := r.network.Dial(primaryIP, r.creds.dialOption())
+	if err != nil {
+		return err
+	}
+	t.primary = services.NewBulletinBoardServiceClient(conn)
+
+	r.transport = t
+
+	go r.followVerdicts(&grpcBoard{client: t.primary, log: r.log})
+
+	return nil
+}
+
+// followVerdicts passes the aborted epochs on to Run, since they shift the old group of later epochs.
+func (r *Replica) followVerdicts(board Board) {
+	for post := range board.Subscribe(services.BoardPost_ADVANCE_EPOCH) {
+		if post.From != BoardId {
+			continue
+		}
+
+		advance := &services.EpochAdvance{}
+		if err := proto.Unmarshal(post.Payload, advance); err != nil {
+			r.log.Warnf("ignoring a bad verdict on epoch %d: %s", post.Epoch, err.Error())
+			continue
+		}
+
+		if advance.Aborted {
+			r.events <- epochAborted(post.Epoch)
+		}
+	}
+}
+
+func (r *Replica) Serve() {
+	url := r.replicas.urls[r.id]
+	lis, err := r.network.Listen(url)
+	if err != nil {
+		r.log.Fatalf("cannot listen to %s, %v", url, err)
+	}
+
+	s := grpc.NewServer(r.creds.serverOptions()...)
+	services.RegisterReplicaServiceServer(s, r)
+
+	r.log.Infof("replica serving on %s", lis.Addr())
+	if err := s.Serve(lis); err != nil {
+		r.log.Fatalf("can't serve")
+	}
+}
+
+func (r *Replica) SetViewTimeout(timeout time.Duration) {
+	r.viewTimeout = timeout
+}
+
+// SetCredentials makes the replica use mutual TLS on all its connections, and check who sends each message.
+// It has to be called before Connect and Serve.
+func (r *Replica) SetCredentials(creds *Credentials) {
+	r.creds = creds
+}
+
+// SetTransport makes the replica reach the other parties through the given transport instead of TCP.
+// It has to be called before Connect and Serve.
+func (r *Replica) SetTransport(network Transport) {
+	r.network = network
+}
+
+func BuildReplica(pp PublicParameter, logger *logrus.Logger, id int64, key ed25519.PrivateKey, replicas *ReplicaSet) Replica {
+	return Replica{
+		id:          id,
+		key:         key,
+		replicas:    replicas,
+		config:      pp,
+		viewTimeout: defaultViewTimeout,
+		network:     GRPCTransport{},
+		events:      make(chan interface{}, 1024),
+		slots:       make(map[Epoch]*slot),
+		log: logger.WithFields(
+			logrus.Fields{
+				"replica": id,
+			}),
+	}
+}
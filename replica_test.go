@@ -0,0 +1,221 @@
+package Schultz
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"./services"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+// localReplicaNetwork connects replicas running in the same process.
+type localReplicaNetwork struct {
+	replicas  map[int64]*Replica
+	down      map[int64]bool
+	delivered chan *services.ProposalHashList
+}
+
+func (n *localReplicaNetwork) sendToReplica(id int64, msg *services.ConsensusMessage) {
+	if n.down[id] {
+		return
+	}
+
+	go func() { n.replicas[id].events <- msg }()
+}
+
+func (n *localReplicaNetwork) deliver(config PublicParameter, list *services.ProposalHashList) {
+	n.delivered <- list
+}
+
+// buildReplicaSet makes n replicas with ids 1, ..., n, and returns their keys.
+func buildReplicaSet(t *testing.T, n int) (*ReplicaSet, map[int64]ed25519.PrivateKey) {
+	configs := make(map[string]ReplicaConfig)
+	keys := make(map[int64]ed25519.PrivateKey)
+
+	for id := int64(1); id <= int64(n); id++ {
+		pub, priv, err := ed25519.GenerateKey(crand.Reader)
+		assert.Nil(t, err)
+
+		keys[id] = priv
+		configs[strconv.FormatInt(id, 10)] = ReplicaConfig{Id: id, PublicKey: hex.EncodeToString(pub)}
+	}
+
+	replicaSet, err := BuildReplicaSet(configs)
+	assert.Nil(t, err)
+
+	return replicaSet, keys
+}
+
+// startLocalReplicas runs n replicas with ids 1, ..., n. Those marked down never run.
+func startLocalReplicas(t *testing.T, n int, pp PublicParameter, down map[int64]bool) (*localReplicaNetwork, *ReplicaSet) {
+	replicaSet, keys := buildReplicaSet(t, n)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	network := &localReplicaNetwork{
+		replicas:  make(map[int64]*Replica),
+		down:      down,
+		delivered: make(chan *services.ProposalHashList, n),
+	}
+
+	for id, key := range keys {
+		replica := BuildReplica(pp, logger, id, key, replicaSet)
+		replica.SetViewTimeout(100 * time.Millisecond)
+		replica.transport = network
+
+		network.replicas[id] = &replica
+		if !down[id] {
+			go replica.Run()
+		}
+	}
+
+	return network, replicaSet
+}
+
+// submitHashes sends a proposal hash from every old node to every running replica, in a different order each.
+func submitHashes(network *localReplicaNetwork, pp PublicParameter, keys map[int64]ed25519.PrivateKey, epoch Epoch) {
+	oldGroup := pp.GetOldGroup()
+
+	for id, replica := range network.replicas {
+		if network.down[id] {
+			continue
+		}
+
+		for i := range oldGroup {
+			proposer := oldGroup[(i+int(id))%len(oldGroup)]
+
+			hash := make([]byte, 32)
+			hash[0] = byte(proposer)
+
+			ph := &services.ProposalHash{Epoch: int32(epoch), Proposer: proposer, Hash: hash, Version: ProposalHashVersion}
+			signMessage(keys[proposer], ph)
+
+			replica.events <- ph
+		}
+	}
+}
+
+// collectLists waits for a certified list from each of n replicas and checks they all agree.
+func collectLists(t *testing.T, network *localReplicaNetwork, replicaSet *ReplicaSet, n int) *services.ProposalHashList {
+	var first *services.ProposalHashList
+
+	for i := 0; i < n; i++ {
+		select {
+		case list := <-network.delivered:
+			assert.Nil(t, replicaSet.VerifyCertificate(list))
+
+			if first == nil {
+				first = list
+			} else {
+				assert.Equal(t, digestHashList(first), digestHashList(list))
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d replicas delivered a list", i, n)
+		}
+	}
+
+	return first
+}
+
+func TestReplicas_Order(t *testing.T) {
+	pp, keys := withIdentityKeys(BuildConfig(1, FieldPrime, []int64{1, 2, 3, 4}, []int64{1, 2, 3, 4}))
+
+	network, replicaSet := startLocalReplicas(t, 4, pp, nil)
+	submitHashes(network, pp, keys, 1)
+
+	list := collectLists(t, network, replicaSet, 4)
+	assert.Equal(t, 3, len(list.List))
+	assert.Equal(t, int32(1), list.Epoch)
+
+	// changing the list breaks the certificate
+	tampered := &services.ProposalHashList{Epoch: list.Epoch, Certificate: list.Certificate}
+	tampered.List = append(tampered.List, list.List[1], list.List[0], list.List[2])
+	assert.NotNil(t, replicaSet.VerifyCertificate(tampered))
+
+	// so does dropping signatures below the quorum
+	weak := &services.ProposalHashList{Epoch: list.Epoch, List: list.List, Certificate: list.Certificate[:replicaSet.Quorum()-1]}
+	assert.NotNil(t, replicaSet.VerifyCertificate(weak))
+
+	// and repeating a signature
+	var repeated []*services.ReplicaSignature
+	for i := 0; i < replicaSet.Quorum(); i++ {
+		repeated = append(repeated, list.Certificate[0])
+	}
+	weak.Certificate = repeated
+	assert.NotNil(t, replicaSet.VerifyCertificate(weak))
+}
+
+func TestReplicas_ViewChange(t *testing.T) {
+	pp, keys := withIdentityKeys(BuildConfig(1, FieldPrime, []int64{1, 2, 3, 4}, []int64{1, 2, 3, 4}))
+
+	// the leader of the first view of epoch 1 is down
+	leader := int64(2)
+
+	network, replicaSet := startLocalReplicas(t, 4, pp, map[int64]bool{leader: true})
+	assert.Equal(t, leader, replicaSet.leader(1, 0))
+
+	submitHashes(network, pp, keys, 1)
+
+	list := collectLists(t, network, replicaSet, 3)
+	assert.Equal(t, 3, len(list.List))
+
+	for _, sig := range list.Certificate {
+		assert.NotEqual(t, leader, sig.Replica)
+		assert.True(t, sig.View > 0)
+	}
+}
+
+// certify signs the commit of the list by the replicas.
+func certify(list *services.ProposalHashList, keys map[int64]ed25519.PrivateKey, replicas []int64) *services.ProposalHashList {
+	digest := digestHashList(list)
+	msg := signingBytes(services.ConsensusMessage_COMMIT, Epoch(list.Epoch), 0, 0, digest[:])
+
+	certified := &services.ProposalHashList{Epoch: list.Epoch, List: list.List}
+	for _, id := range replicas {
+		certified.Certificate = append(certified.Certificate, &services.ReplicaSignature{
+			Replica:   id,
+			Signature: ed25519.Sign(keys[id], msg),
+		})
+	}
+
+	return certified
+}
+
+func TestReplicas_ConflictingCertificates(t *testing.T) {
+	a := &services.ProposalHashList{Epoch: 1, List: []*services.ProposalHash{{Proposer: 1, Hash: make([]byte, 32)}}}
+	b := &services.ProposalHashList{Epoch: 1, List: []*services.ProposalHash{{Proposer: 2, Hash: make([]byte, 32)}}}
+
+	// n = 3f+1, and one more than 3f+1 with the same f
+	for _, n := range []int{4, 6} {
+		replicaSet, keys := buildReplicaSet(t, n)
+		f := replicaSet.faulty()
+
+		var faulty, honest []int64
+		for _, id := range replicaSet.ids {
+			if len(faulty) < f {
+				faulty = append(faulty, id)
+			} else {
+				honest = append(honest, id)
+			}
+		}
+
+		// the honest replicas alone certify a list
+		assert.Nil(t, replicaSet.VerifyCertificate(certify(a, keys, honest)), "n = %d", n)
+
+		// the faulty replicas sign both lists, and the honest ones are split between them
+		for split := 0; split <= len(honest); split++ {
+			forA := append(append([]int64{}, faulty...), honest[:split]...)
+			forB := append(append([]int64{}, faulty...), honest[split:]...)
+
+			certifiedA := replicaSet.VerifyCertificate(certify(a, keys, forA)) == nil
+			certifiedB := replicaSet.VerifyCertificate(certify(b, keys, forB)) == nil
+			assert.False(t, certifiedA && certifiedB, "n = %d, %d honest replicas for the first list", n, split)
+		}
+	}
+}
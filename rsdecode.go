@@ -0,0 +1,210 @@
+package Schultz
+
+import (
+	"errors"
+	"fmt"
+
+	"../../utils/polyring"
+	"github.com/ncw/gmp"
+)
+
+var errTooManyErrors = errors.New("too many errors to decode")
+
+// DecodeReedSolomon finds the polynomial of the given degree that passes through all but at most
+// (n-degree-1)/2 of the n points (xs[i], ys[i]), using the Berlekamp-Welch algorithm.
+// It returns the polynomial and the indices of the points not on it.
+func DecodeReedSolomon(degree int, xs, ys []*gmp.Int, prime *gmp.Int) (polyring.Polynomial, []int, error) {
+	n := len(xs)
+	if n != len(ys) {
+		return polyring.Polynomial{}, nil, fmt.Errorf("%d xs but %d ys", len(xs), len(ys))
+	}
+
+	if n < degree+1 {
+		return polyring.Polynomial{}, nil, fmt.Errorf("need at least %d points, got %d", degree+1, n)
+	}
+
+	// number of errors we can correct
+	e := (n - degree - 1) / 2
+
+	// Find Q of degree degree+e and a monic E of degree e with Q(x_i) = y_i * E(x_i) for all i.
+	// The unknowns are q_0, ..., q_{degree+e}, e_0, ..., e_{e-1}.
+	numQ := degree + e + 1
+	numUnknowns := numQ + e
+
+	matrix := make([][]*gmp.Int, n)
+	for i := range matrix {
+		row := make([]*gmp.Int, numUnknowns+1)
+
+		xPow := gmp.NewInt(1)
+		for k := 0; k < numQ; k++ {
+			row[k] = gmp.NewInt(0).Set(xPow)
+
+			if k < e {
+				// -y_i * x_i^k
+				row[numQ+k] = gmp.NewInt(0).Mul(ys[i], xPow)
+				row[numQ+k].Neg(row[numQ+k])
+				row[numQ+k].Mod(row[numQ+k], prime)
+			}
+
+			if k == e {
+				// y_i * x_i^e goes to the right hand side
+				row[numUnknowns] = gmp.NewInt(0).Mul(ys[i], xPow)
+				row[numUnknowns].Mod(row[numUnknowns], prime)
+			}
+
+			xPow.Mul(xPow, xs[i])
+			xPow.Mod(xPow, prime)
+		}
+
+		matrix[i] = row
+	}
+
+	solution, err := solveLinearSystem(matrix, numUnknowns, prime)
+	if err != nil {
+		return polyring.Polynomial{}, nil, err
+	}
+
+	// E is monic
+	errorLocator := make([]*gmp.Int, e+1)
+	copy(errorLocator, solution[numQ:])
+	errorLocator[e] = gmp.NewInt(1)
+
+	quotient, err := divideExactly(solution[:numQ], errorLocator, prime)
+	if err != nil {
+		return polyring.Polynomial{}, nil, err
+	}
+
+	poly, err := polyring.New(degree)
+	if err != nil {
+		return polyring.Polynomial{}, nil, err
+	}
+
+	for i := 0; i <= degree; i++ {
+		if i < len(quotient) {
+			if err := poly.SetCoefficientBig(i, quotient[i]); err != nil {
+				return polyring.Polynomial{}, nil, err
+			}
+		}
+	}
+
+	var wrong []int
+	y := gmp.NewInt(0)
+	yi := gmp.NewInt(0)
+	for i := range xs {
+		poly.EvalMod(xs[i], prime, y)
+		yi.Mod(ys[i], prime)
+		if y.Cmp(yi) != 0 {
+			wrong = append(wrong, i)
+		}
+	}
+
+	if len(wrong) > e {
+		return polyring.Polynomial{}, nil, errTooManyErrors
+	}
+
+	return poly, wrong, nil
+}
+
+// solveLinearSystem returns a solution to the system given as an augmented matrix.
+// Free variables are set to zero.
+func solveLinearSystem(matrix [][]*gmp.Int, numUnknowns int, prime *gmp.Int) ([]*gmp.Int, error) {
+	pivotCols := make([]int, 0, numUnknowns)
+	tmp := gmp.NewInt(0)
+	inv := gmp.NewInt(0)
+
+	row := 0
+	for col := 0; col < numUnknowns && row < len(matrix); col++ {
+		pivot := -1
+		for r := row; r < len(matrix); r++ {
+			if matrix[r][col].Sign() != 0 {
+				pivot = r
+				break
+			}
+		}
+
+		if pivot < 0 {
+			continue
+		}
+
+		matrix[row], matrix[pivot] = matrix[pivot], matrix[row]
+
+		// scale the pivot row so the pivot is one
+		inv.ModInverse(matrix[row][col], prime)
+		for c := col; c <= numUnknowns; c++ {
+			matrix[row][c].Mul(matrix[row][c], inv)
+			matrix[row][c].Mod(matrix[row][c], prime)
+		}
+
+		// eliminate the column everywhere else
+		for r := range matrix {
+			if r == row || matrix[r][col].Sign() == 0 {
+				continue
+			}
+
+			factor := gmp.NewInt(0).Set(matrix[r][col])
+			for c := col; c <= numUnknowns; c++ {
+				tmp.Mul(factor, matrix[row][c])
+				matrix[r][c].Sub(matrix[r][c], tmp)
+				matrix[r][c].Mod(matrix[r][c], prime)
+			}
+		}
+
+		pivotCols = append(pivotCols, col)
+		row++
+	}
+
+	// a row 0 = c with c != 0 means there is no solution
+	for r := row; r < len(matrix); r++ {
+		if matrix[r][numUnknowns].Sign() != 0 {
+			return nil, errTooManyErrors
+		}
+	}
+
+	solution := make([]*gmp.Int, numUnknowns)
+	for i := range solution {
+		solution[i] = gmp.NewInt(0)
+	}
+
+	for r, col := range pivotCols {
+		solution[col].Set(matrix[r][numUnknowns])
+	}
+
+	return solution, nil
+}
+
+// divideExactly divides the polynomial a by the monic polynomial b, both given by their coefficients,
+// and fails if there is a remainder.
+func divideExactly(a, b []*gmp.Int, prime *gmp.Int) ([]*gmp.Int, error) {
+	remainder := make([]*gmp.Int, len(a))
+	for i := range a {
+		remainder[i] = gmp.NewInt(0).Set(a[i])
+	}
+
+	degB := len(b) - 1
+	if len(a) < len(b) {
+		return nil, fmt.Errorf("can't divide a degree %d polynomial by a degree %d one", len(a)-1, degB)
+	}
+
+	quotient := make([]*gmp.Int, len(a)-degB)
+	tmp := gmp.NewInt(0)
+
+	for i := len(quotient) - 1; i >= 0; i-- {
+		// b is monic, so the next coefficient of the quotient is the leading coefficient of the remainder
+		q := gmp.NewInt(0).Set(remainder[i+degB])
+		quotient[i] = q
+
+		for j := 0; j <= degB; j++ {
+			tmp.Mul(q, b[j])
+			remainder[i+j].Sub(remainder[i+j], tmp)
+			remainder[i+j].Mod(remainder[i+j], prime)
+		}
+	}
+
+	for _, r := range remainder {
+		if r.Sign() != 0 {
+			return nil, errTooManyErrors
+		}
+	}
+
+	return quotient, nil
+}
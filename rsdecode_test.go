@@ -0,0 +1,143 @@
+package Schultz
+
+import (
+	"../../utils/polyring"
+	"./services"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"testing"
+)
+
+func evalAtOneToN(poly polyring.Polynomial, n int, prime *gmp.Int) ([]*gmp.Int, []*gmp.Int) {
+	xs := make([]*gmp.Int, n)
+	ys := make([]*gmp.Int, n)
+	for i := range xs {
+		xs[i] = gmp.NewInt(int64(i + 1))
+		ys[i] = gmp.NewInt(0)
+		poly.EvalMod(xs[i], prime, ys[i])
+	}
+
+	return xs, ys
+}
+
+func TestDecodeReedSolomon(t *testing.T) {
+	prime := FieldPrime
+	r := rand.New(rand.NewSource(0))
+
+	for _, degree := range []int{1, 2, 5} {
+		n := 3*degree + 1
+
+		poly, err := polyring.NewRand(degree, r, prime)
+		assert.Nil(t, err)
+
+		xs, ys := evalAtOneToN(poly, n, prime)
+
+		// no errors
+		decoded, wrong, err := DecodeReedSolomon(degree, xs, ys, prime)
+		assert.Nil(t, err)
+		assert.Empty(t, wrong)
+
+		secret := gmp.NewInt(0)
+		decoded.EvalMod(gmp.NewInt(0), prime, secret)
+		assert.Equal(t, poly.GetPtrToConstant().String(), secret.String())
+
+		// corrupt t points
+		corrupted := r.Perm(n)[:degree]
+		for _, i := range corrupted {
+			ys[i].Add(ys[i], gmp.NewInt(1+r.Int63()))
+		}
+
+		decoded, wrong, err = DecodeReedSolomon(degree, xs, ys, prime)
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, corrupted, wrong)
+
+		decoded.EvalMod(gmp.NewInt(0), prime, secret)
+		assert.Equal(t, poly.GetPtrToConstant().String(), secret.String())
+	}
+}
+
+func TestDecodeReedSolomon_TooManyErrors(t *testing.T) {
+	prime := FieldPrime
+	r := rand.New(rand.NewSource(1))
+
+	degree := 2
+	poly, err := polyring.NewRand(degree, r, prime)
+	assert.Nil(t, err)
+
+	xs, ys := evalAtOneToN(poly, 3*degree+1, prime)
+	for _, i := range []int{0, 3, 5} {
+		ys[i].Add(ys[i], gmp.NewInt(1))
+	}
+
+	_, _, err = DecodeReedSolomon(degree, xs, ys, prime)
+	assert.NotNil(t, err)
+
+	_, _, err = DecodeReedSolomon(degree, xs[:2], ys[:2], prime)
+	assert.NotNil(t, err)
+}
+
+func TestShareReconstructor(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	// blindedShares returns blinded shares for node 2 on a random f, the first of them bad, and f(2)
+	blindedShares := func(pp PublicParameter) ([]*gmp.Int, []*gmp.Int, *gmp.Int) {
+		f, err := polyring.NewRand(pp.reconstructionDegree(), rand.New(rand.NewSource(0)), pp.GetPrime())
+		assert.Nil(t, err)
+		xs, ys := evalAtOneToN(f, len(pp.GetOldGroup()), pp.GetPrime())
+		ys[0].Add(ys[0], gmp.NewInt(1))
+
+		want := gmp.NewInt(0)
+		f.EvalMod(gmp.NewInt(2), pp.GetPrime(), want)
+
+		return xs, ys, want
+	}
+
+	reconstruct := func(pp PublicParameter, xs, ys []*gmp.Int, from []int) reconstructedShare {
+		const epoch = Epoch(1)
+
+		node := BuildNode(pp, logger, 2, "", "", nil, nil)
+		node.SetDeadlines(DeadlineConfig{BlindedShares: 100})
+
+		for _, i := range from {
+			node.blindedShares.put(epoch, xs[i].Int64(), &services.BlindedShare{Epoch: int32(epoch), From: xs[i].Int64(), Share: ys[i].Bytes()})
+		}
+
+		return <-node.startShareReconstructor(epoch, &BenchmarkEntry{})
+	}
+
+	// t = t' = 1 with the fewest old nodes, 4
+	pp := BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4))
+	xs, ys, want := blindedShares(pp)
+
+	// all the shares correct the bad one
+	result := reconstruct(pp, xs, ys, []int{0, 1, 2, 3})
+	assert.Nil(t, result.err)
+	assert.Equal(t, 0, result.share.Cmp(want))
+
+	// an old node that sends nothing doesn't stop the others: t'+1+t good shares are enough
+	result = reconstruct(pp, xs, ys, []int{1, 2, 3})
+	assert.Nil(t, result.err)
+	assert.Equal(t, 0, result.share.Cmp(want))
+
+	// t' good shares and a bad one would give a wrong share: the epoch aborts instead
+	result = reconstruct(pp, xs, ys, []int{0, 1})
+	assert.NotNil(t, result.err)
+
+	// raising the degree to t' = 2, with the fewest old nodes, 5
+	pp = mustBuildHandoffConfig(t, 1, 2, makeOneToN(5), makeOneToN(5))
+	xs, ys, want = blindedShares(pp)
+
+	result = reconstruct(pp, xs, ys, []int{0, 1, 2, 3, 4})
+	assert.Nil(t, result.err)
+	assert.Equal(t, 0, result.share.Cmp(want))
+
+	result = reconstruct(pp, xs, ys, []int{1, 2, 3, 4})
+	assert.Nil(t, result.err)
+	assert.Equal(t, 0, result.share.Cmp(want))
+
+	result = reconstruct(pp, xs, ys, []int{0, 1, 2})
+	assert.NotNil(t, result.err)
+}
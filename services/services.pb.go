@@ -22,269 +22,2643 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type BoardPost_Kind int32
+
+const (
+	BoardPost_PROPOSAL_HASH           BoardPost_Kind = 0
+	BoardPost_PROPOSAL_HASH_LIST      BoardPost_Kind = 1
+	BoardPost_COMPLAINTS              BoardPost_Kind = 2
+	BoardPost_FINAL_LIST              BoardPost_Kind = 3
+	BoardPost_SHARE                   BoardPost_Kind = 4
+	BoardPost_DEALING_COMMITMENT      BoardPost_Kind = 5
+	BoardPost_DEALING_COMMITMENT_LIST BoardPost_Kind = 6
+	BoardPost_ADVANCE_EPOCH           BoardPost_Kind = 7
+	BoardPost_KILL                    BoardPost_Kind = 8
+	BoardPost_SHARE_CHECK             BoardPost_Kind = 9
+	BoardPost_SHARE_ERASED            BoardPost_Kind = 10
+	BoardPost_DEALING_COMPLAINTS      BoardPost_Kind = 11
+	BoardPost_DEALING_ACCUSATIONS     BoardPost_Kind = 12
+	BoardPost_DEALING_REVEAL          BoardPost_Kind = 13
+	BoardPost_QUALIFIED_DEALERS       BoardPost_Kind = 14
+)
+
+var BoardPost_Kind_name = map[int32]string{
+	0:  "PROPOSAL_HASH",
+	1:  "PROPOSAL_HASH_LIST",
+	2:  "COMPLAINTS",
+	3:  "FINAL_LIST",
+	4:  "SHARE",
+	5:  "DEALING_COMMITMENT",
+	6:  "DEALING_COMMITMENT_LIST",
+	7:  "ADVANCE_EPOCH",
+	8:  "KILL",
+	9:  "SHARE_CHECK",
+	10: "SHARE_ERASED",
+	11: "DEALING_COMPLAINTS",
+	12: "DEALING_ACCUSATIONS",
+	13: "DEALING_REVEAL",
+	14: "QUALIFIED_DEALERS",
+}
+
+var BoardPost_Kind_value = map[string]int32{
+	"PROPOSAL_HASH":           0,
+	"PROPOSAL_HASH_LIST":      1,
+	"COMPLAINTS":              2,
+	"FINAL_LIST":              3,
+	"SHARE":                   4,
+	"DEALING_COMMITMENT":      5,
+	"DEALING_COMMITMENT_LIST": 6,
+	"ADVANCE_EPOCH":           7,
+	"KILL":                    8,
+	"SHARE_CHECK":             9,
+	"SHARE_ERASED":            10,
+	"DEALING_COMPLAINTS":      11,
+	"DEALING_ACCUSATIONS":     12,
+	"DEALING_REVEAL":          13,
+	"QUALIFIED_DEALERS":       14,
+}
+
+func (x BoardPost_Kind) String() string {
+	return proto.EnumName(BoardPost_Kind_name, int32(x))
+}
+
+func (BoardPost_Kind) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{0, 0}
+}
+
+type ConsensusMessage_Type int32
+
+const (
+	ConsensusMessage_PRE_PREPARE ConsensusMessage_Type = 0
+	ConsensusMessage_PREPARE     ConsensusMessage_Type = 1
+	ConsensusMessage_COMMIT      ConsensusMessage_Type = 2
+	ConsensusMessage_VIEW_CHANGE ConsensusMessage_Type = 3
+	ConsensusMessage_NEW_VIEW    ConsensusMessage_Type = 4
+)
+
+var ConsensusMessage_Type_name = map[int32]string{
+	0: "PRE_PREPARE",
+	1: "PREPARE",
+	2: "COMMIT",
+	3: "VIEW_CHANGE",
+	4: "NEW_VIEW",
+}
+
+var ConsensusMessage_Type_value = map[string]int32{
+	"PRE_PREPARE": 0,
+	"PREPARE":     1,
+	"COMMIT":      2,
+	"VIEW_CHANGE": 3,
+	"NEW_VIEW":    4,
+}
+
+func (x ConsensusMessage_Type) String() string {
+	return proto.EnumName(ConsensusMessage_Type_name, int32(x))
+}
+
+func (ConsensusMessage_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{13, 0}
+}
+
+// an entry on the bulletin board
+type BoardPost struct {
+	Kind  BoardPost_Kind `protobuf:"varint,1,opt,name=kind,proto3,enum=services.BoardPost_Kind" json:"kind,omitempty"`
+	Epoch int32          `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From  int64          `protobuf:"varint,3,opt,name=from,proto3" json:"from,omitempty"`
+	// the marshaled message of the kind
+	Payload []byte `protobuf:"bytes,4,opt,name=payload,proto3" json:"payload,omitempty"`
+	// the block including the post, if the board is a chain
+	Block int64 `protobuf:"varint,5,opt,name=block,proto3" json:"block,omitempty"`
+	// the poster's signature over the post, if the board is a chain, which takes it like a signed transaction
+	Signature            []byte   `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BoardPost) Reset()         { *m = BoardPost{} }
+func (m *BoardPost) String() string { return proto.CompactTextString(m) }
+func (*BoardPost) ProtoMessage()    {}
+func (*BoardPost) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{0}
+}
+
+func (m *BoardPost) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BoardPost.Unmarshal(m, b)
+}
+func (m *BoardPost) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BoardPost.Marshal(b, m, deterministic)
+}
+func (m *BoardPost) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BoardPost.Merge(m, src)
+}
+func (m *BoardPost) XXX_Size() int {
+	return xxx_messageInfo_BoardPost.Size(m)
+}
+func (m *BoardPost) XXX_DiscardUnknown() {
+	xxx_messageInfo_BoardPost.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BoardPost proto.InternalMessageInfo
+
+func (m *BoardPost) GetKind() BoardPost_Kind {
+	if m != nil {
+		return m.Kind
+	}
+	return BoardPost_PROPOSAL_HASH
+}
+
+func (m *BoardPost) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *BoardPost) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *BoardPost) GetPayload() []byte {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (m *BoardPost) GetBlock() int64 {
+	if m != nil {
+		return m.Block
+	}
+	return 0
+}
+
+func (m *BoardPost) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type SubscribeRequest struct {
+	Kinds                []BoardPost_Kind `protobuf:"varint,1,rep,packed,name=kinds,proto3,enum=services.BoardPost_Kind" json:"kinds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *SubscribeRequest) Reset()         { *m = SubscribeRequest{} }
+func (m *SubscribeRequest) String() string { return proto.CompactTextString(m) }
+func (*SubscribeRequest) ProtoMessage()    {}
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{1}
+}
+
+func (m *SubscribeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubscribeRequest.Unmarshal(m, b)
+}
+func (m *SubscribeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubscribeRequest.Marshal(b, m, deterministic)
+}
+func (m *SubscribeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscribeRequest.Merge(m, src)
+}
+func (m *SubscribeRequest) XXX_Size() int {
+	return xxx_messageInfo_SubscribeRequest.Size(m)
+}
+func (m *SubscribeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscribeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscribeRequest proto.InternalMessageInfo
+
+func (m *SubscribeRequest) GetKinds() []BoardPost_Kind {
+	if m != nil {
+		return m.Kinds
+	}
+	return nil
+}
+
+type EpochRequest struct {
+	Epoch                int32    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EpochRequest) Reset()         { *m = EpochRequest{} }
+func (m *EpochRequest) String() string { return proto.CompactTextString(m) }
+func (*EpochRequest) ProtoMessage()    {}
+func (*EpochRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{2}
+}
+
+func (m *EpochRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EpochRequest.Unmarshal(m, b)
+}
+func (m *EpochRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EpochRequest.Marshal(b, m, deterministic)
+}
+func (m *EpochRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EpochRequest.Merge(m, src)
+}
+func (m *EpochRequest) XXX_Size() int {
+	return xxx_messageInfo_EpochRequest.Size(m)
+}
+func (m *EpochRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_EpochRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EpochRequest proto.InternalMessageInfo
+
+func (m *EpochRequest) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+type BoardPostList struct {
+	List                 []*BoardPost `protobuf:"bytes,1,rep,name=list,proto3" json:"list,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *BoardPostList) Reset()         { *m = BoardPostList{} }
+func (m *BoardPostList) String() string { return proto.CompactTextString(m) }
+func (*BoardPostList) ProtoMessage()    {}
+func (*BoardPostList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{3}
+}
+
+func (m *BoardPostList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BoardPostList.Unmarshal(m, b)
+}
+func (m *BoardPostList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BoardPostList.Marshal(b, m, deterministic)
+}
+func (m *BoardPostList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BoardPostList.Merge(m, src)
+}
+func (m *BoardPostList) XXX_Size() int {
+	return xxx_messageInfo_BoardPostList.Size(m)
+}
+func (m *BoardPostList) XXX_DiscardUnknown() {
+	xxx_messageInfo_BoardPostList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BoardPostList proto.InternalMessageInfo
+
+func (m *BoardPostList) GetList() []*BoardPost {
+	if m != nil {
+		return m.List
+	}
+	return nil
+}
+
+// the board's verdict on an epoch, and the nodes taking part in the next one.
+// An aborted epoch changes nothing: the same handoff is retried at the next epoch.
+type EpochAdvance struct {
+	Members []int64 `protobuf:"varint,1,rep,packed,name=members,proto3" json:"members,omitempty"`
+	Aborted bool    `protobuf:"varint,2,opt,name=aborted,proto3" json:"aborted,omitempty"`
+	// nodes that missed a deadline of the epoch
+	Missed               []int64  `protobuf:"varint,3,rep,packed,name=missed,proto3" json:"missed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EpochAdvance) Reset()         { *m = EpochAdvance{} }
+func (m *EpochAdvance) String() string { return proto.CompactTextString(m) }
+func (*EpochAdvance) ProtoMessage()    {}
+func (*EpochAdvance) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{4}
+}
+
+func (m *EpochAdvance) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EpochAdvance.Unmarshal(m, b)
+}
+func (m *EpochAdvance) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EpochAdvance.Marshal(b, m, deterministic)
+}
+func (m *EpochAdvance) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EpochAdvance.Merge(m, src)
+}
+func (m *EpochAdvance) XXX_Size() int {
+	return xxx_messageInfo_EpochAdvance.Size(m)
+}
+func (m *EpochAdvance) XXX_DiscardUnknown() {
+	xxx_messageInfo_EpochAdvance.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EpochAdvance proto.InternalMessageInfo
+
+func (m *EpochAdvance) GetMembers() []int64 {
+	if m != nil {
+		return m.Members
+	}
+	return nil
+}
+
+func (m *EpochAdvance) GetAborted() bool {
+	if m != nil {
+		return m.Aborted
+	}
+	return false
+}
+
+func (m *EpochAdvance) GetMissed() []int64 {
+	if m != nil {
+		return m.Missed
+	}
+	return nil
+}
+
 type Share struct {
+	Epoch int32  `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From  int64  `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Share []byte `protobuf:"bytes,3,opt,name=share,proto3" json:"share,omitempty"`
+	// signature of the sender with its identity key, over the message without it
+	Signature            []byte   `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Share) Reset()         { *m = Share{} }
+func (m *Share) String() string { return proto.CompactTextString(m) }
+func (*Share) ProtoMessage()    {}
+func (*Share) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{5}
+}
+
+func (m *Share) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Share.Unmarshal(m, b)
+}
+func (m *Share) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Share.Marshal(b, m, deterministic)
+}
+func (m *Share) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Share.Merge(m, src)
+}
+func (m *Share) XXX_Size() int {
+	return xxx_messageInfo_Share.Size(m)
+}
+func (m *Share) XXX_DiscardUnknown() {
+	xxx_messageInfo_Share.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Share proto.InternalMessageInfo
+
+func (m *Share) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Share) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *Share) GetShare() []byte {
+	if m != nil {
+		return m.Share
+	}
+	return nil
+}
+
+func (m *Share) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type BlindedShare struct {
+	Epoch int32  `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From  int64  `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Share []byte `protobuf:"bytes,3,opt,name=share,proto3" json:"share,omitempty"`
+	// commitment to the new sharing, as computed by the sender. Only in production mode.
+	Commitment           []byte   `protobuf:"bytes,4,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	Signature            []byte   `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BlindedShare) Reset()         { *m = BlindedShare{} }
+func (m *BlindedShare) String() string { return proto.CompactTextString(m) }
+func (*BlindedShare) ProtoMessage()    {}
+func (*BlindedShare) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{6}
+}
+
+func (m *BlindedShare) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlindedShare.Unmarshal(m, b)
+}
+func (m *BlindedShare) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlindedShare.Marshal(b, m, deterministic)
+}
+func (m *BlindedShare) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlindedShare.Merge(m, src)
+}
+func (m *BlindedShare) XXX_Size() int {
+	return xxx_messageInfo_BlindedShare.Size(m)
+}
+func (m *BlindedShare) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlindedShare.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlindedShare proto.InternalMessageInfo
+
+func (m *BlindedShare) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *BlindedShare) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *BlindedShare) GetShare() []byte {
+	if m != nil {
+		return m.Share
+	}
+	return nil
+}
+
+func (m *BlindedShare) GetCommitment() []byte {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
+func (m *BlindedShare) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// ShareCheck tells the board whether the share of a node lies on the committed polynomial,
+// without revealing the share.
+type ShareCheck struct {
+	Epoch                int32    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From                 int64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Commitment           []byte   `protobuf:"bytes,3,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	Valid                bool     `protobuf:"varint,4,opt,name=valid,proto3" json:"valid,omitempty"`
+	Signature            []byte   `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ShareCheck) Reset()         { *m = ShareCheck{} }
+func (m *ShareCheck) String() string { return proto.CompactTextString(m) }
+func (*ShareCheck) ProtoMessage()    {}
+func (*ShareCheck) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{7}
+}
+
+func (m *ShareCheck) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ShareCheck.Unmarshal(m, b)
+}
+func (m *ShareCheck) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ShareCheck.Marshal(b, m, deterministic)
+}
+func (m *ShareCheck) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShareCheck.Merge(m, src)
+}
+func (m *ShareCheck) XXX_Size() int {
+	return xxx_messageInfo_ShareCheck.Size(m)
+}
+func (m *ShareCheck) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShareCheck.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ShareCheck proto.InternalMessageInfo
+
+func (m *ShareCheck) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ShareCheck) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *ShareCheck) GetCommitment() []byte {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
+func (m *ShareCheck) GetValid() bool {
+	if m != nil {
+		return m.Valid
+	}
+	return false
+}
+
+func (m *ShareCheck) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// Goodbye is the last post of a node, once it is done with the protocol.
+type Goodbye struct {
+	Epoch                int32    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From                 int64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Signature            []byte   `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Goodbye) Reset()         { *m = Goodbye{} }
+func (m *Goodbye) String() string { return proto.CompactTextString(m) }
+func (*Goodbye) ProtoMessage()    {}
+func (*Goodbye) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{8}
+}
+
+func (m *Goodbye) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Goodbye.Unmarshal(m, b)
+}
+func (m *Goodbye) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Goodbye.Marshal(b, m, deterministic)
+}
+func (m *Goodbye) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Goodbye.Merge(m, src)
+}
+func (m *Goodbye) XXX_Size() int {
+	return xxx_messageInfo_Goodbye.Size(m)
+}
+func (m *Goodbye) XXX_DiscardUnknown() {
+	xxx_messageInfo_Goodbye.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Goodbye proto.InternalMessageInfo
+
+func (m *Goodbye) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Goodbye) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *Goodbye) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// ShareErasure records on the board that a node destroyed the share it held before the epoch.
+type ShareErasure struct {
+	Epoch                int32    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From                 int64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Signature            []byte   `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ShareErasure) Reset()         { *m = ShareErasure{} }
+func (m *ShareErasure) String() string { return proto.CompactTextString(m) }
+func (*ShareErasure) ProtoMessage()    {}
+func (*ShareErasure) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{9}
+}
+
+func (m *ShareErasure) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ShareErasure.Unmarshal(m, b)
+}
+func (m *ShareErasure) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ShareErasure.Marshal(b, m, deterministic)
+}
+func (m *ShareErasure) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShareErasure.Merge(m, src)
+}
+func (m *ShareErasure) XXX_Size() int {
+	return xxx_messageInfo_ShareErasure.Size(m)
+}
+func (m *ShareErasure) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShareErasure.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ShareErasure proto.InternalMessageInfo
+
+func (m *ShareErasure) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ShareErasure) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *ShareErasure) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type ProposalHash struct {
+	Epoch    int32  `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Proposer int64  `protobuf:"varint,2,opt,name=proposer,proto3" json:"proposer,omitempty"`
+	Hash     []byte `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	// makes the lists of the board evidence of what each proposer committed to
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	// version of the encoding the proposal was hashed over
+	Version              uint32   `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProposalHash) Reset()         { *m = ProposalHash{} }
+func (m *ProposalHash) String() string { return proto.CompactTextString(m) }
+func (*ProposalHash) ProtoMessage()    {}
+func (*ProposalHash) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{10}
+}
+
+func (m *ProposalHash) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProposalHash.Unmarshal(m, b)
+}
+func (m *ProposalHash) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProposalHash.Marshal(b, m, deterministic)
+}
+func (m *ProposalHash) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProposalHash.Merge(m, src)
+}
+func (m *ProposalHash) XXX_Size() int {
+	return xxx_messageInfo_ProposalHash.Size(m)
+}
+func (m *ProposalHash) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProposalHash.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProposalHash proto.InternalMessageInfo
+
+func (m *ProposalHash) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ProposalHash) GetProposer() int64 {
+	if m != nil {
+		return m.Proposer
+	}
+	return 0
+}
+
+func (m *ProposalHash) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *ProposalHash) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *ProposalHash) GetVersion() uint32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type ProposalHashList struct {
+	Epoch int32           `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	List  []*ProposalHash `protobuf:"bytes,2,rep,name=list,proto3" json:"list,omitempty"`
+	// proposals the primary had to fetch from their proposers to resolve complaints
+	Revealed []*Proposal `protobuf:"bytes,3,rep,name=revealed,proto3" json:"revealed,omitempty"`
+	// commit signatures of a quorum of replicas, if the bulletin board is replicated
+	Certificate          []*ReplicaSignature `protobuf:"bytes,4,rep,name=certificate,proto3" json:"certificate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *ProposalHashList) Reset()         { *m = ProposalHashList{} }
+func (m *ProposalHashList) String() string { return proto.CompactTextString(m) }
+func (*ProposalHashList) ProtoMessage()    {}
+func (*ProposalHashList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{11}
+}
+
+func (m *ProposalHashList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProposalHashList.Unmarshal(m, b)
+}
+func (m *ProposalHashList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProposalHashList.Marshal(b, m, deterministic)
+}
+func (m *ProposalHashList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProposalHashList.Merge(m, src)
+}
+func (m *ProposalHashList) XXX_Size() int {
+	return xxx_messageInfo_ProposalHashList.Size(m)
+}
+func (m *ProposalHashList) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProposalHashList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProposalHashList proto.InternalMessageInfo
+
+func (m *ProposalHashList) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ProposalHashList) GetList() []*ProposalHash {
+	if m != nil {
+		return m.List
+	}
+	return nil
+}
+
+func (m *ProposalHashList) GetRevealed() []*Proposal {
+	if m != nil {
+		return m.Revealed
+	}
+	return nil
+}
+
+func (m *ProposalHashList) GetCertificate() []*ReplicaSignature {
+	if m != nil {
+		return m.Certificate
+	}
+	return nil
+}
+
+type ReplicaSignature struct {
+	Replica              int64    `protobuf:"varint,1,opt,name=replica,proto3" json:"replica,omitempty"`
+	View                 int32    `protobuf:"varint,2,opt,name=view,proto3" json:"view,omitempty"`
+	Signature            []byte   `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplicaSignature) Reset()         { *m = ReplicaSignature{} }
+func (m *ReplicaSignature) String() string { return proto.CompactTextString(m) }
+func (*ReplicaSignature) ProtoMessage()    {}
+func (*ReplicaSignature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{12}
+}
+
+func (m *ReplicaSignature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplicaSignature.Unmarshal(m, b)
+}
+func (m *ReplicaSignature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplicaSignature.Marshal(b, m, deterministic)
+}
+func (m *ReplicaSignature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplicaSignature.Merge(m, src)
+}
+func (m *ReplicaSignature) XXX_Size() int {
+	return xxx_messageInfo_ReplicaSignature.Size(m)
+}
+func (m *ReplicaSignature) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplicaSignature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplicaSignature proto.InternalMessageInfo
+
+func (m *ReplicaSignature) GetReplica() int64 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *ReplicaSignature) GetView() int32 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *ReplicaSignature) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// a message of the ordering protocol run among the bulletin board replicas
+type ConsensusMessage struct {
+	Type    ConsensusMessage_Type `protobuf:"varint,1,opt,name=type,proto3,enum=services.ConsensusMessage_Type" json:"type,omitempty"`
+	Epoch   int32                 `protobuf:"varint,2,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	View    int32                 `protobuf:"varint,3,opt,name=view,proto3" json:"view,omitempty"`
+	Replica int64                 `protobuf:"varint,4,opt,name=replica,proto3" json:"replica,omitempty"`
+	Digest  []byte                `protobuf:"bytes,5,opt,name=digest,proto3" json:"digest,omitempty"`
+	// the proposed list (pre-prepare, commit and new-view), or the prepared list (view-change)
+	List *ProposalHashList `protobuf:"bytes,6,opt,name=list,proto3" json:"list,omitempty"`
+	// view-change: the view in which list was prepared, and the prepares proving it
+	PreparedView int32               `protobuf:"varint,7,opt,name=prepared_view,json=preparedView,proto3" json:"prepared_view,omitempty"`
+	Prepares     []*ReplicaSignature `protobuf:"bytes,8,rep,name=prepares,proto3" json:"prepares,omitempty"`
+	// new-view: the view-changes justifying the choice of the new leader
+	ViewChanges          []*ConsensusMessage `protobuf:"bytes,9,rep,name=view_changes,json=viewChanges,proto3" json:"view_changes,omitempty"`
+	Signature            []byte              `protobuf:"bytes,10,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *ConsensusMessage) Reset()         { *m = ConsensusMessage{} }
+func (m *ConsensusMessage) String() string { return proto.CompactTextString(m) }
+func (*ConsensusMessage) ProtoMessage()    {}
+func (*ConsensusMessage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{13}
+}
+
+func (m *ConsensusMessage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConsensusMessage.Unmarshal(m, b)
+}
+func (m *ConsensusMessage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConsensusMessage.Marshal(b, m, deterministic)
+}
+func (m *ConsensusMessage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConsensusMessage.Merge(m, src)
+}
+func (m *ConsensusMessage) XXX_Size() int {
+	return xxx_messageInfo_ConsensusMessage.Size(m)
+}
+func (m *ConsensusMessage) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConsensusMessage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConsensusMessage proto.InternalMessageInfo
+
+func (m *ConsensusMessage) GetType() ConsensusMessage_Type {
+	if m != nil {
+		return m.Type
+	}
+	return ConsensusMessage_PRE_PREPARE
+}
+
+func (m *ConsensusMessage) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ConsensusMessage) GetView() int32 {
+	if m != nil {
+		return m.View
+	}
+	return 0
+}
+
+func (m *ConsensusMessage) GetReplica() int64 {
+	if m != nil {
+		return m.Replica
+	}
+	return 0
+}
+
+func (m *ConsensusMessage) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *ConsensusMessage) GetList() *ProposalHashList {
+	if m != nil {
+		return m.List
+	}
+	return nil
+}
+
+func (m *ConsensusMessage) GetPreparedView() int32 {
+	if m != nil {
+		return m.PreparedView
+	}
+	return 0
+}
+
+func (m *ConsensusMessage) GetPrepares() []*ReplicaSignature {
+	if m != nil {
+		return m.Prepares
+	}
+	return nil
+}
+
+func (m *ConsensusMessage) GetViewChanges() []*ConsensusMessage {
+	if m != nil {
+		return m.ViewChanges
+	}
+	return nil
+}
+
+func (m *ConsensusMessage) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// a proposal, made for the epoch by its proposer
+type Proposal struct {
+	Epoch     int32  `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From      int64  `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	// the session the proposal was made for
+	Session []byte      `protobuf:"bytes,5,opt,name=session,proto3" json:"session,omitempty"`
+	CommQ   *PolyCommit `protobuf:"bytes,6,opt,name=commQ,proto3" json:"commQ,omitempty"`
+	// one for each new node
+	CommRs []*BlindingCommitment `protobuf:"bytes,7,rep,name=commRs,proto3" json:"commRs,omitempty"`
+	// sent by the proposer to an old node: the points for that node, and the Merkle path from them
+	// to the root. Handed out by GetProposal and revealed in the final list: the points for every old node.
+	Points               []*VerifiablePoints `protobuf:"bytes,10,rep,name=points,proto3" json:"points,omitempty"`
+	Path                 *MerklePath         `protobuf:"bytes,9,opt,name=path,proto3" json:"path,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *Proposal) Reset()         { *m = Proposal{} }
+func (m *Proposal) String() string { return proto.CompactTextString(m) }
+func (*Proposal) ProtoMessage()    {}
+func (*Proposal) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{14}
+}
+
+func (m *Proposal) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Proposal.Unmarshal(m, b)
+}
+func (m *Proposal) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Proposal.Marshal(b, m, deterministic)
+}
+func (m *Proposal) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Proposal.Merge(m, src)
+}
+func (m *Proposal) XXX_Size() int {
+	return xxx_messageInfo_Proposal.Size(m)
+}
+func (m *Proposal) XXX_DiscardUnknown() {
+	xxx_messageInfo_Proposal.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Proposal proto.InternalMessageInfo
+
+func (m *Proposal) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Proposal) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *Proposal) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *Proposal) GetSession() []byte {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *Proposal) GetCommQ() *PolyCommit {
+	if m != nil {
+		return m.CommQ
+	}
+	return nil
+}
+
+func (m *Proposal) GetCommRs() []*BlindingCommitment {
+	if m != nil {
+		return m.CommRs
+	}
+	return nil
+}
+
+func (m *Proposal) GetPoints() []*VerifiablePoints {
+	if m != nil {
+		return m.Points
+	}
+	return nil
+}
+
+func (m *Proposal) GetPath() *MerklePath {
+	if m != nil {
+		return m.Path
+	}
+	return nil
+}
+
+// names a proposal streamed to a node
+type ProposalTransfer struct {
+	Epoch int32 `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From  int64 `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	// sha256 of the encoded proposal
+	Digest []byte `protobuf:"bytes,3,opt,name=digest,proto3" json:"digest,omitempty"`
+	// of the encoded proposal, in bytes
+	Size                 int64    `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProposalTransfer) Reset()         { *m = ProposalTransfer{} }
+func (m *ProposalTransfer) String() string { return proto.CompactTextString(m) }
+func (*ProposalTransfer) ProtoMessage()    {}
+func (*ProposalTransfer) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{15}
+}
+
+func (m *ProposalTransfer) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProposalTransfer.Unmarshal(m, b)
+}
+func (m *ProposalTransfer) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProposalTransfer.Marshal(b, m, deterministic)
+}
+func (m *ProposalTransfer) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProposalTransfer.Merge(m, src)
+}
+func (m *ProposalTransfer) XXX_Size() int {
+	return xxx_messageInfo_ProposalTransfer.Size(m)
+}
+func (m *ProposalTransfer) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProposalTransfer.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProposalTransfer proto.InternalMessageInfo
+
+func (m *ProposalTransfer) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ProposalTransfer) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *ProposalTransfer) GetDigest() []byte {
+	if m != nil {
+		return m.Digest
+	}
+	return nil
+}
+
+func (m *ProposalTransfer) GetSize() int64 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type ProposalChunk struct {
+	Transfer *ProposalTransfer `protobuf:"bytes,1,opt,name=transfer,proto3" json:"transfer,omitempty"`
+	Offset   int64             `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data     []byte            `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	// sha256 of data
+	Checksum             []byte   `protobuf:"bytes,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProposalChunk) Reset()         { *m = ProposalChunk{} }
+func (m *ProposalChunk) String() string { return proto.CompactTextString(m) }
+func (*ProposalChunk) ProtoMessage()    {}
+func (*ProposalChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{16}
+}
+
+func (m *ProposalChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProposalChunk.Unmarshal(m, b)
+}
+func (m *ProposalChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProposalChunk.Marshal(b, m, deterministic)
+}
+func (m *ProposalChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProposalChunk.Merge(m, src)
+}
+func (m *ProposalChunk) XXX_Size() int {
+	return xxx_messageInfo_ProposalChunk.Size(m)
+}
+func (m *ProposalChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProposalChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProposalChunk proto.InternalMessageInfo
+
+func (m *ProposalChunk) GetTransfer() *ProposalTransfer {
+	if m != nil {
+		return m.Transfer
+	}
+	return nil
+}
+
+func (m *ProposalChunk) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *ProposalChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *ProposalChunk) GetChecksum() []byte {
+	if m != nil {
+		return m.Checksum
+	}
+	return nil
+}
+
+type ProposalTransferStatus struct {
+	// bytes received so far, from which the sender resumes
+	Received             int64    `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`
+	Complete             bool     `protobuf:"varint,2,opt,name=complete,proto3" json:"complete,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProposalTransferStatus) Reset()         { *m = ProposalTransferStatus{} }
+func (m *ProposalTransferStatus) String() string { return proto.CompactTextString(m) }
+func (*ProposalTransferStatus) ProtoMessage()    {}
+func (*ProposalTransferStatus) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{17}
+}
+
+func (m *ProposalTransferStatus) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProposalTransferStatus.Unmarshal(m, b)
+}
+func (m *ProposalTransferStatus) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProposalTransferStatus.Marshal(b, m, deterministic)
+}
+func (m *ProposalTransferStatus) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProposalTransferStatus.Merge(m, src)
+}
+func (m *ProposalTransferStatus) XXX_Size() int {
+	return xxx_messageInfo_ProposalTransferStatus.Size(m)
+}
+func (m *ProposalTransferStatus) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProposalTransferStatus.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProposalTransferStatus proto.InternalMessageInfo
+
+func (m *ProposalTransferStatus) GetReceived() int64 {
+	if m != nil {
+		return m.Received
+	}
+	return 0
+}
+
+func (m *ProposalTransferStatus) GetComplete() bool {
+	if m != nil {
+		return m.Complete
+	}
+	return false
+}
+
+// a commitment to a polynomial: for each coefficient a_i, from the constant term up, the compressed P-256
+// point a_i G on 33 bytes, all zero for the point at infinity
+type PolyCommit struct {
+	Coefficients         [][]byte `protobuf:"bytes,2,rep,name=coefficients,proto3" json:"coefficients,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PolyCommit) Reset()         { *m = PolyCommit{} }
+func (m *PolyCommit) String() string { return proto.CompactTextString(m) }
+func (*PolyCommit) ProtoMessage()    {}
+func (*PolyCommit) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{18}
+}
+
+func (m *PolyCommit) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PolyCommit.Unmarshal(m, b)
+}
+func (m *PolyCommit) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PolyCommit.Marshal(b, m, deterministic)
+}
+func (m *PolyCommit) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PolyCommit.Merge(m, src)
+}
+func (m *PolyCommit) XXX_Size() int {
+	return xxx_messageInfo_PolyCommit.Size(m)
+}
+func (m *PolyCommit) XXX_DiscardUnknown() {
+	xxx_messageInfo_PolyCommit.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PolyCommit proto.InternalMessageInfo
+
+func (m *PolyCommit) GetCoefficients() [][]byte {
+	if m != nil {
+		return m.Coefficients
+	}
+	return nil
+}
+
+type BlindingCommitment struct {
+	Id                   int32       `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Commitment           *PolyCommit `protobuf:"bytes,2,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *BlindingCommitment) Reset()         { *m = BlindingCommitment{} }
+func (m *BlindingCommitment) String() string { return proto.CompactTextString(m) }
+func (*BlindingCommitment) ProtoMessage()    {}
+func (*BlindingCommitment) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{19}
+}
+
+func (m *BlindingCommitment) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BlindingCommitment.Unmarshal(m, b)
+}
+func (m *BlindingCommitment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BlindingCommitment.Marshal(b, m, deterministic)
+}
+func (m *BlindingCommitment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BlindingCommitment.Merge(m, src)
+}
+func (m *BlindingCommitment) XXX_Size() int {
+	return xxx_messageInfo_BlindingCommitment.Size(m)
+}
+func (m *BlindingCommitment) XXX_DiscardUnknown() {
+	xxx_messageInfo_BlindingCommitment.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BlindingCommitment proto.InternalMessageInfo
+
+func (m *BlindingCommitment) GetId() int32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *BlindingCommitment) GetCommitment() *PolyCommit {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
+// a field element for a node, big-endian on as many bytes as the prime
+type NodePoint struct {
+	Id                   int32    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Value                []byte   `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodePoint) Reset()         { *m = NodePoint{} }
+func (m *NodePoint) String() string { return proto.CompactTextString(m) }
+func (*NodePoint) ProtoMessage()    {}
+func (*NodePoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{20}
+}
+
+func (m *NodePoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodePoint.Unmarshal(m, b)
+}
+func (m *NodePoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodePoint.Marshal(b, m, deterministic)
+}
+func (m *NodePoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodePoint.Merge(m, src)
+}
+func (m *NodePoint) XXX_Size() int {
+	return xxx_messageInfo_NodePoint.Size(m)
+}
+func (m *NodePoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodePoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodePoint proto.InternalMessageInfo
+
+func (m *NodePoint) GetId() int32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *NodePoint) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+// one point for each new node
+type PointsOnBlindingPoly struct {
+	Points               []*NodePoint `protobuf:"bytes,1,rep,name=points,proto3" json:"points,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *PointsOnBlindingPoly) Reset()         { *m = PointsOnBlindingPoly{} }
+func (m *PointsOnBlindingPoly) String() string { return proto.CompactTextString(m) }
+func (*PointsOnBlindingPoly) ProtoMessage()    {}
+func (*PointsOnBlindingPoly) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{21}
+}
+
+func (m *PointsOnBlindingPoly) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PointsOnBlindingPoly.Unmarshal(m, b)
+}
+func (m *PointsOnBlindingPoly) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PointsOnBlindingPoly.Marshal(b, m, deterministic)
+}
+func (m *PointsOnBlindingPoly) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PointsOnBlindingPoly.Merge(m, src)
+}
+func (m *PointsOnBlindingPoly) XXX_Size() int {
+	return xxx_messageInfo_PointsOnBlindingPoly.Size(m)
+}
+func (m *PointsOnBlindingPoly) XXX_DiscardUnknown() {
+	xxx_messageInfo_PointsOnBlindingPoly.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PointsOnBlindingPoly proto.InternalMessageInfo
+
+func (m *PointsOnBlindingPoly) GetPoints() []*NodePoint {
+	if m != nil {
+		return m.Points
+	}
+	return nil
+}
+
+// the points of a proposal for one old node, encrypted to its key in 16-bit chunks
+type VerifiablePoints struct {
+	Recipient int32 `protobuf:"varint,1,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	// one for each new node
+	Points []*EncryptedPoint `protobuf:"bytes,2,rep,name=points,proto3" json:"points,omitempty"`
+	// Chaum-Pedersen proof that the points lie on Q+Rk: two compressed P-256 points and a scalar on 32 bytes
+	Proof                []byte   `protobuf:"bytes,3,opt,name=proof,proto3" json:"proof,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VerifiablePoints) Reset()         { *m = VerifiablePoints{} }
+func (m *VerifiablePoints) String() string { return proto.CompactTextString(m) }
+func (*VerifiablePoints) ProtoMessage()    {}
+func (*VerifiablePoints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{22}
+}
+
+func (m *VerifiablePoints) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifiablePoints.Unmarshal(m, b)
+}
+func (m *VerifiablePoints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifiablePoints.Marshal(b, m, deterministic)
+}
+func (m *VerifiablePoints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifiablePoints.Merge(m, src)
+}
+func (m *VerifiablePoints) XXX_Size() int {
+	return xxx_messageInfo_VerifiablePoints.Size(m)
+}
+func (m *VerifiablePoints) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifiablePoints.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifiablePoints proto.InternalMessageInfo
+
+func (m *VerifiablePoints) GetRecipient() int32 {
+	if m != nil {
+		return m.Recipient
+	}
+	return 0
+}
+
+func (m *VerifiablePoints) GetPoints() []*EncryptedPoint {
+	if m != nil {
+		return m.Points
+	}
+	return nil
+}
+
+func (m *VerifiablePoints) GetProof() []byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+// the ElGamal ciphertexts of the chunks of the point for a new node, least significant first
+type EncryptedPoint struct {
+	Id int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// compressed P-256 points rG
+	Ephemerals [][]byte `protobuf:"bytes,2,rep,name=ephemerals,proto3" json:"ephemerals,omitempty"`
+	// compressed P-256 points mG + r PK, for the chunk m
+	Masked               [][]byte `protobuf:"bytes,3,rep,name=masked,proto3" json:"masked,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EncryptedPoint) Reset()         { *m = EncryptedPoint{} }
+func (m *EncryptedPoint) String() string { return proto.CompactTextString(m) }
+func (*EncryptedPoint) ProtoMessage()    {}
+func (*EncryptedPoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{23}
+}
+
+func (m *EncryptedPoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EncryptedPoint.Unmarshal(m, b)
+}
+func (m *EncryptedPoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EncryptedPoint.Marshal(b, m, deterministic)
+}
+func (m *EncryptedPoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EncryptedPoint.Merge(m, src)
+}
+func (m *EncryptedPoint) XXX_Size() int {
+	return xxx_messageInfo_EncryptedPoint.Size(m)
+}
+func (m *EncryptedPoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_EncryptedPoint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EncryptedPoint proto.InternalMessageInfo
+
+func (m *EncryptedPoint) GetId() int32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *EncryptedPoint) GetEphemerals() [][]byte {
+	if m != nil {
+		return m.Ephemerals
+	}
+	return nil
+}
+
+func (m *EncryptedPoint) GetMasked() [][]byte {
+	if m != nil {
+		return m.Masked
+	}
+	return nil
+}
+
+// points for one old node, encrypted to its key
+type EncryptedPoints struct {
+	Recipient int32 `protobuf:"varint,1,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	// uncompressed P-256 point R = rG
+	Ephemeral []byte `protobuf:"bytes,2,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
+	// point + pad, for each new node
+	Cipher *PointsOnBlindingPoly `protobuf:"bytes,3,opt,name=cipher,proto3" json:"cipher,omitempty"`
+	// Schnorr proof of knowledge of r
+	ProofCommitment      []byte   `protobuf:"bytes,4,opt,name=proofCommitment,proto3" json:"proofCommitment,omitempty"`
+	ProofResponse        []byte   `protobuf:"bytes,5,opt,name=proofResponse,proto3" json:"proofResponse,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EncryptedPoints) Reset()         { *m = EncryptedPoints{} }
+func (m *EncryptedPoints) String() string { return proto.CompactTextString(m) }
+func (*EncryptedPoints) ProtoMessage()    {}
+func (*EncryptedPoints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{24}
+}
+
+func (m *EncryptedPoints) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EncryptedPoints.Unmarshal(m, b)
+}
+func (m *EncryptedPoints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EncryptedPoints.Marshal(b, m, deterministic)
+}
+func (m *EncryptedPoints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EncryptedPoints.Merge(m, src)
+}
+func (m *EncryptedPoints) XXX_Size() int {
+	return xxx_messageInfo_EncryptedPoints.Size(m)
+}
+func (m *EncryptedPoints) XXX_DiscardUnknown() {
+	xxx_messageInfo_EncryptedPoints.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EncryptedPoints proto.InternalMessageInfo
+
+func (m *EncryptedPoints) GetRecipient() int32 {
+	if m != nil {
+		return m.Recipient
+	}
+	return 0
+}
+
+func (m *EncryptedPoints) GetEphemeral() []byte {
+	if m != nil {
+		return m.Ephemeral
+	}
+	return nil
+}
+
+func (m *EncryptedPoints) GetCipher() *PointsOnBlindingPoly {
+	if m != nil {
+		return m.Cipher
+	}
+	return nil
+}
+
+func (m *EncryptedPoints) GetProofCommitment() []byte {
+	if m != nil {
+		return m.ProofCommitment
+	}
+	return nil
+}
+
+func (m *EncryptedPoints) GetProofResponse() []byte {
+	if m != nil {
+		return m.ProofResponse
+	}
+	return nil
+}
+
+// the position of a leaf among the leaves of a Merkle tree, and the path from it to the root
+type MerklePath struct {
+	Index                int32    `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Count                int32    `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Nodes                [][]byte `protobuf:"bytes,3,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerklePath) Reset()         { *m = MerklePath{} }
+func (m *MerklePath) String() string { return proto.CompactTextString(m) }
+func (*MerklePath) ProtoMessage()    {}
+func (*MerklePath) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{25}
+}
+
+func (m *MerklePath) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerklePath.Unmarshal(m, b)
+}
+func (m *MerklePath) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerklePath.Marshal(b, m, deterministic)
+}
+func (m *MerklePath) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerklePath.Merge(m, src)
+}
+func (m *MerklePath) XXX_Size() int {
+	return xxx_messageInfo_MerklePath.Size(m)
+}
+func (m *MerklePath) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerklePath.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerklePath proto.InternalMessageInfo
+
+func (m *MerklePath) GetIndex() int32 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *MerklePath) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *MerklePath) GetNodes() [][]byte {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+// an accusation against a proposer in the agreed list
+type Complaint struct {
+	Accused int64 `protobuf:"varint,1,opt,name=accused,proto3" json:"accused,omitempty"`
+	// the accuser's slice of the agreed proposal, if it is invalid. Empty if it was never received.
+	Proposal *Proposal `protobuf:"bytes,2,opt,name=proposal,proto3" json:"proposal,omitempty"`
+	// the key of a chunk of the points sent to the accuser and a proof that it is the right one,
+	// if the chunk doesn't decrypt
+	SharedKey       []byte `protobuf:"bytes,3,opt,name=sharedKey,proto3" json:"sharedKey,omitempty"`
+	DisclosureProof []byte `protobuf:"bytes,4,opt,name=disclosureProof,proto3" json:"disclosureProof,omitempty"`
+	// the chunk whose key is disclosed: the new node of its point, and its position in the point
+	Point                int32    `protobuf:"varint,5,opt,name=point,proto3" json:"point,omitempty"`
+	Chunk                int32    `protobuf:"varint,6,opt,name=chunk,proto3" json:"chunk,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Complaint) Reset()         { *m = Complaint{} }
+func (m *Complaint) String() string { return proto.CompactTextString(m) }
+func (*Complaint) ProtoMessage()    {}
+func (*Complaint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{26}
+}
+
+func (m *Complaint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Complaint.Unmarshal(m, b)
+}
+func (m *Complaint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Complaint.Marshal(b, m, deterministic)
+}
+func (m *Complaint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Complaint.Merge(m, src)
+}
+func (m *Complaint) XXX_Size() int {
+	return xxx_messageInfo_Complaint.Size(m)
+}
+func (m *Complaint) XXX_DiscardUnknown() {
+	xxx_messageInfo_Complaint.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Complaint proto.InternalMessageInfo
+
+func (m *Complaint) GetAccused() int64 {
+	if m != nil {
+		return m.Accused
+	}
+	return 0
+}
+
+func (m *Complaint) GetProposal() *Proposal {
+	if m != nil {
+		return m.Proposal
+	}
+	return nil
+}
+
+func (m *Complaint) GetSharedKey() []byte {
+	if m != nil {
+		return m.SharedKey
+	}
+	return nil
+}
+
+func (m *Complaint) GetDisclosureProof() []byte {
+	if m != nil {
+		return m.DisclosureProof
+	}
+	return nil
+}
+
+func (m *Complaint) GetPoint() int32 {
+	if m != nil {
+		return m.Point
+	}
+	return 0
+}
+
+func (m *Complaint) GetChunk() int32 {
+	if m != nil {
+		return m.Chunk
+	}
+	return 0
+}
+
+type ComplaintList struct {
+	Epoch                int32        `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From                 int64        `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	List                 []*Complaint `protobuf:"bytes,3,rep,name=list,proto3" json:"list,omitempty"`
+	Signature            []byte       `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *ComplaintList) Reset()         { *m = ComplaintList{} }
+func (m *ComplaintList) String() string { return proto.CompactTextString(m) }
+func (*ComplaintList) ProtoMessage()    {}
+func (*ComplaintList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{27}
+}
+
+func (m *ComplaintList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ComplaintList.Unmarshal(m, b)
+}
+func (m *ComplaintList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ComplaintList.Marshal(b, m, deterministic)
+}
+func (m *ComplaintList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ComplaintList.Merge(m, src)
+}
+func (m *ComplaintList) XXX_Size() int {
+	return xxx_messageInfo_ComplaintList.Size(m)
+}
+func (m *ComplaintList) XXX_DiscardUnknown() {
+	xxx_messageInfo_ComplaintList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ComplaintList proto.InternalMessageInfo
+
+func (m *ComplaintList) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ComplaintList) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *ComplaintList) GetList() []*Complaint {
+	if m != nil {
+		return m.List
+	}
+	return nil
+}
+
+func (m *ComplaintList) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type ProposalRequest struct {
 	Epoch                int32    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
-	From                 int64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
-	Share                []byte   `protobuf:"bytes,3,opt,name=share,proto3" json:"share,omitempty"`
+	Proposer             int64    `protobuf:"varint,2,opt,name=proposer,proto3" json:"proposer,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ProposalRequest) Reset()         { *m = ProposalRequest{} }
+func (m *ProposalRequest) String() string { return proto.CompactTextString(m) }
+func (*ProposalRequest) ProtoMessage()    {}
+func (*ProposalRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{28}
+}
+
+func (m *ProposalRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ProposalRequest.Unmarshal(m, b)
+}
+func (m *ProposalRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ProposalRequest.Marshal(b, m, deterministic)
+}
+func (m *ProposalRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ProposalRequest.Merge(m, src)
+}
+func (m *ProposalRequest) XXX_Size() int {
+	return xxx_messageInfo_ProposalRequest.Size(m)
+}
+func (m *ProposalRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ProposalRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ProposalRequest proto.InternalMessageInfo
+
+func (m *ProposalRequest) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *ProposalRequest) GetProposer() int64 {
+	if m != nil {
+		return m.Proposer
+	}
+	return 0
+}
+
+// asks a holder for masks to recover the share of the sender
+type RecoveryRequest struct {
+	From int64 `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	// random, picked by the recovering node for this recovery
+	Session              []byte   `protobuf:"bytes,2,opt,name=session,proto3" json:"session,omitempty"`
+	Helpers              []int64  `protobuf:"varint,3,rep,packed,name=helpers,proto3" json:"helpers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RecoveryRequest) Reset()         { *m = RecoveryRequest{} }
+func (m *RecoveryRequest) String() string { return proto.CompactTextString(m) }
+func (*RecoveryRequest) ProtoMessage()    {}
+func (*RecoveryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{29}
+}
+
+func (m *RecoveryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoveryRequest.Unmarshal(m, b)
+}
+func (m *RecoveryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoveryRequest.Marshal(b, m, deterministic)
+}
+func (m *RecoveryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoveryRequest.Merge(m, src)
+}
+func (m *RecoveryRequest) XXX_Size() int {
+	return xxx_messageInfo_RecoveryRequest.Size(m)
+}
+func (m *RecoveryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoveryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoveryRequest proto.InternalMessageInfo
+
+func (m *RecoveryRequest) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *RecoveryRequest) GetSession() []byte {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *RecoveryRequest) GetHelpers() []int64 {
+	if m != nil {
+		return m.Helpers
+	}
+	return nil
+}
+
+// a point on the mask polynomial of a helper, encrypted to the helper it is for
+type RecoveryMask struct {
+	Epoch                int32            `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From                 int64            `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	To                   int64            `protobuf:"varint,3,opt,name=to,proto3" json:"to,omitempty"`
+	Session              []byte           `protobuf:"bytes,4,opt,name=session,proto3" json:"session,omitempty"`
+	Mask                 *EncryptedPoints `protobuf:"bytes,5,opt,name=mask,proto3" json:"mask,omitempty"`
+	Signature            []byte           `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *RecoveryMask) Reset()         { *m = RecoveryMask{} }
+func (m *RecoveryMask) String() string { return proto.CompactTextString(m) }
+func (*RecoveryMask) ProtoMessage()    {}
+func (*RecoveryMask) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{30}
+}
+
+func (m *RecoveryMask) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoveryMask.Unmarshal(m, b)
+}
+func (m *RecoveryMask) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoveryMask.Marshal(b, m, deterministic)
+}
+func (m *RecoveryMask) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoveryMask.Merge(m, src)
+}
+func (m *RecoveryMask) XXX_Size() int {
+	return xxx_messageInfo_RecoveryMask.Size(m)
+}
+func (m *RecoveryMask) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoveryMask.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoveryMask proto.InternalMessageInfo
+
+func (m *RecoveryMask) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *RecoveryMask) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *RecoveryMask) GetTo() int64 {
+	if m != nil {
+		return m.To
+	}
+	return 0
+}
+
+func (m *RecoveryMask) GetSession() []byte {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *RecoveryMask) GetMask() *EncryptedPoints {
+	if m != nil {
+		return m.Mask
+	}
+	return nil
+}
+
+func (m *RecoveryMask) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+type RecoveryMaskList struct {
+	Epoch                int32           `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From                 int64           `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	HandedOff            bool            `protobuf:"varint,3,opt,name=handedOff,proto3" json:"handedOff,omitempty"`
+	Masks                []*RecoveryMask `protobuf:"bytes,4,rep,name=masks,proto3" json:"masks,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *RecoveryMaskList) Reset()         { *m = RecoveryMaskList{} }
+func (m *RecoveryMaskList) String() string { return proto.CompactTextString(m) }
+func (*RecoveryMaskList) ProtoMessage()    {}
+func (*RecoveryMaskList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{31}
+}
+
+func (m *RecoveryMaskList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoveryMaskList.Unmarshal(m, b)
+}
+func (m *RecoveryMaskList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoveryMaskList.Marshal(b, m, deterministic)
+}
+func (m *RecoveryMaskList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoveryMaskList.Merge(m, src)
+}
+func (m *RecoveryMaskList) XXX_Size() int {
+	return xxx_messageInfo_RecoveryMaskList.Size(m)
+}
+func (m *RecoveryMaskList) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoveryMaskList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoveryMaskList proto.InternalMessageInfo
+
+func (m *RecoveryMaskList) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *RecoveryMaskList) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *RecoveryMaskList) GetHandedOff() bool {
+	if m != nil {
+		return m.HandedOff
+	}
+	return false
+}
+
+func (m *RecoveryMaskList) GetMasks() []*RecoveryMask {
+	if m != nil {
+		return m.Masks
+	}
+	return nil
+}
+
+// hands a helper the masks of the other helpers
+type RecoverShareRequest struct {
+	From                 int64           `protobuf:"varint,1,opt,name=from,proto3" json:"from,omitempty"`
+	Session              []byte          `protobuf:"bytes,2,opt,name=session,proto3" json:"session,omitempty"`
+	Masks                []*RecoveryMask `protobuf:"bytes,3,rep,name=masks,proto3" json:"masks,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *RecoverShareRequest) Reset()         { *m = RecoverShareRequest{} }
+func (m *RecoverShareRequest) String() string { return proto.CompactTextString(m) }
+func (*RecoverShareRequest) ProtoMessage()    {}
+func (*RecoverShareRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{32}
+}
+
+func (m *RecoverShareRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoverShareRequest.Unmarshal(m, b)
+}
+func (m *RecoverShareRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoverShareRequest.Marshal(b, m, deterministic)
+}
+func (m *RecoverShareRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoverShareRequest.Merge(m, src)
+}
+func (m *RecoverShareRequest) XXX_Size() int {
+	return xxx_messageInfo_RecoverShareRequest.Size(m)
+}
+func (m *RecoverShareRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoverShareRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RecoverShareRequest proto.InternalMessageInfo
+
+func (m *RecoverShareRequest) GetFrom() int64 {
+	if m != nil {
+		return m.From
+	}
+	return 0
+}
+
+func (m *RecoverShareRequest) GetSession() []byte {
+	if m != nil {
+		return m.Session
+	}
+	return nil
+}
+
+func (m *RecoverShareRequest) GetMasks() []*RecoveryMask {
+	if m != nil {
+		return m.Masks
+	}
+	return nil
+}
+
+// the share of a helper plus the masks, which together vanish at the recovering node
+type RecoveredPoint struct {
+	Epoch      int32  `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From       int64  `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Value      []byte `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	Commitment []byte `protobuf:"bytes,4,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	HandedOff  bool   `protobuf:"varint,5,opt,name=handedOff,proto3" json:"handedOff,omitempty"`
+	// the helpers whose masks are in the value
+	Helpers              []int64  `protobuf:"varint,6,rep,packed,name=helpers,proto3" json:"helpers,omitempty"`
+	Signature            []byte   `protobuf:"bytes,7,opt,name=signature,proto3" json:"signature,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *Share) Reset()         { *m = Share{} }
-func (m *Share) String() string { return proto.CompactTextString(m) }
-func (*Share) ProtoMessage()    {}
-func (*Share) Descriptor() ([]byte, []int) {
-	return fileDescriptor_8e16ccb8c5307b32, []int{0}
+func (m *RecoveredPoint) Reset()         { *m = RecoveredPoint{} }
+func (m *RecoveredPoint) String() string { return proto.CompactTextString(m) }
+func (*RecoveredPoint) ProtoMessage()    {}
+func (*RecoveredPoint) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{33}
 }
 
-func (m *Share) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Share.Unmarshal(m, b)
+func (m *RecoveredPoint) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RecoveredPoint.Unmarshal(m, b)
 }
-func (m *Share) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Share.Marshal(b, m, deterministic)
+func (m *RecoveredPoint) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RecoveredPoint.Marshal(b, m, deterministic)
 }
-func (m *Share) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Share.Merge(m, src)
+func (m *RecoveredPoint) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RecoveredPoint.Merge(m, src)
 }
-func (m *Share) XXX_Size() int {
-	return xxx_messageInfo_Share.Size(m)
+func (m *RecoveredPoint) XXX_Size() int {
+	return xxx_messageInfo_RecoveredPoint.Size(m)
 }
-func (m *Share) XXX_DiscardUnknown() {
-	xxx_messageInfo_Share.DiscardUnknown(m)
+func (m *RecoveredPoint) XXX_DiscardUnknown() {
+	xxx_messageInfo_RecoveredPoint.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_Share proto.InternalMessageInfo
+var xxx_messageInfo_RecoveredPoint proto.InternalMessageInfo
 
-func (m *Share) GetEpoch() int32 {
+func (m *RecoveredPoint) GetEpoch() int32 {
 	if m != nil {
 		return m.Epoch
 	}
 	return 0
 }
 
-func (m *Share) GetFrom() int64 {
+func (m *RecoveredPoint) GetFrom() int64 {
 	if m != nil {
 		return m.From
 	}
 	return 0
 }
 
-func (m *Share) GetShare() []byte {
+func (m *RecoveredPoint) GetValue() []byte {
 	if m != nil {
-		return m.Share
+		return m.Value
 	}
 	return nil
 }
 
-type BlindedShare struct {
-	Epoch                int32    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
-	From                 int64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
-	Share                []byte   `protobuf:"bytes,3,opt,name=share,proto3" json:"share,omitempty"`
+func (m *RecoveredPoint) GetCommitment() []byte {
+	if m != nil {
+		return m.Commitment
+	}
+	return nil
+}
+
+func (m *RecoveredPoint) GetHandedOff() bool {
+	if m != nil {
+		return m.HandedOff
+	}
+	return false
+}
+
+func (m *RecoveredPoint) GetHelpers() []int64 {
+	if m != nil {
+		return m.Helpers
+	}
+	return nil
+}
+
+func (m *RecoveredPoint) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// a share dealt privately to one node during the DKG
+type Dealing struct {
+	Epoch     int32  `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	From      int64  `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
+	Share     []byte `protobuf:"bytes,3,opt,name=share,proto3" json:"share,omitempty"`
+	Signature []byte `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	// the node the share is for
+	To                   int64    `protobuf:"varint,5,opt,name=to,proto3" json:"to,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *BlindedShare) Reset()         { *m = BlindedShare{} }
-func (m *BlindedShare) String() string { return proto.CompactTextString(m) }
-func (*BlindedShare) ProtoMessage()    {}
-func (*BlindedShare) Descriptor() ([]byte, []int) {
-	return fileDescriptor_8e16ccb8c5307b32, []int{1}
+func (m *Dealing) Reset()         { *m = Dealing{} }
+func (m *Dealing) String() string { return proto.CompactTextString(m) }
+func (*Dealing) ProtoMessage()    {}
+func (*Dealing) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{34}
 }
 
-func (m *BlindedShare) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_BlindedShare.Unmarshal(m, b)
+func (m *Dealing) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Dealing.Unmarshal(m, b)
 }
-func (m *BlindedShare) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_BlindedShare.Marshal(b, m, deterministic)
+func (m *Dealing) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Dealing.Marshal(b, m, deterministic)
 }
-func (m *BlindedShare) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_BlindedShare.Merge(m, src)
+func (m *Dealing) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Dealing.Merge(m, src)
 }
-func (m *BlindedShare) XXX_Size() int {
-	return xxx_messageInfo_BlindedShare.Size(m)
+func (m *Dealing) XXX_Size() int {
+	return xxx_messageInfo_Dealing.Size(m)
 }
-func (m *BlindedShare) XXX_DiscardUnknown() {
-	xxx_messageInfo_BlindedShare.DiscardUnknown(m)
+func (m *Dealing) XXX_DiscardUnknown() {
+	xxx_messageInfo_Dealing.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_BlindedShare proto.InternalMessageInfo
+var xxx_messageInfo_Dealing proto.InternalMessageInfo
 
-func (m *BlindedShare) GetEpoch() int32 {
+func (m *Dealing) GetEpoch() int32 {
 	if m != nil {
 		return m.Epoch
 	}
 	return 0
 }
 
-func (m *BlindedShare) GetFrom() int64 {
+func (m *Dealing) GetFrom() int64 {
 	if m != nil {
 		return m.From
 	}
 	return 0
 }
 
-func (m *BlindedShare) GetShare() []byte {
+func (m *Dealing) GetShare() []byte {
 	if m != nil {
 		return m.Share
 	}
 	return nil
 }
 
-type ProposalHash struct {
+func (m *Dealing) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *Dealing) GetTo() int64 {
+	if m != nil {
+		return m.To
+	}
+	return 0
+}
+
+// the commitment to a dealer's polynomial, posted on the bulletin board
+type DealingCommitment struct {
 	Epoch                int32    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
-	Proposer             int64    `protobuf:"varint,2,opt,name=proposer,proto3" json:"proposer,omitempty"`
-	Hash                 []byte   `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	Dealer               int64    `protobuf:"varint,2,opt,name=dealer,proto3" json:"dealer,omitempty"`
+	Commitment           []byte   `protobuf:"bytes,3,opt,name=commitment,proto3" json:"commitment,omitempty"`
+	Signature            []byte   `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ProposalHash) Reset()         { *m = ProposalHash{} }
-func (m *ProposalHash) String() string { return proto.CompactTextString(m) }
-func (*ProposalHash) ProtoMessage()    {}
-func (*ProposalHash) Descriptor() ([]byte, []int) {
-	return fileDescriptor_8e16ccb8c5307b32, []int{2}
+func (m *DealingCommitment) Reset()         { *m = DealingCommitment{} }
+func (m *DealingCommitment) String() string { return proto.CompactTextString(m) }
+func (*DealingCommitment) ProtoMessage()    {}
+func (*DealingCommitment) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{35}
 }
 
-func (m *ProposalHash) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ProposalHash.Unmarshal(m, b)
+func (m *DealingCommitment) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DealingCommitment.Unmarshal(m, b)
 }
-func (m *ProposalHash) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ProposalHash.Marshal(b, m, deterministic)
+func (m *DealingCommitment) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DealingCommitment.Marshal(b, m, deterministic)
 }
-func (m *ProposalHash) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ProposalHash.Merge(m, src)
+func (m *DealingCommitment) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DealingCommitment.Merge(m, src)
 }
-func (m *ProposalHash) XXX_Size() int {
-	return xxx_messageInfo_ProposalHash.Size(m)
+func (m *DealingCommitment) XXX_Size() int {
+	return xxx_messageInfo_DealingCommitment.Size(m)
 }
-func (m *ProposalHash) XXX_DiscardUnknown() {
-	xxx_messageInfo_ProposalHash.DiscardUnknown(m)
+func (m *DealingCommitment) XXX_DiscardUnknown() {
+	xxx_messageInfo_DealingCommitment.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ProposalHash proto.InternalMessageInfo
+var xxx_messageInfo_DealingCommitment proto.InternalMessageInfo
 
-func (m *ProposalHash) GetEpoch() int32 {
+func (m *DealingCommitment) GetEpoch() int32 {
 	if m != nil {
 		return m.Epoch
 	}
 	return 0
 }
 
-func (m *ProposalHash) GetProposer() int64 {
+func (m *DealingCommitment) GetDealer() int64 {
 	if m != nil {
-		return m.Proposer
+		return m.Dealer
 	}
 	return 0
 }
 
-func (m *ProposalHash) GetHash() []byte {
+func (m *DealingCommitment) GetCommitment() []byte {
 	if m != nil {
-		return m.Hash
+		return m.Commitment
 	}
 	return nil
 }
 
-type ProposalHashList struct {
-	Epoch                int32           `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
-	List                 []*ProposalHash `protobuf:"bytes,2,rep,name=list,proto3" json:"list,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+func (m *DealingCommitment) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
 }
 
-func (m *ProposalHashList) Reset()         { *m = ProposalHashList{} }
-func (m *ProposalHashList) String() string { return proto.CompactTextString(m) }
-func (*ProposalHashList) ProtoMessage()    {}
-func (*ProposalHashList) Descriptor() ([]byte, []int) {
-	return fileDescriptor_8e16ccb8c5307b32, []int{3}
+type DealingCommitmentList struct {
+	Epoch                int32                `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	List                 []*DealingCommitment `protobuf:"bytes,2,rep,name=list,proto3" json:"list,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
 }
 
-func (m *ProposalHashList) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ProposalHashList.Unmarshal(m, b)
+func (m *DealingCommitmentList) Reset()         { *m = DealingCommitmentList{} }
+func (m *DealingCommitmentList) String() string { return proto.CompactTextString(m) }
+func (*DealingCommitmentList) ProtoMessage()    {}
+func (*DealingCommitmentList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{36}
 }
-func (m *ProposalHashList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ProposalHashList.Marshal(b, m, deterministic)
+
+func (m *DealingCommitmentList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DealingCommitmentList.Unmarshal(m, b)
 }
-func (m *ProposalHashList) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ProposalHashList.Merge(m, src)
+func (m *DealingCommitmentList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DealingCommitmentList.Marshal(b, m, deterministic)
 }
-func (m *ProposalHashList) XXX_Size() int {
-	return xxx_messageInfo_ProposalHashList.Size(m)
+func (m *DealingCommitmentList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DealingCommitmentList.Merge(m, src)
 }
-func (m *ProposalHashList) XXX_DiscardUnknown() {
-	xxx_messageInfo_ProposalHashList.DiscardUnknown(m)
+func (m *DealingCommitmentList) XXX_Size() int {
+	return xxx_messageInfo_DealingCommitmentList.Size(m)
+}
+func (m *DealingCommitmentList) XXX_DiscardUnknown() {
+	xxx_messageInfo_DealingCommitmentList.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ProposalHashList proto.InternalMessageInfo
+var xxx_messageInfo_DealingCommitmentList proto.InternalMessageInfo
 
-func (m *ProposalHashList) GetEpoch() int32 {
+func (m *DealingCommitmentList) GetEpoch() int32 {
 	if m != nil {
 		return m.Epoch
 	}
 	return 0
 }
 
-func (m *ProposalHashList) GetList() []*ProposalHash {
+func (m *DealingCommitmentList) GetList() []*DealingCommitment {
 	if m != nil {
 		return m.List
 	}
 	return nil
 }
 
-type Proposal struct {
+// the dealers a node got no valid dealing from, in the DKG
+type DealingComplaints struct {
 	Epoch                int32    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
 	From                 int64    `protobuf:"varint,2,opt,name=from,proto3" json:"from,omitempty"`
-	Gob                  []byte   `protobuf:"bytes,3,opt,name=gob,proto3" json:"gob,omitempty"`
+	Dealers              []int64  `protobuf:"varint,3,rep,packed,name=dealers,proto3" json:"dealers,omitempty"`
+	Signature            []byte   `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *Proposal) Reset()         { *m = Proposal{} }
-func (m *Proposal) String() string { return proto.CompactTextString(m) }
-func (*Proposal) ProtoMessage()    {}
-func (*Proposal) Descriptor() ([]byte, []int) {
-	return fileDescriptor_8e16ccb8c5307b32, []int{4}
+func (m *DealingComplaints) Reset()         { *m = DealingComplaints{} }
+func (m *DealingComplaints) String() string { return proto.CompactTextString(m) }
+func (*DealingComplaints) ProtoMessage()    {}
+func (*DealingComplaints) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{37}
 }
 
-func (m *Proposal) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_Proposal.Unmarshal(m, b)
+func (m *DealingComplaints) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DealingComplaints.Unmarshal(m, b)
 }
-func (m *Proposal) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_Proposal.Marshal(b, m, deterministic)
+func (m *DealingComplaints) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DealingComplaints.Marshal(b, m, deterministic)
 }
-func (m *Proposal) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_Proposal.Merge(m, src)
+func (m *DealingComplaints) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DealingComplaints.Merge(m, src)
 }
-func (m *Proposal) XXX_Size() int {
-	return xxx_messageInfo_Proposal.Size(m)
+func (m *DealingComplaints) XXX_Size() int {
+	return xxx_messageInfo_DealingComplaints.Size(m)
 }
-func (m *Proposal) XXX_DiscardUnknown() {
-	xxx_messageInfo_Proposal.DiscardUnknown(m)
+func (m *DealingComplaints) XXX_DiscardUnknown() {
+	xxx_messageInfo_DealingComplaints.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_Proposal proto.InternalMessageInfo
+var xxx_messageInfo_DealingComplaints proto.InternalMessageInfo
 
-func (m *Proposal) GetEpoch() int32 {
+func (m *DealingComplaints) GetEpoch() int32 {
 	if m != nil {
 		return m.Epoch
 	}
 	return 0
 }
 
-func (m *Proposal) GetFrom() int64 {
+func (m *DealingComplaints) GetFrom() int64 {
 	if m != nil {
 		return m.From
 	}
 	return 0
 }
 
-func (m *Proposal) GetGob() []byte {
+func (m *DealingComplaints) GetDealers() []int64 {
+	if m != nil {
+		return m.Dealers
+	}
+	return nil
+}
+
+func (m *DealingComplaints) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// the complaints the accused dealers have to answer
+type DealingAccusationList struct {
+	Epoch                int32                `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	List                 []*DealingComplaints `protobuf:"bytes,2,rep,name=list,proto3" json:"list,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *DealingAccusationList) Reset()         { *m = DealingAccusationList{} }
+func (m *DealingAccusationList) String() string { return proto.CompactTextString(m) }
+func (*DealingAccusationList) ProtoMessage()    {}
+func (*DealingAccusationList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{38}
+}
+
+func (m *DealingAccusationList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DealingAccusationList.Unmarshal(m, b)
+}
+func (m *DealingAccusationList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DealingAccusationList.Marshal(b, m, deterministic)
+}
+func (m *DealingAccusationList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DealingAccusationList.Merge(m, src)
+}
+func (m *DealingAccusationList) XXX_Size() int {
+	return xxx_messageInfo_DealingAccusationList.Size(m)
+}
+func (m *DealingAccusationList) XXX_DiscardUnknown() {
+	xxx_messageInfo_DealingAccusationList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DealingAccusationList proto.InternalMessageInfo
+
+func (m *DealingAccusationList) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *DealingAccusationList) GetList() []*DealingComplaints {
+	if m != nil {
+		return m.List
+	}
+	return nil
+}
+
+// a dealer answers the complaints against it by revealing the dealings of the complainers
+type DealingReveal struct {
+	Epoch                int32      `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Dealer               int64      `protobuf:"varint,2,opt,name=dealer,proto3" json:"dealer,omitempty"`
+	Dealings             []*Dealing `protobuf:"bytes,3,rep,name=dealings,proto3" json:"dealings,omitempty"`
+	Signature            []byte     `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *DealingReveal) Reset()         { *m = DealingReveal{} }
+func (m *DealingReveal) String() string { return proto.CompactTextString(m) }
+func (*DealingReveal) ProtoMessage()    {}
+func (*DealingReveal) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{39}
+}
+
+func (m *DealingReveal) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DealingReveal.Unmarshal(m, b)
+}
+func (m *DealingReveal) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DealingReveal.Marshal(b, m, deterministic)
+}
+func (m *DealingReveal) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DealingReveal.Merge(m, src)
+}
+func (m *DealingReveal) XXX_Size() int {
+	return xxx_messageInfo_DealingReveal.Size(m)
+}
+func (m *DealingReveal) XXX_DiscardUnknown() {
+	xxx_messageInfo_DealingReveal.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DealingReveal proto.InternalMessageInfo
+
+func (m *DealingReveal) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *DealingReveal) GetDealer() int64 {
+	if m != nil {
+		return m.Dealer
+	}
+	return 0
+}
+
+func (m *DealingReveal) GetDealings() []*Dealing {
+	if m != nil {
+		return m.Dealings
+	}
+	return nil
+}
+
+func (m *DealingReveal) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// the dealers whose dealings make up the initial sharing, and the dealings they had to reveal
+type QualifiedDealers struct {
+	Epoch                int32      `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Dealers              []int64    `protobuf:"varint,2,rep,packed,name=dealers,proto3" json:"dealers,omitempty"`
+	Revealed             []*Dealing `protobuf:"bytes,3,rep,name=revealed,proto3" json:"revealed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *QualifiedDealers) Reset()         { *m = QualifiedDealers{} }
+func (m *QualifiedDealers) String() string { return proto.CompactTextString(m) }
+func (*QualifiedDealers) ProtoMessage()    {}
+func (*QualifiedDealers) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8e16ccb8c5307b32, []int{40}
+}
+
+func (m *QualifiedDealers) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QualifiedDealers.Unmarshal(m, b)
+}
+func (m *QualifiedDealers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QualifiedDealers.Marshal(b, m, deterministic)
+}
+func (m *QualifiedDealers) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QualifiedDealers.Merge(m, src)
+}
+func (m *QualifiedDealers) XXX_Size() int {
+	return xxx_messageInfo_QualifiedDealers.Size(m)
+}
+func (m *QualifiedDealers) XXX_DiscardUnknown() {
+	xxx_messageInfo_QualifiedDealers.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QualifiedDealers proto.InternalMessageInfo
+
+func (m *QualifiedDealers) GetEpoch() int32 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *QualifiedDealers) GetDealers() []int64 {
+	if m != nil {
+		return m.Dealers
+	}
+	return nil
+}
+
+func (m *QualifiedDealers) GetRevealed() []*Dealing {
 	if m != nil {
-		return m.Gob
+		return m.Revealed
 	}
 	return nil
 }
@@ -299,7 +2673,7 @@ func (m *Empty) Reset()         { *m = Empty{} }
 func (m *Empty) String() string { return proto.CompactTextString(m) }
 func (*Empty) ProtoMessage()    {}
 func (*Empty) Descriptor() ([]byte, []int) {
-	return fileDescriptor_8e16ccb8c5307b32, []int{5}
+	return fileDescriptor_8e16ccb8c5307b32, []int{41}
 }
 
 func (m *Empty) XXX_Unmarshal(b []byte) error {
@@ -321,41 +2695,193 @@ func (m *Empty) XXX_DiscardUnknown() {
 var xxx_messageInfo_Empty proto.InternalMessageInfo
 
 func init() {
+	proto.RegisterEnum("services.BoardPost_Kind", BoardPost_Kind_name, BoardPost_Kind_value)
+	proto.RegisterEnum("services.ConsensusMessage_Type", ConsensusMessage_Type_name, ConsensusMessage_Type_value)
+	proto.RegisterType((*BoardPost)(nil), "services.BoardPost")
+	proto.RegisterType((*SubscribeRequest)(nil), "services.SubscribeRequest")
+	proto.RegisterType((*EpochRequest)(nil), "services.EpochRequest")
+	proto.RegisterType((*BoardPostList)(nil), "services.BoardPostList")
+	proto.RegisterType((*EpochAdvance)(nil), "services.EpochAdvance")
 	proto.RegisterType((*Share)(nil), "services.Share")
 	proto.RegisterType((*BlindedShare)(nil), "services.BlindedShare")
+	proto.RegisterType((*ShareCheck)(nil), "services.ShareCheck")
+	proto.RegisterType((*Goodbye)(nil), "services.Goodbye")
+	proto.RegisterType((*ShareErasure)(nil), "services.ShareErasure")
 	proto.RegisterType((*ProposalHash)(nil), "services.ProposalHash")
 	proto.RegisterType((*ProposalHashList)(nil), "services.ProposalHashList")
+	proto.RegisterType((*ReplicaSignature)(nil), "services.ReplicaSignature")
+	proto.RegisterType((*ConsensusMessage)(nil), "services.ConsensusMessage")
 	proto.RegisterType((*Proposal)(nil), "services.Proposal")
+	proto.RegisterType((*ProposalTransfer)(nil), "services.ProposalTransfer")
+	proto.RegisterType((*ProposalChunk)(nil), "services.ProposalChunk")
+	proto.RegisterType((*ProposalTransferStatus)(nil), "services.ProposalTransferStatus")
+	proto.RegisterType((*PolyCommit)(nil), "services.PolyCommit")
+	proto.RegisterType((*BlindingCommitment)(nil), "services.BlindingCommitment")
+	proto.RegisterType((*NodePoint)(nil), "services.NodePoint")
+	proto.RegisterType((*PointsOnBlindingPoly)(nil), "services.PointsOnBlindingPoly")
+	proto.RegisterType((*VerifiablePoints)(nil), "services.VerifiablePoints")
+	proto.RegisterType((*EncryptedPoint)(nil), "services.EncryptedPoint")
+	proto.RegisterType((*EncryptedPoints)(nil), "services.EncryptedPoints")
+	proto.RegisterType((*MerklePath)(nil), "services.MerklePath")
+	proto.RegisterType((*Complaint)(nil), "services.Complaint")
+	proto.RegisterType((*ComplaintList)(nil), "services.ComplaintList")
+	proto.RegisterType((*ProposalRequest)(nil), "services.ProposalRequest")
+	proto.RegisterType((*RecoveryRequest)(nil), "services.RecoveryRequest")
+	proto.RegisterType((*RecoveryMask)(nil), "services.RecoveryMask")
+	proto.RegisterType((*RecoveryMaskList)(nil), "services.RecoveryMaskList")
+	proto.RegisterType((*RecoverShareRequest)(nil), "services.RecoverShareRequest")
+	proto.RegisterType((*RecoveredPoint)(nil), "services.RecoveredPoint")
+	proto.RegisterType((*Dealing)(nil), "services.Dealing")
+	proto.RegisterType((*DealingCommitment)(nil), "services.DealingCommitment")
+	proto.RegisterType((*DealingCommitmentList)(nil), "services.DealingCommitmentList")
+	proto.RegisterType((*DealingComplaints)(nil), "services.DealingComplaints")
+	proto.RegisterType((*DealingAccusationList)(nil), "services.DealingAccusationList")
+	proto.RegisterType((*DealingReveal)(nil), "services.DealingReveal")
+	proto.RegisterType((*QualifiedDealers)(nil), "services.QualifiedDealers")
 	proto.RegisterType((*Empty)(nil), "services.Empty")
 }
 
 func init() { proto.RegisterFile("services.proto", fileDescriptor_8e16ccb8c5307b32) }
 
 var fileDescriptor_8e16ccb8c5307b32 = []byte{
-	// 366 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x53, 0x5d, 0x6b, 0xe2, 0x40,
-	0x14, 0xdd, 0x98, 0x64, 0x57, 0xee, 0x66, 0x5d, 0x19, 0x44, 0x82, 0x4f, 0x21, 0x4f, 0xc1, 0x07,
-	0x59, 0x94, 0x7d, 0x2c, 0x45, 0x8b, 0x6d, 0xa1, 0x45, 0x4a, 0xe2, 0x1f, 0xc8, 0xc7, 0xd4, 0x0c,
-	0x9d, 0x64, 0xc2, 0xcc, 0x28, 0xf4, 0x9f, 0xf5, 0x8f, 0xf5, 0xbd, 0x64, 0x62, 0x6c, 0x6c, 0x94,
-	0x52, 0xe8, 0xdb, 0xbd, 0x27, 0xe7, 0x9c, 0x99, 0x7b, 0x6e, 0x06, 0x7a, 0x02, 0xf3, 0x1d, 0x89,
-	0xb1, 0x98, 0x14, 0x9c, 0x49, 0x86, 0xba, 0x75, 0xef, 0xde, 0x80, 0x19, 0xa4, 0x21, 0xc7, 0x68,
-	0x00, 0x26, 0x2e, 0x58, 0x9c, 0xda, 0x9a, 0xa3, 0x79, 0xa6, 0x5f, 0x35, 0x08, 0x81, 0xf1, 0xc8,
-	0x59, 0x66, 0x77, 0x1c, 0xcd, 0xd3, 0x7d, 0x55, 0x97, 0x4c, 0x51, 0x4a, 0x6c, 0xdd, 0xd1, 0x3c,
-	0xcb, 0xaf, 0x1a, 0x77, 0x05, 0xd6, 0x82, 0x92, 0x3c, 0xc1, 0xc9, 0xf7, 0xf8, 0xad, 0xc1, 0x7a,
-	0xe0, 0xac, 0x60, 0x22, 0xa4, 0xb7, 0xa1, 0x48, 0xcf, 0xf8, 0x8d, 0xa0, 0x5b, 0x28, 0x16, 0xe6,
-	0x7b, 0xcf, 0x43, 0x5f, 0x9e, 0x95, 0x86, 0x22, 0xdd, 0xdb, 0xaa, 0xda, 0x5d, 0x43, 0xbf, 0xe9,
-	0x7a, 0x4f, 0x84, 0x3c, 0xe3, 0x3c, 0x06, 0x83, 0x12, 0x21, 0xed, 0x8e, 0xa3, 0x7b, 0xbf, 0xa7,
-	0xc3, 0xc9, 0x21, 0xc1, 0xa6, 0xde, 0x57, 0x1c, 0xf7, 0x1a, 0xba, 0x35, 0xfa, 0x85, 0xb9, 0xfb,
-	0xa0, 0x6f, 0x58, 0xb4, 0xbf, 0x5e, 0x59, 0xba, 0xbf, 0xc0, 0x5c, 0x66, 0x85, 0x7c, 0x9e, 0xbe,
-	0x68, 0x30, 0x58, 0x6c, 0x29, 0xc5, 0x92, 0xe4, 0x0b, 0x16, 0xf2, 0x24, 0xa8, 0x4e, 0x47, 0x97,
-	0x80, 0x82, 0x6d, 0x94, 0x11, 0x79, 0x94, 0xcd, 0x99, 0xdb, 0x8d, 0xfe, 0xbe, 0xe3, 0xca, 0xd7,
-	0xfd, 0x81, 0x66, 0xf0, 0x67, 0x2e, 0x04, 0xce, 0x22, 0x8a, 0xab, 0x3d, 0x35, 0x38, 0x0a, 0x38,
-	0x25, 0x1a, 0x83, 0x71, 0x47, 0x28, 0x45, 0x1f, 0x3f, 0x9d, 0xe0, 0x4e, 0x5f, 0x35, 0x30, 0x56,
-	0x2c, 0xc1, 0xe8, 0x1f, 0x58, 0xf3, 0x64, 0x17, 0xe6, 0x31, 0x5e, 0xaa, 0x08, 0x3e, 0x15, 0xa3,
-	0x25, 0x0c, 0x03, 0x19, 0x72, 0x79, 0x95, 0xe2, 0xf8, 0x89, 0xe4, 0x9b, 0x7a, 0x16, 0x81, 0x46,
-	0xa7, 0x07, 0x2c, 0xd7, 0xd7, 0xb6, 0xf9, 0x0f, 0xbd, 0xe3, 0x8c, 0x10, 0x6a, 0xcb, 0xdb, 0xb2,
-	0x8b, 0x3a, 0xda, 0xa3, 0xdf, 0xb8, 0x11, 0x6d, 0x13, 0x6f, 0xc9, 0xa3, 0x9f, 0xea, 0x65, 0xcd,
-	0xde, 0x02, 0x00, 0x00, 0xff, 0xff, 0xb1, 0xed, 0x19, 0x3a, 0x6b, 0x03, 0x00, 0x00,
+	// 2182 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x19, 0x5d, 0x6f, 0xe3, 0xc6,
+	0xf1, 0x28, 0x51, 0x5f, 0x63, 0xd9, 0xe6, 0xed, 0x39, 0x3e, 0xc5, 0xb9, 0x5e, 0x0d, 0x36, 0x40,
+	0x8d, 0x34, 0xe7, 0xa6, 0xbe, 0xf4, 0x50, 0x14, 0x49, 0x5b, 0x1d, 0xcd, 0xb3, 0x7d, 0x27, 0xcb,
+	0xba, 0x95, 0xe3, 0xb4, 0x79, 0x88, 0x40, 0x93, 0x2b, 0x8b, 0x30, 0x45, 0xf2, 0xb8, 0x94, 0x13,
+	0xf7, 0xa5, 0x7d, 0x2c, 0x82, 0x16, 0x45, 0x7f, 0x48, 0xd1, 0x3e, 0xf6, 0xb9, 0x0f, 0x05, 0xfa,
+	0x03, 0xfa, 0x5a, 0xa0, 0xff, 0xa4, 0xd8, 0xe5, 0x2e, 0xc5, 0x0f, 0x9d, 0x7c, 0xbe, 0xa6, 0x6f,
+	0x9a, 0xe1, 0xec, 0x7c, 0xcf, 0xce, 0xcc, 0x0a, 0xd6, 0x28, 0x89, 0xae, 0x5c, 0x9b, 0xd0, 0xdd,
+	0x30, 0x0a, 0xe2, 0x00, 0x35, 0x25, 0xac, 0xff, 0xa7, 0x0a, 0xad, 0xa7, 0x81, 0x15, 0x39, 0x83,
+	0x80, 0xc6, 0xe8, 0x43, 0x50, 0x2f, 0x5d, 0xdf, 0xe9, 0x28, 0xdb, 0xca, 0xce, 0xda, 0x5e, 0x67,
+	0x37, 0x3d, 0x96, 0x92, 0xec, 0xbe, 0x70, 0x7d, 0x07, 0x73, 0x2a, 0xb4, 0x01, 0x35, 0x12, 0x06,
+	0xf6, 0xa4, 0x53, 0xd9, 0x56, 0x76, 0x6a, 0x38, 0x01, 0x10, 0x02, 0x75, 0x1c, 0x05, 0xd3, 0x4e,
+	0x75, 0x5b, 0xd9, 0xa9, 0x62, 0xfe, 0x1b, 0x75, 0xa0, 0x11, 0x5a, 0xd7, 0x5e, 0x60, 0x39, 0x1d,
+	0x75, 0x5b, 0xd9, 0x69, 0x63, 0x09, 0x32, 0x1e, 0xe7, 0x5e, 0x60, 0x5f, 0x76, 0x6a, 0x9c, 0x3c,
+	0x01, 0xd0, 0x03, 0x68, 0x51, 0xf7, 0xc2, 0xb7, 0xe2, 0x59, 0x44, 0x3a, 0x0d, 0x7e, 0x62, 0x8e,
+	0xd0, 0xff, 0x5c, 0x01, 0x95, 0xa9, 0x81, 0xee, 0xc2, 0xea, 0x00, 0x9f, 0x0c, 0x4e, 0x86, 0xdd,
+	0xde, 0xe8, 0xb0, 0x3b, 0x3c, 0xd4, 0xee, 0xa0, 0x4d, 0x40, 0x39, 0xd4, 0xa8, 0x77, 0x34, 0x3c,
+	0xd5, 0x14, 0xb4, 0x06, 0x60, 0x9c, 0x1c, 0x0f, 0x7a, 0xdd, 0xa3, 0xfe, 0xe9, 0x50, 0xab, 0x30,
+	0xf8, 0xd9, 0x51, 0xbf, 0xdb, 0x4b, 0xbe, 0x57, 0x51, 0x0b, 0x6a, 0xc3, 0xc3, 0x2e, 0x36, 0x35,
+	0x95, 0xb1, 0xd8, 0x37, 0xbb, 0xbd, 0xa3, 0xfe, 0xc1, 0xc8, 0x38, 0x39, 0x3e, 0x3e, 0x3a, 0x3d,
+	0x36, 0xfb, 0xa7, 0x5a, 0x0d, 0xbd, 0x07, 0xf7, 0xcb, 0xf8, 0xe4, 0x7c, 0x9d, 0xa9, 0xd2, 0xdd,
+	0x3f, 0xeb, 0xf6, 0x0d, 0x73, 0x64, 0x0e, 0x4e, 0x8c, 0x43, 0xad, 0x81, 0x9a, 0xa0, 0xbe, 0x38,
+	0xea, 0xf5, 0xb4, 0x26, 0x5a, 0x87, 0x15, 0xce, 0x7c, 0x64, 0x1c, 0x9a, 0xc6, 0x0b, 0xad, 0x85,
+	0x34, 0x68, 0x27, 0x08, 0x13, 0x77, 0x87, 0xe6, 0xbe, 0x06, 0x05, 0xa1, 0x52, 0xcf, 0x15, 0x74,
+	0x1f, 0xee, 0x49, 0x7c, 0xd7, 0x30, 0x3e, 0x1b, 0x76, 0x4f, 0x8f, 0x4e, 0xfa, 0x43, 0xad, 0x8d,
+	0x10, 0xac, 0xc9, 0x0f, 0xd8, 0x3c, 0x33, 0xbb, 0x3d, 0x6d, 0x15, 0xbd, 0x03, 0x77, 0x5f, 0x7e,
+	0xd6, 0xed, 0x1d, 0x3d, 0x3b, 0x32, 0xf7, 0x47, 0xec, 0xab, 0x89, 0x87, 0xda, 0x9a, 0xfe, 0x14,
+	0xb4, 0xe1, 0xec, 0x9c, 0xda, 0x91, 0x7b, 0x4e, 0x30, 0x79, 0x35, 0x23, 0x34, 0x46, 0xbb, 0x50,
+	0x63, 0x31, 0xa4, 0x1d, 0x65, 0xbb, 0xba, 0x34, 0xd4, 0x09, 0x99, 0xfe, 0x3e, 0xb4, 0x4d, 0x16,
+	0x5e, 0x79, 0x3e, 0x8d, 0xbd, 0x92, 0x89, 0xbd, 0xfe, 0x13, 0x58, 0x4d, 0x8f, 0xf7, 0x5c, 0x1a,
+	0xa3, 0xef, 0x83, 0xea, 0xb9, 0x34, 0xe6, 0x52, 0x56, 0xf6, 0xee, 0x2d, 0x90, 0x82, 0x39, 0x81,
+	0xfe, 0x85, 0xe0, 0xdf, 0x75, 0xae, 0x2c, 0xdf, 0x26, 0x2c, 0x63, 0xa6, 0x64, 0x7a, 0x4e, 0xa2,
+	0x44, 0xc3, 0x2a, 0x96, 0x20, 0xfb, 0x62, 0x9d, 0x07, 0x51, 0x4c, 0x1c, 0x9e, 0x77, 0x4d, 0x2c,
+	0x41, 0xb4, 0x09, 0xf5, 0xa9, 0x4b, 0x29, 0x71, 0x3a, 0x55, 0x7e, 0x44, 0x40, 0x3a, 0x81, 0xda,
+	0x70, 0x62, 0x45, 0x64, 0xb1, 0xd2, 0x69, 0xc2, 0x56, 0x32, 0x09, 0xbb, 0x01, 0x35, 0xca, 0x8e,
+	0xf0, 0x2c, 0x6e, 0xe3, 0x04, 0xc8, 0xa7, 0xa5, 0x5a, 0x4c, 0xcb, 0xdf, 0x2b, 0xd0, 0x7e, 0xea,
+	0xb9, 0xbe, 0x43, 0x9c, 0x6f, 0x47, 0xdc, 0x43, 0x00, 0x3b, 0x98, 0x4e, 0xdd, 0x78, 0x4a, 0xfc,
+	0x58, 0xc8, 0xcb, 0x60, 0xf2, 0xea, 0xd4, 0x8a, 0xea, 0x7c, 0xa3, 0x00, 0x70, 0x3d, 0x8c, 0x09,
+	0xb1, 0x2f, 0x6f, 0xa1, 0x4c, 0x5e, 0x6c, 0xb5, 0x24, 0x76, 0x03, 0x6a, 0x57, 0x96, 0xe7, 0x26,
+	0xa5, 0xdc, 0xc4, 0x09, 0x70, 0x83, 0x32, 0x2f, 0xa1, 0x71, 0x10, 0x04, 0xce, 0xf9, 0xf5, 0x6d,
+	0xbc, 0x92, 0x63, 0x59, 0x2d, 0xb2, 0x3c, 0x83, 0x36, 0x37, 0xcf, 0x8c, 0x2c, 0x3a, 0x8b, 0xbe,
+	0x3d, 0xbe, 0x2c, 0x8c, 0x83, 0x28, 0x08, 0x03, 0x6a, 0x79, 0x87, 0x16, 0x9d, 0xbc, 0x86, 0xf1,
+	0x16, 0x34, 0x43, 0x4e, 0x45, 0x22, 0xc1, 0x3c, 0x85, 0x99, 0xd0, 0x89, 0x45, 0x27, 0x82, 0x37,
+	0xff, 0xbd, 0x3c, 0x77, 0x58, 0x52, 0x5f, 0x91, 0x88, 0xba, 0x81, 0xcf, 0x7d, 0xb7, 0x8a, 0x25,
+	0xa8, 0xff, 0x5d, 0x01, 0x2d, 0xab, 0x0e, 0x2f, 0xab, 0xc5, 0x2a, 0x7d, 0x20, 0x8a, 0xad, 0xc2,
+	0x8b, 0x6d, 0x73, 0x5e, 0x6c, 0xd9, 0xf3, 0x49, 0xbd, 0xa1, 0x5d, 0x68, 0x46, 0xe4, 0x8a, 0x58,
+	0x9e, 0xa8, 0x96, 0x95, 0x3d, 0x54, 0xa6, 0xc7, 0x29, 0x0d, 0xfa, 0x04, 0x56, 0x6c, 0x12, 0xc5,
+	0xee, 0xd8, 0xb5, 0xad, 0x98, 0x19, 0xc0, 0x8e, 0x6c, 0xcd, 0x8f, 0x60, 0x12, 0x7a, 0xae, 0x6d,
+	0x0d, 0xa5, 0x45, 0x38, 0x4b, 0xae, 0x7f, 0x09, 0x5a, 0x91, 0x80, 0x99, 0x1c, 0x25, 0x38, 0x6e,
+	0x45, 0x15, 0x4b, 0x90, 0xb9, 0xef, 0xca, 0x25, 0x5f, 0x89, 0xb6, 0xc2, 0x7f, 0xdf, 0x10, 0xb3,
+	0x7f, 0x57, 0x41, 0x33, 0x02, 0x9f, 0x12, 0x9f, 0xce, 0xe8, 0x31, 0xa1, 0xd4, 0xba, 0x20, 0xe8,
+	0x31, 0xa8, 0xf1, 0x75, 0x48, 0x44, 0x33, 0xfb, 0xee, 0x5c, 0xd7, 0x22, 0xe5, 0xee, 0xe9, 0x75,
+	0x48, 0x30, 0x27, 0x7e, 0x7d, 0x4f, 0xe3, 0x1a, 0x55, 0x33, 0x1a, 0x65, 0xf4, 0x57, 0xf3, 0xfa,
+	0x6f, 0x42, 0xdd, 0x71, 0x2f, 0x08, 0x8d, 0x45, 0x1d, 0x08, 0x08, 0xed, 0x8a, 0xf8, 0xd4, 0xb7,
+	0x95, 0xbc, 0xf3, 0x8a, 0xf1, 0x15, 0x31, 0xfa, 0x1e, 0xac, 0x86, 0x11, 0x09, 0xad, 0x88, 0x38,
+	0x23, 0x2e, 0xbe, 0xc1, 0xc5, 0xb7, 0x25, 0xf2, 0x8c, 0xa9, 0xf1, 0x04, 0x9a, 0x02, 0xa6, 0x9d,
+	0xe6, 0x8d, 0x51, 0x49, 0x69, 0xd1, 0xa7, 0xd0, 0x66, 0x3c, 0x47, 0xf6, 0xc4, 0xf2, 0x2f, 0x08,
+	0xed, 0xb4, 0x8a, 0x67, 0x8b, 0x5e, 0xc2, 0x2b, 0x8c, 0xde, 0x48, 0xc8, 0xf3, 0xf1, 0x80, 0x62,
+	0x3c, 0x4e, 0x40, 0x65, 0x3e, 0x65, 0x8d, 0x6f, 0x80, 0xcd, 0xd1, 0x00, 0x9b, 0x03, 0xd6, 0x5b,
+	0xef, 0xa0, 0x15, 0x68, 0x48, 0x40, 0x41, 0x00, 0xf5, 0xa4, 0x91, 0x6a, 0x15, 0x46, 0x79, 0x76,
+	0x64, 0x7e, 0x3e, 0x32, 0x0e, 0xbb, 0xfd, 0x03, 0x53, 0xab, 0xa2, 0x36, 0x34, 0xfb, 0xe6, 0xe7,
+	0x23, 0x86, 0xd4, 0x54, 0xfd, 0x6f, 0x15, 0x68, 0x4a, 0x2f, 0xbd, 0x6d, 0xa5, 0x2f, 0x2a, 0x3a,
+	0x4a, 0x68, 0x5a, 0x74, 0x6d, 0x2c, 0x41, 0xf4, 0x01, 0xd4, 0xd8, 0x85, 0xf7, 0x52, 0x84, 0x6a,
+	0x23, 0x13, 0xaa, 0xc0, 0xbb, 0x36, 0xf8, 0x5d, 0x88, 0x13, 0x12, 0xf4, 0x31, 0xd4, 0xd9, 0x0f,
+	0x4c, 0x3b, 0x0d, 0xee, 0xc2, 0x07, 0x99, 0x26, 0xc7, 0xba, 0x81, 0xeb, 0x5f, 0x18, 0xe9, 0xe5,
+	0x89, 0x05, 0x2d, 0xda, 0x83, 0x7a, 0x18, 0xb8, 0x7e, 0x4c, 0x3b, 0x50, 0x74, 0xfc, 0x19, 0x89,
+	0xdc, 0xb1, 0x6b, 0x9d, 0x7b, 0x64, 0xc0, 0x29, 0xb0, 0xa0, 0x44, 0x3b, 0xa0, 0x86, 0x56, 0x3c,
+	0xe9, 0xb4, 0x8a, 0x4a, 0x1d, 0x93, 0xe8, 0xd2, 0x23, 0x03, 0x2b, 0x9e, 0x60, 0x4e, 0xf1, 0x5c,
+	0x6d, 0x56, 0x35, 0xf5, 0xb9, 0xda, 0x6c, 0x6a, 0x2d, 0x7d, 0x32, 0xbf, 0x3f, 0x4e, 0x23, 0xcb,
+	0xa7, 0x63, 0x12, 0xdd, 0xc2, 0x83, 0xf3, 0x5c, 0xae, 0xe6, 0x72, 0x19, 0x81, 0x4a, 0xdd, 0x5f,
+	0x13, 0x91, 0xfa, 0xfc, 0xb7, 0xfe, 0x47, 0x05, 0x56, 0xa5, 0x28, 0x63, 0x32, 0xf3, 0x2f, 0x59,
+	0x72, 0xc6, 0x42, 0x26, 0x17, 0xb5, 0x30, 0xeb, 0xa5, 0x56, 0x38, 0xa5, 0x65, 0x52, 0x83, 0xf1,
+	0x98, 0x92, 0x58, 0xe8, 0x22, 0x20, 0x26, 0xd5, 0xb1, 0x62, 0x4b, 0x5e, 0xac, 0xec, 0x37, 0xbb,
+	0x88, 0x6d, 0xd6, 0xe1, 0xe8, 0x6c, 0x2a, 0x42, 0x9c, 0xc2, 0xfa, 0x00, 0x36, 0x8b, 0x52, 0x86,
+	0xb1, 0x15, 0xcf, 0x28, 0x3b, 0x15, 0x11, 0x9b, 0xb8, 0x57, 0xc4, 0x11, 0xd7, 0x4f, 0x0a, 0x73,
+	0x8e, 0xc1, 0x34, 0xf4, 0x48, 0x4c, 0xc4, 0x88, 0x91, 0xc2, 0xfa, 0x13, 0x80, 0x79, 0x0a, 0x20,
+	0x1d, 0xda, 0x76, 0x40, 0xc6, 0x63, 0xd7, 0x76, 0x09, 0x8b, 0x25, 0xbb, 0x79, 0xdb, 0x38, 0x87,
+	0x7b, 0xae, 0x36, 0x15, 0xad, 0xa2, 0x7f, 0x01, 0xa8, 0x9c, 0x0d, 0x68, 0x0d, 0x2a, 0xae, 0x23,
+	0x82, 0x50, 0x71, 0x1d, 0xf4, 0x71, 0xae, 0xf5, 0x56, 0x96, 0x24, 0x5f, 0x86, 0x4e, 0xff, 0x11,
+	0xb4, 0xfa, 0x81, 0x93, 0x64, 0x4b, 0x89, 0x65, 0xd2, 0xad, 0x67, 0x89, 0x25, 0x6d, 0x9c, 0x00,
+	0xba, 0x01, 0x1b, 0x9c, 0x9c, 0x9e, 0xf8, 0x52, 0x2d, 0xc6, 0x1c, 0xfd, 0x20, 0x4d, 0xcb, 0xd2,
+	0xc4, 0x96, 0x8a, 0x90, 0xf9, 0xa8, 0x7f, 0x0d, 0x5a, 0x31, 0x57, 0x59, 0xc5, 0x45, 0xc4, 0x76,
+	0x43, 0x66, 0xbb, 0xd0, 0x62, 0x8e, 0x40, 0x1f, 0xa5, 0xec, 0x93, 0x1e, 0x95, 0x19, 0x3b, 0x4d,
+	0xdf, 0x8e, 0xae, 0xc3, 0x98, 0x38, 0x39, 0x19, 0x4c, 0xfd, 0x30, 0x0a, 0x82, 0xb1, 0x9c, 0x8c,
+	0x38, 0xa0, 0xff, 0x12, 0xd6, 0xf2, 0xf4, 0x25, 0xb3, 0x1f, 0x02, 0x90, 0x70, 0x42, 0xa6, 0x24,
+	0xb2, 0x3c, 0x19, 0x97, 0x0c, 0x86, 0xcf, 0x8a, 0x16, 0xbd, 0x14, 0xdd, 0xaf, 0x8d, 0x05, 0xa4,
+	0xff, 0x4b, 0x81, 0xf5, 0x3c, 0xeb, 0x9b, 0x6c, 0x7a, 0x00, 0xad, 0x94, 0xaf, 0x70, 0xf2, 0x1c,
+	0x81, 0x9e, 0x40, 0xdd, 0x76, 0xc3, 0x09, 0x89, 0xb8, 0x01, 0x2b, 0x7b, 0x0f, 0xb3, 0xd1, 0x2c,
+	0x07, 0x00, 0x0b, 0x6a, 0xb4, 0x03, 0xeb, 0xdc, 0x54, 0xa3, 0x38, 0x00, 0x16, 0xd1, 0xe8, 0x7d,
+	0xd6, 0x25, 0x82, 0x60, 0x8c, 0x09, 0x0d, 0xd9, 0x95, 0x2d, 0xee, 0xb2, 0x3c, 0x52, 0xef, 0x03,
+	0xcc, 0x6f, 0x09, 0xe6, 0x55, 0x36, 0xa7, 0x7e, 0x2d, 0xeb, 0x9f, 0x03, 0x0c, 0x6b, 0x07, 0x33,
+	0x91, 0x78, 0x35, 0x9c, 0x00, 0x0c, 0xeb, 0x07, 0x0e, 0xa1, 0xc2, 0x51, 0x09, 0xa0, 0xff, 0x53,
+	0x81, 0x96, 0xc1, 0x8a, 0xc2, 0x62, 0xde, 0x67, 0x33, 0xb9, 0x6d, 0xcf, 0x68, 0x5a, 0x4c, 0x12,
+	0x64, 0x73, 0x46, 0x28, 0x2a, 0x50, 0xe4, 0xf3, 0xc2, 0x39, 0x43, 0xd2, 0xf0, 0x1b, 0x9b, 0x4d,
+	0x75, 0xce, 0x0b, 0x72, 0x9d, 0xf6, 0x79, 0x89, 0x60, 0x5e, 0x71, 0x5c, 0x6a, 0x7b, 0x01, 0x9b,
+	0xf8, 0x06, 0x3c, 0x2f, 0x84, 0x57, 0x0a, 0x68, 0x9e, 0x37, 0xcc, 0xbf, 0xdc, 0x1b, 0x35, 0x9c,
+	0x00, 0xdc, 0x42, 0x76, 0x31, 0x75, 0xea, 0xc2, 0x42, 0x06, 0xe8, 0xbf, 0x55, 0x60, 0x35, 0xb5,
+	0x65, 0xc9, 0x7c, 0xb5, 0xe8, 0x7e, 0x94, 0x0b, 0x4e, 0xb5, 0x58, 0x2e, 0x29, 0x43, 0xd1, 0xcc,
+	0x97, 0xef, 0x0e, 0x06, 0xac, 0xa7, 0xce, 0x58, 0xb6, 0x61, 0x2d, 0x1b, 0x3b, 0xf5, 0x5f, 0xc1,
+	0x3a, 0x26, 0x76, 0x70, 0x45, 0xa2, 0x6b, 0xc9, 0x44, 0xaa, 0xac, 0xe4, 0x97, 0x71, 0xd9, 0xf6,
+	0x2a, 0xf9, 0xb6, 0xd7, 0x81, 0xc6, 0x84, 0x78, 0x21, 0x89, 0xa8, 0xd8, 0xa0, 0x24, 0xa8, 0xff,
+	0x45, 0x81, 0xb6, 0xe4, 0x7d, 0x6c, 0xd1, 0xdb, 0xac, 0x13, 0x6b, 0x50, 0x89, 0x03, 0xf1, 0x1a,
+	0x50, 0x89, 0x83, 0xac, 0x78, 0x35, 0x2f, 0xfe, 0x11, 0xa8, 0xac, 0x0a, 0x79, 0xc8, 0x56, 0xf6,
+	0xde, 0x7d, 0xdd, 0xdd, 0x40, 0x31, 0x27, 0xcb, 0x7b, 0xb4, 0x5e, 0xf4, 0xe8, 0xef, 0x14, 0xd0,
+	0xb2, 0x1a, 0xdf, 0x32, 0xae, 0x0f, 0xa0, 0x35, 0xb1, 0xd8, 0x2a, 0x77, 0x32, 0x4e, 0xee, 0x9e,
+	0x26, 0x9e, 0x23, 0xd0, 0x87, 0x50, 0x63, 0x2a, 0x50, 0x31, 0x07, 0x6f, 0x66, 0x27, 0xae, 0xb9,
+	0x48, 0x9c, 0x10, 0xe9, 0xaf, 0xe0, 0x9e, 0x40, 0xf3, 0x85, 0xe5, 0xed, 0x62, 0x93, 0x8a, 0xac,
+	0xbe, 0x89, 0xc8, 0x7f, 0x28, 0xb0, 0x26, 0xf0, 0xf2, 0x86, 0xbc, 0xd5, 0x36, 0x9a, 0xb4, 0x8c,
+	0x6a, 0xa6, 0x65, 0xbc, 0xc9, 0x36, 0x3a, 0xf7, 0x58, 0xad, 0xe8, 0xb1, 0x4c, 0x6a, 0xd5, 0x73,
+	0xa9, 0x75, 0xc3, 0x5b, 0xcf, 0x57, 0xd0, 0xd8, 0x27, 0x96, 0xe7, 0xfa, 0x17, 0xff, 0xdf, 0xed,
+	0x5d, 0xa4, 0x69, 0x4d, 0xa6, 0xa9, 0xfe, 0x1b, 0xb8, 0x2b, 0x04, 0x1b, 0xb9, 0xd5, 0x77, 0x81,
+	0x0a, 0x6c, 0x46, 0x62, 0x5b, 0x92, 0xac, 0x48, 0x01, 0xdd, 0xb8, 0x48, 0x2f, 0xbf, 0x12, 0xbe,
+	0x84, 0x77, 0x4a, 0x0a, 0x2c, 0x49, 0xe2, 0x1f, 0xe6, 0x96, 0xbf, 0xf7, 0xe6, 0xe9, 0x51, 0x62,
+	0x22, 0x5e, 0x5c, 0x66, 0x59, 0x03, 0x93, 0xab, 0x8a, 0xde, 0xc2, 0xc7, 0x1d, 0x68, 0x24, 0x66,
+	0xa6, 0x77, 0x85, 0x00, 0xdf, 0xd8, 0xac, 0x2e, 0x6b, 0x11, 0x56, 0xec, 0x06, 0xfe, 0xff, 0x66,
+	0x96, 0xd0, 0x5d, 0x98, 0xf5, 0x8d, 0x02, 0xab, 0xe2, 0x1b, 0xe6, 0xcb, 0xeb, 0x2d, 0x83, 0xf6,
+	0x08, 0x9a, 0x4e, 0x72, 0x5c, 0x96, 0xda, 0xdd, 0x92, 0x50, 0x9c, 0x92, 0xdc, 0x60, 0xec, 0x2b,
+	0xd0, 0x5e, 0xce, 0x2c, 0xcf, 0x1d, 0xbb, 0xc4, 0xd9, 0x17, 0xee, 0x59, 0xac, 0x4e, 0xc6, 0x9d,
+	0x95, 0xbc, 0x3b, 0x1f, 0x95, 0x36, 0xf5, 0x45, 0x0a, 0x49, 0x12, 0xbd, 0x01, 0x35, 0x73, 0x1a,
+	0xc6, 0xd7, 0x7b, 0x7f, 0xaa, 0xc0, 0xc6, 0xd3, 0x99, 0xe7, 0x91, 0xd8, 0xf5, 0xf9, 0x6b, 0xdb,
+	0x30, 0x39, 0xc4, 0xd6, 0x50, 0xfe, 0xd8, 0xbb, 0xe8, 0x35, 0x6e, 0x6b, 0x7d, 0x8e, 0xe4, 0x6c,
+	0xf4, 0x3b, 0xe8, 0x17, 0xd0, 0x4a, 0x9f, 0x0f, 0x51, 0x66, 0x7e, 0x2f, 0xbe, 0x29, 0x6e, 0x2d,
+	0x62, 0xa8, 0xdf, 0xf9, 0x48, 0x41, 0x3f, 0x83, 0x16, 0x26, 0x96, 0x63, 0x26, 0x8e, 0xcf, 0x48,
+	0xc8, 0xbc, 0x28, 0x6e, 0xdd, 0x5f, 0x70, 0x9a, 0x25, 0x86, 0x7e, 0x07, 0x1d, 0xc0, 0xe6, 0x70,
+	0x76, 0x3e, 0x75, 0xe3, 0xd2, 0x43, 0xc8, 0x92, 0x25, 0x7a, 0x81, 0x29, 0x7b, 0x7f, 0xe0, 0xd7,
+	0x62, 0xb2, 0x13, 0x0b, 0x6f, 0xfc, 0x1c, 0x50, 0x99, 0x37, 0x7a, 0xcd, 0xe3, 0xc9, 0x22, 0xf7,
+	0xfc, 0x14, 0x5a, 0xe9, 0xaa, 0x8c, 0x96, 0xec, 0xcf, 0x8b, 0xf4, 0xf9, 0x6b, 0x0d, 0x54, 0x36,
+	0x57, 0x23, 0x13, 0x36, 0x87, 0xb1, 0x15, 0xc5, 0xfc, 0xad, 0x8e, 0xcd, 0x82, 0x42, 0x28, 0xbd,
+	0x95, 0x85, 0xe8, 0xc7, 0xb0, 0x96, 0x37, 0x06, 0x2d, 0x98, 0xb6, 0xca, 0xc7, 0x86, 0xb0, 0x91,
+	0x3f, 0x36, 0x8c, 0x23, 0x62, 0x4d, 0xd1, 0xfd, 0xf2, 0x61, 0xbe, 0xd7, 0x6d, 0x6d, 0xbf, 0x7e,
+	0x8b, 0x4b, 0xf6, 0xab, 0x1d, 0x05, 0x0d, 0xe1, 0xde, 0x01, 0x89, 0x8b, 0x9f, 0xd1, 0x92, 0x05,
+	0xf0, 0x66, 0xb6, 0xe8, 0x53, 0x19, 0xad, 0xdc, 0x43, 0xeb, 0x66, 0x61, 0xe5, 0x16, 0xf8, 0xb2,
+	0xa1, 0x8f, 0x61, 0x35, 0x39, 0x2e, 0x7b, 0x4a, 0xb9, 0x94, 0xca, 0x87, 0x3e, 0x81, 0x95, 0x8c,
+	0x21, 0xe8, 0xdd, 0xb2, 0x92, 0x32, 0x85, 0x17, 0x38, 0x9b, 0xbd, 0xb3, 0x0c, 0x66, 0x9e, 0xf7,
+	0xb6, 0xc7, 0x9f, 0xc1, 0x6a, 0xb6, 0xbf, 0xd3, 0xec, 0xf9, 0xc2, 0xb0, 0xb7, 0xb5, 0xb5, 0x78,
+	0x26, 0xe0, 0x85, 0x72, 0x90, 0xce, 0x6f, 0x89, 0xcb, 0xbe, 0x53, 0xa2, 0xcd, 0xce, 0x26, 0x5b,
+	0x9d, 0xd2, 0x67, 0x31, 0x46, 0x9c, 0xd7, 0xf9, 0x3f, 0x48, 0x8f, 0xff, 0x3b, 0x00, 0x3e, 0x69,
+	0xca, 0xf8, 0x53, 0x1a, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -370,9 +2896,10 @@ const _ = grpc.SupportPackageIsVersion4
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type BulletinBoardServiceClient interface {
-	SubmitProposalHash(ctx context.Context, in *ProposalHash, opts ...grpc.CallOption) (*Empty, error)
-	AssembleShare(ctx context.Context, in *Share, opts ...grpc.CallOption) (*Empty, error)
-	Kill(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
+	Post(ctx context.Context, in *BoardPost, opts ...grpc.CallOption) (*Empty, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (BulletinBoardService_SubscribeClient, error)
+	ReadEpoch(ctx context.Context, in *EpochRequest, opts ...grpc.CallOption) (*BoardPostList, error)
+	SubmitProposalHashList(ctx context.Context, in *ProposalHashList, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type bulletinBoardServiceClient struct {
@@ -383,27 +2910,59 @@ func NewBulletinBoardServiceClient(cc *grpc.ClientConn) BulletinBoardServiceClie
 	return &bulletinBoardServiceClient{cc}
 }
 
-func (c *bulletinBoardServiceClient) SubmitProposalHash(ctx context.Context, in *ProposalHash, opts ...grpc.CallOption) (*Empty, error) {
+func (c *bulletinBoardServiceClient) Post(ctx context.Context, in *BoardPost, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
-	err := c.cc.Invoke(ctx, "/services.BulletinBoardService/SubmitProposalHash", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/services.BulletinBoardService/Post", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bulletinBoardServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (BulletinBoardService_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_BulletinBoardService_serviceDesc.Streams[0], "/services.BulletinBoardService/Subscribe", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	x := &bulletinBoardServiceSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BulletinBoardService_SubscribeClient interface {
+	Recv() (*BoardPost, error)
+	grpc.ClientStream
+}
+
+type bulletinBoardServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *bulletinBoardServiceSubscribeClient) Recv() (*BoardPost, error) {
+	m := new(BoardPost)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-func (c *bulletinBoardServiceClient) AssembleShare(ctx context.Context, in *Share, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
-	err := c.cc.Invoke(ctx, "/services.BulletinBoardService/AssembleShare", in, out, opts...)
+func (c *bulletinBoardServiceClient) ReadEpoch(ctx context.Context, in *EpochRequest, opts ...grpc.CallOption) (*BoardPostList, error) {
+	out := new(BoardPostList)
+	err := c.cc.Invoke(ctx, "/services.BulletinBoardService/ReadEpoch", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *bulletinBoardServiceClient) Kill(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+func (c *bulletinBoardServiceClient) SubmitProposalHashList(ctx context.Context, in *ProposalHashList, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
-	err := c.cc.Invoke(ctx, "/services.BulletinBoardService/Kill", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/services.BulletinBoardService/SubmitProposalHashList", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -412,65 +2971,87 @@ func (c *bulletinBoardServiceClient) Kill(ctx context.Context, in *Empty, opts .
 
 // BulletinBoardServiceServer is the server API for BulletinBoardService service.
 type BulletinBoardServiceServer interface {
-	SubmitProposalHash(context.Context, *ProposalHash) (*Empty, error)
-	AssembleShare(context.Context, *Share) (*Empty, error)
-	Kill(context.Context, *Empty) (*Empty, error)
+	Post(context.Context, *BoardPost) (*Empty, error)
+	Subscribe(*SubscribeRequest, BulletinBoardService_SubscribeServer) error
+	ReadEpoch(context.Context, *EpochRequest) (*BoardPostList, error)
+	SubmitProposalHashList(context.Context, *ProposalHashList) (*Empty, error)
 }
 
 func RegisterBulletinBoardServiceServer(s *grpc.Server, srv BulletinBoardServiceServer) {
 	s.RegisterService(&_BulletinBoardService_serviceDesc, srv)
 }
 
-func _BulletinBoardService_SubmitProposalHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ProposalHash)
+func _BulletinBoardService_Post_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BoardPost)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BulletinBoardServiceServer).SubmitProposalHash(ctx, in)
+		return srv.(BulletinBoardServiceServer).Post(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/services.BulletinBoardService/SubmitProposalHash",
+		FullMethod: "/services.BulletinBoardService/Post",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BulletinBoardServiceServer).SubmitProposalHash(ctx, req.(*ProposalHash))
+		return srv.(BulletinBoardServiceServer).Post(ctx, req.(*BoardPost))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BulletinBoardService_AssembleShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Share)
+func _BulletinBoardService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BulletinBoardServiceServer).Subscribe(m, &bulletinBoardServiceSubscribeServer{stream})
+}
+
+type BulletinBoardService_SubscribeServer interface {
+	Send(*BoardPost) error
+	grpc.ServerStream
+}
+
+type bulletinBoardServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *bulletinBoardServiceSubscribeServer) Send(m *BoardPost) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BulletinBoardService_ReadEpoch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EpochRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BulletinBoardServiceServer).AssembleShare(ctx, in)
+		return srv.(BulletinBoardServiceServer).ReadEpoch(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/services.BulletinBoardService/AssembleShare",
+		FullMethod: "/services.BulletinBoardService/ReadEpoch",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BulletinBoardServiceServer).AssembleShare(ctx, req.(*Share))
+		return srv.(BulletinBoardServiceServer).ReadEpoch(ctx, req.(*EpochRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _BulletinBoardService_Kill_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+func _BulletinBoardService_SubmitProposalHashList_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProposalHashList)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(BulletinBoardServiceServer).Kill(ctx, in)
+		return srv.(BulletinBoardServiceServer).SubmitProposalHashList(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/services.BulletinBoardService/Kill",
+		FullMethod: "/services.BulletinBoardService/SubmitProposalHashList",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(BulletinBoardServiceServer).Kill(ctx, req.(*Empty))
+		return srv.(BulletinBoardServiceServer).SubmitProposalHashList(ctx, req.(*ProposalHashList))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -480,16 +3061,119 @@ var _BulletinBoardService_serviceDesc = grpc.ServiceDesc{
 	HandlerType: (*BulletinBoardServiceServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "SubmitProposalHash",
-			Handler:    _BulletinBoardService_SubmitProposalHash_Handler,
+			MethodName: "Post",
+			Handler:    _BulletinBoardService_Post_Handler,
+		},
+		{
+			MethodName: "ReadEpoch",
+			Handler:    _BulletinBoardService_ReadEpoch_Handler,
+		},
+		{
+			MethodName: "SubmitProposalHashList",
+			Handler:    _BulletinBoardService_SubmitProposalHashList_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _BulletinBoardService_Subscribe_Handler,
+			ServerStreams: true,
 		},
+	},
+	Metadata: "services.proto",
+}
+
+// ReplicaServiceClient is the client API for ReplicaService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ReplicaServiceClient interface {
+	SubmitProposalHash(ctx context.Context, in *ProposalHash, opts ...grpc.CallOption) (*Empty, error)
+	Consensus(ctx context.Context, in *ConsensusMessage, opts ...grpc.CallOption) (*Empty, error)
+}
+
+type replicaServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewReplicaServiceClient(cc *grpc.ClientConn) ReplicaServiceClient {
+	return &replicaServiceClient{cc}
+}
+
+func (c *replicaServiceClient) SubmitProposalHash(ctx context.Context, in *ProposalHash, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/services.ReplicaService/SubmitProposalHash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *replicaServiceClient) Consensus(ctx context.Context, in *ConsensusMessage, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/services.ReplicaService/Consensus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReplicaServiceServer is the server API for ReplicaService service.
+type ReplicaServiceServer interface {
+	SubmitProposalHash(context.Context, *ProposalHash) (*Empty, error)
+	Consensus(context.Context, *ConsensusMessage) (*Empty, error)
+}
+
+func RegisterReplicaServiceServer(s *grpc.Server, srv ReplicaServiceServer) {
+	s.RegisterService(&_ReplicaService_serviceDesc, srv)
+}
+
+func _ReplicaService_SubmitProposalHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProposalHash)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicaServiceServer).SubmitProposalHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/services.ReplicaService/SubmitProposalHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServiceServer).SubmitProposalHash(ctx, req.(*ProposalHash))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReplicaService_Consensus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConsensusMessage)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicaServiceServer).Consensus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/services.ReplicaService/Consensus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServiceServer).Consensus(ctx, req.(*ConsensusMessage))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ReplicaService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "services.ReplicaService",
+	HandlerType: (*ReplicaServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "AssembleShare",
-			Handler:    _BulletinBoardService_AssembleShare_Handler,
+			MethodName: "SubmitProposalHash",
+			Handler:    _ReplicaService_SubmitProposalHash_Handler,
 		},
 		{
-			MethodName: "Kill",
-			Handler:    _BulletinBoardService_Kill_Handler,
+			MethodName: "Consensus",
+			Handler:    _ReplicaService_Consensus_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -500,10 +3184,20 @@ var _BulletinBoardService_serviceDesc = grpc.ServiceDesc{
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type NodeClient interface {
-	AdvanceEpoch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
 	StartCheckingProposals(ctx context.Context, in *ProposalHashList, opts ...grpc.CallOption) (*Empty, error)
 	SubmitProposal(ctx context.Context, in *Proposal, opts ...grpc.CallOption) (*Empty, error)
+	// takes a proposal too large for one message in chunks
+	SubmitProposalStream(ctx context.Context, opts ...grpc.CallOption) (Node_SubmitProposalStreamClient, error)
+	// tells how much of a streamed proposal arrived, so that its sender can resume after a dropped connection
+	GetProposalTransfer(ctx context.Context, in *ProposalTransfer, opts ...grpc.CallOption) (*ProposalTransferStatus, error)
 	SubmitBlindedShare(ctx context.Context, in *BlindedShare, opts ...grpc.CallOption) (*Empty, error)
+	SubmitDealing(ctx context.Context, in *Dealing, opts ...grpc.CallOption) (*Empty, error)
+	GetProposal(ctx context.Context, in *ProposalRequest, opts ...grpc.CallOption) (*Proposal, error)
+	// hands a whole proposal to a member of the old group that missed its slice
+	PullProposal(ctx context.Context, in *ProposalRequest, opts ...grpc.CallOption) (*Proposal, error)
+	// the two rounds of the recovery of a share lost by a holder
+	RecoveryMasks(ctx context.Context, in *RecoveryRequest, opts ...grpc.CallOption) (*RecoveryMaskList, error)
+	RecoverShare(ctx context.Context, in *RecoverShareRequest, opts ...grpc.CallOption) (*RecoveredPoint, error)
 }
 
 type nodeClient struct {
@@ -514,27 +3208,61 @@ func NewNodeClient(cc *grpc.ClientConn) NodeClient {
 	return &nodeClient{cc}
 }
 
-func (c *nodeClient) AdvanceEpoch(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+func (c *nodeClient) StartCheckingProposals(ctx context.Context, in *ProposalHashList, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
-	err := c.cc.Invoke(ctx, "/services.Node/AdvanceEpoch", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/services.Node/StartCheckingProposals", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *nodeClient) StartCheckingProposals(ctx context.Context, in *ProposalHashList, opts ...grpc.CallOption) (*Empty, error) {
+func (c *nodeClient) SubmitProposal(ctx context.Context, in *Proposal, opts ...grpc.CallOption) (*Empty, error) {
 	out := new(Empty)
-	err := c.cc.Invoke(ctx, "/services.Node/StartCheckingProposals", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/services.Node/SubmitProposal", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *nodeClient) SubmitProposal(ctx context.Context, in *Proposal, opts ...grpc.CallOption) (*Empty, error) {
-	out := new(Empty)
-	err := c.cc.Invoke(ctx, "/services.Node/SubmitProposal", in, out, opts...)
+func (c *nodeClient) SubmitProposalStream(ctx context.Context, opts ...grpc.CallOption) (Node_SubmitProposalStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Node_serviceDesc.Streams[0], "/services.Node/SubmitProposalStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &nodeSubmitProposalStreamClient{stream}
+	return x, nil
+}
+
+type Node_SubmitProposalStreamClient interface {
+	Send(*ProposalChunk) error
+	CloseAndRecv() (*ProposalTransferStatus, error)
+	grpc.ClientStream
+}
+
+type nodeSubmitProposalStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *nodeSubmitProposalStreamClient) Send(m *ProposalChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *nodeSubmitProposalStreamClient) CloseAndRecv() (*ProposalTransferStatus, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ProposalTransferStatus)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *nodeClient) GetProposalTransfer(ctx context.Context, in *ProposalTransfer, opts ...grpc.CallOption) (*ProposalTransferStatus, error) {
+	out := new(ProposalTransferStatus)
+	err := c.cc.Invoke(ctx, "/services.Node/GetProposalTransfer", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -550,68 +3278,149 @@ func (c *nodeClient) SubmitBlindedShare(ctx context.Context, in *BlindedShare, o
 	return out, nil
 }
 
+func (c *nodeClient) SubmitDealing(ctx context.Context, in *Dealing, opts ...grpc.CallOption) (*Empty, error) {
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, "/services.Node/SubmitDealing", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) GetProposal(ctx context.Context, in *ProposalRequest, opts ...grpc.CallOption) (*Proposal, error) {
+	out := new(Proposal)
+	err := c.cc.Invoke(ctx, "/services.Node/GetProposal", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) PullProposal(ctx context.Context, in *ProposalRequest, opts ...grpc.CallOption) (*Proposal, error) {
+	out := new(Proposal)
+	err := c.cc.Invoke(ctx, "/services.Node/PullProposal", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) RecoveryMasks(ctx context.Context, in *RecoveryRequest, opts ...grpc.CallOption) (*RecoveryMaskList, error) {
+	out := new(RecoveryMaskList)
+	err := c.cc.Invoke(ctx, "/services.Node/RecoveryMasks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *nodeClient) RecoverShare(ctx context.Context, in *RecoverShareRequest, opts ...grpc.CallOption) (*RecoveredPoint, error) {
+	out := new(RecoveredPoint)
+	err := c.cc.Invoke(ctx, "/services.Node/RecoverShare", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // NodeServer is the server API for Node service.
 type NodeServer interface {
-	AdvanceEpoch(context.Context, *Empty) (*Empty, error)
 	StartCheckingProposals(context.Context, *ProposalHashList) (*Empty, error)
 	SubmitProposal(context.Context, *Proposal) (*Empty, error)
+	// takes a proposal too large for one message in chunks
+	SubmitProposalStream(Node_SubmitProposalStreamServer) error
+	// tells how much of a streamed proposal arrived, so that its sender can resume after a dropped connection
+	GetProposalTransfer(context.Context, *ProposalTransfer) (*ProposalTransferStatus, error)
 	SubmitBlindedShare(context.Context, *BlindedShare) (*Empty, error)
+	SubmitDealing(context.Context, *Dealing) (*Empty, error)
+	GetProposal(context.Context, *ProposalRequest) (*Proposal, error)
+	// hands a whole proposal to a member of the old group that missed its slice
+	PullProposal(context.Context, *ProposalRequest) (*Proposal, error)
+	// the two rounds of the recovery of a share lost by a holder
+	RecoveryMasks(context.Context, *RecoveryRequest) (*RecoveryMaskList, error)
+	RecoverShare(context.Context, *RecoverShareRequest) (*RecoveredPoint, error)
 }
 
 func RegisterNodeServer(s *grpc.Server, srv NodeServer) {
 	s.RegisterService(&_Node_serviceDesc, srv)
 }
 
-func _Node_AdvanceEpoch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Empty)
+func _Node_StartCheckingProposals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProposalHashList)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NodeServer).AdvanceEpoch(ctx, in)
+		return srv.(NodeServer).StartCheckingProposals(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/services.Node/AdvanceEpoch",
+		FullMethod: "/services.Node/StartCheckingProposals",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NodeServer).AdvanceEpoch(ctx, req.(*Empty))
+		return srv.(NodeServer).StartCheckingProposals(ctx, req.(*ProposalHashList))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Node_StartCheckingProposals_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ProposalHashList)
+func _Node_SubmitProposal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Proposal)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NodeServer).StartCheckingProposals(ctx, in)
+		return srv.(NodeServer).SubmitProposal(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/services.Node/StartCheckingProposals",
+		FullMethod: "/services.Node/SubmitProposal",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NodeServer).StartCheckingProposals(ctx, req.(*ProposalHashList))
+		return srv.(NodeServer).SubmitProposal(ctx, req.(*Proposal))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-func _Node_SubmitProposal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Proposal)
+func _Node_SubmitProposalStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(NodeServer).SubmitProposalStream(&nodeSubmitProposalStreamServer{stream})
+}
+
+type Node_SubmitProposalStreamServer interface {
+	SendAndClose(*ProposalTransferStatus) error
+	Recv() (*ProposalChunk, error)
+	grpc.ServerStream
+}
+
+type nodeSubmitProposalStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *nodeSubmitProposalStreamServer) SendAndClose(m *ProposalTransferStatus) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *nodeSubmitProposalStreamServer) Recv() (*ProposalChunk, error) {
+	m := new(ProposalChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Node_GetProposalTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProposalTransfer)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(NodeServer).SubmitProposal(ctx, in)
+		return srv.(NodeServer).GetProposalTransfer(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/services.Node/SubmitProposal",
+		FullMethod: "/services.Node/GetProposalTransfer",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(NodeServer).SubmitProposal(ctx, req.(*Proposal))
+		return srv.(NodeServer).GetProposalTransfer(ctx, req.(*ProposalTransfer))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -634,14 +3443,100 @@ func _Node_SubmitBlindedShare_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Node_SubmitDealing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Dealing)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).SubmitDealing(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/services.Node/SubmitDealing",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).SubmitDealing(ctx, req.(*Dealing))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_GetProposal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProposalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).GetProposal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/services.Node/GetProposal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).GetProposal(ctx, req.(*ProposalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_PullProposal_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProposalRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).PullProposal(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/services.Node/PullProposal",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).PullProposal(ctx, req.(*ProposalRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_RecoveryMasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecoveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).RecoveryMasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/services.Node/RecoveryMasks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).RecoveryMasks(ctx, req.(*RecoveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Node_RecoverShare_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecoverShareRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(NodeServer).RecoverShare(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/services.Node/RecoverShare",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(NodeServer).RecoverShare(ctx, req.(*RecoverShareRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Node_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "services.Node",
 	HandlerType: (*NodeServer)(nil),
 	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "AdvanceEpoch",
-			Handler:    _Node_AdvanceEpoch_Handler,
-		},
 		{
 			MethodName: "StartCheckingProposals",
 			Handler:    _Node_StartCheckingProposals_Handler,
@@ -650,11 +3545,41 @@ var _Node_serviceDesc = grpc.ServiceDesc{
 			MethodName: "SubmitProposal",
 			Handler:    _Node_SubmitProposal_Handler,
 		},
+		{
+			MethodName: "GetProposalTransfer",
+			Handler:    _Node_GetProposalTransfer_Handler,
+		},
 		{
 			MethodName: "SubmitBlindedShare",
 			Handler:    _Node_SubmitBlindedShare_Handler,
 		},
+		{
+			MethodName: "SubmitDealing",
+			Handler:    _Node_SubmitDealing_Handler,
+		},
+		{
+			MethodName: "GetProposal",
+			Handler:    _Node_GetProposal_Handler,
+		},
+		{
+			MethodName: "PullProposal",
+			Handler:    _Node_PullProposal_Handler,
+		},
+		{
+			MethodName: "RecoveryMasks",
+			Handler:    _Node_RecoveryMasks_Handler,
+		},
+		{
+			MethodName: "RecoverShare",
+			Handler:    _Node_RecoverShare_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubmitProposalStream",
+			Handler:       _Node_SubmitProposalStream_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "services.proto",
 }
@@ -0,0 +1,157 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"../../utils/conv"
+	"./services"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+)
+
+// nextCommitment is the commitment to the sharing after a handoff. The new sharing is the old one
+// plus the Q of every proposal used, since the blinding polynomials vanish at their new node.
+func nextCommitment(current PolyCommit, proposals []*ProposalSlice) PolyCommit {
+	for _, p := range proposals {
+		current = AdditiveHomomorphism(current, p.commQ)
+	}
+
+	return current
+}
+
+// majorityCommitment returns the commitment sent by the most nodes, if at least threshold of them agree
+// and it is to a polynomial of the given degree.
+func majorityCommitment(received map[int64][]byte, threshold, degree int) (PolyCommit, error) {
+	votes := make(map[string]int)
+	for _, comm := range received {
+		votes[string(comm)] += 1
+	}
+
+	best := ""
+	tie := false
+	for comm, n := range votes {
+		if n > votes[best] {
+			best = comm
+			tie = false
+		} else if n == votes[best] {
+			tie = true
+		}
+	}
+
+	if tie || votes[best] < threshold {
+		return PolyCommit{}, fmt.Errorf("only %d of %d nodes agree", votes[best], len(received))
+	}
+
+	return decodeCommitmentOfDegree([]byte(best), degree)
+}
+
+// ReportShare tells the board the handoff of the epoch is over for this node. In benchmark mode,
+// it sends the share itself. In production mode, it only tells whether the share lies on the
+// committed polynomial.
+func (node *Node) ReportShare(epoch Epoch) error {
+	return node.reportShare(epoch, node.share, node.secretCommitment)
+}
+
+func (node *Node) reportShare(epoch Epoch, share *gmp.Int, commitment PolyCommit) error {
+	if node.mode != ModeProduction {
+		node.log.Debugf("new share sending to the primary")
+		if err := node.submitShare(epoch, share); err != nil {
+			return err
+		}
+		node.log.Debugf("new share sent to the primary")
+		return nil
+	}
+
+	valid := commitment.VerifyEval(big.NewInt(node.id), conv.GmpInt2BigInt(share))
+	if !valid {
+		node.log.Errorf("my share for epoch %d is not on the committed polynomial", epoch)
+	}
+
+	msg := services.ShareCheck{
+		Epoch:      int32(epoch),
+		From:       node.id,
+		Commitment: encodeCommitment(commitment),
+		Valid:      valid,
+	}
+	node.sign(&msg)
+
+	if err := node.board.Post(context.Background(), newPost(services.BoardPost_SHARE_CHECK, epoch, node.id, &msg)); err != nil {
+		return fmt.Errorf("can't post the share check: %s", err.Error())
+	}
+
+	return nil
+}
+
+func (bb *BulletinBoard) submitShareCheck(check *services.ShareCheck) {
+	bb.log.Debugf("from=%d, valid=%t", check.From, check.Valid)
+
+	bb.shareChecks.put(Epoch(check.Epoch), check.From, check)
+}
+
+// confirmHandoff waits for every holder of the new sharing to check its share, up to the deadline, and makes sure
+// enough of them hold shares on the same polynomial to recover the secret. The board never sees a share.
+func (bb *BulletinBoard) confirmHandoff(epoch Epoch) error {
+	degree, holders := bb.holders(epoch)
+
+	checks := make(map[int64]*services.ShareCheck)
+	stop := bb.sharesDeadline(epoch)
+	for len(checks) < len(holders) {
+		msg := bb.shareChecks.next(epoch, stop)
+		if msg == nil {
+			bb.recordMissed("share checks", missing(holders, func(id int64) bool { return checks[id] != nil }))
+			break
+		}
+		check := msg.(*services.ShareCheck)
+
+		if !contains(holders, check.From) {
+			bb.log.Warnf("[primary] ignoring a share check from %d, which holds no share", check.From)
+			continue
+		}
+
+		checks[check.From] = check
+	}
+
+	// only the holders whose shares lie on the polynomial vouch for it
+	vouching := make(map[int64][]byte)
+	for from, check := range checks {
+		if check.Valid {
+			vouching[from] = check.Commitment
+		}
+	}
+
+	// the dealers fixed the initial commitment
+	agreed := bb.secretCommitment
+	if epoch > 0 {
+		var err error
+		agreed, err = majorityCommitment(vouching, degree+1, degree)
+		if err != nil {
+			return fmt.Errorf("no agreed polynomial: %s", err.Error())
+		}
+	}
+
+	// the secret can be recovered from degree+1 shares on the agreed polynomial
+	expected := encodeCommitment(agreed)
+	confirmed := 0
+	for _, id := range holders {
+		if comm, ok := vouching[id]; ok && string(comm) == string(expected) {
+			confirmed += 1
+		} else {
+			bb.log.Warnf("[primary] %d holds no share on the agreed polynomial", id)
+		}
+	}
+
+	if confirmed < degree+1 {
+		return fmt.Errorf("only %d shares on the agreed polynomial", confirmed)
+	}
+
+	bb.secretCommitment = agreed
+
+	bb.log.WithFields(logrus.Fields{
+		"commitment": agreed.String(),
+		"confirmed":  confirmed,
+	}).Warnf("finishing epoch %d", epoch)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package Schultz
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"../../utils/conv"
+	"../../utils/polyring"
+	"github.com/ncw/gmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextCommitment(t *testing.T) {
+	pp, keys := withEncryptionKeys(mustBuildHandoffConfig(t, 1, 2, makeOneToN(4), []int64{3, 4, 5, 6, 7, 8, 9}))
+
+	secretPoly, err := polyring.NewRand(pp.GetDegree(), rand.New(rand.NewSource(0)), pp.GetPrime())
+	assert.Nil(t, err)
+
+	oldShares := make(map[int64]*gmp.Int)
+	for _, j := range pp.GetOldGroup() {
+		oldShares[j] = gmp.NewInt(0)
+		secretPoly.EvalMod(gmp.NewInt(j), pp.GetPrime(), oldShares[j])
+	}
+
+	var proposals []*Proposal
+	var slices []*ProposalSlice
+	for i := 0; i < 2*pp.GetDegree()+1; i++ {
+		p := GenerateProposal(pp, 1)
+		proposals = append(proposals, &p)
+
+		// any old node's slices carry the commitments
+		slice, err := p.Slice(OldNodeID(1))
+		assert.Nil(t, err)
+		slices = append(slices, &slice)
+	}
+
+	newShares := handoffWithProposals(t, pp, keys, oldShares, proposals)
+
+	// every new share lies on the polynomial committed to by the old commitment plus the Qs
+	commitment := nextCommitment(NewPolyCommit(secretPoly), slices)
+	for k, share := range newShares {
+		assert.True(t, commitment.VerifyEval(big.NewInt(k), conv.GmpInt2BigInt(share)))
+	}
+
+	// but not if a proposal is left out
+	partial := nextCommitment(NewPolyCommit(secretPoly), slices[1:])
+	for k, share := range newShares {
+		assert.False(t, partial.VerifyEval(big.NewInt(k), conv.GmpInt2BigInt(share)))
+	}
+}
+
+func TestMajorityCommitment(t *testing.T) {
+	polyA, err := polyring.NewRand(2, rand.New(rand.NewSource(1)), FieldPrime)
+	assert.Nil(t, err)
+	polyB, err := polyring.NewRand(2, rand.New(rand.NewSource(2)), FieldPrime)
+	assert.Nil(t, err)
+
+	a := encodeCommitment(NewPolyCommit(polyA))
+	b := encodeCommitment(NewPolyCommit(polyB))
+
+	comm, err := majorityCommitment(map[int64][]byte{1: a, 2: a, 3: b}, 2, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, a, encodeCommitment(comm))
+
+	// not enough nodes agree
+	_, err = majorityCommitment(map[int64][]byte{1: a, 2: a, 3: b}, 3, 2)
+	assert.NotNil(t, err)
+
+	// a tie
+	_, err = majorityCommitment(map[int64][]byte{1: a, 2: a, 3: b, 4: b}, 2, 2)
+	assert.NotNil(t, err)
+
+	// the nodes agree on a polynomial of another degree
+	_, err = majorityCommitment(map[int64][]byte{1: a, 2: a, 3: b}, 2, 1)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,330 @@
+package Schultz
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	crand "crypto/rand"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ncw/gmp"
+	"golang.org/x/crypto/scrypt"
+)
+
+// ShareState is what a node needs to take its place in the committee again after a restart.
+type ShareState struct {
+	// the last epoch the node finished
+	Epoch Epoch
+	// the share held after that epoch, nil if none
+	Share *gmp.Int
+	// commitment to the sharing the share lies on
+	Commitment PolyCommit
+	// whether the new group took over
+	HandedOff bool
+	// a new share reported to the board and waiting for its verdict, if any
+	Pending *PendingShare
+}
+
+// PendingShare is a new share the board has not confirmed yet. It replaces the share
+// only if the board confirms its epoch.
+type PendingShare struct {
+	Epoch      Epoch
+	Share      *gmp.Int
+	Commitment PolyCommit
+}
+
+// ShareStore keeps the state of a node across restarts.
+type ShareStore interface {
+	// Load returns the saved state, or nil if nothing was saved yet.
+	Load() (*ShareState, error)
+	// Save replaces the saved state.
+	Save(state *ShareState) error
+}
+
+// storedState is how a ShareState is encoded.
+type storedState struct {
+	Epoch      int32
+	HasShare   bool
+	Share      []byte
+	Commitment []byte
+	HandedOff  bool
+
+	HasPending        bool
+	PendingEpoch      int32
+	PendingShare      []byte
+	PendingCommitment []byte
+}
+
+func (s *ShareState) ToBytes() []byte {
+	stored := storedState{
+		Epoch:      int32(s.Epoch),
+		HasShare:   s.Share != nil,
+		Commitment: encodeCommitment(s.Commitment),
+		HandedOff:  s.HandedOff,
+	}
+	if s.Share != nil {
+		stored.Share = s.Share.Bytes()
+	}
+	if s.Pending != nil {
+		stored.HasPending = true
+		stored.PendingEpoch = int32(s.Pending.Epoch)
+		stored.PendingShare = s.Pending.Share.Bytes()
+		stored.PendingCommitment = encodeCommitment(s.Pending.Commitment)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(stored); err != nil {
+		panic(err.Error())
+	}
+	zeroBytes(stored.Share)
+	zeroBytes(stored.PendingShare)
+
+	return buf.Bytes()
+}
+
+func ShareStateFromBytes(b []byte) (*ShareState, error) {
+	var stored storedState
+	if err := gob.NewDecoder(bytes.NewBuffer(b)).Decode(&stored); err != nil {
+		return nil, err
+	}
+
+	commitment, err := decodeCommitment(stored.Commitment)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ShareState{
+		Epoch:      Epoch(stored.Epoch),
+		Commitment: commitment,
+		HandedOff:  stored.HandedOff,
+	}
+	if stored.HasShare {
+		s.Share = gmp.NewInt(0).SetBytes(stored.Share)
+	}
+	if stored.HasPending {
+		commitment, err := decodeCommitment(stored.PendingCommitment)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Pending = &PendingShare{
+			Epoch:      Epoch(stored.PendingEpoch),
+			Share:      gmp.NewInt(0).SetBytes(stored.PendingShare),
+			Commitment: commitment,
+		}
+	}
+
+	return s, nil
+}
+
+// MemoryShareStore keeps the state in memory, for nodes that never restart and for tests.
+type MemoryShareStore struct {
+	saved []byte
+	lock  *sync.Mutex
+}
+
+func NewMemoryShareStore() *MemoryShareStore {
+	return &MemoryShareStore{lock: &sync.Mutex{}}
+}
+
+func (m *MemoryShareStore) Load() (*ShareState, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.saved == nil {
+		return nil, nil
+	}
+
+	return ShareStateFromBytes(m.saved)
+}
+
+func (m *MemoryShareStore) Save(state *ShareState) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.saved = state.ToBytes()
+
+	return nil
+}
+
+// A share store file is the magic, a random salt, and the state sealed with AES-GCM under a key
+// derived from the secret and the salt with scrypt.
+const (
+	shareStoreMagic = "mpss-share-store-v1"
+	shareStoreSalt  = 16
+)
+
+// FileShareStore keeps the state in a file, encrypted under a key derived from a passphrase or the
+// contents of a key file. The file is replaced atomically, so a crash leaves either the old or the new state.
+type FileShareStore struct {
+	path   string
+	secret []byte
+
+	// the key derived from the secret, for the salt of the file
+	salt []byte
+	key  []byte
+	lock *sync.Mutex
+}
+
+func NewFileShareStore(path string, secret []byte) (*FileShareStore, error) {
+	if len(secret) == 0 {
+		return nil, fmt.Errorf("no passphrase or key for the share store")
+	}
+
+	return &FileShareStore{
+		path:   path,
+		secret: secret,
+		lock:   &sync.Mutex{},
+	}, nil
+}
+
+// GenerateShareStoreKey writes a new random key for a share store to file.
+func GenerateShareStoreKey(file string) error {
+	key := make([]byte, 32)
+	if _, err := crand.Read(key); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, []byte(hex.EncodeToString(key)), 0600)
+}
+
+// LoadShareStoreKey reads the key of a share store from file.
+func LoadShareStoreKey(file string) ([]byte, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.TrimSpace(string(content))), nil
+}
+
+func (f *FileShareStore) aead(salt []byte) (cipher.AEAD, error) {
+	if f.key == nil || !bytes.Equal(f.salt, salt) {
+		key, err := scrypt.Key(f.secret, salt, 1<<15, 8, 1, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		f.salt = salt
+		f.key = key
+	}
+
+	block, err := aes.NewCipher(f.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (f *FileShareStore) Load() (*ShareState, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	content, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(content, []byte(shareStoreMagic)) || len(content) < len(shareStoreMagic)+shareStoreSalt {
+		return nil, fmt.Errorf("%s is not a share store", f.path)
+	}
+	header := content[:len(shareStoreMagic)+shareStoreSalt]
+	salt := header[len(shareStoreMagic):]
+
+	aead, err := f.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := content[len(header):]
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s is truncated", f.path)
+	}
+
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], header)
+	if err != nil {
+		return nil, fmt.Errorf("can't open %s: wrong key, or the file was tampered with", f.path)
+	}
+
+	defer zeroBytes(plain)
+
+	return ShareStateFromBytes(plain)
+}
+
+func (f *FileShareStore) Save(state *ShareState) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	salt := f.salt
+	if salt == nil {
+		salt = make([]byte, shareStoreSalt)
+		if _, err := crand.Read(salt); err != nil {
+			return err
+		}
+	}
+
+	aead, err := f.aead(salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := crand.Read(nonce); err != nil {
+		return err
+	}
+
+	plain := state.ToBytes()
+	defer zeroBytes(plain)
+
+	header := append([]byte(shareStoreMagic), salt...)
+	content := append(append(append([]byte{}, header...), nonce...), aead.Seal(nil, nonce, plain, header)...)
+
+	return writeFileAtomic(f.path, content)
+}
+
+// writeFileAtomic replaces the file with content, or leaves it as it was.
+func writeFileAtomic(path string, content []byte) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	// make the rename itself durable
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,123 @@
+package Schultz
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func testShareState() *ShareState {
+	return &ShareState{
+		Epoch:     3,
+		Share:     gmp.NewInt(42),
+		HandedOff: true,
+		Pending: &PendingShare{
+			Epoch: 4,
+			Share: gmp.NewInt(0),
+		},
+	}
+}
+
+func testShareStore(t *testing.T, store ShareStore) {
+	state, err := store.Load()
+	assert.Nil(t, err)
+	assert.Nil(t, state)
+
+	assert.Nil(t, store.Save(testShareState()))
+
+	state, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, testShareState().ToBytes(), state.ToBytes())
+	assert.Equal(t, 0, state.Share.Cmp(gmp.NewInt(42)))
+	assert.Equal(t, 0, state.Pending.Share.Cmp(gmp.NewInt(0)))
+
+	// a node that left the committee holds no share
+	assert.Nil(t, store.Save(&ShareState{Epoch: 5}))
+
+	state, err = store.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, Epoch(5), state.Epoch)
+	assert.Nil(t, state.Share)
+	assert.Nil(t, state.Pending)
+}
+
+func TestMemoryShareStore(t *testing.T) {
+	testShareStore(t, NewMemoryShareStore())
+}
+
+func TestFileShareStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mpss")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := path.Join(dir, "store.key")
+	assert.Nil(t, GenerateShareStoreKey(keyFile))
+	key, err := LoadShareStoreKey(keyFile)
+	assert.Nil(t, err)
+
+	storeFile := path.Join(dir, "share")
+	store, err := NewFileShareStore(storeFile, key)
+	assert.Nil(t, err)
+	testShareStore(t, store)
+
+	// only the store itself is left behind
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Len(t, files, 2)
+
+	// a restarted node opens the store with the same key
+	reopened, err := NewFileShareStore(storeFile, key)
+	assert.Nil(t, err)
+	state, err := reopened.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, Epoch(5), state.Epoch)
+
+	// but not with another
+	wrong, err := NewFileShareStore(storeFile, []byte("passphrase"))
+	assert.Nil(t, err)
+	_, err = wrong.Load()
+	assert.NotNil(t, err)
+
+	// and notices tampering
+	content, err := ioutil.ReadFile(storeFile)
+	assert.Nil(t, err)
+	content[len(content)-1] ^= 1
+	assert.Nil(t, ioutil.WriteFile(storeFile, content, 0600))
+	_, err = reopened.Load()
+	assert.NotNil(t, err)
+
+	_, err = NewFileShareStore(storeFile, nil)
+	assert.NotNil(t, err)
+}
+
+func TestNode_UseShareStore(t *testing.T) {
+	pp := BuildConfig(1, FieldPrime, makeOneToN(4), []int64{2, 3, 4, 5})
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	store := NewMemoryShareStore()
+
+	// nothing to resume from
+	node := BuildNode(pp, logger, 5, "", "", nil, nil)
+	resumed, err := node.UseShareStore(store)
+	assert.Nil(t, err)
+	assert.False(t, resumed)
+
+	assert.Nil(t, store.Save(testShareState()))
+
+	node = BuildNode(pp, logger, 5, "", "", nil, nil)
+	resumed, err = node.UseShareStore(store)
+	assert.Nil(t, err)
+	assert.True(t, resumed)
+
+	assert.Equal(t, Epoch(3), node.resumeEpoch)
+	assert.Equal(t, 0, node.share.Cmp(gmp.NewInt(42)))
+	assert.Equal(t, Epoch(4), node.pending.Epoch)
+	assert.True(t, node.config.IsOldMember(5))
+}
@@ -0,0 +1,185 @@
+package Schultz
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/crypto/ed25519"
+)
+
+// Every message a node or the dealer sends is signed with its ed25519 identity key, and receivers check
+// the signature against the sender the message names. Signed messages can be shown to anyone: a
+// proposal hash in a list of the board proves what its proposer committed to.
+
+// signedMessage is a message naming its sender and carrying the sender's signature.
+type signedMessage interface {
+	proto.Message
+	GetSignature() []byte
+}
+
+// messageSender returns the sender named in the message.
+func messageSender(msg signedMessage) int64 {
+	switch msg := msg.(type) {
+	case *services.Share:
+		return msg.From
+	case *services.BlindedShare:
+		return msg.From
+	case *services.ShareCheck:
+		return msg.From
+	case *services.ShareErasure:
+		return msg.From
+	case *services.Goodbye:
+		return msg.From
+	case *services.RecoveryMask:
+		return msg.From
+	case *services.RecoveredPoint:
+		return msg.From
+	case *services.ProposalHash:
+		return msg.Proposer
+	case *services.Proposal:
+		return msg.From
+	case *services.ComplaintList:
+		return msg.From
+	case *services.Dealing:
+		return msg.From
+	case *services.DealingCommitment:
+		return msg.Dealer
+	case *services.DealingComplaints:
+		return msg.From
+	case *services.DealingReveal:
+		return msg.Dealer
+	case *services.BoardPost:
+		return msg.From
+	default:
+		panic(fmt.Sprintf("%s is not a signed message", proto.MessageName(msg)))
+	}
+}
+
+func setSignature(msg signedMessage, sig []byte) {
+	switch msg := msg.(type) {
+	case *services.Share:
+		msg.Signature = sig
+	case *services.BlindedShare:
+		msg.Signature = sig
+	case *services.ShareCheck:
+		msg.Signature = sig
+	case *services.ShareErasure:
+		msg.Signature = sig
+	case *services.Goodbye:
+		msg.Signature = sig
+	case *services.RecoveryMask:
+		msg.Signature = sig
+	case *services.RecoveredPoint:
+		msg.Signature = sig
+	case *services.ProposalHash:
+		msg.Signature = sig
+	case *services.Proposal:
+		msg.Signature = sig
+	case *services.ComplaintList:
+		msg.Signature = sig
+	case *services.Dealing:
+		msg.Signature = sig
+	case *services.DealingCommitment:
+		msg.Signature = sig
+	case *services.DealingComplaints:
+		msg.Signature = sig
+	case *services.DealingReveal:
+		msg.Signature = sig
+	case *services.BoardPost:
+		msg.Signature = sig
+	default:
+		panic(fmt.Sprintf("%s is not a signed message", proto.MessageName(msg)))
+	}
+}
+
+// messageSigningBytes is what the sender signs: the type of the message and the message without its signature.
+func messageSigningBytes(msg signedMessage) []byte {
+	unsigned := proto.Clone(msg).(signedMessage)
+	setSignature(unsigned, nil)
+
+	data, err := proto.Marshal(unsigned)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("mpss-message")
+	buf.WriteString(proto.MessageName(msg))
+	buf.WriteByte(0)
+	buf.Write(data)
+
+	return buf.Bytes()
+}
+
+// signMessage sets the signature of msg.
+func signMessage(key ed25519.PrivateKey, msg signedMessage) {
+	setSignature(msg, ed25519.Sign(key, messageSigningBytes(msg)))
+}
+
+// IdentityKeys are the public identity keys of the nodes, and of the dealer under DealerId.
+type IdentityKeys map[int64]ed25519.PublicKey
+
+// verify checks that msg is signed by the sender it names.
+func (keys IdentityKeys) verify(msg signedMessage) error {
+	from := messageSender(msg)
+
+	key, ok := keys[from]
+	if !ok {
+		return fmt.Errorf("no identity key for %d", from)
+	}
+
+	if len(msg.GetSignature()) == 0 {
+		return fmt.Errorf("%s from %d is not signed", proto.MessageName(msg), from)
+	}
+
+	if !ed25519.Verify(key, messageSigningBytes(msg), msg.GetSignature()) {
+		return fmt.Errorf("bad signature on %s from %d", proto.MessageName(msg), from)
+	}
+
+	return nil
+}
+
+// ParseIdentityKey decodes a hex-encoded ed25519 public key.
+func ParseIdentityKey(publicKey string) (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("not an ed25519 public key")
+	}
+
+	return ed25519.PublicKey(key), nil
+}
+
+// GenerateIdentityKey writes a fresh ed25519 seed to keyFile and returns the public key in hex.
+func GenerateIdentityKey(keyFile string) (string, error) {
+	pub, priv, err := ed25519.GenerateKey(crand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	seed := hex.EncodeToString(priv.Seed())
+	if err := ioutil.WriteFile(keyFile, []byte(seed+"\n"), 0600); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(pub), nil
+}
+
+func LoadIdentityKey(keyFile string) (ed25519.PrivateKey, error) {
+	content, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	seed, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s doesn't hold an ed25519 seed", keyFile)
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,112 @@
+package Schultz
+
+import (
+	crand "crypto/rand"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"./services"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ed25519"
+)
+
+// withIdentityKeys gives every node of pp and the dealer a fresh identity key.
+func withIdentityKeys(pp PublicParameter) (PublicParameter, map[int64]ed25519.PrivateKey) {
+	keys := make(map[int64]ed25519.PrivateKey)
+	public := make(IdentityKeys)
+
+	for _, id := range append(pp.Members(), DealerId) {
+		pub, priv, err := ed25519.GenerateKey(crand.Reader)
+		if err != nil {
+			panic(err.Error())
+		}
+
+		keys[id] = priv
+		public[id] = pub
+	}
+
+	return pp.WithIdentityKeys(public), keys
+}
+
+func TestIdentityKeyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mpss")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	keyFile := path.Join(dir, "node.key")
+
+	publicHex, err := GenerateIdentityKey(keyFile)
+	assert.Nil(t, err)
+
+	key, err := LoadIdentityKey(keyFile)
+	assert.Nil(t, err)
+
+	public, err := ParseIdentityKey(publicHex)
+	assert.Nil(t, err)
+	assert.Equal(t, key.Public(), public)
+}
+
+func TestSignedMessages(t *testing.T) {
+	pp, keys := withIdentityKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+
+	messages := []signedMessage{
+		&services.Share{Epoch: 1, From: 1, Share: []byte{1}},
+		&services.BlindedShare{Epoch: 1, From: 1, Share: []byte{1}, Commitment: []byte{2}},
+		&services.ShareCheck{Epoch: 1, From: 1, Commitment: []byte{2}, Valid: true},
+		&services.ShareErasure{Epoch: 1, From: 1},
+		&services.RecoveryMask{Epoch: 1, From: 1, To: 2, Session: []byte{7}, Mask: &services.EncryptedPoints{Ephemeral: []byte{8}}},
+		&services.RecoveredPoint{Epoch: 1, From: 1, Value: []byte{9}, Helpers: []int64{1, 2}},
+		&services.ProposalHash{Epoch: 1, Proposer: 1, Hash: []byte{3}},
+		&services.Proposal{Epoch: 1, From: 1, Session: []byte{4}},
+		&services.ComplaintList{Epoch: 1, From: 1, List: []*services.Complaint{{Accused: 2}}},
+		&services.Dealing{Epoch: 0, From: 1, Share: []byte{5}},
+		&services.DealingCommitment{Epoch: 0, Dealer: 1, Commitment: []byte{6}},
+	}
+
+	for _, msg := range messages {
+		// unsigned
+		assert.NotNil(t, pp.identityKeys.verify(msg))
+
+		// signed by someone else
+		signMessage(keys[2], msg)
+		assert.NotNil(t, pp.identityKeys.verify(msg))
+
+		signMessage(keys[1], msg)
+		assert.Nil(t, pp.identityKeys.verify(msg))
+	}
+
+	// the signature covers every field
+	hash := &services.ProposalHash{Epoch: 1, Proposer: 1, Hash: []byte{3}}
+	signMessage(keys[1], hash)
+
+	tampered := *hash
+	tampered.Hash = []byte{4}
+	assert.NotNil(t, pp.identityKeys.verify(&tampered))
+
+	// and can't be moved to another type with the same fields
+	share := &services.Share{Epoch: 1, From: 1, Share: []byte{3}, Signature: hash.Signature}
+	assert.NotNil(t, pp.identityKeys.verify(share))
+
+	// the dealer signs under its own id
+	dealing := &services.Dealing{Epoch: 0, From: DealerId, Share: []byte{5}}
+	signMessage(keys[DealerId], dealing)
+	assert.Nil(t, pp.identityKeys.verify(dealing))
+}
+
+func TestBulletinBoard_RejectsUnsignedPosts(t *testing.T) {
+	pp, keys := withIdentityKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	bb := BuildBulletinBoard(logger, "", nil, pp)
+
+	hash := &services.ProposalHash{Epoch: 1, Proposer: 1, Hash: make([]byte, 32)}
+	assert.NotNil(t, bb.handlePost(newPost(services.BoardPost_PROPOSAL_HASH, 1, 1, hash)))
+
+	// a node can't post a message signed by another
+	signMessage(keys[1], hash)
+	assert.NotNil(t, bb.handlePost(newPost(services.BoardPost_PROPOSAL_HASH, 1, 2, hash)))
+}
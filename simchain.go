@@ -0,0 +1,243 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ed25519"
+)
+
+// gas schedule of the simulated chain, after Ethereum's
+const (
+	// base cost of a transaction
+	txGas = 21000
+	// calldata costs per byte
+	zeroByteGas    = 4
+	nonZeroByteGas = 16
+	// storing a 32-byte word
+	storageWordGas = 20000
+)
+
+// postGas is the gas a post costs: a transaction carrying the post as calldata, storing the payload.
+func postGas(post *services.BoardPost) uint64 {
+	data, err := proto.Marshal(post)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	gas := uint64(txGas)
+	for _, b := range data {
+		if b == 0 {
+			gas += zeroByteGas
+		} else {
+			gas += nonZeroByteGas
+		}
+	}
+
+	words := (len(post.Payload) + 31) / 32
+	gas += uint64(words) * storageWordGas
+
+	return gas
+}
+
+// chainStats is what an epoch cost on chain.
+type chainStats struct {
+	posts int
+	bytes int
+	gas   uint64
+}
+
+// SimChain is a local simulation of a smart contract acting as the bulletin board.
+// Posts wait in the mempool until they fit in a block, and show up to the subscribers
+// once enough blocks are mined on top of theirs. Like transactions, posts are signed by the
+// account they are from, so a party can't post as another one, nor as the board.
+type SimChain struct {
+	config ChainConfig
+	// the key of each account, by the id of its party
+	accounts IdentityKeys
+
+	lock    *sync.Mutex
+	mempool []*services.BoardPost
+	// mined blocks that are not final yet
+	pending [][]*services.BoardPost
+	height  int64
+	stats   map[Epoch]*chainStats
+
+	// the final posts
+	final *boardLog
+
+	ctx  context.Context
+	stop context.CancelFunc
+
+	// logging
+	log *logrus.Entry
+}
+
+// BuildSimChain starts mining blocks right away, until Stop is called.
+func BuildSimChain(config ChainConfig, accounts IdentityKeys, logger *logrus.Logger) *SimChain {
+	ctx, stop := context.WithCancel(context.Background())
+
+	chain := &SimChain{
+		config:   config,
+		accounts: accounts,
+		lock:     &sync.Mutex{},
+		stats:    make(map[Epoch]*chainStats),
+		final:    newBoardLog(),
+		ctx:      ctx,
+		stop:     stop,
+		log: logger.WithFields(
+			logrus.Fields{
+				"name": "chain",
+			}),
+	}
+
+	go chain.mine()
+
+	return chain
+}
+
+func (c *SimChain) mine() {
+	ticker := time.NewTicker(time.Duration(c.config.GetBlockTime()) * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.mineBlock()
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// mineBlock packs the mempool in order into a block, up to the gas limit, and finalizes the blocks
+// that are deep enough.
+func (c *SimChain) mineBlock() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.height += 1
+
+	var block []*services.BoardPost
+	gasUsed := uint64(0)
+	for len(c.mempool) > 0 {
+		gas := postGas(c.mempool[0])
+		if gasUsed+gas > c.config.GetGasLimit() {
+			break
+		}
+		gasUsed += gas
+
+		post := proto.Clone(c.mempool[0]).(*services.BoardPost)
+		post.Block = c.height
+		block = append(block, post)
+		c.mempool = c.mempool[1:]
+
+		stats, ok := c.stats[Epoch(post.Epoch)]
+		if !ok {
+			stats = &chainStats{}
+			c.stats[Epoch(post.Epoch)] = stats
+		}
+		stats.posts += 1
+		stats.bytes += proto.Size(post)
+		stats.gas += gas
+	}
+
+	if len(block) > 0 {
+		c.log.Debugf("block %d: %d posts, %d gas", c.height, len(block), gasUsed)
+	}
+
+	c.pending = append(c.pending, block)
+
+	// the oldest pending block is at height c.height - len(c.pending) + 1
+	for len(c.pending) > c.config.GetFinality() {
+		for _, post := range c.pending[0] {
+			c.final.append(post)
+		}
+		c.pending = c.pending[1:]
+	}
+}
+
+// Post sends a post to the mempool. Posts that aren't signed by the account they are from, or that
+// can't fit in a block, are rejected.
+func (c *SimChain) Post(ctx context.Context, post *services.BoardPost) error {
+	if err := c.accounts.verify(post); err != nil {
+		return err
+	}
+
+	if gas := postGas(post); gas > c.config.GetGasLimit() {
+		return fmt.Errorf("a %s post needs %d gas, over the block gas limit of %d", post.Kind.String(), gas, c.config.GetGasLimit())
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.mempool = append(c.mempool, post)
+
+	return nil
+}
+
+// Account is the chain as seen by the holder of the key, which signs what it posts.
+func (c *SimChain) Account(key ed25519.PrivateKey) Board {
+	return chainAccount{SimChain: c, key: key}
+}
+
+type chainAccount struct {
+	*SimChain
+	key ed25519.PrivateKey
+}
+
+func (a chainAccount) Post(ctx context.Context, post *services.BoardPost) error {
+	post = proto.Clone(post).(*services.BoardPost)
+	signMessage(a.key, post)
+
+	return a.SimChain.Post(ctx, post)
+}
+
+func (c *SimChain) Subscribe(kinds ...services.BoardPost_Kind) <-chan *services.BoardPost {
+	return c.final.subscribe(c.ctx, kinds)
+}
+
+func (c *SimChain) ReadEpoch(ctx context.Context, epoch Epoch) ([]*services.BoardPost, error) {
+	return c.final.readEpoch(epoch), nil
+}
+
+// Height returns the number of blocks mined so far.
+func (c *SimChain) Height() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.height
+}
+
+// Report logs the cost of each epoch on chain.
+func (c *SimChain) Report() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var epochs []Epoch
+	for epoch := range c.stats {
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+
+	for _, epoch := range epochs {
+		stats := c.stats[epoch]
+		c.log.WithFields(logrus.Fields{
+			"epoch": epoch,
+			"posts": stats.posts,
+			"bytes": stats.bytes,
+			"gas":   stats.gas,
+		}).Warn("chain benchmark.")
+	}
+}
+
+// Stop stops mining and ends the subscriptions.
+func (c *SimChain) Stop() {
+	c.stop()
+}
@@ -0,0 +1,945 @@
+package Schultz
+
+import (
+	"container/heap"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The simulation runs the real nodes and board logic of one process over simulated links, on a
+// virtual clock. Every message and every deadline is an event on one queue. The simulator counts the
+// goroutines of the parties that are running: they start them and wait through the clock of the
+// simulation, and whatever wakes a goroutine up counts it again. Once none runs, the simulator takes
+// the next event off the queue and moves the clock to it. The parties compute in no simulated time,
+// and a run only depends on the events, not on how fast the process is.
+//
+// A message first queues on its link, which sends one message at a time at its bandwidth. It then
+// takes the latency of the link, drawn from its distribution. A link delivers its messages in order,
+// like the TCP connection under gRPC. A packet lost on the way is sent again after a retransmission
+// timeout, as TCP does, so a loss delays the message and every one behind it, but never drops it:
+// only a broken connection loses messages, and the simulated ones don't break. The deadlines of the
+// calls pass on the virtual clock, so a call held up too long by losses fails as it would over gRPC.
+//
+// Events at the same time come in the same order in every run: first the messages sent, by link and
+// name, then the arrivals, in the order the messages were sent, then the deadlines, all at once. The
+// draws of a message only depend on the seed, its link and how many messages went on the link before.
+// The size of a message, and so its time on the link, is the same in every run too. The polynomials of
+// the proposals are drawn from the seed, and so are the commitments to them: anyone with the seed can
+// strip the blinding, so a simulation is no place for a real secret. The numbers that still
+// come from fresh randomness, such as the proofs of the encryption, have a shorter encoding when they
+// happen to have leading zeros. The simulator counts them at their full width.
+
+// mss is the payload of a packet, as on Ethernet.
+const mss = 1460
+
+// scalarBytes is the most a number carried by a message takes: a field element or a P-256 scalar.
+var scalarBytes = maxInt(fieldBytes, 32)
+
+// sampleLatency draws the latency of a message on the link.
+func (c LinkConfig) sampleLatency(rng *rand.Rand) time.Duration {
+	ms := float64(c.Latency)
+	switch c.GetDistribution() {
+	case LatencyUniform:
+		ms += float64(c.Jitter) * (2*rng.Float64() - 1)
+	case LatencyNormal:
+		ms += float64(c.Jitter) * rng.NormFloat64()
+	}
+
+	return time.Duration(math.Max(ms, 0) * float64(time.Millisecond))
+}
+
+// transmission returns how long the link takes to send size bytes.
+func (c LinkConfig) transmission(size int) time.Duration {
+	if c.Bandwidth == 0 {
+		return 0
+	}
+
+	return time.Duration(float64(size*8) / float64(c.Bandwidth*1e6) * float64(time.Second))
+}
+
+// retransmission draws how long the lost packets of a message of size bytes hold it up: a timeout for
+// every time its unluckiest packet was lost.
+func (c LinkConfig) retransmission(size int, rng *rand.Rand) time.Duration {
+	if c.Loss == 0 {
+		return 0
+	}
+
+	packets := (size + mss - 1) / mss
+	if packets == 0 {
+		packets = 1
+	}
+
+	worst := 0
+	for i := 0; i < packets; i++ {
+		lost := 0
+		for rng.Float64() < c.Loss {
+			lost++
+		}
+		if lost > worst {
+			worst = lost
+		}
+	}
+
+	return time.Duration(worst) * c.GetRto()
+}
+
+// rng returns the randomness of what is named by label and values, such as the nth message on a link.
+func (c SimulationConfig) rng(label string, values ...int64) *rand.Rand {
+	h := sha256.New()
+	binary.Write(h, binary.BigEndian, c.Seed)
+	h.Write([]byte(label))
+	binary.Write(h, binary.BigEndian, values)
+
+	return rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(h.Sum(nil)))))
+}
+
+// link is the simulated link from one party to another.
+type link struct {
+	model LinkConfig
+	// when the link is done sending the messages so far
+	free time.Duration
+	// when the last message arrives. The next one can't overtake it.
+	last time.Duration
+	// messages so far, which number the draws of the next
+	sent int64
+}
+
+// send returns when a message of size bytes, sent at now, arrives.
+func (l *link) send(now time.Duration, size int, rng *rand.Rand) time.Duration {
+	l.sent++
+	l.free = later(now, l.free) + l.model.transmission(size)
+	l.last = later(l.free+l.model.sampleLatency(rng)+l.model.retransmission(size, rng), l.last)
+
+	return l.last
+}
+
+// what an event is. At the same time, the messages go out before any arrives, and the deadlines pass last.
+type eventKind int
+
+const (
+	eventSend eventKind = iota
+	eventArrival
+	eventDeadline
+)
+
+// event is something that happens at a point of simulated time.
+type event struct {
+	at   time.Duration
+	kind eventKind
+	// names the event, so that the events at the same time come in the same order in every run
+	name string
+	// breaks the remaining ties, in the order the events were scheduled
+	seq uint64
+	// run by the simulator, without blocking
+	fire func()
+}
+
+type eventQueue []*event
+
+func (q eventQueue) Len() int { return len(q) }
+
+func (q eventQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	if a.at != b.at {
+		return a.at < b.at
+	}
+	if a.kind != b.kind {
+		return a.kind < b.kind
+	}
+	if a.name != b.name {
+		return a.name < b.name
+	}
+	return a.seq < b.seq
+}
+
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*event)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	last := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return last
+}
+
+// Simulation connects the parties of one process over simulated links, and runs the board for them.
+type Simulation struct {
+	config SimulationConfig
+	start  time.Time
+
+	network *MemoryNetwork
+	// party of each address
+	parties map[string]int64
+	// the posts that reached each party
+	seen map[int64]*boardLog
+
+	// the events to come, and the simulated time since the start
+	events eventQueue
+	now    time.Duration
+	links  map[[2]int64]*link
+	// events by name so far, which tells apart messages of the same name
+	named map[string]int
+	seq   uint64
+	lock  *sync.Mutex
+	// the goroutines of the parties that are running. The clock only moves on once there are none.
+	busy int
+	// the goroutines waiting, by the channel they wait on
+	waiting map[uintptr][]*waiter
+	// the posts each party subscribed to
+	subscriptions map[int64][]*subscription
+	// signals the simulator that an event was scheduled, that the parties are done, or that it has to stop
+	changed *sync.Cond
+
+	ctx  context.Context
+	stop context.CancelFunc
+
+	// logging
+	log *logrus.Entry
+}
+
+// BuildSimulation builds a simulation. Its clock stands still until Start.
+func BuildSimulation(config SimulationConfig, logger *logrus.Logger) *Simulation {
+	ctx, stop := context.WithCancel(context.Background())
+	lock := &sync.Mutex{}
+
+	return &Simulation{
+		config:        config,
+		start:         time.Now(),
+		network:       NewMemoryNetwork(),
+		parties:       make(map[string]int64),
+		seen:          make(map[int64]*boardLog),
+		links:         make(map[[2]int64]*link),
+		named:         make(map[string]int),
+		lock:          lock,
+		waiting:       make(map[uintptr][]*waiter),
+		subscriptions: make(map[int64][]*subscription),
+		changed:       sync.NewCond(lock),
+		ctx:           ctx,
+		stop:          stop,
+		log: logger.WithFields(
+			logrus.Fields{
+				"name": "simulation",
+			}),
+	}
+}
+
+// Start starts the clock, which moves on as the parties go. They have to be added and started with Go by then.
+func (s *Simulation) Start() {
+	go s.run()
+}
+
+// Go runs f on a new goroutine of a party, such as the protocol of a node. The clock waits on the
+// goroutines started here, and on those the parties start themselves, but on no other.
+func (s *Simulation) Go(f func()) {
+	s.lock.Lock()
+	s.busy++
+	s.lock.Unlock()
+
+	go func() {
+		defer s.idle()
+		f()
+	}()
+}
+
+// simulatedLimits let any proposal go in one message, which the simulated links can time.
+var simulatedLimits = MessageConfig{
+	MaxSize:         math.MaxInt32,
+	ChunkSize:       math.MaxInt32 - chunkOverhead,
+	MaxProposalSize: math.MaxInt32,
+}
+
+// AddBoard makes the primary run the board logic of the simulation. It doesn't serve.
+func (s *Simulation) AddBoard(bb *BulletinBoard) {
+	s.addParty(BoardId, bb.myIP)
+
+	bb.SetTransport(simTransport{sim: s, party: BoardId})
+	bb.SetBoard(simBoard{sim: s, party: BoardId})
+	bb.SetMessageLimits(simulatedLimits)
+	bb.useClock(simClock{sim: s})
+}
+
+// AddNode connects the node to the simulation.
+func (s *Simulation) AddNode(node *Node) {
+	s.addParty(node.id, node.myIP)
+
+	node.SetTransport(simTransport{sim: s, party: node.id})
+	node.SetBoard(simBoard{sim: s, party: node.id})
+	node.SetMessageLimits(simulatedLimits)
+	node.useClock(simClock{sim: s})
+	node.proposalRand = func(epoch Epoch) *rand.Rand {
+		return s.config.rng("proposal", node.id, int64(epoch))
+	}
+}
+
+func (s *Simulation) addParty(id int64, addr string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.parties[addr] = id
+	s.seen[id] = newBoardLog()
+	s.log.Debugf("party %d is at %s", id, addr)
+}
+
+func (s *Simulation) partyAt(addr string) (int64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	id, ok := s.parties[addr]
+	if !ok {
+		return 0, fmt.Errorf("nobody is at %s in the simulation", addr)
+	}
+
+	return id, nil
+}
+
+// Stop stops the clock. The parties still waiting on a message or a deadline wait forever.
+func (s *Simulation) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.stop()
+	s.changed.Broadcast()
+}
+
+// Now returns the simulated time.
+func (s *Simulation) Now() time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.start.Add(s.now)
+}
+
+// schedule puts an event on the queue, d from now.
+func (s *Simulation) schedule(d time.Duration, kind eventKind, name string, fire func()) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.seq++
+	heap.Push(&s.events, &event{at: s.now + d, kind: kind, name: name, seq: s.seq, fire: fire})
+	s.changed.Broadcast()
+}
+
+// send sends a message of size bytes, named by name, from one party to another. arrive runs once it arrives.
+func (s *Simulation) send(from, to int64, name string, size int, arrive func()) {
+	s.lock.Lock()
+	key := fmt.Sprintf("%d>%d %s", from, to, name)
+	s.named[key]++
+	key = fmt.Sprintf("%s #%d", key, s.named[key])
+	s.lock.Unlock()
+
+	s.schedule(0, eventSend, key, func() {
+		s.lock.Lock()
+		arrival := s.transmit(from, to, size) - s.now
+		s.lock.Unlock()
+
+		s.schedule(arrival, eventArrival, "", arrive)
+	})
+}
+
+// transmit puts a message of size bytes, sent now, on the link from one party to another, and returns when it arrives.
+func (s *Simulation) transmit(from, to int64, size int) time.Duration {
+	if from == to {
+		return s.now
+	}
+
+	l, ok := s.links[[2]int64{from, to}]
+	if !ok {
+		l = &link{model: s.config.linkFor(from, to)}
+		s.links[[2]int64{from, to}] = l
+	}
+
+	return l.send(s.now, size, s.config.rng("link", from, to, l.sent+1))
+}
+
+// deliver sends a message and waits for it to arrive, unless expired is closed first. It tells whether it arrived.
+func (s *Simulation) deliver(from, to int64, name string, size int, expired <-chan struct{}) bool {
+	arrived := make(chan struct{})
+	s.send(from, to, name, size, func() { s.close(arrived) })
+
+	i, _, _ := s.wait(arrived, expired)
+	return i == 0
+}
+
+// publish sends a post that reached the board on to every party, the board included.
+func (s *Simulation) publish(post *services.BoardPost) {
+	s.lock.Lock()
+	var parties []int64
+	for id := range s.seen {
+		parties = append(parties, id)
+	}
+	s.lock.Unlock()
+
+	sort.Slice(parties, func(i, j int) bool { return parties[i] < parties[j] })
+
+	size := paddedSize(post)
+	for _, id := range parties {
+		id := id
+		s.send(BoardId, id, postName(post), size, func() { s.arrive(id, post) })
+	}
+}
+
+// subscriptionBacklog is how many posts a party may leave unread before the simulation gives up.
+const subscriptionBacklog = 1 << 12
+
+// subscription streams the posts of some kinds that reach a party.
+type subscription struct {
+	kinds map[services.BoardPost_Kind]bool
+	posts chan *services.BoardPost
+}
+
+func (sub *subscription) wants(post *services.BoardPost) bool {
+	return len(sub.kinds) == 0 || sub.kinds[post.Kind]
+}
+
+// subscribe streams the posts of the given kinds (all if none) that reach a party, from the first one.
+func (s *Simulation) subscribe(party int64, kinds []services.BoardPost_Kind) <-chan *services.BoardPost {
+	sub := &subscription{
+		kinds: make(map[services.BoardPost_Kind]bool),
+		posts: make(chan *services.BoardPost, subscriptionBacklog),
+	}
+	for _, k := range kinds {
+		sub.kinds[k] = true
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	seen := s.seen[party]
+	seen.lock.Lock()
+	backlog := append([]*services.BoardPost{}, seen.posts...)
+	seen.lock.Unlock()
+
+	for _, post := range backlog {
+		s.pushLocked(party, sub, post)
+	}
+	s.subscriptions[party] = append(s.subscriptions[party], sub)
+
+	return sub.posts
+}
+
+// arrive hands a post that reached a party to its subscriptions.
+func (s *Simulation) arrive(party int64, post *services.BoardPost) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.seen[party].append(post)
+	for _, sub := range s.subscriptions[party] {
+		s.pushLocked(party, sub, post)
+	}
+}
+
+func (s *Simulation) pushLocked(party int64, sub *subscription, post *services.BoardPost) {
+	if sub.wants(post) && !s.sendLocked(sub.posts, post) {
+		s.log.Fatalf("party %d left %d posts unread", party, subscriptionBacklog)
+	}
+}
+
+// seenBy returns the posts that reached a party.
+func (s *Simulation) seenBy(party int64) *boardLog {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.seen[party]
+}
+
+// run takes the events off the queue, one at a time, once the parties are done with the previous one.
+// The deadlines that pass at the same time all pass at once, since their order can't matter.
+func (s *Simulation) run() {
+	for {
+		s.lock.Lock()
+		// with nothing on the queue, the parties are waiting on each other, or done
+		for s.ctx.Err() == nil && (s.busy > 0 || len(s.events) == 0) {
+			s.changed.Wait()
+		}
+		if s.ctx.Err() != nil {
+			s.lock.Unlock()
+			return
+		}
+
+		next := heap.Pop(&s.events).(*event)
+		due := []*event{next}
+		for next.kind == eventDeadline && len(s.events) > 0 && s.events[0].kind == eventDeadline && s.events[0].at == next.at {
+			due = append(due, heap.Pop(&s.events).(*event))
+		}
+		s.now = next.at
+		s.lock.Unlock()
+
+		for _, e := range due {
+			e.fire()
+		}
+	}
+}
+
+// waiter is a goroutine of a party waiting on the clock of the simulation.
+type waiter struct {
+	// whether whatever woke it up counted it as running again
+	woken bool
+}
+
+// chanKey tells channels apart. It is 0 for a nil channel.
+func chanKey(ch interface{}) uintptr {
+	c := reflect.ValueOf(ch)
+	if !c.IsValid() || c.IsNil() {
+		return 0
+	}
+
+	return c.Pointer()
+}
+
+// wait is Wait of the simulated clock. A goroutine waiting doesn't count as running, until whatever
+// wakes it up counts it again: a value sent or a channel closed through the clock, or an event.
+func (s *Simulation) wait(chans ...interface{}) (int, interface{}, bool) {
+	s.lock.Lock()
+	// nothing reaches the channels without the lock, so if none is ready now, the waiter is
+	// registered before any is
+	if i, v, ok := tryReceive(chans); i >= 0 {
+		s.lock.Unlock()
+		return i, v, ok
+	}
+
+	w := &waiter{}
+	var keys []uintptr
+	for _, ch := range chans {
+		if key := chanKey(ch); key != 0 {
+			keys = append(keys, key)
+			s.waiting[key] = append(s.waiting[key], w)
+		}
+	}
+	s.idleLocked()
+	s.lock.Unlock()
+
+	i, v, ok := receive(chans)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, key := range keys {
+		waiting := s.waiting[key][:0]
+		for _, other := range s.waiting[key] {
+			if other != w {
+				waiting = append(waiting, other)
+			}
+		}
+
+		if len(waiting) == 0 {
+			delete(s.waiting, key)
+		} else {
+			s.waiting[key] = waiting
+		}
+	}
+
+	// woken up by a channel the simulation doesn't see, such as the Done of a context on the wall clock
+	if !w.woken {
+		s.busy++
+	}
+
+	return i, v, ok
+}
+
+// wakeLocked counts the goroutines waiting on a channel as running again: the first one, for a value
+// sent on it, or all of them, for a channel closed.
+func (s *Simulation) wakeLocked(key uintptr, all bool) {
+	for _, w := range s.waiting[key] {
+		if w.woken {
+			continue
+		}
+
+		w.woken = true
+		s.busy++
+
+		if !all {
+			return
+		}
+	}
+}
+
+// sendLocked sends v on ch if it has room, and wakes up the goroutine waiting on it.
+func (s *Simulation) sendLocked(ch interface{}, v interface{}) bool {
+	if !reflect.ValueOf(ch).TrySend(reflect.ValueOf(v)) {
+		return false
+	}
+
+	s.wakeLocked(chanKey(ch), false)
+	return true
+}
+
+// close closes ch, and wakes up everyone waiting on it.
+func (s *Simulation) close(ch interface{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	reflect.ValueOf(ch).Close()
+	s.wakeLocked(chanKey(ch), true)
+}
+
+// idle notes that a goroutine of a party stopped running.
+func (s *Simulation) idle() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.idleLocked()
+}
+
+func (s *Simulation) idleLocked() {
+	s.busy--
+	if s.busy == 0 {
+		s.changed.Broadcast()
+	}
+}
+
+// paddedSize is the size of a message on the wire, with the numbers it carries at their full width.
+func paddedSize(msg proto.Message) int {
+	padded := proto.Clone(msg)
+	widen(reflect.ValueOf(padded))
+
+	return proto.Size(padded)
+}
+
+// widen pads the numbers in v to scalarBytes. A post carries its message marshaled, which is widened too.
+func widen(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if post, ok := v.Interface().(*services.BoardPost); ok {
+			if msg := postMessage(post.Kind); msg != nil && proto.Unmarshal(post.Payload, msg) == nil {
+				widen(reflect.ValueOf(msg))
+				if payload, err := proto.Marshal(msg); err == nil {
+					post.Payload = payload
+				}
+			}
+			return
+		}
+		widen(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !strings.HasPrefix(v.Type().Field(i).Name, "XXX_") {
+				widen(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Len() > 0 && v.Len() < scalarBytes {
+				v.SetBytes(make([]byte, scalarBytes))
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			widen(v.Index(i))
+		}
+	}
+}
+
+// postMessage returns an empty message of the kind a post carries.
+func postMessage(kind services.BoardPost_Kind) proto.Message {
+	switch kind {
+	case services.BoardPost_PROPOSAL_HASH:
+		return &services.ProposalHash{}
+	case services.BoardPost_PROPOSAL_HASH_LIST, services.BoardPost_FINAL_LIST:
+		return &services.ProposalHashList{}
+	case services.BoardPost_COMPLAINTS:
+		return &services.ComplaintList{}
+	case services.BoardPost_SHARE:
+		return &services.Share{}
+	case services.BoardPost_SHARE_CHECK:
+		return &services.ShareCheck{}
+	case services.BoardPost_SHARE_ERASED:
+		return &services.ShareErasure{}
+	case services.BoardPost_ADVANCE_EPOCH:
+		return &services.EpochAdvance{}
+	case services.BoardPost_KILL:
+		return &services.Goodbye{}
+	case services.BoardPost_DEALING_COMMITMENT:
+		return &services.DealingCommitment{}
+	case services.BoardPost_DEALING_COMMITMENT_LIST:
+		return &services.DealingCommitmentList{}
+	case services.BoardPost_DEALING_COMPLAINTS:
+		return &services.DealingComplaints{}
+	case services.BoardPost_DEALING_ACCUSATIONS:
+		return &services.DealingAccusationList{}
+	case services.BoardPost_DEALING_REVEAL:
+		return &services.DealingReveal{}
+	case services.BoardPost_QUALIFIED_DEALERS:
+		return &services.QualifiedDealers{}
+	}
+
+	return nil
+}
+
+// simClock is the virtual clock of a simulation.
+type simClock struct {
+	sim *Simulation
+}
+
+func (c simClock) Now() time.Time {
+	return c.sim.Now()
+}
+
+func (c simClock) After(d time.Duration, cancel <-chan struct{}) <-chan struct{} {
+	passed := make(chan struct{})
+	c.sim.schedule(d, eventDeadline, "", func() { c.sim.close(passed) })
+
+	if cancel == nil {
+		return passed
+	}
+
+	out := make(chan struct{})
+	c.Go(func() {
+		c.Wait(passed, cancel)
+		c.Close(out)
+	})
+
+	return out
+}
+
+func (c simClock) WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	ctx := &simContext{
+		Context:  parent,
+		sim:      c.sim,
+		deadline: c.Now().Add(d),
+		done:     make(chan struct{}),
+	}
+	c.sim.schedule(d, eventDeadline, "", func() { ctx.end(context.DeadlineExceeded) })
+
+	if outer, ok := parent.Value(simContextKey{}).(*simContext); ok && outer.deadline.Before(ctx.deadline) {
+		ctx.deadline = outer.deadline
+	}
+	if parent.Done() != nil {
+		c.Go(func() {
+			if i, _, _ := c.Wait(parent.Done(), ctx.done); i == 0 {
+				ctx.end(parent.Err())
+			}
+		})
+	}
+
+	return ctx, func() { ctx.end(context.Canceled) }
+}
+
+func (c simClock) Go(f func()) {
+	c.sim.Go(f)
+}
+
+func (c simClock) Wait(chans ...interface{}) (int, interface{}, bool) {
+	return c.sim.wait(chans...)
+}
+
+func (c simClock) Send(ch interface{}, v interface{}) bool {
+	c.sim.lock.Lock()
+	defer c.sim.lock.Unlock()
+
+	return c.sim.sendLocked(ch, v)
+}
+
+func (c simClock) Close(ch interface{}) {
+	c.sim.close(ch)
+}
+
+type simContextKey struct{}
+
+// simContext is a context done once its deadline passes on the virtual clock, or once cancelled.
+type simContext struct {
+	context.Context
+	sim      *Simulation
+	deadline time.Time
+	done     chan struct{}
+	// guarded by the lock of the simulation
+	err error
+}
+
+func (ctx *simContext) Deadline() (time.Time, bool) {
+	return ctx.deadline, true
+}
+
+func (ctx *simContext) Done() <-chan struct{} {
+	return ctx.done
+}
+
+func (ctx *simContext) Err() error {
+	ctx.sim.lock.Lock()
+	defer ctx.sim.lock.Unlock()
+
+	return ctx.err
+}
+
+func (ctx *simContext) Value(key interface{}) interface{} {
+	if key == (simContextKey{}) {
+		return ctx
+	}
+
+	return ctx.Context.Value(key)
+}
+
+// end makes the context done, unless it already is.
+func (ctx *simContext) end(err error) {
+	ctx.sim.lock.Lock()
+	if ctx.err != nil {
+		ctx.sim.lock.Unlock()
+		return
+	}
+	ctx.err = err
+	ctx.sim.lock.Unlock()
+
+	ctx.sim.close(ctx.done)
+}
+
+// simTransport is the network as seen by one party of the simulation. A call takes the simulated
+// time of its request on the link to the callee, and of its reply on the way back.
+type simTransport struct {
+	sim   *Simulation
+	party int64
+}
+
+func (t simTransport) Listen(addr string) (net.Listener, error) {
+	return t.sim.network.Listen(addr)
+}
+
+func (t simTransport) Dial(addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	to, err := t.sim.partyAt(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.sim.network.Dial(addr, append(opts, grpc.WithUnaryInterceptor(t.call(to)))...)
+}
+
+// callName names a call by its method and what it is about.
+func callName(method string, req interface{}) string {
+	name := method
+	if msg, ok := req.(interface{ GetEpoch() int32 }); ok {
+		name += fmt.Sprintf("/%d", msg.GetEpoch())
+	}
+	if msg, ok := req.(interface{ GetProposer() int64 }); ok {
+		name += fmt.Sprintf("/%d", msg.GetProposer())
+	}
+
+	return name
+}
+
+// expiry returns a channel closed once a call with ctx runs out of time on the virtual clock. The
+// parties get their deadlines from the clock. Any other deadline counts from the call.
+func (s *Simulation) expiry(ctx context.Context) <-chan struct{} {
+	if sc, ok := ctx.Value(simContextKey{}).(*simContext); ok {
+		return sc.Done()
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		return simClock{sim: s}.After(time.Until(deadline), nil)
+	}
+
+	return nil
+}
+
+// expired is the error of a call that ran out of time or was cancelled, as gRPC returns it.
+func expired(ctx context.Context) error {
+	if sc, ok := ctx.Value(simContextKey{}).(*simContext); ok && sc.Err() == context.Canceled {
+		return status.Error(codes.Canceled, context.Canceled.Error())
+	}
+
+	return status.Error(codes.DeadlineExceeded, context.DeadlineExceeded.Error())
+}
+
+func (t simTransport) call(to int64) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		name := callName(method, req)
+
+		// a request that arrives after the deadline is never handled
+		expiry := t.sim.expiry(ctx)
+		if !t.sim.deliver(t.party, to, name, sizeOf(req), expiry) {
+			return expired(ctx)
+		}
+
+		// gRPC would time the call on the wall clock, so it runs without its deadline
+		untimed := context.Background()
+		if md, ok := metadata.FromOutgoingContext(ctx); ok {
+			untimed = metadata.NewOutgoingContext(untimed, md)
+		}
+		err := invoker(untimed, method, req, reply, cc, opts...)
+
+		size := 0
+		if err == nil {
+			size = sizeOf(reply)
+		}
+		if !t.sim.deliver(to, t.party, name, size, expiry) {
+			return expired(ctx)
+		}
+
+		return err
+	}
+}
+
+func sizeOf(msg interface{}) int {
+	if msg, ok := msg.(proto.Message); ok {
+		return paddedSize(msg)
+	}
+
+	return 0
+}
+
+// simBoard is the board as seen by one party of the simulation. The board is hosted by party -1.
+type simBoard struct {
+	sim   *Simulation
+	party int64
+}
+
+// postName names a post, the same way on the way to the board and on to the parties.
+func postName(post *services.BoardPost) string {
+	return fmt.Sprintf("%s/%d/%d", post.Kind.String(), post.Epoch, post.From)
+}
+
+// Post returns once the post reached the board, which sends it on to every party.
+func (b simBoard) Post(ctx context.Context, post *services.BoardPost) error {
+	post = proto.Clone(post).(*services.BoardPost)
+
+	arrived := make(chan struct{})
+	b.sim.send(b.party, BoardId, postName(post), paddedSize(post), func() {
+		b.sim.publish(post)
+		b.sim.close(arrived)
+	})
+	b.sim.wait(arrived)
+
+	return nil
+}
+
+func (b simBoard) Subscribe(kinds ...services.BoardPost_Kind) <-chan *services.BoardPost {
+	return b.sim.subscribe(b.party, kinds)
+}
+
+// ReadEpoch returns the posts of the epoch that reached the party so far.
+func (b simBoard) ReadEpoch(ctx context.Context, epoch Epoch) ([]*services.BoardPost, error) {
+	return b.sim.seenBy(b.party).readEpoch(epoch), nil
+}
+
+// later returns the later of two simulated times.
+func later(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
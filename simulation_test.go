@@ -0,0 +1,278 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"../../utils/polyring"
+	"./services"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLink_Send(t *testing.T) {
+	config := SimulationConfig{Seed: 1, Link: LinkConfig{Latency: 50, Jitter: 10}}
+
+	// the same message always takes the same time
+	l := link{model: config.Link}
+	d := l.send(0, 100, config.rng("link", 2, 1, 1))
+	assert.True(t, d >= 40*time.Millisecond && d <= 60*time.Millisecond)
+	again := link{model: config.Link}
+	assert.Equal(t, d, again.send(0, 100, config.rng("link", 2, 1, 1)))
+	assert.NotEqual(t, config.rng("link", 2, 1, 1).Int63(), config.rng("link", 2, 1, 2).Int63())
+
+	other := config
+	other.Seed = 2
+	assert.NotEqual(t, config.rng("link", 2, 1, 1).Int63(), other.rng("link", 2, 1, 1).Int63())
+
+	// 1000 bytes take a millisecond at 8 Mbit/s, and the next message waits for the link
+	l = link{model: LinkConfig{Latency: 100, Distribution: LatencyConstant, Bandwidth: 8}}
+	assert.Equal(t, 101*time.Millisecond, l.send(0, 1000, config.rng("link", 1, 2, 1)))
+	assert.Equal(t, 102*time.Millisecond, l.send(0, 1000, config.rng("link", 1, 2, 2)))
+	// unless the link is free again by then
+	assert.Equal(t, 111*time.Millisecond, l.send(10*time.Millisecond, 1000, config.rng("link", 1, 2, 3)))
+
+	// a message never overtakes the one before it
+	l = link{model: LinkConfig{Latency: 50, Jitter: 50}}
+	var last time.Duration
+	for i := int64(1); i <= 20; i++ {
+		arrival := l.send(0, 100, config.rng("link", 1, 2, i))
+		assert.True(t, arrival >= last)
+		last = arrival
+	}
+
+	// a loss only delays the message, by a retransmission timeout: losses are modeled as delays
+	lossy := link{model: LinkConfig{Latency: 10, Distribution: LatencyConstant, Loss: 0.5, Rto: 100}}
+	delayed := false
+	for i := int64(1); i <= 10; i++ {
+		lossy.last = 0
+		d := lossy.send(0, 10*mss, config.rng("link", 1, 2, i))
+		assert.True(t, d >= 10*time.Millisecond)
+		assert.Equal(t, time.Duration(0), (d-10*time.Millisecond)%(100*time.Millisecond))
+		delayed = delayed || d > 10*time.Millisecond
+	}
+	assert.True(t, delayed)
+}
+
+func TestPaddedSize(t *testing.T) {
+	short := &services.Share{Epoch: 1, From: 2, Share: []byte{1}, Signature: make([]byte, 64)}
+	full := &services.Share{Epoch: 1, From: 2, Share: make([]byte, fieldBytes), Signature: make([]byte, 64)}
+	assert.Equal(t, paddedSize(full), paddedSize(short))
+	// the message itself is left alone
+	assert.Equal(t, []byte{1}, short.Share)
+
+	// and so are the numbers a post carries
+	assert.Equal(t, paddedSize(newPost(services.BoardPost_SHARE, 1, 2, full)), paddedSize(newPost(services.BoardPost_SHARE, 1, 2, short)))
+}
+
+// simulate runs the protocol over the simulated network, and returns the latency of every epoch of
+// every node and the shares the nodes end up with.
+func simulate(t *testing.T, config SimulationConfig, pp PublicParameter, epochs Epoch) (map[int64][]time.Duration, map[int64]*gmp.Int) {
+	pp, encryptionKeys := withEncryptionKeys(pp)
+	pp, identityKeys := withIdentityKeys(pp)
+	prime := pp.GetPrime()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	sim := BuildSimulation(config, logger)
+	defer sim.Stop()
+
+	primaryUrl := "primary"
+	urls := make(map[NewNodeID]string)
+	for _, id := range pp.Members() {
+		urls[NewNodeID(id)] = fmt.Sprintf("node-%d", id)
+	}
+
+	secretPoly, err := polyring.NewRand(pp.degree, rand.New(rand.NewSource(0)), prime)
+	assert.Nil(t, err)
+
+	primary := BuildBulletinBoard(logger, primaryUrl, urls, pp)
+	primary.SetSuicideOption(false)
+	sim.AddBoard(&primary)
+	sim.Go(primary.StartProtocol)
+
+	nodes := make([]Node, len(pp.Members()))
+	for i, id := range pp.Members() {
+		peers := make(map[NewNodeID]string)
+		for other, url := range urls {
+			if int64(other) != id {
+				peers[other] = url
+			}
+		}
+
+		// only the old group starts with a share
+		var share *gmp.Int
+		if pp.IsOldMember(id) {
+			share = gmp.NewInt(0)
+			secretPoly.EvalMod(gmp.NewInt(id), prime, share)
+		}
+
+		nodes[i] = BuildNode(pp, logger, id, primaryUrl, urls[NewNodeID(id)], peers, share)
+		nodes[i].SetEncryptionKey(encryptionKeys[id])
+		nodes[i].SetIdentityKey(identityKeys[id])
+		sim.AddNode(&nodes[i])
+		go nodes[i].Serve()
+	}
+
+	var finished sync.WaitGroup
+	finished.Add(len(nodes))
+	for i := range nodes {
+		assert.Nil(t, nodes[i].ConnectPrimary())
+
+		node := &nodes[i]
+		sim.Go(func() {
+			if pp.IsOldMember(node.GetId()) {
+				node.ReportShare(0)
+			}
+			node.StartProtocol(&finished, epochs)
+		})
+	}
+	sim.Start()
+
+	done := make(chan struct{})
+	go func() {
+		finished.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		t.Fatalf("the simulation didn't finish")
+	}
+
+	latencies := make(map[int64][]time.Duration)
+	shares := make(map[int64]*gmp.Int)
+	for i := range nodes {
+		id := nodes[i].GetId()
+		for epoch := Epoch(1); epoch <= epochs; epoch++ {
+			latencies[id] = append(latencies[id], nodes[i].benchmark[epoch].latency)
+		}
+		shares[id] = nodes[i].share
+	}
+
+	return latencies, shares
+}
+
+func TestSimulation(t *testing.T) {
+	config := SimulationConfig{
+		Seed: 7,
+		Link: LinkConfig{Latency: 20, Jitter: 15, Bandwidth: 10, Loss: 0.01},
+		Links: []LinkOverride{
+			{From: 3, To: BoardId, LinkConfig: LinkConfig{Latency: 80}},
+		},
+	}
+	pp := BuildConfig(1, FieldPrime, makeOneToN(5), makeOneToN(5))
+
+	// the same seed, the same latencies, however the real run went
+	first, _ := simulate(t, config, pp, 2)
+	again, _ := simulate(t, config, pp, 2)
+	assert.Equal(t, first, again)
+
+	// an epoch takes a few round trips, and a slower network makes it longer
+	slower := config
+	slower.Link.Latency = 60
+	second, _ := simulate(t, slower, pp, 2)
+
+	for id, latencies := range first {
+		for i, latency := range latencies {
+			assert.True(t, latency >= 60*time.Millisecond, "node %d took %s", id, latency)
+			assert.True(t, second[id][i] > latency, "node %d took %s, then %s", id, latency, second[id][i])
+		}
+	}
+}
+
+func TestSimulation_Committees(t *testing.T) {
+	config := SimulationConfig{Seed: 3, Link: LinkConfig{Latency: 30, Jitter: 10}}
+
+	// from nodes 1 to 4 to nodes 3 to 6
+	newGroup := []int64{3, 4, 5, 6}
+	pp := BuildConfig(1, FieldPrime, makeOneToN(4), newGroup)
+
+	latencies, shares := simulate(t, config, pp, 1)
+
+	// the new group holds a sharing of the same secret
+	var Xs, Ys []*gmp.Int
+	for _, id := range newGroup {
+		assert.True(t, latencies[id][0] > 0, "node %d took %s", id, latencies[id][0])
+		assert.NotNil(t, shares[id])
+
+		Xs = append(Xs, gmp.NewInt(id))
+		Ys = append(Ys, shares[id])
+	}
+
+	poly, wrong, err := DecodeReedSolomon(1, Xs, Ys, pp.GetPrime())
+	assert.Nil(t, err)
+	assert.Empty(t, wrong)
+
+	secretPoly, err := polyring.NewRand(1, rand.New(rand.NewSource(0)), pp.GetPrime())
+	assert.Nil(t, err)
+
+	secret := gmp.NewInt(0)
+	poly.EvalMod(gmp.NewInt(0), pp.GetPrime(), secret)
+	assert.Equal(t, 0, secret.Cmp(secretPoly.GetPtrToConstant()))
+}
+
+func TestSimulation_Deadlines(t *testing.T) {
+	pp, encryptionKeys := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(2), makeOneToN(2)))
+	pp, identityKeys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// 100ms each way
+	sim := BuildSimulation(SimulationConfig{Seed: 1, Link: LinkConfig{Latency: 100, Distribution: LatencyConstant}}, logger)
+	defer sim.Stop()
+
+	caller := BuildNode(pp, logger, 1, "primary", "node-1", map[NewNodeID]string{2: "node-2"}, nil)
+	callee := BuildNode(pp, logger, 2, "primary", "node-2", map[NewNodeID]string{1: "node-1"}, nil)
+	for _, node := range []*Node{&caller, &callee} {
+		node.SetEncryptionKey(encryptionKeys[node.GetId()])
+		node.SetIdentityKey(identityKeys[node.GetId()])
+		sim.AddNode(node)
+		go node.Serve()
+	}
+	assert.Nil(t, caller.ConnectPeers())
+
+	pull := func(ctx context.Context) error {
+		_, err := caller.nodes[2].PullProposal(ctx, &services.ProposalRequest{Epoch: 1, Proposer: 2})
+		return err
+	}
+
+	errs := make(chan error, 3)
+	sim.Go(func() {
+		// the reply is still on its way when the deadline passes
+		ctx, cancel := caller.clock.WithTimeout(context.Background(), 150*time.Millisecond)
+		errs <- pull(ctx)
+		cancel()
+
+		ctx, cancel = caller.clock.WithTimeout(context.Background(), 250*time.Millisecond)
+		errs <- pull(ctx)
+		cancel()
+
+		ctx, cancel = caller.clock.WithTimeout(context.Background(), time.Second)
+		cancel()
+		errs <- pull(ctx)
+	})
+	sim.Start()
+
+	var got []codes.Code
+	for i := 0; i < 3; i++ {
+		select {
+		case err := <-errs:
+			got = append(got, status.Code(err))
+		case <-time.After(time.Minute):
+			t.Fatalf("the calls didn't return")
+		}
+	}
+
+	assert.Equal(t, []codes.Code{codes.DeadlineExceeded, codes.NotFound, codes.Canceled}, got)
+}
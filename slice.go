@@ -0,0 +1,232 @@
+package Schultz
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// Instead of the whole proposal, which holds points for every old node, each old node only gets its
+// slice: the commitments, its own encrypted points and the Merkle path from them to the root. The
+// slice hashes to the same value as the whole proposal, so it can be checked against the agreed hash.
+
+// sliceLeaf is the leaf of the Merkle tree for the points sent to old node j.
+func sliceLeaf(j OldNodeID, points VerifiablePoints) [32]byte {
+	c := &canonical{}
+	c.raw([]byte{0})
+	c.uint32(uint32(j))
+	c.bytes(points.Bytes())
+
+	return sha256.Sum256(c.Bytes())
+}
+
+func merkleParent(left, right [32]byte) [32]byte {
+	hash := sha256.New()
+	hash.Write([]byte{1})
+	hash.Write(left[:])
+	hash.Write(right[:])
+
+	var result [32]byte
+	copy(result[:], hash.Sum(nil))
+
+	return result
+}
+
+// merkleLevels returns every level of the tree, from the leaves up to the root.
+// A node without a sibling moves up unchanged.
+func merkleLevels(leaves [][32]byte) [][][32]byte {
+	levels := [][][32]byte{leaves}
+
+	for level := leaves; len(level) > 1; {
+		var next [][32]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 < len(level) {
+				next = append(next, merkleParent(level[i], level[i+1]))
+			} else {
+				next = append(next, level[i])
+			}
+		}
+
+		levels = append(levels, next)
+		level = next
+	}
+
+	return levels
+}
+
+func merkleRoot(leaves [][32]byte) [32]byte {
+	if len(leaves) == 0 {
+		return [32]byte{}
+	}
+
+	levels := merkleLevels(leaves)
+
+	return levels[len(levels)-1][0]
+}
+
+// merklePath returns the siblings of the leaf at index, from the bottom up. Levels where the node has no sibling are skipped.
+func merklePath(leaves [][32]byte, index int) [][32]byte {
+	var path [][32]byte
+
+	levels := merkleLevels(leaves)
+	for _, level := range levels[:len(levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			path = append(path, level[sibling])
+		}
+		index /= 2
+	}
+
+	return path
+}
+
+// merkleRootFromPath recomputes the root from the leaf at index of a tree with count leaves.
+func merkleRootFromPath(leaf [32]byte, index, count int, path [][32]byte) ([32]byte, error) {
+	if index < 0 || index >= count {
+		return [32]byte{}, fmt.Errorf("leaf %d out of %d", index, count)
+	}
+
+	node := leaf
+	for width := count; width > 1; width = (width + 1) / 2 {
+		sibling := index ^ 1
+		if sibling < width {
+			if len(path) == 0 {
+				return [32]byte{}, fmt.Errorf("the Merkle path is too short")
+			}
+
+			if index%2 == 0 {
+				node = merkleParent(node, path[0])
+			} else {
+				node = merkleParent(path[0], node)
+			}
+			path = path[1:]
+		}
+		index /= 2
+	}
+
+	if len(path) > 0 {
+		return [32]byte{}, fmt.Errorf("the Merkle path is too long")
+	}
+
+	return node, nil
+}
+
+// ProposalSlice is the part of a proposal for one old node.
+type ProposalSlice struct {
+	epoch   Epoch
+	session [32]byte
+
+	commQ  PolyCommit
+	commRs map[NewNodeID]PolyCommit
+
+	recipient OldNodeID
+	points    VerifiablePoints
+
+	// position of the recipient among the leaves, and the number of leaves
+	index int
+	count int
+	path  [][32]byte
+}
+
+// Slice cuts the part of the proposal for old node j.
+func (p Proposal) Slice(j OldNodeID) (ProposalSlice, error) {
+	points, ok := p.pointToPeers[j]
+	if !ok {
+		return ProposalSlice{}, fmt.Errorf("no points for %d", j)
+	}
+
+	recipients := p.recipients()
+
+	index := 0
+	for i, r := range recipients {
+		if r == j {
+			index = i
+		}
+	}
+
+	return ProposalSlice{
+		epoch:     p.epoch,
+		session:   p.session,
+		commQ:     p.commQ,
+		commRs:    p.commRs,
+		recipient: j,
+		points:    points,
+		index:     index,
+		count:     len(recipients),
+		path:      merklePath(p.leaves(), index),
+	}, nil
+}
+
+func (s ProposalSlice) GetRecipient() OldNodeID {
+	return s.recipient
+}
+
+// Hash returns the hash of the proposal the slice was cut from, if the Merkle path is right.
+func (s ProposalSlice) Hash() ([32]byte, error) {
+	root, err := merkleRootFromPath(sliceLeaf(s.recipient, s.points), s.index, s.count, s.path)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	return proposalHash(s.commitmentBytes(), root), nil
+}
+
+func (s ProposalSlice) commitmentBytes() []byte {
+	return commitmentBytes(s.epoch, s.session, s.commQ, s.commRs)
+}
+
+// Verify runs the checks of Proposal.Verify that concern the slice.
+func (s ProposalSlice) Verify(pp PublicParameter, epoch Epoch) error {
+	if err := verifyBinding(s.epoch, s.session, pp, epoch); err != nil {
+		return err
+	}
+
+	if err := verifyCommitments(s.commQ, s.commRs, pp); err != nil {
+		return err
+	}
+
+	if !pp.IsOldMember(int64(s.recipient)) {
+		return fmt.Errorf("%d is not in the old group", s.recipient)
+	}
+
+	// one leaf for each old node
+	if s.count != len(pp.oldGroup) {
+		return fmt.Errorf("wrong number of peers: wanted %d, got %d", len(pp.oldGroup), s.count)
+	}
+
+	return verifyEncryptedPoints(s.recipient, s.points, s.commQ, s.commRs, s.commitmentBytes(), pp)
+}
+
+// PointsFor decrypts the points with the key of the recipient, and checks that they lie on Q+Rk.
+func (s ProposalSlice) PointsFor(key *EncryptionKey, pp PublicParameter) (PointsOnBlindingPoly, error) {
+	points, err := s.points.decrypt(key, pp.prime)
+	if err != nil {
+		return PointsOnBlindingPoly{}, err
+	}
+
+	return points, verifyPoints(s.commQ, s.commRs, s.recipient, points)
+}
+
+// Disclose reveals the key of a chunk of the points that doesn't decrypt, so that anyone can check it.
+func (s ProposalSlice) Disclose(key *EncryptionKey) (KeyDisclosure, error) {
+	return s.points.disclose(key)
+}
+
+// VerifyDisclosedPoints checks that the chunk whose key the recipient disclosed doesn't decrypt.
+// A bad disclosure is reported as such, since it says nothing about the proposal.
+func (s ProposalSlice) VerifyDisclosedPoints(disclosure KeyDisclosure, pp PublicParameter) (badDisclosure bool, err error) {
+	pk, ok := pp.encryptionKeys[int64(s.recipient)]
+	if !ok {
+		return true, fmt.Errorf("no encryption key for %d", s.recipient)
+	}
+
+	decrypts, err := s.points.decryptsDisclosed(pk, disclosure)
+	if err != nil {
+		return true, err
+	}
+
+	if !decrypts {
+		return false, fmt.Errorf("chunk %d of the point for %d doesn't decrypt", disclosure.Chunk, disclosure.Point)
+	}
+
+	return false, nil
+}
@@ -0,0 +1,88 @@
+package Schultz
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerklePath(t *testing.T) {
+	for count := 1; count <= 9; count++ {
+		var leaves [][32]byte
+		for i := 0; i < count; i++ {
+			leaves = append(leaves, sha256.Sum256([]byte{byte(i)}))
+		}
+		root := merkleRoot(leaves)
+
+		for i := range leaves {
+			path := merklePath(leaves, i)
+
+			fromPath, err := merkleRootFromPath(leaves[i], i, count, path)
+			assert.Nil(t, err)
+			assert.Equal(t, root, fromPath)
+
+			// the path doesn't fit another position
+			if count > 1 {
+				other, err := merkleRootFromPath(leaves[i], (i+1)%count, count, path)
+				assert.True(t, err != nil || other != root)
+			}
+		}
+	}
+}
+
+func TestProposalSlice(t *testing.T) {
+	pp, keys := withEncryptionKeys(mustBuildHandoffConfig(t, 1, 2, makeOneToN(5), []int64{3, 4, 5, 6, 7, 8, 9}))
+
+	p := GenerateProposal(pp, 1)
+	whole := proto.Size(p.Message(1))
+
+	for _, j := range pp.GetOldGroup() {
+		slice, err := p.Slice(OldNodeID(j))
+		assert.Nil(t, err)
+
+		decoded, err := ProposalSliceFromMessage(slice.Message(1), pp)
+		assert.Nil(t, err)
+
+		// a slice hashes to the hash of the whole proposal
+		hash, err := decoded.Hash()
+		assert.Nil(t, err)
+		assert.Equal(t, p.Hash(), hash)
+
+		assert.Nil(t, decoded.Verify(pp, 1))
+
+		_, err = decoded.PointsFor(keys[j], pp)
+		assert.Nil(t, err)
+
+		// and is smaller
+		assert.True(t, proto.Size(slice.Message(1)) < whole)
+	}
+
+	// points swapped with another node's don't hash to the proposal
+	slice, err := p.Slice(OldNodeID(1))
+	assert.Nil(t, err)
+
+	slice.points = p.pointToPeers[OldNodeID(2)]
+	hash, err := slice.Hash()
+	assert.True(t, err != nil || hash != p.Hash())
+
+	// neither does a slice claimed by another node
+	slice, err = p.Slice(OldNodeID(1))
+	assert.Nil(t, err)
+
+	slice.recipient = OldNodeID(2)
+	hash, err = slice.Hash()
+	assert.True(t, err != nil || hash != p.Hash())
+
+	// or a tampered path
+	slice, err = p.Slice(OldNodeID(1))
+	assert.Nil(t, err)
+
+	slice.path[0][0] ^= 1
+	hash, err = slice.Hash()
+	assert.True(t, err != nil || hash != p.Hash())
+
+	_, err = p.Slice(OldNodeID(9))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,298 @@
+package Schultz
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"io"
+	"time"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A proposal too large for one message is streamed to its recipient in chunks. Each chunk carries its
+// checksum and the proposal its digest, so that nothing corrupted is taken. The recipient keeps what
+// arrived, and a sender whose connection dropped asks how much that is and resumes from there.
+
+// chunkOverhead bounds the size of a chunk message without its data.
+const chunkOverhead = 1024
+
+// streamRetryDelay is how long a sender waits before resuming a broken stream.
+const streamRetryDelay = 200 * time.Millisecond
+
+func (c MessageConfig) dialOption() grpc.DialOption {
+	return grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(c.GetMaxSize()), grpc.MaxCallSendMsgSize(c.GetMaxSize()))
+}
+
+func (c MessageConfig) serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{grpc.MaxRecvMsgSize(c.GetMaxSize()), grpc.MaxSendMsgSize(c.GetMaxSize())}
+}
+
+// proposalTransfer is a proposal being streamed to this node.
+type proposalTransfer struct {
+	epoch  Epoch
+	digest []byte
+	size   int64
+
+	data     []byte
+	received int64
+	done     bool
+}
+
+func (t *proposalTransfer) is(header *services.ProposalTransfer) bool {
+	return t.epoch == Epoch(header.Epoch) && bytes.Equal(t.digest, header.Digest) && t.size == header.Size
+}
+
+func (t *proposalTransfer) status() *services.ProposalTransferStatus {
+	return &services.ProposalTransferStatus{Received: t.received, Complete: t.done}
+}
+
+func (node *Node) GetProposalTransfer(ctx context.Context, header *services.ProposalTransfer) (*services.ProposalTransferStatus, error) {
+	if err := node.creds.authorize(ctx, NodeIdentity(header.From)); err != nil {
+		return nil, err
+	}
+
+	node.transfersLock.Lock()
+	defer node.transfersLock.Unlock()
+
+	t, ok := node.transfers[header.From]
+	if !ok || !t.is(header) {
+		return &services.ProposalTransferStatus{}, nil
+	}
+
+	return t.status(), nil
+}
+
+func (node *Node) SubmitProposalStream(stream services.Node_SubmitProposalStreamServer) error {
+	var header *services.ProposalTransfer
+	var last *services.ProposalTransferStatus
+	var complete []byte
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if chunk.Transfer == nil {
+			return status.Errorf(codes.InvalidArgument, "a chunk of no proposal")
+		}
+		if header == nil {
+			if err := node.creds.authorize(stream.Context(), NodeIdentity(chunk.Transfer.From)); err != nil {
+				return err
+			}
+			header = chunk.Transfer
+		} else if !proto.Equal(header, chunk.Transfer) {
+			return status.Errorf(codes.InvalidArgument, "one proposal per stream")
+		}
+
+		last, complete, err = node.acceptChunk(chunk)
+		if err != nil {
+			return err
+		}
+
+		// the proposal is taken as soon as it is whole, so that a stream breaking after its last
+		// chunk doesn't lose it
+		if complete != nil {
+			last, err = node.finishTransfer(stream.Context(), header, complete)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if header == nil {
+		return status.Errorf(codes.InvalidArgument, "no chunks")
+	}
+
+	return stream.SendAndClose(last)
+}
+
+// finishTransfer takes a streamed proposal, and only then marks its transfer done.
+func (node *Node) finishTransfer(ctx context.Context, header *services.ProposalTransfer, complete []byte) (*services.ProposalTransferStatus, error) {
+	proposal := &services.Proposal{}
+	err := proto.Unmarshal(complete, proposal)
+	if err == nil && (proposal.From != header.From || proposal.Epoch != header.Epoch) {
+		err = status.Errorf(codes.InvalidArgument, "got the proposal from %d for epoch %d", proposal.From, proposal.Epoch)
+	}
+	if err == nil {
+		err = node.receiveProposal(ctx, proposal)
+	}
+
+	node.transfersLock.Lock()
+	defer node.transfersLock.Unlock()
+
+	t, ok := node.transfers[header.From]
+	if err != nil {
+		// the sender has to start over
+		if ok && t.is(header) {
+			delete(node.transfers, header.From)
+		}
+
+		return nil, status.Errorf(codes.InvalidArgument, "%s", status.Convert(err).Message())
+	}
+
+	if !ok || !t.is(header) {
+		// a new proposal from the sender replaced this one while we took it
+		return &services.ProposalTransferStatus{Received: header.Size, Complete: true}, nil
+	}
+
+	t.data = nil
+	t.done = true
+
+	return t.status(), nil
+}
+
+// acceptChunk adds a chunk to the transfer it belongs to, and returns the proposal once it is complete.
+// The transfer keeps the proposal until the caller took it and calls finishTransfer.
+func (node *Node) acceptChunk(chunk *services.ProposalChunk) (*services.ProposalTransferStatus, []byte, error) {
+	header := chunk.Transfer
+	if len(header.Digest) != sha256.Size || header.Size <= 0 || header.Size > int64(node.limits.GetMaxProposalSize()) {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "won't take a proposal of %d bytes", header.Size)
+	}
+
+	if sum := sha256.Sum256(chunk.Data); !bytes.Equal(sum[:], chunk.Checksum) {
+		return nil, nil, status.Errorf(codes.DataLoss, "the chunk at %d is corrupted", chunk.Offset)
+	}
+
+	node.transfersLock.Lock()
+	defer node.transfersLock.Unlock()
+
+	// a new proposal from the sender replaces the one it was sending
+	t, ok := node.transfers[header.From]
+	if !ok || !t.is(header) {
+		t = &proposalTransfer{
+			epoch:  Epoch(header.Epoch),
+			digest: header.Digest,
+			size:   header.Size,
+		}
+		node.transfers[header.From] = t
+	}
+
+	if t.done {
+		return t.status(), nil, nil
+	}
+
+	if chunk.Offset != t.received {
+		return nil, nil, status.Errorf(codes.Aborted, "expected the chunk at %d, got the one at %d", t.received, chunk.Offset)
+	}
+	if t.received+int64(len(chunk.Data)) > t.size {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "the chunk at %d goes past the end", chunk.Offset)
+	}
+
+	t.data = append(t.data, chunk.Data...)
+	t.received += int64(len(chunk.Data))
+	if t.received < t.size {
+		return t.status(), nil, nil
+	}
+
+	if sum := sha256.Sum256(t.data); !bytes.Equal(sum[:], t.digest) {
+		delete(node.transfers, header.From)
+		return nil, nil, status.Errorf(codes.DataLoss, "the proposal doesn't match its digest")
+	}
+
+	return t.status(), t.data, nil
+}
+
+// sendProposal sends a proposal, in chunks if it is too large for one message.
+func (node *Node) sendProposal(ctx context.Context, client services.NodeClient, msg *services.Proposal) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if len(data) <= node.limits.GetChunkSize() {
+		_, err := client.SubmitProposal(ctx, msg)
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+	header := &services.ProposalTransfer{
+		Epoch:  msg.Epoch,
+		From:   msg.From,
+		Digest: digest[:],
+		Size:   int64(len(data)),
+	}
+
+	for {
+		err := node.resumeTransfer(ctx, client, header, data)
+		if err == nil {
+			return nil
+		}
+
+		switch status.Code(err) {
+		case codes.Unavailable, codes.Aborted, codes.DataLoss:
+		default:
+			return err
+		}
+
+		node.log.Debugf("the stream of the proposal broke: %s", status.Convert(err).Message())
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(streamRetryDelay):
+		}
+	}
+}
+
+// resumeTransfer streams what the recipient hasn't got yet.
+func (node *Node) resumeTransfer(ctx context.Context, client services.NodeClient, header *services.ProposalTransfer, data []byte) error {
+	st, err := client.GetProposalTransfer(ctx, header)
+	if err != nil {
+		return err
+	}
+	if st.Complete {
+		return nil
+	}
+	if st.Received < 0 || st.Received >= header.Size {
+		return status.Errorf(codes.Aborted, "the recipient claims %d of %d bytes", st.Received, header.Size)
+	}
+
+	stream, err := client.SubmitProposalStream(ctx)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := int64(node.limits.GetChunkSize())
+	for offset := st.Received; offset < header.Size; offset += chunkSize {
+		end := offset + chunkSize
+		if end > header.Size {
+			end = header.Size
+		}
+
+		part := data[offset:end]
+		sum := sha256.Sum256(part)
+
+		err := stream.Send(&services.ProposalChunk{
+			Transfer: header,
+			Offset:   offset,
+			Data:     part,
+			Checksum: sum[:],
+		})
+		if err == io.EOF {
+			// the recipient gave up, and tells why
+			_, err = stream.CloseAndRecv()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	st, err = stream.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	if !st.Complete {
+		return status.Errorf(codes.Aborted, "only %d of %d bytes arrived", st.Received, header.Size)
+	}
+
+	return nil
+}
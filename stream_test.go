@@ -0,0 +1,243 @@
+package Schultz
+
+import (
+	"context"
+	"crypto/sha256"
+	"net"
+	"testing"
+	"time"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// flakyClient drops the connection of its first stream after a number of chunks.
+type flakyClient struct {
+	services.NodeClient
+	breakAfter int
+	streams    int
+	// offset of the first chunk of each stream
+	startedAt []int64
+}
+
+func (c *flakyClient) SubmitProposalStream(ctx context.Context, opts ...grpc.CallOption) (services.Node_SubmitProposalStreamClient, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.NodeClient.SubmitProposalStream(ctx, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	c.streams++
+	left := -1
+	if c.streams == 1 {
+		left = c.breakAfter
+	}
+
+	return &flakyStream{Node_SubmitProposalStreamClient: stream, client: c, cancel: cancel, left: left}, nil
+}
+
+type flakyStream struct {
+	services.Node_SubmitProposalStreamClient
+	client *flakyClient
+	cancel context.CancelFunc
+	left   int
+	last   *services.ProposalChunk
+}
+
+// drop waits for the chunks on their way, then drops the connection.
+func (s *flakyStream) drop(header *services.ProposalTransfer, received int64) error {
+	for {
+		st, err := s.client.GetProposalTransfer(context.Background(), header)
+		if err != nil || st.Received == received {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	s.cancel()
+
+	return status.Errorf(codes.Unavailable, "connection dropped")
+}
+
+func (s *flakyStream) Send(chunk *services.ProposalChunk) error {
+	if s.last == nil {
+		s.client.startedAt = append(s.client.startedAt, chunk.Offset)
+	}
+
+	if s.left == 0 {
+		return s.drop(chunk.Transfer, chunk.Offset)
+	}
+	s.left--
+	s.last = chunk
+
+	return s.Node_SubmitProposalStreamClient.Send(chunk)
+}
+
+// CloseAndRecv drops the connection if the stream is to break right after its last chunk.
+func (s *flakyStream) CloseAndRecv() (*services.ProposalTransferStatus, error) {
+	if s.left == 0 && s.last != nil {
+		return nil, s.drop(s.last.Transfer, s.last.Offset+int64(len(s.last.Data)))
+	}
+
+	return s.Node_SubmitProposalStreamClient.CloseAndRecv()
+}
+
+func TestNode_SendProposalInChunks(t *testing.T) {
+	pp, _ := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	pp, keys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	receiver := BuildNode(pp, logger, 2, "", "", nil, nil)
+
+	lis, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := grpc.NewServer()
+	services.RegisterNodeServer(s, &receiver)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	sender := BuildNode(pp, logger, 1, "", "", nil, nil)
+	sender.SetMessageLimits(MessageConfig{ChunkSize: 128})
+
+	slice, err := GenerateProposal(pp, 1).Slice(2)
+	assert.Nil(t, err)
+	msg := slice.Message(1)
+	signMessage(keys[1], msg)
+	assert.True(t, proto.Size(msg) > 4*128)
+
+	// the first stream breaks after three chunks, and the second picks up from there
+	client := &flakyClient{NodeClient: services.NewNodeClient(conn), breakAfter: 3}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.Nil(t, sender.sendProposal(ctx, client, msg))
+	assert.Equal(t, []int64{0, 3 * 128}, client.startedAt)
+
+	got := receiver.proposals.next(1, timeout(time.Second, nil))
+	assert.True(t, proto.Equal(msg, got))
+
+	// the transfer is done, and sending it again is a no-op
+	assert.Nil(t, sender.sendProposal(ctx, client, msg))
+	assert.Equal(t, 2, client.streams)
+}
+
+func TestNode_StreamBreaksAfterLastChunk(t *testing.T) {
+	pp, _ := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	pp, keys := withIdentityKeys(pp)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	receiver := BuildNode(pp, logger, 2, "", "", nil, nil)
+
+	lis, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(t, err)
+	s := grpc.NewServer()
+	services.RegisterNodeServer(s, &receiver)
+	go s.Serve(lis)
+	defer s.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	sender := BuildNode(pp, logger, 1, "", "", nil, nil)
+	sender.SetMessageLimits(MessageConfig{ChunkSize: 256})
+
+	slice, err := GenerateProposal(pp, 1).Slice(2)
+	assert.Nil(t, err)
+	msg := slice.Message(1)
+	signMessage(keys[1], msg)
+
+	// every chunk arrives, and then the connection drops before the stream is closed
+	chunks := (proto.Size(msg) + 255) / 256
+	client := &flakyClient{NodeClient: services.NewNodeClient(conn), breakAfter: chunks}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.Nil(t, sender.sendProposal(ctx, client, msg))
+	assert.Equal(t, []int64{0}, client.startedAt)
+
+	// the receiver took the proposal all the same, and the sender didn't have to send it again
+	got := receiver.proposals.next(1, timeout(time.Second, nil))
+	assert.True(t, proto.Equal(msg, got))
+	assert.Equal(t, 1, client.streams)
+}
+
+func TestNode_AcceptChunk(t *testing.T) {
+	pp := BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := BuildNode(pp, logger, 2, "", "", nil, nil)
+	node.SetMessageLimits(MessageConfig{MaxProposalSize: 8})
+
+	data := []byte("proposal")
+	digest := sha256.Sum256(data)
+	header := &services.ProposalTransfer{Epoch: 1, From: 1, Digest: digest[:], Size: int64(len(data))}
+
+	chunk := func(header *services.ProposalTransfer, offset int64, data []byte) *services.ProposalChunk {
+		sum := sha256.Sum256(data)
+		return &services.ProposalChunk{Transfer: header, Offset: offset, Data: data, Checksum: sum[:]}
+	}
+
+	// a corrupted chunk
+	corrupted := chunk(header, 0, data[:4])
+	corrupted.Data = []byte("prop")
+	corrupted.Data[0] ^= 1
+	_, _, err := node.acceptChunk(corrupted)
+	assert.Equal(t, codes.DataLoss, status.Code(err))
+
+	st, complete, err := node.acceptChunk(chunk(header, 0, data[:4]))
+	assert.Nil(t, err)
+	assert.Nil(t, complete)
+	assert.Equal(t, int64(4), st.Received)
+
+	// a chunk out of order
+	_, _, err = node.acceptChunk(chunk(header, 6, data[6:]))
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	// past the end
+	_, _, err = node.acceptChunk(chunk(header, 4, append(data[4:], 'x')))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// the transfer is done only once the proposal is taken
+	st, complete, err = node.acceptChunk(chunk(header, 4, data[4:]))
+	assert.Nil(t, err)
+	assert.False(t, st.Complete)
+	assert.Equal(t, int64(8), st.Received)
+	assert.Equal(t, data, complete)
+
+	// which fails for what isn't a proposal from the sender, and the sender starts over
+	_, err = node.finishTransfer(context.Background(), header, complete)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	st, err = node.GetProposalTransfer(context.Background(), header)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), st.Received)
+
+	// chunks that don't add up to the digest
+	wrong := &services.ProposalTransfer{Epoch: 2, From: 1, Digest: digest[:], Size: 4}
+	_, _, err = node.acceptChunk(chunk(wrong, 0, data[:4]))
+	assert.Equal(t, codes.DataLoss, status.Code(err))
+
+	st, err = node.GetProposalTransfer(context.Background(), wrong)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), st.Received)
+
+	// too large
+	large := &services.ProposalTransfer{Epoch: 1, From: 1, Digest: digest[:], Size: 9}
+	_, _, err = node.acceptChunk(chunk(large, 0, data[:4]))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,328 @@
+package Schultz
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// roles of the parties, written in the common name of their certificates
+const (
+	RolePrimary = "primary"
+	RoleNode    = "node"
+	RoleReplica = "replica"
+	RoleDealer  = "dealer"
+)
+
+// Identity is a party as named in its certificate: "primary", "dealer", "node-<id>" or "replica-<id>".
+type Identity struct {
+	Role string
+	Id   int64
+}
+
+func PrimaryIdentity() Identity {
+	return Identity{Role: RolePrimary}
+}
+
+func DealerIdentity() Identity {
+	return Identity{Role: RoleDealer}
+}
+
+func NodeIdentity(id int64) Identity {
+	return Identity{Role: RoleNode, Id: id}
+}
+
+func ReplicaIdentity(id int64) Identity {
+	return Identity{Role: RoleReplica, Id: id}
+}
+
+// senderIdentity is the party behind the sender id of a message: a node, or the dealer.
+func senderIdentity(id int64) Identity {
+	if id == DealerId {
+		return DealerIdentity()
+	}
+
+	return NodeIdentity(id)
+}
+
+func (i Identity) String() string {
+	switch i.Role {
+	case RoleNode, RoleReplica:
+		return fmt.Sprintf("%s-%d", i.Role, i.Id)
+	default:
+		return i.Role
+	}
+}
+
+func ParseIdentity(name string) (Identity, error) {
+	switch name {
+	case RolePrimary:
+		return PrimaryIdentity(), nil
+	case RoleDealer:
+		return DealerIdentity(), nil
+	}
+
+	for _, role := range []string{RoleNode, RoleReplica} {
+		if !strings.HasPrefix(name, role+"-") {
+			continue
+		}
+
+		id, err := strconv.ParseInt(strings.TrimPrefix(name, role+"-"), 10, 64)
+		if err != nil {
+			return Identity{}, fmt.Errorf("invalid identity %q: %s", name, err.Error())
+		}
+
+		return Identity{Role: role, Id: id}, nil
+	}
+
+	return Identity{}, fmt.Errorf("invalid identity %q", name)
+}
+
+// Credentials are the certificate of a party and the CA all parties trust. Every connection is mutual TLS:
+// servers only take clients with a certificate signed by the CA, and tell who they are from it.
+// A nil *Credentials means plain connections, only for the local simulation.
+type Credentials struct {
+	identity Identity
+	ca       *x509.CertPool
+	cert     tls.Certificate
+}
+
+// LoadCredentials reads PEM files: the CA certificate, and the certificate of the party signed by it along with its key.
+func LoadCredentials(caFile, certFile, keyFile string) (*Credentials, error) {
+	caPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := x509.NewCertPool()
+	if !ca.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificate in %s", caFile)
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = leaf.Verify(x509.VerifyOptions{Roots: ca, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+	if err != nil {
+		return nil, fmt.Errorf("%s is not signed by the CA: %s", certFile, err.Error())
+	}
+
+	identity, err := ParseIdentity(leaf.Subject.CommonName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credentials{
+		identity: identity,
+		ca:       ca,
+		cert:     cert,
+	}, nil
+}
+
+// Identity returns the party the certificate was issued to.
+func (c *Credentials) Identity() Identity {
+	return c.identity
+}
+
+func (c *Credentials) dialOption() grpc.DialOption {
+	if c == nil {
+		return grpc.WithInsecure()
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{c.cert},
+		RootCAs:      c.ca,
+		MinVersion:   tls.VersionTLS12,
+	}))
+}
+
+func (c *Credentials) serverOptions() []grpc.ServerOption {
+	if c == nil {
+		return nil
+	}
+
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{c.cert},
+		ClientCAs:    c.ca,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}))}
+}
+
+// caller returns the identity in the verified client certificate of the connection.
+func caller(ctx context.Context) (Identity, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return Identity{}, fmt.Errorf("no peer")
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return Identity{}, fmt.Errorf("no client certificate")
+	}
+
+	return ParseIdentity(info.State.VerifiedChains[0][0].Subject.CommonName)
+}
+
+// authorize checks that the caller is the given party. Without credentials, anyone is.
+func (c *Credentials) authorize(ctx context.Context, want Identity) error {
+	if c == nil {
+		return nil
+	}
+
+	got, err := caller(ctx)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "%s", err.Error())
+	}
+
+	if got != want {
+		return status.Errorf(codes.PermissionDenied, "%s can't speak for %s", got, want)
+	}
+
+	return nil
+}
+
+// authorizeRole checks that the caller has the given role.
+func (c *Credentials) authorizeRole(ctx context.Context, role string) error {
+	if c == nil {
+		return nil
+	}
+
+	got, err := caller(ctx)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "%s", err.Error())
+	}
+
+	if got.Role != role {
+		return status.Errorf(codes.PermissionDenied, "only a %s can do this, not %s", role, got)
+	}
+
+	return nil
+}
+
+// TLSFiles are the PEM files of a party.
+type TLSFiles struct {
+	Cert string
+	Key  string
+}
+
+// GenerateCertificates creates a CA in dir and signs a certificate for each party, valid for the host
+// of its url. Parties without a url, such as the dealer, only act as clients.
+// It returns the file of the CA certificate and the files of each party.
+func GenerateCertificates(dir string, parties map[Identity]string) (string, map[Identity]TLSFiles, error) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", nil, err
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := notBefore.AddDate(10, 0, 0)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "MPSS CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return "", nil, err
+	}
+
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return "", nil, err
+	}
+
+	caFiles := TLSFiles{Cert: path.Join(dir, "ca.pem"), Key: path.Join(dir, "ca.key")}
+	if err := writePEM(caFiles, caDER, caKey); err != nil {
+		return "", nil, err
+	}
+
+	files := make(map[Identity]TLSFiles)
+	serial := int64(2)
+	for identity, url := range parties {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return "", nil, err
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: identity.String()},
+			NotBefore:    notBefore,
+			NotAfter:     notAfter,
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+		serial += 1
+
+		if url != "" {
+			host, _, err := net.SplitHostPort(url)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid url for %s: %s", identity, err.Error())
+			}
+
+			if ip := net.ParseIP(host); ip != nil {
+				template.IPAddresses = []net.IP{ip}
+			} else {
+				template.DNSNames = []string{host}
+			}
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			return "", nil, err
+		}
+
+		files[identity] = TLSFiles{Cert: path.Join(dir, identity.String()+".pem"), Key: path.Join(dir, identity.String()+".key")}
+		if err := writePEM(files[identity], der, key); err != nil {
+			return "", nil, err
+		}
+	}
+
+	return caFiles.Cert, files, nil
+}
+
+func writePEM(files TLSFiles, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(files.Cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(files.Key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+}
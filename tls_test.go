@@ -0,0 +1,101 @@
+package Schultz
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"./services"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseIdentity(t *testing.T) {
+	for _, identity := range []Identity{PrimaryIdentity(), DealerIdentity(), NodeIdentity(3), ReplicaIdentity(12)} {
+		parsed, err := ParseIdentity(identity.String())
+		assert.Nil(t, err)
+		assert.Equal(t, identity, parsed)
+	}
+
+	for _, name := range []string{"", "node", "node-", "node-x", "client-1"} {
+		_, err := ParseIdentity(name)
+		assert.NotNil(t, err)
+	}
+}
+
+func freeAddress(t *testing.T) string {
+	lis, err := net.Listen("tcp4", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	return lis.Addr().String()
+}
+
+func TestCredentials_Authorize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mpss")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	primaryUrl := freeAddress(t)
+
+	ca, files, err := GenerateCertificates(dir, map[Identity]string{
+		PrimaryIdentity(): primaryUrl,
+		NodeIdentity(1):   "127.0.0.1:1",
+		NodeIdentity(2):   "127.0.0.1:2",
+	})
+	assert.Nil(t, err)
+
+	load := func(identity Identity) *Credentials {
+		creds, err := LoadCredentials(ca, files[identity].Cert, files[identity].Key)
+		assert.Nil(t, err)
+		assert.Equal(t, identity, creds.Identity())
+		return creds
+	}
+
+	// a certificate from another CA is refused
+	otherDir, err := ioutil.TempDir("", "mpss")
+	assert.Nil(t, err)
+	defer os.RemoveAll(otherDir)
+
+	_, otherFiles, err := GenerateCertificates(otherDir, map[Identity]string{NodeIdentity(1): ""})
+	assert.Nil(t, err)
+	_, err = LoadCredentials(ca, otherFiles[NodeIdentity(1)].Cert, otherFiles[NodeIdentity(1)].Key)
+	assert.NotNil(t, err)
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	bb := BuildBulletinBoard(logger, primaryUrl, nil, BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4)))
+	bb.SetCredentials(load(PrimaryIdentity()))
+	go bb.Serve()
+
+	board, err := DialBoard(GRPCTransport{}, primaryUrl, load(NodeIdentity(1)), logger)
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// any party with a certificate can read
+	_, err = board.ReadEpoch(ctx, 0)
+	assert.Nil(t, err)
+
+	// but node 1 can't post for node 2
+	post := newPost(services.BoardPost_PROPOSAL_HASH, 0, 2, &services.ProposalHash{Proposer: 2})
+	err = board.Post(ctx, post)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// and a client without a certificate can't connect
+	plain, err := DialBoard(GRPCTransport{}, primaryUrl, nil, logger)
+	assert.Nil(t, err)
+
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer shortCancel()
+
+	_, err = plain.ReadEpoch(shortCtx, 0)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,160 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// Transport is how the parties reach each other. Every party serves its gRPC service on a listener
+// of its transport, and dials the others through it.
+type Transport interface {
+	// Listen listens for connections to addr, the address the other parties know this one by.
+	Listen(addr string) (net.Listener, error)
+	// Dial connects to the party at addr.
+	Dial(addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error)
+}
+
+// GRPCTransport connects the parties over TCP. A party listens on the port of its address on all interfaces.
+type GRPCTransport struct{}
+
+func (GRPCTransport) Listen(addr string) (net.Listener, error) {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	portUint, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.Listen("tcp4", fmt.Sprintf("0.0.0.0:%d", portUint))
+}
+
+func (GRPCTransport) Dial(addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.Dial(addr, opts...)
+}
+
+// MemoryNetwork connects the parties of one process through in-memory pipes, with no sockets.
+// The addresses are only names, and dialing one nobody listens on waits until someone does.
+type MemoryNetwork struct {
+	listeners map[string]*memoryListener
+	// closed and replaced whenever a listener comes up
+	changed chan struct{}
+	lock    *sync.Mutex
+}
+
+func NewMemoryNetwork() *MemoryNetwork {
+	return &MemoryNetwork{
+		listeners: make(map[string]*memoryListener),
+		changed:   make(chan struct{}),
+		lock:      &sync.Mutex{},
+	}
+}
+
+func (n *MemoryNetwork) Listen(addr string) (net.Listener, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if _, ok := n.listeners[addr]; ok {
+		return nil, fmt.Errorf("%s is in use", addr)
+	}
+
+	lis := &memoryListener{
+		network: n,
+		addr:    memoryAddr(addr),
+		conns:   make(chan net.Conn),
+		closed:  make(chan struct{}),
+	}
+	n.listeners[addr] = lis
+
+	close(n.changed)
+	n.changed = make(chan struct{})
+
+	return lis, nil
+}
+
+func (n *MemoryNetwork) Dial(addr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	return grpc.Dial(addr, append(opts, grpc.WithContextDialer(n.dial))...)
+}
+
+// dial hands one end of a pipe to the listener at addr.
+func (n *MemoryNetwork) dial(ctx context.Context, addr string) (net.Conn, error) {
+	for {
+		n.lock.Lock()
+		lis, ok := n.listeners[addr]
+		changed := n.changed
+		n.lock.Unlock()
+
+		if !ok {
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		client, server := net.Pipe()
+		select {
+		case lis.conns <- server:
+			return client, nil
+		case <-lis.closed:
+			client.Close()
+			server.Close()
+		case <-ctx.Done():
+			client.Close()
+			server.Close()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+type memoryAddr string
+
+func (a memoryAddr) Network() string {
+	return "memory"
+}
+
+func (a memoryAddr) String() string {
+	return string(a)
+}
+
+type memoryListener struct {
+	network *MemoryNetwork
+	addr    memoryAddr
+	conns   chan net.Conn
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func (l *memoryListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, fmt.Errorf("%s is closed", l.addr)
+	}
+}
+
+func (l *memoryListener) Close() error {
+	l.once.Do(func() {
+		close(l.closed)
+
+		l.network.lock.Lock()
+		if l.network.listeners[string(l.addr)] == l {
+			delete(l.network.listeners, string(l.addr))
+		}
+		l.network.lock.Unlock()
+	})
+
+	return nil
+}
+
+func (l *memoryListener) Addr() net.Addr {
+	return l.addr
+}
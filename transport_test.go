@@ -0,0 +1,268 @@
+package Schultz
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"../../utils/polyring"
+	"./services"
+	"github.com/ncw/gmp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestMemoryNetwork(t *testing.T) {
+	pp := BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4))
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	node := BuildNode(pp, logger, 1, "", "node-1", nil, nil)
+
+	network := NewMemoryNetwork()
+
+	// a node dialed before it listens is reached once it does
+	conn, err := network.Dial("node-1", grpc.WithInsecure())
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make(chan error)
+	go func() {
+		_, err := services.NewNodeClient(conn).GetProposalTransfer(ctx, &services.ProposalTransfer{From: 2}, grpc.WaitForReady(true))
+		result <- err
+	}()
+
+	lis, err := network.Listen("node-1")
+	assert.Nil(t, err)
+	s := grpc.NewServer()
+	services.RegisterNodeServer(s, &node)
+	go s.Serve(lis)
+
+	assert.Nil(t, <-result)
+
+	// one listener per address, until it is closed
+	_, err = network.Listen("node-1")
+	assert.NotNil(t, err)
+
+	s.Stop()
+	lis, err = network.Listen("node-1")
+	assert.Nil(t, err)
+	lis.Close()
+}
+
+func TestProtocol_InMemory(t *testing.T) {
+	const n, degree, epochs = 10, 3, 2
+
+	pp, encryptionKeys := withEncryptionKeys(BuildConfig(degree, FieldPrime, makeOneToN(n), makeOneToN(n)))
+	pp, identityKeys := withIdentityKeys(pp)
+	prime := pp.GetPrime()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	// the addresses are only names: nothing listens on a port
+	network := NewMemoryNetwork()
+	primaryUrl := "127.0.0.1:8000"
+	urls := make(map[NewNodeID]string)
+	for _, id := range pp.Members() {
+		urls[NewNodeID(id)] = fmt.Sprintf("127.0.0.1:%d", 8000+id)
+	}
+
+	secretPoly, err := polyring.NewRand(degree, rand.New(rand.NewSource(0)), prime)
+	assert.Nil(t, err)
+
+	primary := BuildBulletinBoard(logger, primaryUrl, urls, pp)
+	primary.SetSuicideOption(false)
+	primary.SetTransport(network)
+	go primary.Serve()
+	go primary.StartProtocol()
+
+	nodes := make([]Node, n)
+	initial := make(map[int64]*gmp.Int)
+	for i, id := range pp.Members() {
+		peers := make(map[NewNodeID]string)
+		for other, url := range urls {
+			if int64(other) != id {
+				peers[other] = url
+			}
+		}
+
+		share := gmp.NewInt(0)
+		secretPoly.EvalMod(gmp.NewInt(id), prime, share)
+		initial[id] = gmp.NewInt(0).Set(share)
+
+		nodes[i] = BuildNode(pp, logger, id, primaryUrl, urls[NewNodeID(id)], peers, share)
+		nodes[i].SetTransport(network)
+		nodes[i].SetEncryptionKey(encryptionKeys[id])
+		nodes[i].SetIdentityKey(identityKeys[id])
+		go nodes[i].Serve()
+	}
+
+	var finished sync.WaitGroup
+	finished.Add(n)
+	for i := range nodes {
+		assert.Nil(t, nodes[i].ConnectPrimary())
+
+		go func(node *Node) {
+			node.ReportShare(0)
+			node.StartProtocol(&finished, epochs)
+		}(&nodes[i])
+	}
+
+	done := make(chan struct{})
+	go func() {
+		finished.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Minute):
+		t.Fatalf("the protocol didn't finish")
+	}
+
+	// every node holds a new share of the same secret
+	var Xs, Ys []*gmp.Int
+	for i := range nodes {
+		id := nodes[i].GetId()
+		assert.NotNil(t, nodes[i].share)
+		assert.NotEqual(t, 0, initial[id].Cmp(nodes[i].share))
+
+		Xs = append(Xs, gmp.NewInt(id))
+		Ys = append(Ys, nodes[i].share)
+	}
+
+	poly, wrong, err := DecodeReedSolomon(degree, Xs, Ys, prime)
+	assert.Nil(t, err)
+	assert.Empty(t, wrong)
+
+	secret := gmp.NewInt(0)
+	poly.EvalMod(gmp.NewInt(0), prime, secret)
+	assert.Equal(t, 0, secret.Cmp(secretPoly.GetPtrToConstant()))
+}
+
+// hangingPeer takes the connections to a party and never answers on them, like a peer that hung.
+func hangingPeer(network *MemoryNetwork, addr string) (net.Listener, error) {
+	lis, err := network.Listen(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		var held []net.Conn
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				for _, c := range held {
+					c.Close()
+				}
+				return
+			}
+			held = append(held, conn)
+		}
+	}()
+
+	return lis, nil
+}
+
+func TestProtocol_HungPeer(t *testing.T) {
+	const n, degree, hung = 5, 1, 5
+
+	pp, encryptionKeys := withEncryptionKeys(BuildConfig(degree, FieldPrime, makeOneToN(n), makeOneToN(n)))
+	pp, identityKeys := withIdentityKeys(pp)
+	prime := pp.GetPrime()
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.FatalLevel)
+
+	deadlines := DeadlineConfig{
+		ProposalHashes: 1000,
+		Proposals:      1000,
+		Complaints:     1000,
+		BlindedShares:  3000,
+		Shares:         2000,
+		Recovery:       1000,
+	}
+
+	network := NewMemoryNetwork()
+	primaryUrl := "127.0.0.1:8000"
+	urls := make(map[NewNodeID]string)
+	for _, id := range pp.Members() {
+		urls[NewNodeID(id)] = fmt.Sprintf("127.0.0.1:%d", 8000+id)
+	}
+
+	lis, err := hangingPeer(network, urls[hung])
+	assert.Nil(t, err)
+	defer lis.Close()
+
+	secretPoly, err := polyring.NewRand(degree, rand.New(rand.NewSource(0)), prime)
+	assert.Nil(t, err)
+
+	primary := BuildBulletinBoard(logger, primaryUrl, urls, pp)
+	primary.SetSuicideOption(false)
+	primary.SetDeadlines(deadlines)
+	primary.SetTransport(network)
+	go primary.Serve()
+	go primary.StartProtocol()
+
+	var nodes []*Node
+	for _, id := range pp.Members() {
+		peers := make(map[NewNodeID]string)
+		for other, url := range urls {
+			if int64(other) != id {
+				peers[other] = url
+			}
+		}
+
+		share := gmp.NewInt(0)
+		secretPoly.EvalMod(gmp.NewInt(id), prime, share)
+
+		node := BuildNode(pp, logger, id, primaryUrl, urls[NewNodeID(id)], peers, share)
+		node.SetTransport(network)
+		node.SetDeadlines(deadlines)
+		node.SetEncryptionKey(encryptionKeys[id])
+		node.SetIdentityKey(identityKeys[id])
+
+		// the hung peer hands in its initial share, and then never answers again
+		if id == hung {
+			assert.Nil(t, node.ConnectPrimary())
+			node.ReportShare(0)
+			continue
+		}
+
+		go node.Serve()
+		nodes = append(nodes, &node)
+	}
+
+	var finished sync.WaitGroup
+	finished.Add(len(nodes))
+	for _, node := range nodes {
+		assert.Nil(t, node.ConnectPrimary())
+
+		go func(node *Node) {
+			node.ReportShare(0)
+			node.StartProtocol(&finished, 1)
+		}(node)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		finished.Wait()
+		close(done)
+	}()
+
+	// the epoch ends, or is aborted, within its deadlines however long the hung peer takes
+	select {
+	case <-done:
+	case <-time.After(20 * time.Second):
+		t.Fatalf("the hung peer held up the epoch")
+	}
+}
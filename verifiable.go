@@ -0,0 +1,422 @@
+package Schultz
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"filippo.io/nistec"
+	"github.com/ncw/gmp"
+)
+
+// The points of a proposal are encrypted to their old node so that anyone can check them against the
+// commitments without decrypting them. The point s for new node k is cut into 16-bit chunks m_0, ..., m_15,
+// least significant first, and each chunk is encrypted with ElGamal in the exponent: (r_i G, m_i G + r_i PK).
+// Weighing the ciphertexts of the chunks by B^i, B = 2^16, adds them up to (ρG, sG + ρPK), with ρ = Σ B^i r_i,
+// and the commitments give (Q+Rk)(j) G. The ciphertexts thus hold a point on Q+Rk exactly when
+// log_G ρG = log_PK (sG + ρPK - (Q+Rk)(j) G). A Chaum-Pedersen proof shows it for all the points of an
+// old node at once, the points being weighed with scalars hashed from the ciphertexts.
+//
+// The old node decrypts each m_i G and looks m_i up in a table of the B possible chunks. The proof doesn't
+// show that every m_i is below B, only that they add up to the right point, so a proposer can still encrypt
+// a chunk that doesn't decrypt. Its recipient then discloses the key of the chunk, r_i PK, with a proof that
+// it is the right one (log_G PK = log_R S), and anyone can tell that the chunk is not in the table.
+
+const chunkBits = 16
+
+// chunkBase is the number of values a chunk takes.
+const chunkBase = 1 << chunkBits
+
+// pointChunks is the number of chunks a point is cut into.
+var pointChunks = (fieldBytes*8 + chunkBits - 1) / chunkBits
+
+// dleqProofBytes is the size of a Chaum-Pedersen proof: two points and a scalar.
+const dleqProofBytes = 2*pointBytes + 32
+
+func generator() curvePoint {
+	return curvePoint{nistec.NewP256Point().SetGenerator()}
+}
+
+// point returns the key as a curve point. Keys are checked to be on the curve when they are parsed, so
+// a key that isn't can only be the zero value, and is taken as the point at infinity.
+func (pk EncryptionPublicKey) point() curvePoint {
+	if pk.x == nil {
+		return infinity()
+	}
+
+	p, err := parsePoint(elliptic.MarshalCompressed(encryptionCurve, pk.x, pk.y))
+	if err != nil {
+		return infinity()
+	}
+
+	return p
+}
+
+// neg returns -p, which has the same x and the other y, so only the parity byte of the compression differs.
+func (p curvePoint) neg() curvePoint {
+	if p.isInfinity() {
+		return p
+	}
+
+	b := p.Bytes()
+	b[0] ^= 1
+	q, _ := parsePoint(b)
+
+	return q
+}
+
+var (
+	chunkTableOnce sync.Once
+	// the compression of mG, for each chunk m
+	chunkTable map[string]int
+)
+
+// chunkValue looks up the chunk m of mG.
+func chunkValue(p curvePoint) (int, bool) {
+	chunkTableOnce.Do(func() {
+		chunkTable = make(map[string]int, chunkBase)
+		mG := infinity()
+		for m := 0; m < chunkBase; m++ {
+			chunkTable[string(mG.Bytes())] = m
+			mG = mG.add(generator())
+		}
+	})
+
+	m, ok := chunkTable[string(p.Bytes())]
+
+	return m, ok
+}
+
+// proveDLEQ proves log_g1 h1 = log_g2 h2, knowing x = log_g1 h1, as T1 || T2 || z with T1 = w g1,
+// T2 = w g2 and z = w + c x.
+func proveDLEQ(domain string, g1, h1, g2, h2 curvePoint, x *big.Int, context ...[]byte) ([]byte, error) {
+	w, err := randomScalar()
+	if err != nil {
+		return nil, err
+	}
+
+	t1, t2 := g1.mult(w), g2.mult(w)
+	c := challenge(domain, append([][]byte{g1.Bytes(), h1.Bytes(), g2.Bytes(), h2.Bytes(), t1.Bytes(), t2.Bytes()}, context...)...)
+
+	z := new(big.Int).Mul(c, x)
+	z.Add(z, w)
+	z.Mod(z, encryptionCurve.Params().N)
+
+	proof := append(append(t1.Bytes(), t2.Bytes()...), make([]byte, 32)...)
+	z.FillBytes(proof[2*pointBytes:])
+
+	return proof, nil
+}
+
+// verifyDLEQ checks z g1 = T1 + c h1 and z g2 = T2 + c h2.
+func verifyDLEQ(domain string, g1, h1, g2, h2 curvePoint, proof []byte, context ...[]byte) bool {
+	if len(proof) != dleqProofBytes {
+		return false
+	}
+
+	t1, err := parsePoint(proof[:pointBytes])
+	if err != nil {
+		return false
+	}
+	t2, err := parsePoint(proof[pointBytes : 2*pointBytes])
+	if err != nil {
+		return false
+	}
+
+	z := new(big.Int).SetBytes(proof[2*pointBytes:])
+	if z.Cmp(encryptionCurve.Params().N) >= 0 {
+		return false
+	}
+
+	c := challenge(domain, append([][]byte{g1.Bytes(), h1.Bytes(), g2.Bytes(), h2.Bytes(), t1.Bytes(), t2.Bytes()}, context...)...)
+
+	return g1.mult(z).equal(t1.add(h1.mult(c))) && g2.mult(z).equal(t2.add(h2.mult(c)))
+}
+
+// chunkCipher is the ElGamal ciphertext of a chunk m: (rG, mG + r PK).
+type chunkCipher struct {
+	ephemeral curvePoint
+	masked    curvePoint
+}
+
+// VerifiablePoints are the points of a proposal for one old node, encrypted to its key chunk by chunk.
+type VerifiablePoints struct {
+	// the ciphertexts of the chunks of the point for each new node, least significant first
+	cipher map[NewNodeID][]chunkCipher
+	// Chaum-Pedersen proof that the points lie on Q+Rk
+	proof []byte
+}
+
+func (e VerifiablePoints) sortedIds() []NewNodeID {
+	var keys []NewNodeID
+	for k := range e.cipher {
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	return keys
+}
+
+// cipherBytes encodes the ciphertexts canonically, each with the id of its new node.
+func (e VerifiablePoints) cipherBytes() []byte {
+	keys := e.sortedIds()
+
+	c := &canonical{}
+	c.uint32(uint32(len(keys)))
+	for _, k := range keys {
+		c.uint32(uint32(k))
+		c.uint32(uint32(len(e.cipher[k])))
+		for _, chunk := range e.cipher[k] {
+			c.raw(chunk.ephemeral.Bytes())
+			c.raw(chunk.masked.Bytes())
+		}
+	}
+
+	return c.Bytes()
+}
+
+func (e VerifiablePoints) Bytes() []byte {
+	c := &canonical{}
+	c.bytes(e.cipherBytes())
+	c.bytes(e.proof)
+
+	return c.Bytes()
+}
+
+func (e VerifiablePoints) Equal(other VerifiablePoints) bool {
+	return bytes.Equal(e.Bytes(), other.Bytes())
+}
+
+// proofContext binds the proof to the ciphertexts, their old node and the proposal.
+func (e VerifiablePoints) proofContext(j OldNodeID, proposal []byte) [][]byte {
+	var id [4]byte
+	binary.BigEndian.PutUint32(id[:], uint32(j))
+
+	return [][]byte{id[:], e.cipherBytes(), proposal}
+}
+
+// weights draws the scalar each point is weighed with in the proof from the ciphertexts.
+func (e VerifiablePoints) weights(j OldNodeID, proposal []byte) map[NewNodeID]*big.Int {
+	context := e.proofContext(j, proposal)
+
+	weights := make(map[NewNodeID]*big.Int, len(e.cipher))
+	for k := range e.cipher {
+		var id [4]byte
+		binary.BigEndian.PutUint32(id[:], uint32(k))
+		weights[k] = challenge("mpss-weight", append([][]byte{id[:]}, context...)...)
+	}
+
+	return weights
+}
+
+// combine weighs the ciphertexts of the chunks by B^i, giving (ρG, sG + ρPK) for the point s.
+func combine(chunks []chunkCipher) (ephemeral, masked curvePoint) {
+	base := big.NewInt(chunkBase)
+
+	ephemeral, masked = infinity(), infinity()
+	for i := len(chunks) - 1; i >= 0; i-- {
+		ephemeral = ephemeral.mult(base).add(chunks[i].ephemeral)
+		masked = masked.mult(base).add(chunks[i].masked)
+	}
+
+	return ephemeral, masked
+}
+
+// chunksOf cuts a point into pointChunks chunks, least significant first.
+func chunksOf(point *gmp.Int) ([]*big.Int, error) {
+	b, err := elementBytes(point)
+	if err != nil {
+		return nil, err
+	}
+	x := new(big.Int).SetBytes(b)
+	mask := big.NewInt(chunkBase - 1)
+
+	chunks := make([]*big.Int, pointChunks)
+	for i := range chunks {
+		chunks[i] = new(big.Int).And(x, mask)
+		x.Rsh(x, chunkBits)
+	}
+
+	return chunks, nil
+}
+
+// encryptVerifiablePoints encrypts the points for old node j to its key pk, and proves that they are the
+// points committed to. proposal is what the proof is bound to.
+func encryptVerifiablePoints(pk EncryptionPublicKey, j OldNodeID, points PointsOnBlindingPoly, proposal []byte) (VerifiablePoints, error) {
+	chunks := make(map[NewNodeID][]*big.Int, len(points.points))
+	for k, point := range points.points {
+		c, err := chunksOf(point)
+		if err != nil {
+			return VerifiablePoints{}, fmt.Errorf("the point for %d: %s", k, err.Error())
+		}
+		chunks[k] = c
+	}
+
+	return encryptChunks(pk, j, chunks, proposal)
+}
+
+// encryptChunks encrypts the given chunks, whatever they are, and proves that they add up to their points.
+func encryptChunks(pk EncryptionPublicKey, j OldNodeID, chunks map[NewNodeID][]*big.Int, proposal []byte) (VerifiablePoints, error) {
+	n := encryptionCurve.Params().N
+	base := big.NewInt(chunkBase)
+
+	e := VerifiablePoints{cipher: make(map[NewNodeID][]chunkCipher, len(chunks))}
+	// ρ for each point
+	randomness := make(map[NewNodeID]*big.Int, len(chunks))
+
+	for k, ms := range chunks {
+		rho := new(big.Int)
+		e.cipher[k] = make([]chunkCipher, len(ms))
+
+		for i := len(ms) - 1; i >= 0; i-- {
+			r, err := randomScalar()
+			if err != nil {
+				return VerifiablePoints{}, err
+			}
+
+			m := new(big.Int).Mod(ms[i], n)
+			e.cipher[k][i] = chunkCipher{
+				ephemeral: baseMult(r),
+				masked:    baseMult(m).add(pk.point().mult(r)),
+			}
+
+			rho.Mul(rho, base)
+			rho.Add(rho, r)
+			rho.Mod(rho, n)
+		}
+		randomness[k] = rho
+	}
+
+	x := new(big.Int)
+	for k, lambda := range e.weights(j, proposal) {
+		x.Add(x, new(big.Int).Mul(lambda, randomness[k]))
+	}
+	x.Mod(x, n)
+
+	proof, err := proveDLEQ("mpss-points", generator(), baseMult(x), pk.point(), pk.point().mult(x), x, e.proofContext(j, proposal)...)
+	if err != nil {
+		return VerifiablePoints{}, err
+	}
+	e.proof = proof
+
+	return e, nil
+}
+
+// verify checks the proof that the ciphertexts for old node j hold points on Q+Rk.
+func (e VerifiablePoints) verify(j OldNodeID, pk EncryptionPublicKey, commQ PolyCommit, commRs map[NewNodeID]PolyCommit, proposal []byte) error {
+	jBig := big.NewInt(int64(j))
+	Qj := commQ.eval(jBig)
+
+	ephemeral, masked := infinity(), infinity()
+	for k, lambda := range e.weights(j, proposal) {
+		commRk, ok := commRs[k]
+		if !ok {
+			return fmt.Errorf("no blinding polynomial for %d", k)
+		}
+		if len(e.cipher[k]) != pointChunks {
+			return fmt.Errorf("the point on Q+R%d for %d has %d chunks", k, j, len(e.cipher[k]))
+		}
+
+		R, C := combine(e.cipher[k])
+		committed := Qj.add(commRk.eval(jBig))
+
+		ephemeral = ephemeral.add(R.mult(lambda))
+		masked = masked.add(C.add(committed.neg()).mult(lambda))
+	}
+
+	if !verifyDLEQ("mpss-points", generator(), ephemeral, pk.point(), masked, e.proof, e.proofContext(j, proposal)...) {
+		return fmt.Errorf("bad proof for the points of %d", j)
+	}
+
+	return nil
+}
+
+// decrypt recovers the points with the key of their old node.
+func (e VerifiablePoints) decrypt(key *EncryptionKey, prime *gmp.Int) (PointsOnBlindingPoly, error) {
+	points := PointsOnBlindingPoly{points: make(map[NewNodeID]*gmp.Int, len(e.cipher))}
+
+	for k, chunks := range e.cipher {
+		point := gmp.NewInt(0)
+		for i := len(chunks) - 1; i >= 0; i-- {
+			shared := chunks[i].ephemeral.mult(key.d)
+			m, ok := chunkValue(chunks[i].masked.add(shared.neg()))
+			if !ok {
+				return PointsOnBlindingPoly{}, fmt.Errorf("chunk %d of the point for %d doesn't decrypt", i, k)
+			}
+
+			point.Mul(point, gmp.NewInt(chunkBase))
+			point.Add(point, gmp.NewInt(int64(m)))
+		}
+		points.points[k] = point.Mod(point, prime)
+	}
+
+	return points, nil
+}
+
+// KeyDisclosure reveals the key of one chunk, with a proof that it is the right one.
+// It says nothing about the key of the old node or the other chunks.
+type KeyDisclosure struct {
+	// the chunk: the new node of its point, and its position in the point
+	Point NewNodeID
+	Chunk int
+
+	Shared []byte
+	Proof  []byte
+}
+
+// disclose reveals the key of the first chunk that doesn't decrypt.
+func (e VerifiablePoints) disclose(key *EncryptionKey) (KeyDisclosure, error) {
+	for _, k := range e.sortedIds() {
+		for i, chunk := range e.cipher[k] {
+			if _, ok := chunkValue(chunk.masked.add(chunk.ephemeral.mult(key.d).neg())); !ok {
+				return e.discloseChunk(key, k, i)
+			}
+		}
+	}
+
+	return KeyDisclosure{}, fmt.Errorf("every chunk decrypts")
+}
+
+// discloseChunk proves log_G PK = log_R S, where R is the ephemeral key of the chunk and S its key.
+func (e VerifiablePoints) discloseChunk(key *EncryptionKey, k NewNodeID, i int) (KeyDisclosure, error) {
+	if i < 0 || i >= len(e.cipher[k]) {
+		return KeyDisclosure{}, fmt.Errorf("no chunk %d for %d", i, k)
+	}
+
+	R := e.cipher[k][i].ephemeral
+	shared := R.mult(key.d)
+
+	proof, err := proveDLEQ("mpss-dleq", generator(), key.Public.point(), R, shared, key.d)
+	if err != nil {
+		return KeyDisclosure{}, err
+	}
+
+	return KeyDisclosure{Point: k, Chunk: i, Shared: shared.Bytes(), Proof: proof}, nil
+}
+
+// decryptsDisclosed checks a disclosure by the holder of pk, and tells whether the chunk decrypts with it.
+func (e VerifiablePoints) decryptsDisclosed(pk EncryptionPublicKey, disclosure KeyDisclosure) (bool, error) {
+	chunks := e.cipher[disclosure.Point]
+	if disclosure.Chunk < 0 || disclosure.Chunk >= len(chunks) {
+		return false, fmt.Errorf("no chunk %d for %d", disclosure.Chunk, disclosure.Point)
+	}
+	chunk := chunks[disclosure.Chunk]
+
+	shared, err := parsePoint(disclosure.Shared)
+	if err != nil {
+		return false, fmt.Errorf("malformed disclosure: %s", err.Error())
+	}
+
+	if !verifyDLEQ("mpss-dleq", generator(), pk.point(), chunk.ephemeral, shared, disclosure.Proof) {
+		return false, fmt.Errorf("bad disclosure proof")
+	}
+
+	_, ok := chunkValue(chunk.masked.add(shared.neg()))
+
+	return ok, nil
+}
@@ -0,0 +1,130 @@
+package Schultz
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ncw/gmp"
+	"github.com/stretchr/testify/assert"
+)
+
+// undecryptablePoints encrypts the points of p for old node j again, with the first two chunks of the
+// point for new node 2 shifted so that they still add up to it but the first one is out of range.
+func undecryptablePoints(pp PublicParameter, p Proposal, j OldNodeID, key *EncryptionKey) (VerifiablePoints, error) {
+	points, err := p.pointToPeers[j].decrypt(key, pp.prime)
+	if err != nil {
+		return VerifiablePoints{}, err
+	}
+
+	chunks := make(map[NewNodeID][]*big.Int)
+	for k, point := range points.points {
+		if chunks[k], err = chunksOf(point); err != nil {
+			return VerifiablePoints{}, err
+		}
+	}
+	chunks[2][0].Add(chunks[2][0], big.NewInt(chunkBase))
+	chunks[2][1].Sub(chunks[2][1], big.NewInt(1))
+
+	return encryptChunks(pp.encryptionKeys[int64(j)], j, chunks, p.commitmentBytes())
+}
+
+func TestVerifiablePoints(t *testing.T) {
+	pp, keys := withEncryptionKeys(BuildConfig(1, FieldPrime, []int64{1, 2, 3, 4}, []int64{1, 2, 3, 4}))
+
+	p := GenerateProposal(pp, 1)
+	assert.Nil(t, p.Verify(pp, 1))
+
+	slice, err := p.Slice(OldNodeID(1))
+	assert.Nil(t, err)
+	points, err := slice.PointsFor(keys[1], pp)
+	assert.Nil(t, err)
+
+	// points off Q+Rk fail the proof, for anyone to see
+	points.points[NewNodeID(2)].Add(points.points[NewNodeID(2)], gmp.NewInt(1))
+	points.points[NewNodeID(2)].Mod(points.points[NewNodeID(2)], pp.prime)
+
+	cheat := GenerateProposal(pp, 1)
+	cheat.commQ, cheat.commRs = p.commQ, p.commRs
+	for j, e := range p.pointToPeers {
+		cheat.pointToPeers[j] = e
+	}
+	cheat.pointToPeers[OldNodeID(1)], err = encryptVerifiablePoints(pp.encryptionKeys[1], OldNodeID(1), points, p.commitmentBytes())
+	assert.Nil(t, err)
+	assert.NotNil(t, cheat.Verify(pp, 1))
+
+	slice, err = cheat.Slice(OldNodeID(1))
+	assert.Nil(t, err)
+	assert.NotNil(t, slice.Verify(pp, 1))
+
+	// and so do the points for another old node
+	cheat.pointToPeers[OldNodeID(1)] = p.pointToPeers[OldNodeID(2)]
+	assert.NotNil(t, cheat.Verify(pp, 1))
+
+	// a ciphertext swapped for another one breaks the proof
+	swapped := VerifiablePoints{cipher: make(map[NewNodeID][]chunkCipher), proof: p.pointToPeers[OldNodeID(1)].proof}
+	for k, chunks := range p.pointToPeers[OldNodeID(1)].cipher {
+		swapped.cipher[k] = append([]chunkCipher{}, chunks...)
+	}
+	swapped.cipher[NewNodeID(3)][5], swapped.cipher[NewNodeID(3)][6] = swapped.cipher[NewNodeID(3)][6], swapped.cipher[NewNodeID(3)][5]
+	cheat.pointToPeers[OldNodeID(1)] = swapped
+	assert.NotNil(t, cheat.Verify(pp, 1))
+}
+
+func TestProposal_Disclosure(t *testing.T) {
+	pp, keys := withEncryptionKeys(BuildConfig(1, FieldPrime, []int64{1, 2, 3, 4}, []int64{1, 2, 3, 4}))
+
+	// an honest proposal has nothing to disclose, and a disclosed chunk of it decrypts
+	p := GenerateProposal(pp, 1)
+	slice, err := p.Slice(OldNodeID(1))
+	assert.Nil(t, err)
+
+	_, err = slice.Disclose(keys[1])
+	assert.NotNil(t, err)
+
+	disclosure, err := slice.points.discloseChunk(keys[1], NewNodeID(2), 0)
+	assert.Nil(t, err)
+
+	bad, err := slice.VerifyDisclosedPoints(disclosure, pp)
+	assert.False(t, bad)
+	assert.Nil(t, err)
+
+	// a cheating proposer encrypts a chunk out of range to node 1, which the proof can't tell
+	p.pointToPeers[OldNodeID(1)], err = undecryptablePoints(pp, p, OldNodeID(1), keys[1])
+	assert.Nil(t, err)
+	assert.Nil(t, p.Verify(pp, 1))
+
+	slice, err = p.Slice(OldNodeID(1))
+	assert.Nil(t, err)
+	assert.Nil(t, slice.Verify(pp, 1))
+
+	_, err = slice.PointsFor(keys[1], pp)
+	assert.NotNil(t, err)
+
+	// once node 1 discloses the key of the chunk, anyone can tell
+	disclosure, err = slice.Disclose(keys[1])
+	assert.Nil(t, err)
+	assert.Equal(t, NewNodeID(2), disclosure.Point)
+	assert.Equal(t, 0, disclosure.Chunk)
+
+	bad, err = slice.VerifyDisclosedPoints(disclosure, pp)
+	assert.False(t, bad)
+	assert.NotNil(t, err)
+
+	// a key disclosed by someone else is no proof
+	forged, err := slice.points.discloseChunk(keys[2], NewNodeID(2), 0)
+	assert.Nil(t, err)
+
+	bad, _ = slice.VerifyDisclosedPoints(forged, pp)
+	assert.True(t, bad)
+
+	// and neither is a tampered key, or the key of another chunk
+	tampered := disclosure
+	tampered.Shared = forged.Shared
+	bad, _ = slice.VerifyDisclosedPoints(tampered, pp)
+	assert.True(t, bad)
+
+	moved := disclosure
+	moved.Chunk = 1
+	bad, _ = slice.VerifyDisclosedPoints(moved, pp)
+	assert.True(t, bad)
+}
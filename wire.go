@@ -0,0 +1,397 @@
+package Schultz
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"./services"
+	"github.com/ncw/gmp"
+)
+
+// Proposals travel as typed protobuf messages, so that nodes written in any language can read them.
+// Decoding is strict: field elements must be in range, curve points on the curve, and every node the
+// parameters expect must be there exactly once. Anything else is rejected before it is looked at.
+
+func commitmentToMessage(comm PolyCommit) *services.PolyCommit {
+	msg := &services.PolyCommit{Coefficients: make([][]byte, len(comm.coefficients))}
+	for i, p := range comm.coefficients {
+		msg.Coefficients[i] = p.Bytes()
+	}
+
+	return msg
+}
+
+// commitmentFromMessage decodes the commitment to a polynomial of the given degree: exactly degree+1
+// points, each on the curve.
+func commitmentFromMessage(msg *services.PolyCommit, degree int) (PolyCommit, error) {
+	if msg == nil {
+		return PolyCommit{}, fmt.Errorf("no commitment")
+	}
+	if len(msg.Coefficients) != degree+1 {
+		return PolyCommit{}, fmt.Errorf("%d coefficients for a polynomial of degree %d", len(msg.Coefficients), degree)
+	}
+
+	comm := PolyCommit{coefficients: make([]curvePoint, len(msg.Coefficients))}
+	for i, b := range msg.Coefficients {
+		p, err := parsePoint(b)
+		if err != nil {
+			return PolyCommit{}, fmt.Errorf("coefficient %d: %s", i, err.Error())
+		}
+		comm.coefficients[i] = p
+	}
+
+	return comm, nil
+}
+
+func commitmentsToMessage(commRs map[NewNodeID]PolyCommit) []*services.BlindingCommitment {
+	var keys []NewNodeID
+	for k := range commRs {
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	list := make([]*services.BlindingCommitment, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, &services.BlindingCommitment{Id: int32(k), Commitment: commitmentToMessage(commRs[k])})
+	}
+
+	return list
+}
+
+// commitmentsFromMessage decodes one commitment for each new node.
+func commitmentsFromMessage(list []*services.BlindingCommitment, pp PublicParameter) (map[NewNodeID]PolyCommit, error) {
+	if len(list) != len(pp.newGroup) {
+		return nil, fmt.Errorf("wrong number of blinding polynomials: wanted %d, got %d", len(pp.newGroup), len(list))
+	}
+
+	commRs := make(map[NewNodeID]PolyCommit, len(list))
+	for _, msg := range list {
+		k := NewNodeID(msg.Id)
+		if _, ok := commRs[k]; ok || !pp.IsNewMember(int64(k)) {
+			return nil, fmt.Errorf("unexpected blinding polynomial for %d", k)
+		}
+
+		comm, err := commitmentFromMessage(msg.Commitment, pp.newDegree)
+		if err != nil {
+			return nil, fmt.Errorf("blinding polynomial for %d: %s", k, err.Error())
+		}
+		commRs[k] = comm
+	}
+
+	return commRs, nil
+}
+
+// ToMessage encodes the points, in the order of their new node.
+func (pz PointsOnBlindingPoly) ToMessage() (*services.PointsOnBlindingPoly, error) {
+	var keys []NewNodeID
+	for k := range pz.points {
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	msg := &services.PointsOnBlindingPoly{Points: make([]*services.NodePoint, 0, len(keys))}
+	for _, k := range keys {
+		value, err := elementBytes(pz.points[k])
+		if err != nil {
+			return nil, fmt.Errorf("the point for %d: %s", k, err.Error())
+		}
+		msg.Points = append(msg.Points, &services.NodePoint{Id: int32(k), Value: value})
+	}
+
+	return msg, nil
+}
+
+// PointsFromMessage decodes exactly one point below the prime for each of the given nodes.
+func PointsFromMessage(msg *services.PointsOnBlindingPoly, ids []int64, prime *gmp.Int) (PointsOnBlindingPoly, error) {
+	if msg == nil {
+		return PointsOnBlindingPoly{}, fmt.Errorf("no points")
+	}
+	if len(msg.Points) != len(ids) {
+		return PointsOnBlindingPoly{}, fmt.Errorf("wrong number of points: wanted %d, got %d", len(ids), len(msg.Points))
+	}
+
+	points := make(map[NewNodeID]*gmp.Int, len(ids))
+	for _, point := range msg.Points {
+		k := NewNodeID(point.Id)
+		if _, ok := points[k]; ok || !contains(ids, int64(k)) {
+			return PointsOnBlindingPoly{}, fmt.Errorf("unexpected point for %d", k)
+		}
+
+		if len(point.Value) != fieldBytes {
+			return PointsOnBlindingPoly{}, fmt.Errorf("the point for %d has %d bytes", k, len(point.Value))
+		}
+
+		x := gmp.NewInt(0).SetBytes(point.Value)
+		if x.Cmp(prime) >= 0 {
+			return PointsOnBlindingPoly{}, fmt.Errorf("the point for %d is not a field element", k)
+		}
+		points[k] = x
+	}
+
+	return PointsOnBlindingPoly{points: points}, nil
+}
+
+func (e EncryptedPoints) toMessage(j OldNodeID) (*services.EncryptedPoints, error) {
+	cipher, err := PointsOnBlindingPoly{points: e.cipher}.ToMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &services.EncryptedPoints{
+		Recipient:       int32(j),
+		Ephemeral:       e.ephemeral,
+		Cipher:          cipher,
+		ProofCommitment: e.proofCommitment,
+	}
+	if e.proofResponse != nil {
+		msg.ProofResponse = e.proofResponse.Bytes()
+	}
+
+	return msg, nil
+}
+
+// encryptedPointsFromMessage decodes the points encrypted to an old node, with a ciphertext for each of
+// the given nodes.
+func encryptedPointsFromMessage(msg *services.EncryptedPoints, ids []int64, prime *gmp.Int) (OldNodeID, EncryptedPoints, error) {
+	if msg == nil {
+		return 0, EncryptedPoints{}, fmt.Errorf("no encrypted points")
+	}
+	j := OldNodeID(msg.Recipient)
+
+	for _, point := range [][]byte{msg.Ephemeral, msg.ProofCommitment} {
+		if x, _ := elliptic.Unmarshal(encryptionCurve, point); x == nil {
+			return 0, EncryptedPoints{}, fmt.Errorf("the points for %d carry a bad curve point", j)
+		}
+	}
+
+	response := new(big.Int).SetBytes(msg.ProofResponse)
+	if len(msg.ProofResponse) == 0 || response.Cmp(encryptionCurve.Params().N) >= 0 {
+		return 0, EncryptedPoints{}, fmt.Errorf("the points for %d carry a bad proof", j)
+	}
+
+	cipher, err := PointsFromMessage(msg.Cipher, ids, prime)
+	if err != nil {
+		return 0, EncryptedPoints{}, fmt.Errorf("the points for %d: %s", j, err.Error())
+	}
+
+	return j, EncryptedPoints{
+		ephemeral:       msg.Ephemeral,
+		cipher:          cipher.points,
+		proofCommitment: msg.ProofCommitment,
+		proofResponse:   response,
+	}, nil
+}
+
+func (e VerifiablePoints) toMessage(j OldNodeID) *services.VerifiablePoints {
+	msg := &services.VerifiablePoints{Recipient: int32(j), Proof: e.proof}
+
+	for _, k := range e.sortedIds() {
+		point := &services.EncryptedPoint{Id: int32(k)}
+		for _, chunk := range e.cipher[k] {
+			point.Ephemerals = append(point.Ephemerals, chunk.ephemeral.Bytes())
+			point.Masked = append(point.Masked, chunk.masked.Bytes())
+		}
+		msg.Points = append(msg.Points, point)
+	}
+
+	return msg
+}
+
+// verifiablePointsFromMessage decodes the points encrypted to an old node, with pointChunks chunks of
+// points on the curve for each of the given nodes.
+func verifiablePointsFromMessage(msg *services.VerifiablePoints, ids []int64) (OldNodeID, VerifiablePoints, error) {
+	if msg == nil {
+		return 0, VerifiablePoints{}, fmt.Errorf("no encrypted points")
+	}
+	j := OldNodeID(msg.Recipient)
+
+	if len(msg.Proof) != dleqProofBytes {
+		return 0, VerifiablePoints{}, fmt.Errorf("the points for %d carry a bad proof", j)
+	}
+
+	if len(msg.Points) != len(ids) {
+		return 0, VerifiablePoints{}, fmt.Errorf("the points for %d: wanted %d, got %d", j, len(ids), len(msg.Points))
+	}
+
+	cipher := make(map[NewNodeID][]chunkCipher, len(ids))
+	for _, point := range msg.Points {
+		k := NewNodeID(point.Id)
+		if _, ok := cipher[k]; ok || !contains(ids, int64(k)) {
+			return 0, VerifiablePoints{}, fmt.Errorf("the points for %d: unexpected point for %d", j, k)
+		}
+
+		if len(point.Ephemerals) != pointChunks || len(point.Masked) != pointChunks {
+			return 0, VerifiablePoints{}, fmt.Errorf("the point for %d sent to %d has %d chunks", k, j, len(point.Masked))
+		}
+
+		chunks := make([]chunkCipher, pointChunks)
+		for i := range chunks {
+			ephemeral, err := parsePoint(point.Ephemerals[i])
+			if err != nil {
+				return 0, VerifiablePoints{}, fmt.Errorf("the points for %d carry a bad curve point: %s", j, err.Error())
+			}
+			masked, err := parsePoint(point.Masked[i])
+			if err != nil {
+				return 0, VerifiablePoints{}, fmt.Errorf("the points for %d carry a bad curve point: %s", j, err.Error())
+			}
+			chunks[i] = chunkCipher{ephemeral: ephemeral, masked: masked}
+		}
+		cipher[k] = chunks
+	}
+
+	return j, VerifiablePoints{cipher: cipher, proof: msg.Proof}, nil
+}
+
+// Message encodes the whole proposal as made by from.
+func (p Proposal) Message(from int64) *services.Proposal {
+	msg := &services.Proposal{
+		Epoch:   int32(p.epoch),
+		From:    from,
+		Session: append([]byte{}, p.session[:]...),
+		CommQ:   commitmentToMessage(p.commQ),
+		CommRs:  commitmentsToMessage(p.commRs),
+	}
+
+	for _, j := range p.recipients() {
+		msg.Points = append(msg.Points, p.pointToPeers[j].toMessage(j))
+	}
+
+	return msg
+}
+
+// decodeCommitments decodes what the whole proposal and its slices have in common.
+func decodeCommitments(msg *services.Proposal, pp PublicParameter) (session [32]byte, commQ PolyCommit, commRs map[NewNodeID]PolyCommit, err error) {
+	if len(msg.Session) != len(session) {
+		return session, commQ, nil, fmt.Errorf("the session has %d bytes", len(msg.Session))
+	}
+	copy(session[:], msg.Session)
+
+	if commQ, err = commitmentFromMessage(msg.CommQ, pp.newDegree); err != nil {
+		return session, commQ, nil, fmt.Errorf("Q: %s", err.Error())
+	}
+
+	commRs, err = commitmentsFromMessage(msg.CommRs, pp)
+
+	return session, commQ, commRs, err
+}
+
+// ProposalFromMessage decodes a whole proposal, which has points for every old node.
+func ProposalFromMessage(msg *services.Proposal, pp PublicParameter) (Proposal, error) {
+	if msg.Path != nil {
+		return Proposal{}, fmt.Errorf("a slice, not the whole proposal")
+	}
+
+	session, commQ, commRs, err := decodeCommitments(msg, pp)
+	if err != nil {
+		return Proposal{}, err
+	}
+
+	if len(msg.Points) != len(pp.oldGroup) {
+		return Proposal{}, fmt.Errorf("wrong number of peers: wanted %d, got %d", len(pp.oldGroup), len(msg.Points))
+	}
+
+	pointToPeers := make(map[OldNodeID]VerifiablePoints, len(msg.Points))
+	for _, m := range msg.Points {
+		j, points, err := verifiablePointsFromMessage(m, pp.newGroup)
+		if err != nil {
+			return Proposal{}, err
+		}
+
+		if _, ok := pointToPeers[j]; ok || !pp.IsOldMember(int64(j)) {
+			return Proposal{}, fmt.Errorf("unexpected points for %d", j)
+		}
+		pointToPeers[j] = points
+	}
+
+	return Proposal{
+		epoch:        Epoch(msg.Epoch),
+		session:      session,
+		commQ:        commQ,
+		commRs:       commRs,
+		pointToPeers: pointToPeers,
+	}, nil
+}
+
+// Message encodes the slice as sent by from.
+func (s ProposalSlice) Message(from int64) *services.Proposal {
+	path := &services.MerklePath{Index: int32(s.index), Count: int32(s.count)}
+	for _, node := range s.path {
+		path.Nodes = append(path.Nodes, append([]byte{}, node[:]...))
+	}
+
+	return &services.Proposal{
+		Epoch:   int32(s.epoch),
+		From:    from,
+		Session: append([]byte{}, s.session[:]...),
+		CommQ:   commitmentToMessage(s.commQ),
+		CommRs:  commitmentsToMessage(s.commRs),
+		Points:  []*services.VerifiablePoints{s.points.toMessage(s.recipient)},
+		Path:    path,
+	}
+}
+
+// sliceFromMessage decodes the slice for old node j out of a message holding either that slice or the
+// whole proposal. A node that pulled a proposal keeps it whole, as its proposer signed it.
+func sliceFromMessage(msg *services.Proposal, pp PublicParameter, j OldNodeID) (ProposalSlice, error) {
+	if msg.Path != nil {
+		return ProposalSliceFromMessage(msg, pp)
+	}
+
+	proposal, err := ProposalFromMessage(msg, pp)
+	if err != nil {
+		return ProposalSlice{}, err
+	}
+
+	return proposal.Slice(j)
+}
+
+// ProposalSliceFromMessage decodes the slice of a proposal for one old node.
+func ProposalSliceFromMessage(msg *services.Proposal, pp PublicParameter) (ProposalSlice, error) {
+	if msg.Path == nil || len(msg.Points) != 1 {
+		return ProposalSlice{}, fmt.Errorf("not a slice")
+	}
+
+	session, commQ, commRs, err := decodeCommitments(msg, pp)
+	if err != nil {
+		return ProposalSlice{}, err
+	}
+
+	j, points, err := verifiablePointsFromMessage(msg.Points[0], pp.newGroup)
+	if err != nil {
+		return ProposalSlice{}, err
+	}
+	if !pp.IsOldMember(int64(j)) {
+		return ProposalSlice{}, fmt.Errorf("%d is not in the old group", j)
+	}
+
+	// one leaf for each old node
+	index, count := int(msg.Path.Index), int(msg.Path.Count)
+	if count != len(pp.oldGroup) || index < 0 || index >= count {
+		return ProposalSlice{}, fmt.Errorf("leaf %d of %d", index, count)
+	}
+
+	path := make([][32]byte, len(msg.Path.Nodes))
+	for i, node := range msg.Path.Nodes {
+		if len(node) != sha256.Size {
+			return ProposalSlice{}, fmt.Errorf("a node of the Merkle path has %d bytes", len(node))
+		}
+		copy(path[i][:], node)
+	}
+
+	return ProposalSlice{
+		epoch:     Epoch(msg.Epoch),
+		session:   session,
+		commQ:     commQ,
+		commRs:    commRs,
+		recipient: j,
+		points:    points,
+		index:     index,
+		count:     count,
+		path:      path,
+	}, nil
+}
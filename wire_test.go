@@ -0,0 +1,146 @@
+package Schultz
+
+import (
+	"testing"
+
+	"./services"
+	"github.com/golang/protobuf/proto"
+	"github.com/ncw/gmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProposalFromMessage_Strict(t *testing.T) {
+	pp, _ := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), []int64{2, 3, 4, 5}))
+
+	p := GenerateProposal(pp, 1)
+	good := p.Message(1)
+
+	decoded, err := ProposalFromMessage(good, pp)
+	assert.Nil(t, err)
+	assert.True(t, p.Equal(decoded))
+	assert.Equal(t, p.Hash(), decoded.Hash())
+
+	tampered := map[string]func(msg *services.Proposal){
+		"chunk off the curve": func(msg *services.Proposal) {
+			msg.Points[0].Points[0].Masked[3] = offCurvePoint()
+		},
+		"short ephemeral key": func(msg *services.Proposal) {
+			msg.Points[0].Points[0].Ephemerals[0] = msg.Points[0].Points[0].Ephemerals[0][1:]
+		},
+		"missing chunk": func(msg *services.Proposal) {
+			msg.Points[0].Points[0].Masked = msg.Points[0].Points[0].Masked[1:]
+		},
+		"point for an unknown node": func(msg *services.Proposal) {
+			msg.Points[0].Points[0].Id = 9
+		},
+		"point twice": func(msg *services.Proposal) {
+			msg.Points[0].Points[1].Id = msg.Points[0].Points[0].Id
+		},
+		"missing point": func(msg *services.Proposal) {
+			msg.Points[0].Points = msg.Points[0].Points[1:]
+		},
+		"short proof": func(msg *services.Proposal) {
+			msg.Points[0].Proof = msg.Points[0].Proof[1:]
+		},
+		"points for a node twice": func(msg *services.Proposal) {
+			msg.Points[1].Recipient = msg.Points[0].Recipient
+		},
+		"points for a node outside the old group": func(msg *services.Proposal) {
+			msg.Points[0].Recipient = 5
+		},
+		"missing blinding polynomial": func(msg *services.Proposal) {
+			msg.CommRs = msg.CommRs[1:]
+		},
+		"blinding polynomial for an old node": func(msg *services.Proposal) {
+			msg.CommRs[0].Id = 1
+		},
+		"no commitment to Q": func(msg *services.Proposal) {
+			msg.CommQ = nil
+		},
+		"coefficient off the curve": func(msg *services.Proposal) {
+			msg.CommQ.Coefficients[1] = offCurvePoint()
+		},
+		"short coefficient": func(msg *services.Proposal) {
+			msg.CommRs[0].Commitment.Coefficients[0] = msg.CommRs[0].Commitment.Coefficients[0][1:]
+		},
+		"Q of a higher degree": func(msg *services.Proposal) {
+			msg.CommQ.Coefficients = append(msg.CommQ.Coefficients, msg.CommQ.Coefficients[1])
+		},
+		"blinding polynomial of a lower degree": func(msg *services.Proposal) {
+			msg.CommRs[0].Commitment.Coefficients = msg.CommRs[0].Commitment.Coefficients[:1]
+		},
+		"short session": func(msg *services.Proposal) {
+			msg.Session = msg.Session[1:]
+		},
+		"a path": func(msg *services.Proposal) {
+			msg.Path = &services.MerklePath{Count: 4}
+		},
+	}
+
+	for name, tamper := range tampered {
+		msg := proto.Clone(good).(*services.Proposal)
+		tamper(msg)
+
+		_, err := ProposalFromMessage(msg, pp)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestProposalSliceFromMessage_Strict(t *testing.T) {
+	pp, _ := withEncryptionKeys(BuildConfig(1, FieldPrime, makeOneToN(4), []int64{2, 3, 4, 5}))
+
+	p := GenerateProposal(pp, 1)
+	slice, err := p.Slice(3)
+	assert.Nil(t, err)
+	good := slice.Message(1)
+
+	decoded, err := ProposalSliceFromMessage(good, pp)
+	assert.Nil(t, err)
+	assert.Equal(t, OldNodeID(3), decoded.GetRecipient())
+
+	// the whole proposal is not a slice
+	_, err = ProposalSliceFromMessage(p.Message(1), pp)
+	assert.NotNil(t, err)
+
+	tampered := map[string]func(msg *services.Proposal){
+		"leaf out of range": func(msg *services.Proposal) {
+			msg.Path.Index = msg.Path.Count
+		},
+		"wrong number of leaves": func(msg *services.Proposal) {
+			msg.Path.Count = 5
+		},
+		"short node of the path": func(msg *services.Proposal) {
+			msg.Path.Nodes[0] = msg.Path.Nodes[0][1:]
+		},
+		"points for two nodes": func(msg *services.Proposal) {
+			msg.Points = append(msg.Points, msg.Points[0])
+		},
+	}
+
+	for name, tamper := range tampered {
+		msg := proto.Clone(good).(*services.Proposal)
+		tamper(msg)
+
+		_, err := ProposalSliceFromMessage(msg, pp)
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestPointsFromMessage(t *testing.T) {
+	pp := BuildConfig(1, FieldPrime, makeOneToN(4), makeOneToN(4))
+
+	points := PointsOnBlindingPoly{points: map[NewNodeID]*gmp.Int{1: gmp.NewInt(5), 2: gmp.NewInt(0)}}
+
+	msg, err := points.ToMessage()
+	assert.Nil(t, err)
+
+	decoded, err := PointsFromMessage(msg, []int64{1, 2}, pp.prime)
+	assert.Nil(t, err)
+	assert.True(t, points.Equal(decoded))
+
+	_, err = PointsFromMessage(msg, []int64{1, 3}, pp.prime)
+	assert.NotNil(t, err)
+
+	_, err = PointsFromMessage(nil, []int64{1, 2}, pp.prime)
+	assert.NotNil(t, err)
+}